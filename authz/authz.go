@@ -0,0 +1,137 @@
+// Package authz concentra quem pode fazer o quê. Cada ação tem uma regra na
+// matriz abaixo, e os serviços só montam o Subject e chamam Check.
+package authz
+
+import (
+	"lembrago.com/lembrago/errors"
+	"lembrago.com/lembrago/models"
+)
+
+type Action string
+
+const (
+	VaultCreate         Action = "vault.create"
+	VaultUpdate         Action = "vault.update"
+	VaultDelete         Action = "vault.delete"
+	VaultForceOwnership Action = "vault.ownership.force"
+	VaultTransfer       Action = "vault.ownership.transfer"
+	VaultAcceptTransfer Action = "vault.ownership.accept"
+	VaultDirectory      Action = "vault.directory"
+	MemberList          Action = "vault.members.list"
+	MemberAdd           Action = "vault.members.add"
+	MemberUpdate        Action = "vault.members.update"
+	MemberRemove        Action = "vault.members.remove"
+	ItemList            Action = "vault.items.list"
+	ItemCreate          Action = "vault.items.create"
+	ItemUpdate          Action = "vault.items.update"
+	ItemDelete          Action = "vault.items.delete"
+	AttachmentList      Action = "vault.attachments.list"
+	AttachmentRead      Action = "vault.attachments.read"
+	AttachmentCreate    Action = "vault.attachments.create"
+	AttachmentDelete    Action = "vault.attachments.delete"
+	GroupGrantList      Action = "vault.groups.list"
+	GroupGrantAdd       Action = "vault.groups.add"
+	GroupGrantRemove    Action = "vault.groups.remove"
+
+	UserList       Action = "org.users.list"
+	UserInvite     Action = "org.users.invite"
+	UserDelete     Action = "org.users.delete"
+	UserRoleUpdate Action = "org.users.role"
+	MediaList      Action = "org.media.list"
+	MediaUpload    Action = "org.media.upload"
+	MediaDelete    Action = "org.media.delete"
+)
+
+// Rule é o mínimo exigido para uma ação: uma permissão da organização, uma
+// permissão no cofre e/ou ser o dono do cofre. Campos vazios não exigem nada.
+type Rule struct {
+	Org   models.OrgPermission
+	Vault models.VaultPermission
+	Owner bool
+}
+
+// Matrix é a única fonte das regras. As ações de cofre não exigem permissão da
+// organização porque as rotas já pedem vaults:use.
+var Matrix = map[Action]Rule{
+	VaultCreate:         {Org: models.PermVaultsManage},
+	VaultUpdate:         {Vault: models.ADMIN, Owner: true},
+	VaultDelete:         {Vault: models.ADMIN, Owner: true},
+	VaultForceOwnership: {Org: models.PermOrgManage},
+	VaultTransfer:       {Vault: models.ADMIN, Owner: true},
+	VaultAcceptTransfer: {Vault: models.ADMIN},
+	VaultDirectory:      {Vault: models.ADMIN},
+	MemberList:          {Vault: models.READ},
+	MemberAdd:           {Vault: models.ADMIN},
+	MemberUpdate:        {Vault: models.ADMIN},
+	MemberRemove:        {Vault: models.ADMIN},
+	ItemList:            {Vault: models.READ},
+	ItemCreate:          {Vault: models.WRITE},
+	ItemUpdate:          {Vault: models.WRITE},
+	ItemDelete:          {Vault: models.WRITE},
+	AttachmentList:      {Vault: models.READ},
+	AttachmentRead:      {Vault: models.READ},
+	AttachmentCreate:    {Vault: models.WRITE},
+	AttachmentDelete:    {Vault: models.WRITE},
+	GroupGrantList:      {Vault: models.ADMIN},
+	GroupGrantAdd:       {Vault: models.ADMIN},
+	GroupGrantRemove:    {Vault: models.ADMIN},
+
+	UserList:       {Org: models.PermUsersRead},
+	UserInvite:     {Org: models.PermUsersInvite},
+	UserDelete:     {Org: models.PermUsersDelete},
+	UserRoleUpdate: {Org: models.PermRolesManage},
+	MediaList:      {Org: models.PermOrgManage},
+	MediaUpload:    {Org: models.PermOrgManage},
+	MediaDelete:    {Org: models.PermOrgManage},
+}
+
+// Subject é quem tenta a ação: as permissões da sua função e, nas ações de
+// cofre, o seu acesso ao cofre (nil se não é membro) e se é o dono.
+type Subject struct {
+	Permissions []models.OrgPermission
+	Member      *models.VaultMember
+	Owner       bool
+}
+
+var vaultRank = map[models.VaultPermission]int{
+	models.READ:  1,
+	models.WRITE: 2,
+	models.ADMIN: 3,
+}
+
+// AtLeast diz se a permissão no cofre dá tudo o que want dá. Permissões
+// desconhecidas não dão nada.
+func AtLeast(have, want models.VaultPermission) bool {
+	return vaultRank[have] > 0 && vaultRank[have] >= vaultRank[want]
+}
+
+// HigherPermission devolve a maior das duas permissões, para quem tem acesso ao
+// cofre por mais de um caminho.
+func HigherPermission(a, b models.VaultPermission) models.VaultPermission {
+	if vaultRank[b] > vaultRank[a] {
+		return b
+	}
+	return a
+}
+
+// Check devolve 403 se o Subject não cumpre a regra da ação. Ações fora da
+// matriz são sempre negadas.
+func Check(action Action, subject Subject) error {
+	rule, ok := Matrix[action]
+	if !ok {
+		return errors.NewAppError(403, "Invalid Permission")
+	}
+
+	if rule.Org != "" && !models.HasPermission(subject.Permissions, rule.Org) {
+		return errors.NewAppError(403, "Permission denied: missing permission "+string(rule.Org))
+	}
+	if rule.Vault != "" {
+		if subject.Member == nil || !AtLeast(subject.Member.Permission, rule.Vault) {
+			return errors.NewAppError(403, "Invalid Permission")
+		}
+	}
+	if rule.Owner && !subject.Owner {
+		return errors.NewAppError(403, "Only the vault owner can do this")
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package authz
+
+import (
+	"testing"
+
+	"lembrago.com/lembrago/models"
+)
+
+// expectation descreve, sem reaproveitar a matriz, quem pode cada ação: as
+// funções embutidas aceitas (nil = qualquer uma), os acessos ao cofre aceitos
+// (nil = nenhum exigido) e se só o dono pode.
+type expectation struct {
+	roles []models.UserRole
+	vault []models.VaultPermission
+	owner bool
+}
+
+var (
+	anyVault   = []models.VaultPermission{models.READ, models.WRITE, models.ADMIN}
+	writeVault = []models.VaultPermission{models.WRITE, models.ADMIN}
+	adminVault = []models.VaultPermission{models.ADMIN}
+)
+
+var expectations = map[Action]expectation{
+	VaultCreate:         {roles: []models.UserRole{models.RoleAdmin, models.RoleVaultManager}},
+	VaultUpdate:         {vault: adminVault, owner: true},
+	VaultDelete:         {vault: adminVault, owner: true},
+	VaultForceOwnership: {roles: []models.UserRole{models.RoleAdmin}},
+	VaultTransfer:       {vault: adminVault, owner: true},
+	VaultAcceptTransfer: {vault: adminVault},
+	VaultDirectory:      {vault: adminVault},
+	MemberList:          {vault: anyVault},
+	MemberAdd:           {vault: adminVault},
+	MemberUpdate:        {vault: adminVault},
+	MemberRemove:        {vault: adminVault},
+	ItemList:            {vault: anyVault},
+	ItemCreate:          {vault: writeVault},
+	ItemUpdate:          {vault: writeVault},
+	ItemDelete:          {vault: writeVault},
+	AttachmentList:      {vault: anyVault},
+	AttachmentRead:      {vault: anyVault},
+	AttachmentCreate:    {vault: writeVault},
+	AttachmentDelete:    {vault: writeVault},
+	GroupGrantList:      {vault: adminVault},
+	GroupGrantAdd:       {vault: adminVault},
+	GroupGrantRemove:    {vault: adminVault},
+
+	UserList:       {roles: []models.UserRole{models.RoleAdmin, models.RoleUserManager}},
+	UserInvite:     {roles: []models.UserRole{models.RoleAdmin, models.RoleUserManager}},
+	UserDelete:     {roles: []models.UserRole{models.RoleAdmin}},
+	UserRoleUpdate: {roles: []models.UserRole{models.RoleAdmin}},
+	MediaList:      {roles: []models.UserRole{models.RoleAdmin}},
+	MediaUpload:    {roles: []models.UserRole{models.RoleAdmin}},
+	MediaDelete:    {roles: []models.UserRole{models.RoleAdmin}},
+}
+
+func TestMatrixIsCovered(t *testing.T) {
+	for action := range Matrix {
+		if _, ok := expectations[action]; !ok {
+			t.Errorf("action %s has no expectation", action)
+		}
+	}
+	for action := range expectations {
+		if _, ok := Matrix[action]; !ok {
+			t.Errorf("action %s is missing from the matrix", action)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	memberships := []*models.VaultMember{
+		nil,
+		{Permission: models.READ},
+		{Permission: models.WRITE},
+		{Permission: models.ADMIN},
+	}
+
+	for action, want := range expectations {
+		for _, role := range models.BuiltInRoles {
+			for _, member := range memberships {
+				for _, owner := range []bool{false, true} {
+					allowed := contains(want.roles, role.Key) && containsMember(want.vault, member) && (owner || !want.owner)
+
+					err := Check(action, Subject{Permissions: role.Permissions, Member: member, Owner: owner})
+					if allowed != (err == nil) {
+						t.Errorf("%s role=%s member=%v owner=%v: allowed=%v, got %v", action, role.Key, permissionOf(member), owner, allowed, err)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestCheckCustomRole(t *testing.T) {
+	tests := []struct {
+		name        string
+		action      Action
+		permissions []models.OrgPermission
+		allowed     bool
+	}{
+		{"auditor-like role cannot list users", UserList, []models.OrgPermission{models.PermAuditRead}, false},
+		{"single permission is enough", UserList, []models.OrgPermission{models.PermUsersRead}, true},
+		{"vaults:use does not create vaults", VaultCreate, []models.OrgPermission{models.PermVaultsUse}, false},
+		{"no permissions", UserDelete, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Check(tt.action, Subject{Permissions: tt.permissions})
+			if tt.allowed != (err == nil) {
+				t.Fatalf("allowed=%v, got %v", tt.allowed, err)
+			}
+		})
+	}
+}
+
+func TestCheckUnknownAction(t *testing.T) {
+	admin := Subject{Permissions: models.BuiltInRoles[0].Permissions, Member: &models.VaultMember{Permission: models.ADMIN}, Owner: true}
+	if err := Check("vault.unknown", admin); err == nil {
+		t.Fatal("unknown action was allowed")
+	}
+}
+
+func TestHigherPermission(t *testing.T) {
+	tests := []struct {
+		a, b, want models.VaultPermission
+	}{
+		{models.READ, models.WRITE, models.WRITE},
+		{models.ADMIN, models.READ, models.ADMIN},
+		{models.WRITE, models.WRITE, models.WRITE},
+		{"", models.READ, models.READ},
+		{models.READ, "owner", models.READ},
+	}
+	for _, tt := range tests {
+		if got := HigherPermission(tt.a, tt.b); got != tt.want {
+			t.Errorf("HigherPermission(%q, %q) = %q, want %q", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestAtLeast(t *testing.T) {
+	tests := []struct {
+		have, want models.VaultPermission
+		ok         bool
+	}{
+		{models.ADMIN, models.WRITE, true},
+		{models.WRITE, models.WRITE, true},
+		{models.READ, models.WRITE, false},
+		{"owner", models.READ, false},
+		{"", models.READ, false},
+	}
+	for _, tt := range tests {
+		if got := AtLeast(tt.have, tt.want); got != tt.ok {
+			t.Errorf("AtLeast(%q, %q) = %v, want %v", tt.have, tt.want, got, tt.ok)
+		}
+	}
+}
+
+func contains(roles []models.UserRole, role models.UserRole) bool {
+	if roles == nil {
+		return true
+	}
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func containsMember(permissions []models.VaultPermission, member *models.VaultMember) bool {
+	if permissions == nil {
+		return true
+	}
+	if member == nil {
+		return false
+	}
+	for _, p := range permissions {
+		if p == member.Permission {
+			return true
+		}
+	}
+	return false
+}
+
+func permissionOf(member *models.VaultMember) models.VaultPermission {
+	if member == nil {
+		return ""
+	}
+	return member.Permission
+}
@@ -98,6 +98,14 @@ func SetTTL(key string, expiration time.Duration) (bool, error) {
 	return RedisClient.Expire(ctx, key, expiration).Result()
 }
 
+// SetNX grava a chave só se ela não existir; serve de trava entre instâncias.
+func SetNX(key string, value string, expiration time.Duration) (bool, error) {
+	if RedisClient == nil {
+		return false, fmt.Errorf("client Redis not initialized")
+	}
+	return RedisClient.SetNX(ctx, key, value, expiration).Result()
+}
+
 func Delete(key string) error {
 	if RedisClient == nil {
 		return fmt.Errorf("cliente Redis não inicializado")
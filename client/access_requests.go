@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"lembrago.com/lembrago/client/envelope"
+	"lembrago.com/lembrago/models"
+)
+
+// VaultDirectory lista os cofres da organização que aceitam pedidos de acesso.
+func (c *Client) VaultDirectory(ctx context.Context) ([]models.DirectoryVaultResponse, error) {
+	var res []models.DirectoryVaultResponse
+	err := c.do(ctx, http.MethodGet, "/vaults/directory", nil, nil, &res)
+	return res, err
+}
+
+// UpdateVaultDirectory publica o cofre no diretório com displayName em texto
+// claro, ou o retira quando requestable é false. Requer admin do cofre.
+func (c *Client) UpdateVaultDirectory(ctx context.Context, vaultID string, requestable bool, displayName string) (*models.DirectoryVaultResponse, error) {
+	var res models.DirectoryVaultResponse
+	err := c.do(ctx, http.MethodPut, "/vaults/directory", nil, models.UpdateVaultDirectoryRequest{
+		VaultID:     vaultID,
+		Requestable: requestable,
+		DisplayName: displayName,
+	}, &res)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) RequestVaultAccess(ctx context.Context, vaultID string, permission models.VaultPermission, justification string) (*models.AccessRequestResponse, error) {
+	var res models.AccessRequestResponse
+	err := c.do(ctx, http.MethodPost, "/vaults/access-requests", nil, models.CreateAccessRequestRequest{
+		VaultID:       vaultID,
+		Permission:    permission,
+		Justification: justification,
+	}, &res)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// MyAccessRequests lista os pedidos do usuário, inclusive os já decididos.
+func (c *Client) MyAccessRequests(ctx context.Context) ([]models.AccessRequestResponse, error) {
+	var res []models.AccessRequestResponse
+	err := c.do(ctx, http.MethodGet, "/vaults/access-requests/mine", nil, nil, &res)
+	return res, err
+}
+
+// AccessRequests lista os pedidos pendentes dos cofres em que o usuário é admin.
+func (c *Client) AccessRequests(ctx context.Context) ([]models.AccessRequestResponse, error) {
+	var res []models.AccessRequestResponse
+	err := c.do(ctx, http.MethodGet, "/vaults/access-requests", nil, nil, &res)
+	return res, err
+}
+
+func (c *Client) ApproveAccessRequest(ctx context.Context, requestID string, req *models.ApproveAccessRequestRequest) (*models.VaultMemberResponse, error) {
+	var res models.VaultMemberResponse
+	if err := c.do(ctx, http.MethodPost, "/vaults/access-requests/"+url.PathEscape(requestID)+"/approve", nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) RejectAccessRequest(ctx context.Context, requestID, note string) error {
+	return c.do(ctx, http.MethodPost, "/vaults/access-requests/"+url.PathEscape(requestID)+"/reject", nil, models.RejectAccessRequestRequest{Note: note}, nil)
+}
+
+func (c *Client) CancelAccessRequest(ctx context.Context, requestID string) error {
+	return c.do(ctx, http.MethodDelete, "/vaults/access-requests/"+url.PathEscape(requestID), nil, nil, nil)
+}
+
+// ApproveAccessRequest embrulha a chave do cofre para o solicitante, conferindo
+// antes a impressão digital como em ConfirmPendingShare.
+func (s *Session) ApproveAccessRequest(ctx context.Context, request models.AccessRequestResponse, fingerprint, note string) (*models.VaultMemberResponse, error) {
+	actual, err := envelope.Fingerprint(request.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if !models.SameFingerprint(actual, fingerprint) {
+		return nil, ErrFingerprintMismatch
+	}
+
+	key, err := s.vaultKeyByID(ctx, request.VaultID)
+	if err != nil {
+		return nil, err
+	}
+	esvk, err := envelope.SealVaultKey(key, request.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Client.ApproveAccessRequest(ctx, request.ID, &models.ApproveAccessRequestRequest{
+		Fingerprint:    actual,
+		ESVK_PubK_User: esvk,
+		Note:           note,
+	})
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"encoding/base64"
+
+	"lembrago.com/lembrago/client/envelope"
+	"lembrago.com/lembrago/client/items"
+	"lembrago.com/lembrago/models"
+)
+
+// NewUserRequest gera localmente tudo o que o cadastro precisa a partir da Senha
+// Mestra: verificador, par de chaves, chave secreta e, se personalVault não for
+// nil, o cofre pessoal. O servidor nunca recebe a senha nem as chaves em claro.
+// Serve tanto para CreateOrganizationRequest.User quanto para RegisterUser.
+func NewUserRequest(username, masterPassword string, params models.Argo2IDParameters, personalVault *items.VaultMetadata) (*models.CreateUserRequest, error) {
+	saltLength := int(params.SaltLength)
+	if saltLength == 0 {
+		saltLength = 16
+	}
+	params.KeyLength = envelope.KeySize
+
+	saltPV, err := envelope.NewSalt(saltLength)
+	if err != nil {
+		return nil, err
+	}
+	saltEK, err := envelope.NewSalt(saltLength)
+	if err != nil {
+		return nil, err
+	}
+	saltPVBytes, _ := base64.StdEncoding.DecodeString(saltPV)
+	saltEKBytes, _ := base64.StdEncoding.DecodeString(saltEK)
+
+	masterKey := envelope.DeriveMasterKey(masterPassword, saltEKBytes, params)
+
+	keys, err := envelope.GenerateKeyPair()
+	if err != nil {
+		return nil, err
+	}
+	encryptedPrivateKey, err := envelope.Seal(&masterKey, keys.PrivateKey[:])
+	if err != nil {
+		return nil, err
+	}
+
+	secretKey, err := envelope.NewKey()
+	if err != nil {
+		return nil, err
+	}
+	encryptedSecretKey, err := envelope.Seal(&masterKey, secretKey[:])
+	if err != nil {
+		return nil, err
+	}
+
+	req := &models.CreateUserRequest{
+		Username: username,
+		Salt_ek:  saltEK,
+		Keys: models.KeysDTO{
+			PublicKey:           keys.PublicKeyBase64(),
+			EncryptedPrivateKey: encryptedPrivateKey,
+			EncryptedSecretKey:  encryptedSecretKey,
+		},
+	}
+	req.PasswordVerifier.Salt = saltPV
+	req.PasswordVerifier.Verifier = envelope.DeriveVerifier(masterPassword, saltPVBytes, params)
+	req.PasswordVerifier.Parameters = params
+
+	if personalVault != nil {
+		vaultKey, err := envelope.NewKey()
+		if err != nil {
+			return nil, err
+		}
+		metadata, err := personalVault.Encrypt(vaultKey)
+		if err != nil {
+			return nil, err
+		}
+		esvk, err := envelope.SealVaultKey(vaultKey, keys.PublicKeyBase64())
+		if err != nil {
+			return nil, err
+		}
+
+		personal := true
+		req.MyVault = &models.CreateVaultRequest{
+			EncryptedVaultMetadata: metadata,
+			ESVK_PubK_User:         esvk,
+			PersonalVault:          &personal,
+		}
+	}
+
+	return req, nil
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"lembrago.com/lembrago/models"
+)
+
+// MaxChunkSize é o maior chunk cifrado aceito pelo servidor.
+const MaxChunkSize = 8 * 1024 * 1024
+
+// CreateAttachment reserva um anexo de req.Size bytes cifrados em req.ChunkCount chunks.
+// Depois envie cada chunk com UploadAttachmentChunk e finalize com CompleteAttachment.
+func (c *Client) CreateAttachment(ctx context.Context, req *models.CreateAttachmentRequest) (*models.AttachmentResponse, error) {
+	var res models.AttachmentResponse
+	if err := c.do(ctx, http.MethodPost, "/vaults/attachments", nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// UploadAttachmentChunk envia um chunk já cifrado. Reenviar o mesmo índice substitui o anterior.
+func (c *Client) UploadAttachmentChunk(ctx context.Context, attachmentID string, index int, chunk []byte) error {
+	path := fmt.Sprintf("/vaults/attachments/%s/chunks/%d", url.PathEscape(attachmentID), index)
+	res, err := c.send(ctx, c.HTTPClient, http.MethodPut, path, nil, bytes.NewReader(chunk), "application/octet-stream")
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+func (c *Client) DownloadAttachmentChunk(ctx context.Context, attachmentID string, index int) ([]byte, error) {
+	path := fmt.Sprintf("/vaults/attachments/%s/chunks/%d", url.PathEscape(attachmentID), index)
+	res, err := c.send(ctx, c.HTTPClient, http.MethodGet, path, nil, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	return io.ReadAll(io.LimitReader(res.Body, MaxChunkSize))
+}
+
+// CompleteAttachment confere que todos os chunks chegaram e libera o anexo para download.
+func (c *Client) CompleteAttachment(ctx context.Context, attachmentID string) (*models.AttachmentResponse, error) {
+	var res models.AttachmentResponse
+	if err := c.do(ctx, http.MethodPost, "/vaults/attachments/"+url.PathEscape(attachmentID)+"/complete", nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) Attachments(ctx context.Context, passwordID string) ([]models.AttachmentResponse, error) {
+	var res []models.AttachmentResponse
+	err := c.do(ctx, http.MethodGet, "/vaults/attachments", url.Values{"passwordId": {passwordID}}, nil, &res)
+	return res, err
+}
+
+func (c *Client) DeleteAttachment(ctx context.Context, attachmentID string) error {
+	return c.do(ctx, http.MethodDelete, "/vaults/attachments/"+url.PathEscape(attachmentID), nil, nil, nil)
+}
+
+// StorageUsage devolve o espaço usado pelos anexos da organização e a cota.
+func (c *Client) StorageUsage(ctx context.Context) (*models.StorageUsageResponse, error) {
+	var res models.StorageUsageResponse
+	if err := c.do(ctx, http.MethodGet, "/vaults/attachments/usage", nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
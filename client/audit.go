@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"lembrago.com/lembrago/models"
+)
+
+// AuditLog consulta o log de auditoria da organização, do registro mais novo
+// para o mais antigo. Campos vazios de query não filtram.
+func (c *Client) AuditLog(ctx context.Context, query models.AuditQuery) (*models.AuditLogResponse, error) {
+	values := url.Values{}
+	for key, value := range map[string]string{
+		"action":   query.Action,
+		"actorId":  query.ActorID,
+		"targetId": query.TargetID,
+		"vaultId":  query.VaultID,
+		"from":     query.From,
+		"to":       query.To,
+	} {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	if query.Page > 0 {
+		values.Set("page", strconv.Itoa(query.Page))
+	}
+	if query.Limit > 0 {
+		values.Set("limit", strconv.Itoa(query.Limit))
+	}
+
+	var res models.AuditLogResponse
+	if err := c.do(ctx, http.MethodGet, "/org/audit", values, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// VerifyAuditLog pede ao servidor para refazer a cadeia de hashes do log.
+func (c *Client) VerifyAuditLog(ctx context.Context) (*models.AuditVerifyResponse, error) {
+	var res models.AuditVerifyResponse
+	if err := c.do(ctx, http.MethodGet, "/org/audit/verify", nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
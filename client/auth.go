@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"lembrago.com/lembrago/models"
+)
+
+// CreateOrganization cria a organização e o seu primeiro administrador (POST /organizations).
+// Use NewUserRequest para gerar req.User a partir da Senha Mestra.
+func (c *Client) CreateOrganization(ctx context.Context, req *models.CreateOrganizationRequest) (*models.Organization, error) {
+	var res models.Organization
+	if err := c.do(ctx, http.MethodPost, "/organizations", nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// RequestAuthCode envia o código de 6 dígitos para o e-mail (POST /auth).
+func (c *Client) RequestAuthCode(ctx context.Context, email string) error {
+	return c.do(ctx, http.MethodPost, "/auth", nil, models.AuthCodeRequest{Email: email}, nil)
+}
+
+// LoginInfo troca o código pelas organizações do e-mail e seus parâmetros de verificação (POST /login).
+func (c *Client) LoginInfo(ctx context.Context, email, code string) ([]models.UserWithOrganizationResponse, error) {
+	var res []models.UserWithOrganizationResponse
+	err := c.do(ctx, http.MethodPost, "/login", nil, models.AuthCodeSendRequest{Email: email, Code: code}, &res)
+	return res, err
+}
+
+// Login envia o verificador derivado da Senha Mestra e guarda o token recebido.
+func (c *Client) Login(ctx context.Context, orgID, email, verifier string) (*models.UserLoginResponse, error) {
+	var res models.UserLoginResponse
+	err := c.do(ctx, http.MethodPost, "/environment/login", nil, models.UserLoginComparison{
+		OrgID:    orgID,
+		Email:    email,
+		Verifier: verifier,
+		DeviceID: c.DeviceID,
+	}, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Token = res.Token
+	return &res, nil
+}
+
+// Signout revoga o token atual no servidor e o esquece.
+func (c *Client) Signout(ctx context.Context) error {
+	if err := c.do(ctx, http.MethodDelete, "/signout", nil, nil, nil); err != nil {
+		return err
+	}
+	c.Token = ""
+	return nil
+}
+
+// InviteUser convida um e-mail para a organização e devolve o código do convite (POST /invites).
+func (c *Client) InviteUser(ctx context.Context, email string, role models.UserRole) (string, error) {
+	var res struct {
+		InvitedCode string `json:"invitedCode"`
+	}
+	err := c.do(ctx, http.MethodPost, "/invites", nil, models.InviteUserRequest{Email: email, Role: role}, &res)
+	return res.InvitedCode, err
+}
+
+// GetInvite troca o código do convite pelo token de cadastro (GET /invites/:id).
+func (c *Client) GetInvite(ctx context.Context, code string) (*models.MinOrgWithTokenResponse, error) {
+	var res models.MinOrgWithTokenResponse
+	if err := c.do(ctx, http.MethodGet, "/invites/"+url.PathEscape(code), nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// RegisterUser conclui o cadastro de um convidado (POST /users/creation). invite
+// vem de GetInvite e req.Code deve ser o código do convite.
+func (c *Client) RegisterUser(ctx context.Context, invite *models.MinOrgWithTokenResponse, req *models.CreateUserRequest) error {
+	return c.withToken(invite.Token).do(ctx, http.MethodPost, "/users/creation", nil, req, nil)
+}
+
+// Me devolve o usuário do token com as chaves ainda cifradas (GET /users/me).
+// Junto com OpenSession permite desbloquear a partir de um token já emitido.
+func (c *Client) Me(ctx context.Context) (*models.UserResponse, error) {
+	var res models.UserResponse
+	if err := c.do(ctx, http.MethodGet, "/users/me", nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// SetLocale escolhe o idioma dos e-mails do usuário ("pt-BR" ou "en-US"); vazio
+// volta ao idioma da organização.
+func (c *Client) SetLocale(ctx context.Context, locale string) (*models.UserResponse, error) {
+	var res models.UserResponse
+	if err := c.do(ctx, http.MethodPut, "/users/me/preferences", nil, models.UpdateUserPreferencesRequest{Locale: locale}, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -0,0 +1,182 @@
+// Package client é o cliente HTTP da API do LemBRAGO para ferramentas em Go.
+// Cobre todas as rotas do servidor com os mesmos tipos de models; a
+// criptografia do lado do cliente fica em client/envelope e os métodos de
+// Session juntam as duas coisas (cofres e itens já cifrados/decifrados).
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type Client struct {
+	BaseURL    string
+	Token      string
+	HTTPClient *http.Client
+	// DeviceID identifica esta instalação no login; o servidor avisa por
+	// e-mail quando a conta entra de um aparelho que ainda não conhecia.
+	DeviceID string
+}
+
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// APIError é uma resposta de erro do servidor ({"message"} do ErrorHandler ou {"error"}).
+type APIError struct {
+	Status  int
+	Message string
+	Details json.RawMessage
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("lembrago: %d %s", e.Status, e.Message)
+}
+
+// DecodeDetails decodifica o campo details de um erro, ex.: o resultado de um lote rejeitado.
+func (e *APIError) DecodeDetails(out interface{}) error {
+	if len(e.Details) == 0 {
+		return fmt.Errorf("lembrago: error has no details")
+	}
+	return json.Unmarshal(e.Details, out)
+}
+
+// withToken devolve uma cópia do cliente que usa outro token, ex.: o token de
+// cadastro de um convite ou o token de administração de versões.
+func (c *Client) withToken(token string) *Client {
+	clone := *c
+	clone.Token = token
+	return &clone
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	var reader io.Reader
+	contentType := ""
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+		contentType = "application/json"
+	}
+
+	res, err := c.send(ctx, c.HTTPClient, method, path, query, reader, contentType)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	return decodeResponse(res, out)
+}
+
+// send faz a requisição e devolve a resposta ainda aberta. Respostas de erro já
+// são convertidas em *APIError.
+func (c *Client) send(ctx context.Context, httpClient *http.Client, method, path string, query url.Values, body io.Reader, contentType string) (*http.Response, error) {
+	endpoint := c.BaseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= 300 {
+		defer res.Body.Close()
+		return nil, decodeResponse(res, nil)
+	}
+	return res, nil
+}
+
+// upload envia um único arquivo como multipart/form-data junto com campos de texto.
+func (c *Client) upload(ctx context.Context, path, fileField, filename string, file io.Reader, fields map[string]string, out interface{}) error {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, value := range fields {
+		if err := writer.WriteField(name, value); err != nil {
+			return err
+		}
+	}
+	part, err := writer.CreateFormFile(fileField, filename)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	res, err := c.send(ctx, c.HTTPClient, http.MethodPost, path, nil, &body, writer.FormDataContentType())
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	return decodeResponse(res, out)
+}
+
+// download copia o corpo de uma resposta binária para w.
+func (c *Client) download(ctx context.Context, path string, w io.Writer) (int64, error) {
+	res, err := c.send(ctx, c.HTTPClient, http.MethodGet, path, nil, nil, "")
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	return io.Copy(w, res.Body)
+}
+
+func decodeResponse(res *http.Response, out interface{}) error {
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode >= 300 {
+		apiErr := &APIError{Status: res.StatusCode, Message: http.StatusText(res.StatusCode)}
+		var body struct {
+			Message string          `json:"message"`
+			Error   string          `json:"error"`
+			Details json.RawMessage `json:"details"`
+		}
+		if json.Unmarshal(data, &body) == nil {
+			if body.Message != "" {
+				apiErr.Message = body.Message
+			} else if body.Error != "" {
+				apiErr.Message = body.Error
+			}
+			apiErr.Details = body.Details
+		}
+		return apiErr
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
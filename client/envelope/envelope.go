@@ -0,0 +1,233 @@
+// Package envelope implementa, do lado do cliente, a criptografia que o servidor
+// apenas armazena: derivação das chaves a partir da Senha Mestra, abertura da
+// chave privada do usuário e das chaves dos cofres, e o envelope ciphertext/nonce
+// (models.EncryptedKey) usado em itens e metadados.
+//
+// Este pacote não importa nada do servidor além de models, para poder ser usado
+// em ferramentas que nunca se conectam ao MongoDB ou ao Redis.
+package envelope
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/nacl/box"
+	"golang.org/x/crypto/nacl/secretbox"
+	"lembrago.com/lembrago/models"
+)
+
+const (
+	KeySize   = 32
+	NonceSize = 24
+)
+
+var ErrDecrypt = errors.New("envelope: decryption failed")
+
+// Key é uma chave simétrica de 32 bytes (chave mestra, chave do cofre).
+type Key [KeySize]byte
+
+// DefaultParameters são os parâmetros de Argon2id usados em contas novas.
+var DefaultParameters = models.Argo2IDParameters{
+	Memory:      64 * 1024,
+	Time:        3,
+	Parallelism: 4,
+	SaltLength:  16,
+	KeyLength:   KeySize,
+}
+
+// DeriveKey aplica Argon2id com os parâmetros armazenados para o usuário.
+// keyLength zero significa 32 bytes.
+func DeriveKey(password, salt []byte, params models.Argo2IDParameters) []byte {
+	keyLength := params.KeyLength
+	if keyLength == 0 {
+		keyLength = KeySize
+	}
+	return argon2.IDKey(password, salt, params.Time, params.Memory, params.Parallelism, keyLength)
+}
+
+// DeriveVerifier gera o PV enviado em /environment/login.
+func DeriveVerifier(masterPassword string, saltPV []byte, params models.Argo2IDParameters) string {
+	return base64.StdEncoding.EncodeToString(DeriveKey([]byte(masterPassword), saltPV, params))
+}
+
+// DeriveMasterKey gera a chave que cifra a chave privada do usuário (EUserPrivK).
+func DeriveMasterKey(masterPassword string, saltEK []byte, params models.Argo2IDParameters) Key {
+	params.KeyLength = KeySize
+	var key Key
+	copy(key[:], DeriveKey([]byte(masterPassword), saltEK, params))
+	return key
+}
+
+// DeriveServiceAccountKeys separa o segredo de uma credencial de conta de
+// serviço em duas chaves: a de autenticação, enviada ao servidor como
+// clientSecret, e a que cifra as chaves da conta. O segredo tem alta entropia,
+// então HKDF basta no lugar do Argon2id.
+func DeriveServiceAccountKeys(secret []byte) (clientSecret string, wrapKey Key, err error) {
+	auth := make([]byte, KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, nil, []byte("lembrago service account auth")), auth); err != nil {
+		return "", Key{}, err
+	}
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, nil, []byte("lembrago service account keys")), wrapKey[:]); err != nil {
+		return "", Key{}, err
+	}
+	return base64.StdEncoding.EncodeToString(auth), wrapKey, nil
+}
+
+func Seal(key *Key, plaintext []byte) (models.EncryptedKeyDto, error) {
+	var nonce [NonceSize]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return models.EncryptedKeyDto{}, err
+	}
+
+	k := [KeySize]byte(*key)
+	ciphertext := secretbox.Seal(nil, plaintext, &nonce, &k)
+
+	return models.EncryptedKeyDto{
+		Ciphertext: base64.StdEncoding.EncodeToString(ciphertext),
+		Nonce:      base64.StdEncoding.EncodeToString(nonce[:]),
+	}, nil
+}
+
+func Open(key *Key, envelope models.EncryptedKeyDto) ([]byte, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(envelope.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: invalid ciphertext: %w", err)
+	}
+	nonceBytes, err := base64.StdEncoding.DecodeString(envelope.Nonce)
+	if err != nil || len(nonceBytes) != NonceSize {
+		return nil, fmt.Errorf("envelope: invalid nonce")
+	}
+
+	var nonce [NonceSize]byte
+	copy(nonce[:], nonceBytes)
+	k := [KeySize]byte(*key)
+
+	plaintext, ok := secretbox.Open(nil, ciphertext, &nonce, &k)
+	if !ok {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// KeyPair é o par X25519 do usuário, usado para abrir os ESVK_PubK_User.
+type KeyPair struct {
+	PublicKey  [KeySize]byte
+	PrivateKey [KeySize]byte
+}
+
+// OpenKeyPair decifra EUserPrivK com a chave mestra.
+func OpenKeyPair(masterKey *Key, publicKey string, encryptedPrivateKey models.EncryptedKeyDto) (*KeyPair, error) {
+	pub, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(pub) != KeySize {
+		return nil, fmt.Errorf("envelope: invalid public key")
+	}
+
+	priv, err := Open(masterKey, encryptedPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(priv) != KeySize {
+		return nil, fmt.Errorf("envelope: invalid private key length")
+	}
+
+	var pair KeyPair
+	copy(pair.PublicKey[:], pub)
+	copy(pair.PrivateKey[:], priv)
+	return &pair, nil
+}
+
+// OpenSecretKey decifra o ESK, a chave simétrica pessoal do usuário, com a chave mestra.
+func OpenSecretKey(masterKey *Key, encryptedSecretKey models.EncryptedKeyDto) (*Key, error) {
+	raw, err := Open(masterKey, encryptedSecretKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) != KeySize {
+		return nil, fmt.Errorf("envelope: invalid secret key length")
+	}
+
+	var key Key
+	copy(key[:], raw)
+	return &key, nil
+}
+
+// GenerateKeyPair cria um novo par X25519 para um usuário.
+func GenerateKeyPair() (*KeyPair, error) {
+	pub, priv, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyPair{PublicKey: *pub, PrivateKey: *priv}, nil
+}
+
+// PublicKeyBase64 é a chave pública no formato que a API espera.
+func (pair *KeyPair) PublicKeyBase64() string {
+	return base64.StdEncoding.EncodeToString(pair.PublicKey[:])
+}
+
+// Fingerprint é a impressão digital (models.PublicKeyFingerprint) de uma chave
+// pública em base64, para conferir por outro canal antes de embrulhar um cofre.
+func Fingerprint(publicKey string) (string, error) {
+	pub, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(pub) != KeySize {
+		return "", fmt.Errorf("envelope: invalid public key")
+	}
+	return models.PublicKeyFingerprint(pub), nil
+}
+
+// OpenVaultKey abre o ESVK_PubK_User (base64) e devolve a chave simétrica do cofre.
+func (pair *KeyPair) OpenVaultKey(esvk string) (*Key, error) {
+	sealed, err := base64.StdEncoding.DecodeString(esvk)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: invalid esvk: %w", err)
+	}
+
+	raw, ok := box.OpenAnonymous(nil, sealed, &pair.PublicKey, &pair.PrivateKey)
+	if !ok || len(raw) != KeySize {
+		return nil, ErrDecrypt
+	}
+
+	var key Key
+	copy(key[:], raw)
+	return &key, nil
+}
+
+// SealVaultKey embrulha a chave de um cofre para a chave pública de um usuário.
+func SealVaultKey(vaultKey *Key, recipientPublicKey string) (string, error) {
+	pub, err := base64.StdEncoding.DecodeString(recipientPublicKey)
+	if err != nil || len(pub) != KeySize {
+		return "", fmt.Errorf("envelope: invalid public key")
+	}
+
+	var recipient [KeySize]byte
+	copy(recipient[:], pub)
+
+	sealed, err := box.SealAnonymous(nil, vaultKey[:], &recipient, rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// NewSalt gera um salt aleatório codificado em base64.
+func NewSalt(size int) (string, error) {
+	salt := make([]byte, size)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(salt), nil
+}
+
+func NewKey() (*Key, error) {
+	var key Key
+	if _, err := rand.Read(key[:]); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"lembrago.com/lembrago/client/envelope"
+	"lembrago.com/lembrago/models"
+)
+
+func (c *Client) Groups(ctx context.Context) ([]models.GroupResponse, error) {
+	var res []models.GroupResponse
+	err := c.do(ctx, http.MethodGet, "/org/groups", nil, nil, &res)
+	return res, err
+}
+
+func (c *Client) CreateGroup(ctx context.Context, req *models.CreateGroupRequest) (*models.GroupResponse, error) {
+	var res models.GroupResponse
+	if err := c.do(ctx, http.MethodPost, "/org/groups", nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) UpdateGroup(ctx context.Context, groupID string, req *models.UpdateGroupRequest) error {
+	return c.do(ctx, http.MethodPut, "/org/groups/"+url.PathEscape(groupID), nil, req, nil)
+}
+
+// DeleteGroup apaga o grupo e revoga os acessos que vieram dele.
+func (c *Client) DeleteGroup(ctx context.Context, groupID string) error {
+	return c.do(ctx, http.MethodDelete, "/org/groups/"+url.PathEscape(groupID), nil, nil, nil)
+}
+
+// AddGroupMember põe o usuário no grupo. Os cofres do grupo em que ele ainda não
+// está viram tarefas de compartilhamento para os admins desses cofres.
+func (c *Client) AddGroupMember(ctx context.Context, groupID, userID string) error {
+	return c.do(ctx, http.MethodPost, "/org/groups/"+url.PathEscape(groupID)+"/members", nil, models.AddGroupMemberRequest{UserID: userID}, nil)
+}
+
+func (c *Client) RemoveGroupMember(ctx context.Context, groupID, userID string) error {
+	return c.do(ctx, http.MethodDelete, "/org/groups/"+url.PathEscape(groupID)+"/members/"+url.PathEscape(userID), nil, nil, nil)
+}
+
+// VaultGroups lista os grupos com acesso ao cofre.
+func (c *Client) VaultGroups(ctx context.Context, vaultID string) ([]models.GroupVaultGrantResponse, error) {
+	var res []models.GroupVaultGrantResponse
+	err := c.do(ctx, http.MethodGet, "/vaults/groups", url.Values{"vaultId": {vaultID}}, nil, &res)
+	return res, err
+}
+
+func (c *Client) GrantVaultToGroup(ctx context.Context, vaultID, groupID string, permission models.VaultPermission) (*models.GroupVaultGrantResponse, error) {
+	var res models.GroupVaultGrantResponse
+	err := c.do(ctx, http.MethodPost, "/vaults/groups", nil, models.CreateGroupVaultGrantRequest{
+		VaultID:    vaultID,
+		GroupID:    groupID,
+		Permission: permission,
+	}, &res)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) RevokeGroupVaultGrant(ctx context.Context, grantID string) error {
+	return c.do(ctx, http.MethodDelete, "/vaults/groups/"+url.PathEscape(grantID), nil, nil, nil)
+}
+
+// KeyShareTasks lista as tarefas pendentes dos cofres em que o usuário é admin.
+func (c *Client) KeyShareTasks(ctx context.Context) ([]models.KeyShareTaskResponse, error) {
+	var res []models.KeyShareTaskResponse
+	err := c.do(ctx, http.MethodGet, "/vaults/key-share-tasks", nil, nil, &res)
+	return res, err
+}
+
+func (c *Client) CompleteKeyShareTask(ctx context.Context, taskID string, req *models.CompleteKeyShareTaskRequest) (*models.VaultMemberResponse, error) {
+	var res models.VaultMemberResponse
+	if err := c.do(ctx, http.MethodPost, "/vaults/key-share-tasks/"+url.PathEscape(taskID), nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// ProcessKeyShareTasks conclui as tarefas pendentes, embrulhando a chave de cada
+// cofre para a chave pública do usuário. confirm recebe a impressão digital
+// calculada localmente e decide se a chave é mesmo do usuário; tarefas recusadas
+// ficam pendentes. Devolve quantos acessos foram criados. Tarefas do mesmo cofre
+// e usuário são concluídas juntas pelo servidor, por isso as já atendidas nesta
+// rodada são puladas.
+func (s *Session) ProcessKeyShareTasks(ctx context.Context, confirm func(task models.KeyShareTaskResponse, fingerprint string) bool) (int, error) {
+	tasks, err := s.Client.KeyShareTasks(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	done := make(map[string]bool)
+	for _, task := range tasks {
+		pair := task.VaultID + "/" + task.UserID
+		if done[pair] {
+			continue
+		}
+
+		fingerprint, err := envelope.Fingerprint(task.PublicKey)
+		if err != nil {
+			return len(done), fmt.Errorf("lembrago: cannot share vault %s with user %s: %w", task.VaultID, task.UserID, err)
+		}
+		if !confirm(task, fingerprint) {
+			continue
+		}
+
+		key, err := s.vaultKeyByID(ctx, task.VaultID)
+		if err != nil {
+			return len(done), err
+		}
+		esvk, err := envelope.SealVaultKey(key, task.PublicKey)
+		if err != nil {
+			return len(done), fmt.Errorf("lembrago: cannot share vault %s with user %s: %w", task.VaultID, task.UserID, err)
+		}
+		_, err = s.Client.CompleteKeyShareTask(ctx, task.ID, &models.CompleteKeyShareTaskRequest{
+			Fingerprint:    fingerprint,
+			ESVK_PubK_User: esvk,
+		})
+		if err != nil {
+			return len(done), err
+		}
+		done[pair] = true
+	}
+	return len(done), nil
+}
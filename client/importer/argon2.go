@@ -0,0 +1,273 @@
+package importer
+
+// Argon2 completo (d, i e id) para o KDF do KDBX 4. golang.org/x/crypto/argon2
+// só expõe as variantes i e id, e o KeePass usa Argon2d por padrão. Adaptado da
+// implementação genérica de golang.org/x/crypto/argon2, acrescentando o segredo
+// (K) e os dados associados (A) que o KDBX permite.
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"math/bits"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	argon2Version = 0x13
+
+	argon2d  = 0
+	argon2i  = 1
+	argon2id = 2
+
+	argon2BlockLength = 128
+	argon2SyncPoints  = 4
+)
+
+type argon2Block [argon2BlockLength]uint64
+
+func argon2Key(mode int, password, salt, secret, data []byte, time, memory, threads, keyLen uint32) ([]byte, error) {
+	if time < 1 || threads < 1 || keyLen < 4 {
+		return nil, fmt.Errorf("invalid Argon2 parameters")
+	}
+	// Evita que um arquivo malicioso peça memória absurda (o limite é 4 GiB em KiB).
+	if memory > 4*1024*1024 {
+		return nil, fmt.Errorf("Argon2 memory parameter too large: %d KiB", memory)
+	}
+	// A RFC 9106 exige m >= 8p. Completar a memória, como faz x/crypto, deixaria
+	// um paralelismo enorme alocar muito além do limite acima.
+	if threads >= 1<<24 || memory < 2*argon2SyncPoints*threads {
+		return nil, fmt.Errorf("invalid Argon2 parameters")
+	}
+
+	h0 := argon2InitHash(password, salt, secret, data, time, memory, threads, keyLen, mode)
+
+	memory = memory / (argon2SyncPoints * threads) * (argon2SyncPoints * threads)
+
+	B := argon2InitBlocks(&h0, memory, threads)
+	argon2ProcessBlocks(B, time, memory, threads, mode)
+	return argon2ExtractKey(B, memory, threads, keyLen), nil
+}
+
+func argon2InitHash(password, salt, key, data []byte, time, memory, threads, keyLen uint32, mode int) [blake2b.Size + 8]byte {
+	var h0 [blake2b.Size + 8]byte
+	var tmp [4]byte
+
+	b2, _ := blake2b.New512(nil)
+	for _, v := range []uint32{threads, keyLen, memory, time, argon2Version, uint32(mode)} {
+		binary.LittleEndian.PutUint32(tmp[:], v)
+		b2.Write(tmp[:])
+	}
+	for _, input := range [][]byte{password, salt, key, data} {
+		binary.LittleEndian.PutUint32(tmp[:], uint32(len(input)))
+		b2.Write(tmp[:])
+		b2.Write(input)
+	}
+	b2.Sum(h0[:0])
+	return h0
+}
+
+func argon2InitBlocks(h0 *[blake2b.Size + 8]byte, memory, threads uint32) []argon2Block {
+	var block0 [1024]byte
+	B := make([]argon2Block, memory)
+	for lane := uint32(0); lane < threads; lane++ {
+		j := lane * (memory / threads)
+		binary.LittleEndian.PutUint32(h0[blake2b.Size+4:], lane)
+
+		for k := uint32(0); k < 2; k++ {
+			binary.LittleEndian.PutUint32(h0[blake2b.Size:], k)
+			argon2Hash(block0[:], h0[:])
+			for i := range B[j+k] {
+				B[j+k][i] = binary.LittleEndian.Uint64(block0[i*8:])
+			}
+		}
+	}
+	return B
+}
+
+func argon2ProcessBlocks(B []argon2Block, time, memory, threads uint32, mode int) {
+	lanes := memory / threads
+	segments := lanes / argon2SyncPoints
+
+	processSegment := func(n, slice, lane uint32) {
+		var addresses, in, zero argon2Block
+		independent := mode == argon2i || (mode == argon2id && n == 0 && slice < argon2SyncPoints/2)
+		if independent {
+			in[0] = uint64(n)
+			in[1] = uint64(lane)
+			in[2] = uint64(slice)
+			in[3] = uint64(memory)
+			in[4] = uint64(time)
+			in[5] = uint64(mode)
+		}
+
+		index := uint32(0)
+		if n == 0 && slice == 0 {
+			index = 2
+			if independent {
+				in[6]++
+				argon2Compress(&addresses, &in, &zero, false)
+				argon2Compress(&addresses, &addresses, &zero, false)
+			}
+		}
+
+		offset := lane*lanes + slice*segments + index
+		var random uint64
+		for index < segments {
+			prev := offset - 1
+			if index == 0 && slice == 0 {
+				prev += lanes
+			}
+			if independent {
+				if index%argon2BlockLength == 0 {
+					in[6]++
+					argon2Compress(&addresses, &in, &zero, false)
+					argon2Compress(&addresses, &addresses, &zero, false)
+				}
+				random = addresses[index%argon2BlockLength]
+			} else {
+				random = B[prev][0]
+			}
+			newOffset := argon2IndexAlpha(random, lanes, segments, threads, n, slice, lane, index)
+			argon2Compress(&B[offset], &B[prev], &B[newOffset], true)
+			index, offset = index+1, offset+1
+		}
+	}
+
+	for n := uint32(0); n < time; n++ {
+		for slice := uint32(0); slice < argon2SyncPoints; slice++ {
+			for lane := uint32(0); lane < threads; lane++ {
+				processSegment(n, slice, lane)
+			}
+		}
+	}
+}
+
+func argon2ExtractKey(B []argon2Block, memory, threads, keyLen uint32) []byte {
+	lanes := memory / threads
+	for lane := uint32(0); lane < threads-1; lane++ {
+		for i, v := range B[(lane*lanes)+lanes-1] {
+			B[memory-1][i] ^= v
+		}
+	}
+
+	var block [1024]byte
+	for i, v := range B[memory-1] {
+		binary.LittleEndian.PutUint64(block[i*8:], v)
+	}
+	key := make([]byte, keyLen)
+	argon2Hash(key, block[:])
+	return key
+}
+
+func argon2IndexAlpha(rand uint64, lanes, segments, threads, n, slice, lane, index uint32) uint32 {
+	refLane := uint32(rand>>32) % threads
+	if n == 0 && slice == 0 {
+		refLane = lane
+	}
+	m, s := 3*segments, ((slice+1)%argon2SyncPoints)*segments
+	if lane == refLane {
+		m += index
+	}
+	if n == 0 {
+		m, s = slice*segments, 0
+		if slice == 0 || lane == refLane {
+			m += index
+		}
+	}
+	if index == 0 || lane == refLane {
+		m--
+	}
+
+	p := rand & 0xFFFFFFFF
+	p = (p * p) >> 32
+	p = (p * uint64(m)) >> 32
+	return refLane*lanes + uint32((uint64(s)+uint64(m)-(p+1))%uint64(lanes))
+}
+
+// argon2Hash é a função de hash de tamanho variável H' da especificação.
+func argon2Hash(out []byte, in []byte) {
+	var b2 hash.Hash
+	if n := len(out); n < blake2b.Size {
+		b2, _ = blake2b.New(n, nil)
+	} else {
+		b2, _ = blake2b.New512(nil)
+	}
+
+	var buffer [blake2b.Size]byte
+	binary.LittleEndian.PutUint32(buffer[:4], uint32(len(out)))
+	b2.Write(buffer[:4])
+	b2.Write(in)
+
+	if len(out) <= blake2b.Size {
+		b2.Sum(out[:0])
+		return
+	}
+
+	outLen := len(out)
+	b2.Sum(buffer[:0])
+	b2.Reset()
+	copy(out, buffer[:32])
+	out = out[32:]
+	for len(out) > blake2b.Size {
+		b2.Write(buffer[:])
+		b2.Sum(buffer[:0])
+		copy(out, buffer[:32])
+		out = out[32:]
+		b2.Reset()
+	}
+
+	if outLen%blake2b.Size > 0 {
+		r := ((outLen + 31) / 32) - 2
+		b2, _ = blake2b.New(outLen-32*r, nil)
+	}
+	b2.Write(buffer[:])
+	b2.Sum(out[:0])
+}
+
+func argon2Compress(out, in1, in2 *argon2Block, xor bool) {
+	var t argon2Block
+	for i := range t {
+		t[i] = in1[i] ^ in2[i]
+	}
+	for i := 0; i < argon2BlockLength; i += 16 {
+		blamka(&t[i+0], &t[i+1], &t[i+2], &t[i+3], &t[i+4], &t[i+5], &t[i+6], &t[i+7],
+			&t[i+8], &t[i+9], &t[i+10], &t[i+11], &t[i+12], &t[i+13], &t[i+14], &t[i+15])
+	}
+	for i := 0; i < argon2BlockLength/8; i += 2 {
+		blamka(&t[i], &t[i+1], &t[16+i], &t[16+i+1], &t[32+i], &t[32+i+1], &t[48+i], &t[48+i+1],
+			&t[64+i], &t[64+i+1], &t[80+i], &t[80+i+1], &t[96+i], &t[96+i+1], &t[112+i], &t[112+i+1])
+	}
+	if xor {
+		for i := range t {
+			out[i] ^= in1[i] ^ in2[i] ^ t[i]
+		}
+	} else {
+		for i := range t {
+			out[i] = in1[i] ^ in2[i] ^ t[i]
+		}
+	}
+}
+
+func blamka(v00, v01, v02, v03, v04, v05, v06, v07, v08, v09, v10, v11, v12, v13, v14, v15 *uint64) {
+	blamkaG(v00, v04, v08, v12)
+	blamkaG(v01, v05, v09, v13)
+	blamkaG(v02, v06, v10, v14)
+	blamkaG(v03, v07, v11, v15)
+	blamkaG(v00, v05, v10, v15)
+	blamkaG(v01, v06, v11, v12)
+	blamkaG(v02, v07, v08, v13)
+	blamkaG(v03, v04, v09, v14)
+}
+
+func blamkaG(a, b, c, d *uint64) {
+	*a += *b + 2*uint64(uint32(*a))*uint64(uint32(*b))
+	*d = bits.RotateLeft64(*d^*a, -32)
+	*c += *d + 2*uint64(uint32(*c))*uint64(uint32(*d))
+	*b = bits.RotateLeft64(*b^*c, -24)
+	*a += *b + 2*uint64(uint32(*a))*uint64(uint32(*b))
+	*d = bits.RotateLeft64(*d^*a, -16)
+	*c += *d + 2*uint64(uint32(*c))*uint64(uint32(*d))
+	*b = bits.RotateLeft64(*b^*c, -63)
+}
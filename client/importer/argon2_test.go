@@ -0,0 +1,79 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Vetores de teste da RFC 9106, seções 5.1 a 5.3: senha de 32 bytes 0x01,
+// salt de 16 bytes 0x02, segredo de 8 bytes 0x03, dados associados de 12
+// bytes 0x04, t=3, m=32 KiB, p=4 e tag de 32 bytes.
+func TestArgon2RFC9106Vectors(t *testing.T) {
+	password := bytes.Repeat([]byte{0x01}, 32)
+	salt := bytes.Repeat([]byte{0x02}, 16)
+	secret := bytes.Repeat([]byte{0x03}, 8)
+	data := bytes.Repeat([]byte{0x04}, 12)
+
+	vectors := []struct {
+		name string
+		mode int
+		tag  string
+	}{
+		{"Argon2d", argon2d, "512b391b6f1162975371d30919734294f868e3be3984f3c1a13a4db9fabe4acb"},
+		{"Argon2i", argon2i, "c814d9d1dc7f37aa13f0d77f2494bda1c8de6b016dd388d29952a4c4672b6ce8"},
+		{"Argon2id", argon2id, "0d640df58d78766c08c037a34a8b53c9d01ef0452d75b65eb52520e96b01e659"},
+	}
+	for _, vector := range vectors {
+		t.Run(vector.name, func(t *testing.T) {
+			key, err := argon2Key(vector.mode, password, salt, secret, data, 3, 32, 4, 32)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hex.EncodeToString(key); got != vector.tag {
+				t.Fatalf("tag = %s, want %s", got, vector.tag)
+			}
+		})
+	}
+}
+
+// Sem segredo e sem dados associados, i e id têm de bater com x/crypto.
+func TestArgon2MatchesXCrypto(t *testing.T) {
+	password, salt := []byte("correct horse battery staple"), []byte("somesaltsomesalt")
+
+	key, err := argon2Key(argon2id, password, salt, nil, nil, 2, 64, 2, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := argon2.IDKey(password, salt, 2, 64, 2, 32); !bytes.Equal(key, want) {
+		t.Fatalf("Argon2id = %x, want %x", key, want)
+	}
+
+	key, err = argon2Key(argon2i, password, salt, nil, nil, 3, 64, 1, 48)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := argon2.Key(password, salt, 3, 64, 1, 48); !bytes.Equal(key, want) {
+		t.Fatalf("Argon2i = %x, want %x", key, want)
+	}
+}
+
+func TestArgon2RejectsInvalidParameters(t *testing.T) {
+	cases := []struct {
+		name                          string
+		time, memory, threads, keyLen uint32
+	}{
+		{"no passes", 0, 64, 1, 32},
+		{"no lanes", 1, 64, 0, 32},
+		{"short tag", 1, 64, 1, 3},
+		{"too much memory", 1, 4*1024*1024 + 1, 1, 32},
+		{"memory below 8 blocks per lane", 1, 64, 1 << 20, 32},
+	}
+	for _, c := range cases {
+		if _, err := argon2Key(argon2d, []byte("p"), []byte("saltsalt"), nil, nil, c.time, c.memory, c.threads, c.keyLen); err == nil {
+			t.Errorf("%s: expected an error", c.name)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"lembrago.com/lembrago/client/items"
+)
+
+type bitwardenExport struct {
+	Encrypted bool `json:"encrypted"`
+	Folders   []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"folders"`
+	Collections []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"collections"`
+	Items []bitwardenItem `json:"items"`
+}
+
+type bitwardenItem struct {
+	Type          int      `json:"type"`
+	Name          string   `json:"name"`
+	Notes         string   `json:"notes"`
+	Favorite      bool     `json:"favorite"`
+	FolderID      string   `json:"folderId"`
+	CollectionIDs []string `json:"collectionIds"`
+	Fields        []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+		Type  int    `json:"type"`
+	} `json:"fields"`
+	Login *struct {
+		URIs []struct {
+			URI string `json:"uri"`
+		} `json:"uris"`
+		Username string `json:"username"`
+		Password string `json:"password"`
+		TOTP     string `json:"totp"`
+	} `json:"login"`
+	Card *struct {
+		CardholderName string `json:"cardholderName"`
+		Brand          string `json:"brand"`
+		Number         string `json:"number"`
+		ExpMonth       string `json:"expMonth"`
+		ExpYear        string `json:"expYear"`
+		Code           string `json:"code"`
+	} `json:"card"`
+	Identity map[string]interface{} `json:"identity"`
+	SSHKey   *struct {
+		PrivateKey     string `json:"privateKey"`
+		PublicKey      string `json:"publicKey"`
+		KeyFingerprint string `json:"keyFingerprint"`
+	} `json:"sshKey"`
+}
+
+const (
+	bitwardenLogin    = 1
+	bitwardenNote     = 2
+	bitwardenCard     = 3
+	bitwardenIdentity = 4
+	bitwardenSSHKey   = 5
+
+	bitwardenFieldHidden = 1
+	bitwardenFieldLinked = 3
+)
+
+// Ordem estável dos campos de identidade do Bitwarden.
+var bitwardenIdentityFields = []string{
+	"title", "firstName", "middleName", "lastName", "username", "company",
+	"email", "phone", "address1", "address2", "address3", "city", "state",
+	"postalCode", "country", "ssn", "passportNumber", "licenseNumber",
+}
+
+func parseBitwarden(data []byte) (*Result, error) {
+	var export bitwardenExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("invalid Bitwarden JSON: %w", err)
+	}
+	if export.Encrypted {
+		return nil, fmt.Errorf("encrypted Bitwarden exports are not supported, export as unencrypted JSON")
+	}
+
+	folders := map[string]string{}
+	for _, folder := range export.Folders {
+		folders[folder.ID] = folder.Name
+	}
+	for _, collection := range export.Collections {
+		folders[collection.ID] = collection.Name
+	}
+
+	result := newResult(FormatBitwarden)
+	for _, bw := range export.Items {
+		item := items.Item{
+			Name:     bw.Name,
+			Notes:    bw.Notes,
+			Favorite: bw.Favorite,
+			Folder:   folders[bw.FolderID],
+		}
+		if item.Folder == "" && len(bw.CollectionIDs) > 0 {
+			item.Folder = folders[bw.CollectionIDs[0]]
+		}
+
+		switch bw.Type {
+		case bitwardenLogin:
+			item.Type = items.TypeLogin
+			if bw.Login != nil {
+				item.Username = bw.Login.Username
+				item.Password = bw.Login.Password
+				item.TOTP = bw.Login.TOTP
+				for _, uri := range bw.Login.URIs {
+					item.URLs = appendURL(item.URLs, uri.URI)
+				}
+			}
+		case bitwardenNote:
+			item.Type = items.TypeNote
+		case bitwardenCard:
+			item.Type = items.TypeCard
+			if bw.Card != nil {
+				item.Card = &items.Card{
+					Holder:   bw.Card.CardholderName,
+					Brand:    bw.Card.Brand,
+					Number:   bw.Card.Number,
+					ExpMonth: bw.Card.ExpMonth,
+					ExpYear:  bw.Card.ExpYear,
+					Code:     bw.Card.Code,
+				}
+			}
+		case bitwardenIdentity:
+			item.Type = items.TypeIdentity
+			for _, name := range bitwardenIdentityFields {
+				if value, ok := bw.Identity[name].(string); ok {
+					item.AddField(name, value, name == "ssn" || name == "passportNumber")
+				}
+			}
+		case bitwardenSSHKey:
+			item.Type = items.TypeNote
+			if bw.SSHKey != nil {
+				item.AddField("privateKey", bw.SSHKey.PrivateKey, true)
+				item.AddField("publicKey", bw.SSHKey.PublicKey, false)
+				item.AddField("fingerprint", bw.SSHKey.KeyFingerprint, false)
+			}
+		default:
+			result.skip(bw.Name, fmt.Sprintf("unknown Bitwarden item type %d", bw.Type))
+			continue
+		}
+
+		for _, field := range bw.Fields {
+			if field.Type == bitwardenFieldLinked {
+				continue
+			}
+			item.AddField(field.Name, field.Value, field.Type == bitwardenFieldHidden)
+		}
+
+		result.add(item)
+	}
+
+	return result.finish(), nil
+}
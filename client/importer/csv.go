@@ -0,0 +1,120 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+
+	"lembrago.com/lembrago/client/items"
+)
+
+// Nomes de coluna reconhecidos, já normalizados (minúsculas, sem espaços,
+// hífens ou sublinhados). Cobrem os CSVs do Bitwarden, LastPass, Chrome,
+// Firefox e KeePassXC; colunas desconhecidas viram campos personalizados.
+var csvColumns = map[string]string{
+	"name": "name", "title": "name", "account": "name",
+	"username": "username", "user": "username", "login": "username", "loginusername": "username", "email": "username",
+	"password": "password", "pass": "password", "loginpassword": "password",
+	"url": "url", "uri": "url", "website": "url", "loginuri": "url", "hostname": "url",
+	"notes": "notes", "note": "notes", "extra": "notes", "comments": "notes",
+	"totp": "totp", "otp": "totp", "logintotp": "totp", "otpauth": "totp",
+	"folder": "folder", "group": "folder", "grouping": "folder", "path": "folder",
+	"favorite": "favorite", "fav": "favorite",
+}
+
+// Colunas conhecidas que não carregam dados úteis para o item.
+var csvIgnored = map[string]bool{
+	"type": true, "fields": true, "reprompt": true, "httprealm": true, "formactionorigin": true,
+	"guid": true, "timecreated": true, "timelastused": true, "timepasswordchanged": true,
+	"lastmodified": true, "created": true, "icon": true,
+}
+
+func parseCSV(data []byte) (*Result, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("empty CSV")
+	}
+
+	header := records[0]
+	columns := make([]string, len(header))
+	recognized := 0
+	for i, name := range header {
+		normalized := normalizeColumn(name)
+		if column, ok := csvColumns[normalized]; ok {
+			columns[i] = column
+			recognized++
+		} else if csvIgnored[normalized] {
+			columns[i] = "-"
+		}
+	}
+	if recognized == 0 {
+		return nil, fmt.Errorf("CSV header has no recognized columns (expected name, username, password, url...)")
+	}
+
+	result := newResult(FormatCSV)
+	for line, record := range records[1:] {
+		if len(record) > len(header) {
+			result.warn("line %d has %d extra columns, ignored", line+2, len(record)-len(header))
+		}
+
+		item := items.Item{Type: items.TypeLogin}
+		for i, value := range record {
+			if i >= len(header) {
+				break
+			}
+			value = strings.TrimSpace(value)
+			switch columns[i] {
+			case "name":
+				item.Name = firstNonEmpty(item.Name, value)
+			case "username":
+				if item.Username == "" {
+					item.Username = value
+				} else {
+					item.AddField(header[i], value, false)
+				}
+			case "password":
+				item.Password = value
+			case "url":
+				item.URLs = appendURL(item.URLs, value)
+			case "notes":
+				item.Notes = value
+			case "totp":
+				item.TOTP = value
+			case "folder":
+				item.Folder = strings.Trim(strings.ReplaceAll(value, "\\", "/"), "/")
+			case "favorite":
+				item.Favorite = value == "1" || strings.EqualFold(value, "true") || strings.EqualFold(value, "yes")
+			case "-":
+			default:
+				item.AddField(header[i], value, false)
+			}
+		}
+
+		// LastPass exporta notas seguras como url "http://sn" sem usuário e senha.
+		if len(item.URLs) == 1 && item.URLs[0] == "http://sn" {
+			item.URLs = nil
+			item.Type = items.TypeNote
+		} else if item.Username == "" && item.Password == "" && len(item.URLs) == 0 && item.Notes != "" {
+			item.Type = items.TypeNote
+		}
+
+		result.add(item)
+	}
+
+	return result.finish(), nil
+}
+
+func normalizeColumn(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.NewReplacer(" ", "", "_", "", "-", "").Replace(name)
+}
@@ -0,0 +1,63 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"lembrago.com/lembrago/client/items"
+)
+
+// Exportação CSV do LastPass, com BOM, uma nota segura, uma linha vazia e uma
+// linha com coluna a mais.
+const csvFixture = "\xef\xbb\xbfurl,username,password,totp,extra,name,grouping,fav,Security Question\n" +
+	"https://github.com/login,octocat,gh-s3cret,JBSWY3DPEHPK3PXP,,GitHub,Work\\Dev,1,first pet?\n" +
+	"http://sn,,,,\"line one\nline two\",Wi-Fi,,0,\n" +
+	",,,,,,,,\n" +
+	"https://example.com,ana@example.com,\"pa,ss\"\"word\",,,Example,,,,surplus\n"
+
+func TestParseCSV(t *testing.T) {
+	result, err := parseNoPanic(t, FormatCSV, []byte(csvFixture), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := result.Report
+	if report.Format != FormatCSV || report.Parsed != 4 || report.Ready != 3 || report.ByType["login"] != 2 || report.ByType["note"] != 1 {
+		t.Fatalf("report = %+v", report)
+	}
+	if len(report.Skipped) != 1 || report.Skipped[0] != (Skipped{Name: "(unnamed)", Reason: "empty entry"}) {
+		t.Fatalf("skipped = %+v", report.Skipped)
+	}
+	if len(report.Warnings) != 1 || report.Warnings[0] != "line 5 has 1 extra columns, ignored" {
+		t.Fatalf("warnings = %v", report.Warnings)
+	}
+	if strings.Join(report.Folders, ",") != "Work/Dev" {
+		t.Fatalf("folders = %v", report.Folders)
+	}
+
+	github := result.Items[0]
+	if github.Name != "GitHub" || github.Username != "octocat" || github.Password != "gh-s3cret" || github.TOTP != "JBSWY3DPEHPK3PXP" ||
+		!github.Favorite || github.Folder != "Work/Dev" || len(github.Fields) != 1 || github.Fields[0] != (items.Field{Name: "Security Question", Value: "first pet?"}) {
+		t.Fatalf("GitHub = %+v", github)
+	}
+	if note := result.Items[1]; note.Type != items.TypeNote || len(note.URLs) != 0 || note.Notes != "line one\nline two" {
+		t.Fatalf("secure note = %+v", note)
+	}
+	if example := result.Items[2]; example.Password != `pa,ss"word` || example.Username != "ana@example.com" {
+		t.Fatalf("Example = %+v", example)
+	}
+}
+
+func TestParseCSVMalformed(t *testing.T) {
+	cases := map[string]string{
+		"empty":           "",
+		"only BOM":        "\xef\xbb\xbf",
+		"unknown columns": "foo,bar\n1,2\n",
+		"binary":          "\x00\x01\x02\x03",
+	}
+	for name, data := range cases {
+		if _, err := parseNoPanic(t, FormatCSV, []byte(data), Options{}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
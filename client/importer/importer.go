@@ -0,0 +1,178 @@
+// Package importer lê exportações de outros gerenciadores de senha localmente e
+// as converte em items.Item. Nada aqui fala com o servidor: o texto em claro
+// nunca sai da máquina do usuário.
+package importer
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"lembrago.com/lembrago/client/items"
+)
+
+type Format string
+
+const (
+	FormatBitwarden Format = "bitwarden"
+	FormatKDBX      Format = "kdbx"
+	Format1PUX      Format = "1pux"
+	FormatCSV       Format = "csv"
+)
+
+var Formats = []Format{FormatBitwarden, FormatKDBX, Format1PUX, FormatCSV}
+
+// Options carrega o que alguns formatos precisam para abrir o arquivo.
+type Options struct {
+	// Senha e arquivo-chave do banco KeePass.
+	Password string
+	KeyFile  []byte
+}
+
+// Result é o resultado de um parse: os itens e o relatório do que aconteceu.
+type Result struct {
+	Items  []items.Item
+	Report Report
+}
+
+type Report struct {
+	Format   Format         `json:"format"`
+	Parsed   int            `json:"parsed"`
+	Ready    int            `json:"ready"` // itens que serão enviados
+	ByType   map[string]int `json:"byType"`
+	Folders  []string       `json:"folders"`
+	Skipped  []Skipped      `json:"skipped"`
+	Warnings []string       `json:"warnings"`
+}
+
+type Skipped struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+func newResult(format Format) *Result {
+	return &Result{
+		Report: Report{
+			Format:   format,
+			ByType:   map[string]int{},
+			Folders:  []string{},
+			Skipped:  []Skipped{},
+			Warnings: []string{},
+		},
+	}
+}
+
+func (r *Result) add(item items.Item) {
+	if item.Name == "" {
+		item.Name = firstNonEmpty(hostOf(item.URLs), item.Username, "(unnamed)")
+	}
+	if item.IsEmpty() {
+		r.skip(item.Name, "empty entry")
+		return
+	}
+	r.Report.Parsed++
+	item.Version = items.SchemaVersion
+	item.Source = string(r.Report.Format)
+	r.Items = append(r.Items, item)
+	r.Report.Ready++
+	r.Report.ByType[string(item.Type)]++
+}
+
+func (r *Result) skip(name, reason string) {
+	r.Report.Parsed++
+	r.Report.Skipped = append(r.Report.Skipped, Skipped{Name: name, Reason: reason})
+}
+
+func (r *Result) warn(format string, args ...interface{}) {
+	r.Report.Warnings = append(r.Report.Warnings, fmt.Sprintf(format, args...))
+}
+
+func (r *Result) finish() *Result {
+	folders := map[string]bool{}
+	entries := map[string]int{}
+	for _, item := range r.Items {
+		if item.Folder != "" && !folders[item.Folder] {
+			folders[item.Folder] = true
+			r.Report.Folders = append(r.Report.Folders, item.Folder)
+		}
+
+		key := strings.ToLower(strings.Join([]string{item.Folder, item.Name, item.Username, hostOf(item.URLs)}, "\x00"))
+		entries[key]++
+		if entries[key] == 2 {
+			r.warn("%q appears more than once with the same username and site", item.Name)
+		}
+	}
+	return r
+}
+
+// DetectFormat escolhe o formato pela extensão do arquivo.
+func DetectFormat(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatBitwarden, nil
+	case ".kdbx":
+		return FormatKDBX, nil
+	case ".1pux":
+		return Format1PUX, nil
+	case ".csv":
+		return FormatCSV, nil
+	}
+	return "", fmt.Errorf("cannot detect format of %q, use one of %v", path, Formats)
+}
+
+// Parse lê todo o conteúdo de r no formato indicado.
+func Parse(format Format, r io.Reader, opts Options) (*Result, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatBitwarden:
+		return parseBitwarden(data)
+	case FormatKDBX:
+		return parseKDBX(data, opts)
+	case Format1PUX:
+		return parse1PUX(data)
+	case FormatCSV:
+		return parseCSV(data)
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+func hostOf(urls []string) string {
+	if len(urls) == 0 {
+		return ""
+	}
+	host := urls[0]
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	if i := strings.IndexAny(host, "/?#"); i >= 0 {
+		host = host[:i]
+	}
+	return host
+}
+
+func appendURL(urls []string, url string) []string {
+	url = strings.TrimSpace(url)
+	if url == "" {
+		return urls
+	}
+	for _, existing := range urls {
+		if existing == url {
+			return urls
+		}
+	}
+	return append(urls, url)
+}
@@ -0,0 +1,41 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	cases := map[string]Format{
+		"bitwarden_export.json": FormatBitwarden,
+		"Senhas.KDBX":           FormatKDBX,
+		"1PasswordExport.1pux":  Format1PUX,
+		"lastpass.csv":          FormatCSV,
+	}
+	for path, want := range cases {
+		if got, err := DetectFormat(path); err != nil || got != want {
+			t.Errorf("DetectFormat(%q) = %q, %v; want %q", path, got, err, want)
+		}
+	}
+	if _, err := DetectFormat("export.txt"); err == nil {
+		t.Error("DetectFormat(export.txt): expected an error")
+	}
+	if _, err := Parse("lastpass", strings.NewReader(""), Options{}); err == nil {
+		t.Error("Parse with an unknown format: expected an error")
+	}
+}
+
+// Arquivos cortados no meio (download interrompido, cópia incompleta) têm de
+// dar erro ou um relatório parcial, nunca pânico.
+func TestParseTruncatedInputs(t *testing.T) {
+	fixtures := map[Format][]byte{
+		FormatBitwarden: []byte(bitwardenFixture),
+		FormatCSV:       []byte(csvFixture),
+		Format1PUX:      build1PUX(t, map[string]string{"export.data": onePuxFixture}),
+	}
+	for format, data := range fixtures {
+		for n := 0; n < len(data); n++ {
+			parseNoPanic(t, format, data[:n], Options{})
+		}
+	}
+}
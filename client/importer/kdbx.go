@@ -0,0 +1,693 @@
+package importer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/salsa20/salsa"
+	"lembrago.com/lembrago/client/items"
+)
+
+// Leitor de bancos KeePass KDBX 4.x (KeePass 2.35+, KeePassXC 2.7+).
+// Referência do formato: https://keepass.info/help/kb/kdbx_4.html
+
+const (
+	kdbxSignature1 = 0x9AA2D903
+	kdbxSignature2 = 0xB54BFB67
+
+	kdbxEndOfHeader     = 0
+	kdbxCipherID        = 2
+	kdbxCompression     = 3
+	kdbxMasterSeed      = 4
+	kdbxEncryptionIV    = 7
+	kdbxKdfParameters   = 11
+	kdbxInnerStreamID   = 1
+	kdbxInnerStreamKey  = 2
+	kdbxStreamSalsa20   = 2
+	kdbxStreamChaCha20  = 3
+	kdbxCompressionGzip = 1
+)
+
+var (
+	kdbxCipherAES256   = mustUUID("31c1f2e6-bf71-4350-be58-05216afc5aff")
+	kdbxCipherChaCha20 = mustUUID("d6038a2b-8b6f-4cb5-a524-339a31dbb59a")
+	kdbxCipherTwofish  = mustUUID("ad68f29f-576f-4bb9-a36a-d47af965346c")
+
+	kdbxKdfAES     = mustUUID("c9d9f39a-628a-4460-bf74-0d08c18a4fea")
+	kdbxKdfAES4    = mustUUID("7c02bb82-79a7-4ac0-927d-114a00648238")
+	kdbxKdfArgon2d = mustUUID("ef636ddf-8c29-444b-91f7-a9a403e30a0c")
+	kdbxKdfArgon2i = mustUUID("9e298b19-56db-4773-b23d-fc3ec6f0a1e6")
+
+	ErrKDBXCredentials = errors.New("invalid KeePass password or key file")
+)
+
+type kdbxHeader struct {
+	cipherID     []byte
+	compression  uint32
+	masterSeed   []byte
+	encryptionIV []byte
+	kdfParams    map[string]interface{}
+}
+
+func parseKDBX(data []byte, opts Options) (*Result, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("invalid KDBX file")
+	}
+	if binary.LittleEndian.Uint32(data[0:4]) != kdbxSignature1 || binary.LittleEndian.Uint32(data[4:8]) != kdbxSignature2 {
+		return nil, fmt.Errorf("not a KeePass KDBX file")
+	}
+	major := binary.LittleEndian.Uint16(data[10:12])
+	if major != 4 {
+		return nil, fmt.Errorf("KDBX %d.x is not supported, save the database as KDBX 4 first", major)
+	}
+
+	header, headerEnd, err := readKDBXHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < headerEnd+64 {
+		return nil, fmt.Errorf("truncated KDBX file")
+	}
+
+	headerBytes := data[:headerEnd]
+	headerHash := sha256.Sum256(headerBytes)
+	if !hmac.Equal(headerHash[:], data[headerEnd:headerEnd+32]) {
+		return nil, fmt.Errorf("corrupted KDBX header")
+	}
+
+	compositeKey, err := kdbxCompositeKey(opts)
+	if err != nil {
+		return nil, err
+	}
+	transformedKey, err := kdbxTransformKey(compositeKey, header.kdfParams)
+	if err != nil {
+		return nil, err
+	}
+
+	encryptionKey := sha256.Sum256(append(append([]byte{}, header.masterSeed...), transformedKey...))
+	hmacKey := sha512.Sum512(append(append(append([]byte{}, header.masterSeed...), transformedKey...), 1))
+
+	headerMAC := hmac.New(sha256.New, kdbxBlockKey(hmacKey[:], ^uint64(0)))
+	headerMAC.Write(headerBytes)
+	if !hmac.Equal(headerMAC.Sum(nil), data[headerEnd+32:headerEnd+64]) {
+		return nil, ErrKDBXCredentials
+	}
+
+	ciphertext, err := readKDBXBlocks(data[headerEnd+64:], hmacKey[:])
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := kdbxDecrypt(header, encryptionKey[:], ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	if header.compression == kdbxCompressionGzip {
+		reader, err := gzip.NewReader(bytes.NewReader(plaintext))
+		if err != nil {
+			return nil, fmt.Errorf("invalid KDBX payload: %w", err)
+		}
+		plaintext, err = io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("invalid KDBX payload: %w", err)
+		}
+	}
+
+	stream, xmlStart, err := readKDBXInnerHeader(plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newResult(FormatKDBX)
+	if err := parseKeePassXML(plaintext[xmlStart:], stream, result); err != nil {
+		return nil, err
+	}
+
+	return result.finish(), nil
+}
+
+func readKDBXHeader(data []byte) (*kdbxHeader, int, error) {
+	header := &kdbxHeader{}
+	pos := 12
+	for {
+		if pos+5 > len(data) {
+			return nil, 0, fmt.Errorf("truncated KDBX header")
+		}
+		id := data[pos]
+		size := int(binary.LittleEndian.Uint32(data[pos+1 : pos+5]))
+		pos += 5
+		if size < 0 || pos+size > len(data) {
+			return nil, 0, fmt.Errorf("truncated KDBX header")
+		}
+		value := data[pos : pos+size]
+		pos += size
+
+		switch id {
+		case kdbxEndOfHeader:
+			if header.cipherID == nil || header.masterSeed == nil || header.kdfParams == nil {
+				return nil, 0, fmt.Errorf("incomplete KDBX header")
+			}
+			return header, pos, nil
+		case kdbxCipherID:
+			header.cipherID = value
+		case kdbxCompression:
+			if len(value) == 4 {
+				header.compression = binary.LittleEndian.Uint32(value)
+			}
+		case kdbxMasterSeed:
+			header.masterSeed = value
+		case kdbxEncryptionIV:
+			header.encryptionIV = value
+		case kdbxKdfParameters:
+			params, err := readVariantDictionary(value)
+			if err != nil {
+				return nil, 0, err
+			}
+			header.kdfParams = params
+		}
+	}
+}
+
+// readVariantDictionary decodifica o VariantDictionary do KDBX 4.
+func readVariantDictionary(data []byte) (map[string]interface{}, error) {
+	if len(data) < 2 || data[1] != 0x01 {
+		return nil, fmt.Errorf("unsupported KDF parameters version")
+	}
+
+	values := map[string]interface{}{}
+	pos := 2
+	for pos < len(data) {
+		kind := data[pos]
+		pos++
+		if kind == 0 {
+			return values, nil
+		}
+
+		if pos+4 > len(data) {
+			break
+		}
+		keyLen := int(binary.LittleEndian.Uint32(data[pos:]))
+		pos += 4
+		if keyLen < 0 || pos+keyLen+4 > len(data) {
+			break
+		}
+		key := string(data[pos : pos+keyLen])
+		pos += keyLen
+		valueLen := int(binary.LittleEndian.Uint32(data[pos:]))
+		pos += 4
+		if valueLen < 0 || pos+valueLen > len(data) {
+			break
+		}
+		value := data[pos : pos+valueLen]
+		pos += valueLen
+
+		switch kind {
+		case 0x04, 0x0C:
+			if len(value) == 4 {
+				values[key] = uint64(binary.LittleEndian.Uint32(value))
+			}
+		case 0x05, 0x0D:
+			if len(value) == 8 {
+				values[key] = binary.LittleEndian.Uint64(value)
+			}
+		case 0x08:
+			values[key] = len(value) > 0 && value[0] != 0
+		case 0x18:
+			values[key] = string(value)
+		case 0x42:
+			values[key] = value
+		}
+	}
+	return nil, fmt.Errorf("invalid KDF parameters")
+}
+
+func kdbxCompositeKey(opts Options) ([]byte, error) {
+	composite := sha256.New()
+	if opts.Password != "" || opts.KeyFile == nil {
+		passwordHash := sha256.Sum256([]byte(opts.Password))
+		composite.Write(passwordHash[:])
+	}
+	if opts.KeyFile != nil {
+		keyFileKey, err := kdbxKeyFileKey(opts.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		composite.Write(keyFileKey)
+	}
+	return composite.Sum(nil), nil
+}
+
+// kdbxKeyFileKey aceita arquivos-chave XML (v1 e v2), binários de 32 bytes,
+// 64 caracteres hex ou qualquer outro arquivo (usado via SHA-256).
+func kdbxKeyFileKey(keyFile []byte) ([]byte, error) {
+	trimmed := bytes.TrimSpace(keyFile)
+
+	if bytes.HasPrefix(trimmed, []byte("<?xml")) || bytes.HasPrefix(trimmed, []byte("<KeyFile")) {
+		var doc struct {
+			Meta struct {
+				Version string `xml:"Version"`
+			} `xml:"Meta"`
+			Key struct {
+				Data string `xml:"Data"`
+			} `xml:"Key"`
+		}
+		if err := xml.Unmarshal(trimmed, &doc); err == nil && doc.Key.Data != "" {
+			if strings.HasPrefix(doc.Meta.Version, "2.") {
+				key, err := hex.DecodeString(strings.Join(strings.Fields(doc.Key.Data), ""))
+				if err != nil || len(key) != 32 {
+					return nil, fmt.Errorf("invalid KeePass key file")
+				}
+				return key, nil
+			}
+			key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(doc.Key.Data))
+			if err != nil {
+				return nil, fmt.Errorf("invalid KeePass key file")
+			}
+			return key, nil
+		}
+	}
+
+	if len(keyFile) == 32 {
+		return keyFile, nil
+	}
+	if len(keyFile) == 64 {
+		if key, err := hex.DecodeString(string(keyFile)); err == nil {
+			return key, nil
+		}
+	}
+
+	sum := sha256.Sum256(keyFile)
+	return sum[:], nil
+}
+
+func kdbxTransformKey(compositeKey []byte, params map[string]interface{}) ([]byte, error) {
+	uuid, _ := params["$UUID"].([]byte)
+	salt, _ := params["S"].([]byte)
+
+	switch {
+	case bytes.Equal(uuid, kdbxKdfArgon2d), bytes.Equal(uuid, kdbxKdfArgon2i):
+		mode := argon2d
+		if bytes.Equal(uuid, kdbxKdfArgon2i) {
+			mode = argon2id
+		}
+		iterations, _ := params["I"].(uint64)
+		memory, _ := params["M"].(uint64)
+		parallelism, _ := params["P"].(uint64)
+		version, _ := params["V"].(uint64)
+		secret, _ := params["K"].([]byte)
+		assoc, _ := params["A"].([]byte)
+		if version != argon2Version {
+			return nil, fmt.Errorf("unsupported Argon2 version 0x%x", version)
+		}
+		if iterations > 1<<32-1 || parallelism > 1<<24 {
+			return nil, fmt.Errorf("invalid Argon2 parameters")
+		}
+		return argon2Key(mode, compositeKey, salt, secret, assoc, uint32(iterations), uint32(memory/1024), uint32(parallelism), 32)
+
+	case bytes.Equal(uuid, kdbxKdfAES), bytes.Equal(uuid, kdbxKdfAES4):
+		rounds, _ := params["R"].(uint64)
+		if len(salt) != 32 {
+			return nil, fmt.Errorf("invalid AES-KDF seed")
+		}
+		block, err := aes.NewCipher(salt)
+		if err != nil {
+			return nil, err
+		}
+		key := append([]byte{}, compositeKey...)
+		for i := uint64(0); i < rounds; i++ {
+			block.Encrypt(key[0:16], key[0:16])
+			block.Encrypt(key[16:32], key[16:32])
+		}
+		sum := sha256.Sum256(key)
+		return sum[:], nil
+	}
+
+	return nil, fmt.Errorf("unsupported KeePass key derivation function")
+}
+
+func kdbxBlockKey(hmacKey []byte, index uint64) []byte {
+	var prefix [8]byte
+	binary.LittleEndian.PutUint64(prefix[:], index)
+	sum := sha512.Sum512(append(prefix[:], hmacKey...))
+	return sum[:]
+}
+
+// readKDBXBlocks verifica o HMAC de cada bloco e concatena o conteúdo cifrado.
+func readKDBXBlocks(data []byte, hmacKey []byte) ([]byte, error) {
+	var out bytes.Buffer
+	pos := 0
+	for index := uint64(0); ; index++ {
+		if pos+36 > len(data) {
+			return nil, fmt.Errorf("truncated KDBX payload")
+		}
+		mac := data[pos : pos+32]
+		size := int(int32(binary.LittleEndian.Uint32(data[pos+32 : pos+36])))
+		pos += 36
+		if size < 0 || pos+size > len(data) {
+			return nil, fmt.Errorf("truncated KDBX payload")
+		}
+		block := data[pos : pos+size]
+		pos += size
+
+		var prefix [12]byte
+		binary.LittleEndian.PutUint64(prefix[:8], index)
+		binary.LittleEndian.PutUint32(prefix[8:], uint32(size))
+		blockMAC := hmac.New(sha256.New, kdbxBlockKey(hmacKey, index))
+		blockMAC.Write(prefix[:])
+		blockMAC.Write(block)
+		if !hmac.Equal(blockMAC.Sum(nil), mac) {
+			return nil, fmt.Errorf("corrupted KDBX payload (block %d)", index)
+		}
+
+		if size == 0 {
+			return out.Bytes(), nil
+		}
+		out.Write(block)
+	}
+}
+
+func kdbxDecrypt(header *kdbxHeader, key, ciphertext []byte) ([]byte, error) {
+	switch {
+	case bytes.Equal(header.cipherID, kdbxCipherAES256):
+		if len(header.encryptionIV) != aes.BlockSize || len(ciphertext)%aes.BlockSize != 0 || len(ciphertext) == 0 {
+			return nil, fmt.Errorf("invalid KDBX payload")
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		plaintext := make([]byte, len(ciphertext))
+		cipher.NewCBCDecrypter(block, header.encryptionIV).CryptBlocks(plaintext, ciphertext)
+		padding := int(plaintext[len(plaintext)-1])
+		if padding == 0 || padding > aes.BlockSize {
+			return nil, fmt.Errorf("invalid KDBX padding")
+		}
+		return plaintext[:len(plaintext)-padding], nil
+
+	case bytes.Equal(header.cipherID, kdbxCipherChaCha20):
+		stream, err := chacha20.NewUnauthenticatedCipher(key, header.encryptionIV)
+		if err != nil {
+			return nil, err
+		}
+		plaintext := make([]byte, len(ciphertext))
+		stream.XORKeyStream(plaintext, ciphertext)
+		return plaintext, nil
+
+	case bytes.Equal(header.cipherID, kdbxCipherTwofish):
+		return nil, fmt.Errorf("Twofish-encrypted KeePass databases are not supported, switch the cipher to AES or ChaCha20")
+	}
+	return nil, fmt.Errorf("unsupported KeePass cipher")
+}
+
+// readKDBXInnerHeader devolve o gerador de keystream dos campos protegidos e
+// onde começa o XML. Os anexos (binários) são ignorados.
+func readKDBXInnerHeader(data []byte) (cipher.Stream, int, error) {
+	var streamID uint32
+	var streamKey []byte
+
+	pos := 0
+	for {
+		if pos+5 > len(data) {
+			return nil, 0, fmt.Errorf("truncated KDBX inner header")
+		}
+		id := data[pos]
+		size := int(binary.LittleEndian.Uint32(data[pos+1 : pos+5]))
+		pos += 5
+		if size < 0 || pos+size > len(data) {
+			return nil, 0, fmt.Errorf("truncated KDBX inner header")
+		}
+		value := data[pos : pos+size]
+		pos += size
+
+		switch id {
+		case kdbxInnerStreamID:
+			if len(value) == 4 {
+				streamID = binary.LittleEndian.Uint32(value)
+			}
+		case kdbxInnerStreamKey:
+			streamKey = value
+		case kdbxEndOfHeader:
+			stream, err := newKDBXInnerStream(streamID, streamKey)
+			return stream, pos, err
+		}
+	}
+}
+
+func newKDBXInnerStream(id uint32, key []byte) (cipher.Stream, error) {
+	switch id {
+	case kdbxStreamChaCha20:
+		sum := sha512.Sum512(key)
+		return chacha20.NewUnauthenticatedCipher(sum[:32], sum[32:44])
+	case kdbxStreamSalsa20:
+		sum := sha256.Sum256(key)
+		return &salsa20Stream{key: sum, nonce: [8]byte{0xE8, 0x30, 0x09, 0x4B, 0x97, 0x20, 0x5D, 0x2A}}, nil
+	}
+	return nil, fmt.Errorf("unsupported KeePass inner stream %d", id)
+}
+
+// salsa20Stream mantém a posição do keystream entre chamadas, como o KeePass espera.
+type salsa20Stream struct {
+	key     [32]byte
+	nonce   [8]byte
+	counter uint64
+	buffer  [64]byte
+	used    int
+}
+
+func (s *salsa20Stream) XORKeyStream(dst, src []byte) {
+	for i := range src {
+		if s.used == 0 || s.used == len(s.buffer) {
+			var in [16]byte
+			copy(in[:8], s.nonce[:])
+			binary.LittleEndian.PutUint64(in[8:], s.counter)
+			var zero [64]byte
+			salsa.XORKeyStream(s.buffer[:], zero[:], &in, &s.key)
+			s.counter++
+			s.used = 0
+		}
+		dst[i] = src[i] ^ s.buffer[s.used]
+		s.used++
+	}
+}
+
+type keepassGroup struct {
+	uuid    string
+	name    string
+	recycle bool
+}
+
+type keepassEntry struct {
+	strings   map[string]string
+	protected map[string]bool
+	order     []string
+	tags      string
+	binaries  int
+}
+
+// parseKeePassXML percorre o XML em ordem de documento: os valores protegidos
+// precisam ser decifrados exatamente nessa ordem, inclusive os do histórico.
+func parseKeePassXML(data []byte, stream cipher.Stream, result *Result) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	var path []string
+	var groups []keepassGroup
+	var entry *keepassEntry
+	var text strings.Builder
+	var key string
+	protectedValue := false
+	historyDepth := 0
+	recycleBinUUID := ""
+
+	parent := func() string {
+		if len(path) < 2 {
+			return ""
+		}
+		return path[len(path)-2]
+	}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("invalid KeePass XML: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			path = append(path, t.Name.Local)
+			text.Reset()
+
+			switch t.Name.Local {
+			case "Group":
+				groups = append(groups, keepassGroup{})
+			case "History":
+				historyDepth++
+			case "Entry":
+				if historyDepth == 0 {
+					entry = &keepassEntry{strings: map[string]string{}, protected: map[string]bool{}}
+				}
+			case "Value":
+				protectedValue = false
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "Protected" && strings.EqualFold(attr.Value, "true") {
+						protectedValue = true
+					}
+				}
+			}
+
+		case xml.CharData:
+			text.Write(t)
+
+		case xml.EndElement:
+			value := text.String()
+			text.Reset()
+
+			switch t.Name.Local {
+			case "RecycleBinUUID":
+				if parent() == "Meta" {
+					recycleBinUUID = strings.TrimSpace(value)
+				}
+			case "UUID":
+				if parent() == "Group" && len(groups) > 0 {
+					group := &groups[len(groups)-1]
+					group.uuid = strings.TrimSpace(value)
+					group.recycle = recycleBinUUID != "" && group.uuid == recycleBinUUID
+				}
+			case "Name":
+				if parent() == "Group" && len(groups) > 0 {
+					groups[len(groups)-1].name = value
+				}
+			case "Key":
+				if parent() == "String" {
+					key = value
+				}
+			case "Value":
+				if protectedValue {
+					raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+					if err != nil {
+						return fmt.Errorf("invalid protected value in KeePass XML")
+					}
+					stream.XORKeyStream(raw, raw)
+					value = string(raw)
+				}
+				if parent() == "String" && historyDepth == 0 && entry != nil {
+					if _, exists := entry.strings[key]; !exists {
+						entry.order = append(entry.order, key)
+					}
+					entry.strings[key] = value
+					entry.protected[key] = protectedValue
+				}
+				protectedValue = false
+			case "Tags":
+				if parent() == "Entry" && historyDepth == 0 && entry != nil {
+					entry.tags = value
+				}
+			case "Binary":
+				if parent() == "Entry" && historyDepth == 0 && entry != nil {
+					entry.binaries++
+				}
+			case "History":
+				historyDepth--
+			case "Entry":
+				if historyDepth == 0 && entry != nil {
+					addKeePassEntry(result, groups, entry)
+					entry = nil
+				}
+			case "Group":
+				if len(groups) > 0 {
+					groups = groups[:len(groups)-1]
+				}
+			}
+
+			if len(path) > 0 {
+				path = path[:len(path)-1]
+			}
+		}
+	}
+
+	return nil
+}
+
+func addKeePassEntry(result *Result, groups []keepassGroup, entry *keepassEntry) {
+	title := entry.strings["Title"]
+	for _, group := range groups {
+		if group.recycle {
+			result.skip(title, "recycle bin")
+			return
+		}
+	}
+
+	// O primeiro grupo é a raiz do banco; as pastas começam abaixo dele.
+	var folders []string
+	for i, group := range groups {
+		if i > 0 {
+			folders = append(folders, group.name)
+		}
+	}
+
+	item := items.Item{
+		Type:     items.TypeLogin,
+		Name:     title,
+		Username: entry.strings["UserName"],
+		Password: entry.strings["Password"],
+		Notes:    entry.strings["Notes"],
+		Folder:   strings.Join(folders, "/"),
+	}
+	item.URLs = appendURL(item.URLs, entry.strings["URL"])
+
+	for _, tag := range strings.FieldsFunc(entry.tags, func(r rune) bool { return r == ';' || r == ',' }) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			item.Tags = append(item.Tags, tag)
+		}
+	}
+
+	for _, name := range entry.order {
+		value := entry.strings[name]
+		switch {
+		case name == "Title" || name == "UserName" || name == "Password" || name == "Notes" || name == "URL":
+		case name == "otp" || name == "TOTP Seed" || name == "TimeOtp-Secret-Base32":
+			if item.TOTP == "" {
+				item.TOTP = value
+			}
+		case name == "TOTP Settings":
+		case strings.HasPrefix(name, "KP2A_URL") || strings.HasPrefix(name, "KeePassXC-Browser URL"):
+			item.URLs = appendURL(item.URLs, value)
+		default:
+			item.AddField(name, value, entry.protected[name])
+		}
+	}
+
+	if item.Username == "" && item.Password == "" && len(item.URLs) == 0 && item.Notes != "" {
+		item.Type = items.TypeNote
+	}
+	if entry.binaries > 0 {
+		result.warn("%q: %d attachment(s) not imported", title, entry.binaries)
+	}
+
+	result.add(item)
+}
+
+func mustUUID(s string) []byte {
+	b, err := hex.DecodeString(strings.ReplaceAll(s, "-", ""))
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
@@ -0,0 +1,368 @@
+package importer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/chacha20"
+	"lembrago.com/lembrago/client/items"
+)
+
+const kdbxTestPassword = "correct horse battery staple"
+
+// kdbxFixture descreve como montar um banco KDBX 4 de teste.
+type kdbxFixture struct {
+	cipher      []byte
+	kdf         []kdbxVariant
+	gzip        bool
+	innerStream uint32
+}
+
+type kdbxVariant struct {
+	kind  byte
+	key   string
+	value []byte
+}
+
+func kdbxUint32(v uint32) []byte {
+	return binary.LittleEndian.AppendUint32(nil, v)
+}
+
+func kdbxUint64(v uint64) []byte {
+	return binary.LittleEndian.AppendUint64(nil, v)
+}
+
+var (
+	kdbxAESKDF = []kdbxVariant{
+		{0x42, "$UUID", kdbxKdfAES},
+		{0x42, "S", bytes.Repeat([]byte{0x11}, 32)},
+		{0x05, "R", kdbxUint64(100)},
+	}
+	kdbxArgon2dKDF = []kdbxVariant{
+		{0x42, "$UUID", kdbxKdfArgon2d},
+		{0x42, "S", bytes.Repeat([]byte{0x22}, 32)},
+		{0x05, "I", kdbxUint64(2)},
+		{0x05, "M", kdbxUint64(64 * 1024)},
+		{0x04, "P", kdbxUint32(2)},
+		{0x04, "V", kdbxUint32(argon2Version)},
+	}
+	kdbxArgon2idKDF = []kdbxVariant{
+		{0x42, "$UUID", kdbxKdfArgon2i},
+		{0x42, "S", bytes.Repeat([]byte{0x33}, 32)},
+		{0x05, "I", kdbxUint64(1)},
+		{0x05, "M", kdbxUint64(32 * 1024)},
+		{0x04, "P", kdbxUint32(1)},
+		{0x04, "V", kdbxUint32(argon2Version)},
+	}
+)
+
+func writeVariantDictionary(entries []kdbxVariant) []byte {
+	out := []byte{0x00, 0x01}
+	for _, entry := range entries {
+		out = append(out, entry.kind)
+		out = append(out, kdbxUint32(uint32(len(entry.key)))...)
+		out = append(out, entry.key...)
+		out = append(out, kdbxUint32(uint32(len(entry.value)))...)
+		out = append(out, entry.value...)
+	}
+	return append(out, 0x00)
+}
+
+func writeKDBXField(buf *bytes.Buffer, id byte, value []byte) {
+	buf.WriteByte(id)
+	buf.Write(kdbxUint32(uint32(len(value))))
+	buf.Write(value)
+}
+
+// kdbxTestXML monta o XML do banco, cifrando os valores protegidos na ordem
+// do documento, inclusive o do histórico.
+func kdbxTestXML(stream cipher.Stream) string {
+	protect := func(value string) string {
+		raw := []byte(value)
+		stream.XORKeyStream(raw, raw)
+		return base64.StdEncoding.EncodeToString(raw)
+	}
+
+	return `<?xml version="1.0" encoding="utf-8" standalone="yes"?>
+<KeePassFile>
+	<Meta><RecycleBinUUID>cmVjeWNsZWJpbg==</RecycleBinUUID></Meta>
+	<Root>
+		<Group>
+			<UUID>cm9vdA==</UUID>
+			<Name>Root</Name>
+			<Entry>
+				<String><Key>Title</Key><Value>GitHub</Value></String>
+				<String><Key>UserName</Key><Value>octocat</Value></String>
+				<String><Key>Password</Key><Value Protected="True">` + protect("gh-s3cret") + `</Value></String>
+				<String><Key>URL</Key><Value>https://github.com/login</Value></String>
+				<String><Key>otp</Key><Value Protected="True">` + protect("otpauth://totp/GitHub?secret=JBSWY3DPEHPK3PXP") + `</Value></String>
+				<String><Key>Recovery</Key><Value Protected="True">` + protect("1234-5678") + `</Value></String>
+				<Tags>dev;work</Tags>
+				<History>
+					<Entry>
+						<String><Key>Title</Key><Value>GitHub</Value></String>
+						<String><Key>Password</Key><Value Protected="True">` + protect("old-password") + `</Value></String>
+					</Entry>
+				</History>
+			</Entry>
+			<Group>
+				<UUID>ZW1haWw=</UUID>
+				<Name>Email</Name>
+				<Entry>
+					<String><Key>Title</Key><Value>Gmail</Value></String>
+					<String><Key>UserName</Key><Value>ana@example.com</Value></String>
+					<String><Key>Password</Key><Value Protected="True">` + protect("gm-s3cret") + `</Value></String>
+					<Binary><Key>backup-codes.txt</Key><Value Ref="0"/></Binary>
+				</Entry>
+			</Group>
+			<Group>
+				<UUID>cmVjeWNsZWJpbg==</UUID>
+				<Name>Recycle Bin</Name>
+				<Entry>
+					<String><Key>Title</Key><Value>Old bank</Value></String>
+					<String><Key>Password</Key><Value Protected="True">` + protect("deleted") + `</Value></String>
+				</Entry>
+			</Group>
+		</Group>
+	</Root>
+</KeePassFile>`
+}
+
+// buildKDBX escreve um banco KDBX 4.0 seguindo https://keepass.info/help/kb/kdbx_4.html.
+func buildKDBX(t *testing.T, fixture kdbxFixture, password string) []byte {
+	t.Helper()
+
+	masterSeed := bytes.Repeat([]byte{0x44}, 32)
+	iv := bytes.Repeat([]byte{0x55}, aes.BlockSize)
+	if bytes.Equal(fixture.cipher, kdbxCipherChaCha20) {
+		iv = iv[:chacha20.NonceSize]
+	}
+	compression := uint32(0)
+	if fixture.gzip {
+		compression = kdbxCompressionGzip
+	}
+
+	var header bytes.Buffer
+	header.Write(kdbxUint32(kdbxSignature1))
+	header.Write(kdbxUint32(kdbxSignature2))
+	header.Write([]byte{0x00, 0x00, 0x04, 0x00})
+	writeKDBXField(&header, kdbxCipherID, fixture.cipher)
+	writeKDBXField(&header, kdbxCompression, kdbxUint32(compression))
+	writeKDBXField(&header, kdbxMasterSeed, masterSeed)
+	writeKDBXField(&header, kdbxEncryptionIV, iv)
+	writeKDBXField(&header, kdbxKdfParameters, writeVariantDictionary(fixture.kdf))
+	writeKDBXField(&header, kdbxEndOfHeader, []byte("\r\n\r\n"))
+
+	passwordHash := sha256.Sum256([]byte(password))
+	compositeKey := sha256.Sum256(passwordHash[:])
+	params, err := readVariantDictionary(writeVariantDictionary(fixture.kdf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	transformedKey, err := kdbxTransformKey(compositeKey[:], params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryptionKey := sha256.Sum256(append(append([]byte{}, masterSeed...), transformedKey...))
+	hmacKey := sha512.Sum512(append(append(append([]byte{}, masterSeed...), transformedKey...), 1))
+
+	innerKey := bytes.Repeat([]byte{0x66}, 64)
+	stream, err := newKDBXInnerStream(fixture.innerStream, innerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload bytes.Buffer
+	writeKDBXField(&payload, kdbxInnerStreamID, kdbxUint32(fixture.innerStream))
+	writeKDBXField(&payload, kdbxInnerStreamKey, innerKey)
+	writeKDBXField(&payload, 3, []byte("\x01attachment"))
+	writeKDBXField(&payload, kdbxEndOfHeader, nil)
+	payload.WriteString(kdbxTestXML(stream))
+
+	plaintext := payload.Bytes()
+	if fixture.gzip {
+		var compressed bytes.Buffer
+		writer := gzip.NewWriter(&compressed)
+		writer.Write(plaintext)
+		writer.Close()
+		plaintext = compressed.Bytes()
+	}
+
+	var ciphertext []byte
+	if bytes.Equal(fixture.cipher, kdbxCipherChaCha20) {
+		stream, err := chacha20.NewUnauthenticatedCipher(encryptionKey[:], iv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ciphertext = make([]byte, len(plaintext))
+		stream.XORKeyStream(ciphertext, plaintext)
+	} else {
+		padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+		plaintext = append(plaintext, bytes.Repeat([]byte{byte(padding)}, padding)...)
+		block, _ := aes.NewCipher(encryptionKey[:])
+		ciphertext = make([]byte, len(plaintext))
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, plaintext)
+	}
+
+	out := bytes.NewBuffer(append([]byte{}, header.Bytes()...))
+	headerHash := sha256.Sum256(header.Bytes())
+	out.Write(headerHash[:])
+	headerMAC := hmac.New(sha256.New, kdbxBlockKey(hmacKey[:], ^uint64(0)))
+	headerMAC.Write(header.Bytes())
+	out.Write(headerMAC.Sum(nil))
+
+	// Dois blocos de dados, para exercitar o índice no HMAC, e o bloco final vazio.
+	half := len(ciphertext) / 2
+	for index, block := range [][]byte{ciphertext[:half], ciphertext[half:], nil} {
+		var prefix [12]byte
+		binary.LittleEndian.PutUint64(prefix[:8], uint64(index))
+		binary.LittleEndian.PutUint32(prefix[8:], uint32(len(block)))
+		blockMAC := hmac.New(sha256.New, kdbxBlockKey(hmacKey[:], uint64(index)))
+		blockMAC.Write(prefix[:])
+		blockMAC.Write(block)
+		out.Write(blockMAC.Sum(nil))
+		out.Write(kdbxUint32(uint32(len(block))))
+		out.Write(block)
+	}
+	return out.Bytes()
+}
+
+func TestParseKDBX(t *testing.T) {
+	fixtures := map[string]kdbxFixture{
+		"AES-KDF, AES-256, gzip, Salsa20":   {cipher: kdbxCipherAES256, kdf: kdbxAESKDF, gzip: true, innerStream: kdbxStreamSalsa20},
+		"Argon2d, ChaCha20, ChaCha20":       {cipher: kdbxCipherChaCha20, kdf: kdbxArgon2dKDF, innerStream: kdbxStreamChaCha20},
+		"Argon2id, AES-256, gzip, ChaCha20": {cipher: kdbxCipherAES256, kdf: kdbxArgon2idKDF, gzip: true, innerStream: kdbxStreamChaCha20},
+	}
+	for name, fixture := range fixtures {
+		t.Run(name, func(t *testing.T) {
+			data := buildKDBX(t, fixture, kdbxTestPassword)
+			result, err := Parse(FormatKDBX, bytes.NewReader(data), Options{Password: kdbxTestPassword})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			report := result.Report
+			if report.Format != FormatKDBX || report.Parsed != 3 || report.Ready != 2 || report.ByType["login"] != 2 {
+				t.Fatalf("report = %+v", report)
+			}
+			if len(report.Skipped) != 1 || report.Skipped[0] != (Skipped{Name: "Old bank", Reason: "recycle bin"}) {
+				t.Fatalf("skipped = %+v", report.Skipped)
+			}
+			if strings.Join(report.Folders, ",") != "Email" {
+				t.Fatalf("folders = %v", report.Folders)
+			}
+			if len(report.Warnings) != 1 || !strings.Contains(report.Warnings[0], `"Gmail": 1 attachment(s)`) {
+				t.Fatalf("warnings = %v", report.Warnings)
+			}
+
+			github, gmail := result.Items[0], result.Items[1]
+			if github.Name != "GitHub" || github.Username != "octocat" || github.Password != "gh-s3cret" ||
+				github.TOTP != "otpauth://totp/GitHub?secret=JBSWY3DPEHPK3PXP" || github.Folder != "" ||
+				strings.Join(github.URLs, ",") != "https://github.com/login" || strings.Join(github.Tags, ",") != "dev,work" ||
+				github.Source != "kdbx" || github.Version != items.SchemaVersion {
+				t.Fatalf("GitHub = %+v", github)
+			}
+			if len(github.Fields) != 1 || github.Fields[0] != (items.Field{Name: "Recovery", Value: "1234-5678", Hidden: true}) {
+				t.Fatalf("GitHub fields = %+v", github.Fields)
+			}
+			if gmail.Name != "Gmail" || gmail.Password != "gm-s3cret" || gmail.Folder != "Email" {
+				t.Fatalf("Gmail = %+v", gmail)
+			}
+		})
+	}
+}
+
+func TestParseKDBXWrongPassword(t *testing.T) {
+	data := buildKDBX(t, kdbxFixture{cipher: kdbxCipherAES256, kdf: kdbxAESKDF, innerStream: kdbxStreamChaCha20}, kdbxTestPassword)
+
+	_, err := Parse(FormatKDBX, bytes.NewReader(data), Options{Password: "wrong"})
+	if !errors.Is(err, ErrKDBXCredentials) {
+		t.Fatalf("err = %v, want ErrKDBXCredentials", err)
+	}
+	_, err = Parse(FormatKDBX, bytes.NewReader(data), Options{KeyFile: []byte("not the key")})
+	if !errors.Is(err, ErrKDBXCredentials) {
+		t.Fatalf("key file only: err = %v, want ErrKDBXCredentials", err)
+	}
+}
+
+func TestParseKDBXMalformed(t *testing.T) {
+	fixture := kdbxFixture{cipher: kdbxCipherAES256, kdf: kdbxAESKDF, gzip: true, innerStream: kdbxStreamSalsa20}
+	valid := buildKDBX(t, fixture, kdbxTestPassword)
+
+	cases := map[string][]byte{
+		"empty":         {},
+		"not KeePass":   []byte("this is not a KeePass database at all"),
+		"KDBX 3.1":      append(append([]byte{}, valid[:8]...), 0x01, 0x00, 0x03, 0x00),
+		"header flip":   flipByte(valid, 20),
+		"payload flip":  flipByte(valid, len(valid)-40),
+		"huge Argon2 P": buildKDBXHeaderOnly(kdbxArgon2dKDF, "P", kdbxUint32(1<<23)),
+		"huge Argon2 M": buildKDBXHeaderOnly(kdbxArgon2dKDF, "M", kdbxUint64(1<<40)),
+		"Argon2 v0x10":  buildKDBXHeaderOnly(kdbxArgon2dKDF, "V", kdbxUint32(0x10)),
+		"unknown KDF":   buildKDBXHeaderOnly(kdbxArgon2dKDF, "$UUID", bytes.Repeat([]byte{0x01}, 16)),
+	}
+	for name, data := range cases {
+		if _, err := parseNoPanic(t, FormatKDBX, data, Options{Password: kdbxTestPassword}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func flipByte(data []byte, i int) []byte {
+	out := append([]byte{}, data...)
+	out[i] ^= 0xFF
+	return out
+}
+
+// buildKDBXHeaderOnly monta um arquivo com cabeçalho e hash válidos, trocando um
+// parâmetro do KDF; o resto não importa, pois o erro vem antes.
+func buildKDBXHeaderOnly(kdf []kdbxVariant, key string, value []byte) []byte {
+	params := make([]kdbxVariant, len(kdf))
+	copy(params, kdf)
+	for i := range params {
+		if params[i].key == key {
+			params[i].value = value
+		}
+	}
+
+	var header bytes.Buffer
+	header.Write(kdbxUint32(kdbxSignature1))
+	header.Write(kdbxUint32(kdbxSignature2))
+	header.Write([]byte{0x00, 0x00, 0x04, 0x00})
+	writeKDBXField(&header, kdbxCipherID, kdbxCipherAES256)
+	writeKDBXField(&header, kdbxMasterSeed, bytes.Repeat([]byte{0x44}, 32))
+	writeKDBXField(&header, kdbxKdfParameters, writeVariantDictionary(params))
+	writeKDBXField(&header, kdbxEndOfHeader, nil)
+
+	hash := sha256.Sum256(header.Bytes())
+	out := append(header.Bytes(), hash[:]...)
+	return append(out, make([]byte, 64)...)
+}
+
+// parseNoPanic falha o teste se o parser entrar em pânico em vez de devolver erro.
+func parseNoPanic(t *testing.T, format Format, data []byte, opts Options) (result *Result, err error) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("%s parser panicked on %d bytes: %v", format, len(data), r)
+		}
+	}()
+	return Parse(format, bytes.NewReader(data), opts)
+}
+
+func TestParseKDBXTruncated(t *testing.T) {
+	valid := buildKDBX(t, kdbxFixture{cipher: kdbxCipherChaCha20, kdf: kdbxAESKDF, innerStream: kdbxStreamChaCha20}, kdbxTestPassword)
+	for n := 0; n < len(valid); n++ {
+		if _, err := parseNoPanic(t, FormatKDBX, valid[:n], Options{Password: kdbxTestPassword}); err == nil {
+			t.Fatalf("%d of %d bytes: expected an error", n, len(valid))
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"lembrago.com/lembrago/client/items"
+)
+
+type onePuxExport struct {
+	Accounts []struct {
+		Attrs struct {
+			AccountName string `json:"accountName"`
+		} `json:"attrs"`
+		Vaults []struct {
+			Attrs struct {
+				Name string `json:"name"`
+			} `json:"attrs"`
+			Items []onePuxItem `json:"items"`
+		} `json:"vaults"`
+	} `json:"accounts"`
+}
+
+type onePuxItem struct {
+	FavIndex     int    `json:"favIndex"`
+	State        string `json:"state"`
+	CategoryUUID string `json:"categoryUuid"`
+	Overview     struct {
+		Title string `json:"title"`
+		URL   string `json:"url"`
+		URLs  []struct {
+			URL string `json:"url"`
+		} `json:"urls"`
+		Tags []string `json:"tags"`
+	} `json:"overview"`
+	Details struct {
+		LoginFields []struct {
+			Value       string `json:"value"`
+			Name        string `json:"name"`
+			FieldType   string `json:"fieldType"`
+			Designation string `json:"designation"`
+		} `json:"loginFields"`
+		NotesPlain string `json:"notesPlain"`
+		Password   string `json:"password"`
+		Sections   []struct {
+			Title  string `json:"title"`
+			Fields []struct {
+				Title string                     `json:"title"`
+				ID    string                     `json:"id"`
+				Value map[string]json.RawMessage `json:"value"`
+			} `json:"fields"`
+		} `json:"sections"`
+		DocumentAttributes *struct {
+			FileName string `json:"fileName"`
+		} `json:"documentAttributes"`
+	} `json:"details"`
+}
+
+// Categorias do 1Password (categoryUuid).
+const (
+	onePuxLogin      = "001"
+	onePuxCard       = "002"
+	onePuxNote       = "003"
+	onePuxIdentity   = "004"
+	onePuxPassword   = "005"
+	onePuxDocument   = "006"
+	onePuxAPICredent = "112"
+)
+
+func parse1PUX(data []byte) (*Result, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid 1PUX archive: %w", err)
+	}
+
+	var exportData []byte
+	for _, file := range archive.File {
+		if file.Name == "export.data" {
+			rc, err := file.Open()
+			if err != nil {
+				return nil, err
+			}
+			exportData, err = io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if exportData == nil {
+		return nil, fmt.Errorf("invalid 1PUX archive: export.data not found")
+	}
+
+	var export onePuxExport
+	if err := json.Unmarshal(exportData, &export); err != nil {
+		return nil, fmt.Errorf("invalid 1PUX export.data: %w", err)
+	}
+
+	result := newResult(Format1PUX)
+	for _, account := range export.Accounts {
+		for _, vault := range account.Vaults {
+			for _, op := range vault.Items {
+				if op.State == "archived" || op.State == "deleted" {
+					result.skip(op.Overview.Title, op.State)
+					continue
+				}
+
+				item := items.Item{
+					Name:     op.Overview.Title,
+					Notes:    op.Details.NotesPlain,
+					Folder:   vault.Attrs.Name,
+					Favorite: op.FavIndex > 0,
+					Tags:     op.Overview.Tags,
+				}
+				item.URLs = appendURL(item.URLs, op.Overview.URL)
+				for _, url := range op.Overview.URLs {
+					item.URLs = appendURL(item.URLs, url.URL)
+				}
+
+				switch op.CategoryUUID {
+				case onePuxLogin, onePuxPassword, onePuxAPICredent:
+					item.Type = items.TypeLogin
+				case onePuxNote:
+					item.Type = items.TypeNote
+				case onePuxCard:
+					item.Type = items.TypeCard
+					item.Card = &items.Card{}
+				case onePuxIdentity:
+					item.Type = items.TypeIdentity
+				case onePuxDocument:
+					result.warn("%q: document contents are not included in 1PUX exports, only its fields were imported", op.Overview.Title)
+					item.Type = items.TypeNote
+				default:
+					item.Type = items.TypeLogin
+				}
+
+				for _, field := range op.Details.LoginFields {
+					switch {
+					case field.Designation == "username" && item.Username == "":
+						item.Username = field.Value
+					case field.Designation == "password" && item.Password == "":
+						item.Password = field.Value
+					default:
+						item.AddField(firstNonEmpty(field.Name, field.Designation), field.Value, field.FieldType == "P")
+					}
+				}
+				if item.Password == "" {
+					item.Password = op.Details.Password
+				}
+
+				for _, section := range op.Details.Sections {
+					for _, field := range section.Fields {
+						kind, value := onePuxValue(field.Value)
+						if value == "" {
+							continue
+						}
+						if onePuxCardField(&item, field.ID, value) {
+							continue
+						}
+						switch {
+						case kind == "totp" && item.TOTP == "":
+							item.TOTP = value
+						case kind == "url":
+							item.URLs = appendURL(item.URLs, value)
+						case (field.ID == "username" || kind == "email") && item.Username == "" && item.Type == items.TypeLogin:
+							item.Username = value
+						case kind == "concealed" && field.ID == "credential" && item.Password == "":
+							item.Password = value
+						default:
+							name := firstNonEmpty(field.Title, field.ID)
+							if section.Title != "" {
+								name = section.Title + " / " + name
+							}
+							item.AddField(name, value, kind == "concealed" || kind == "creditCardNumber")
+						}
+					}
+				}
+
+				result.add(item)
+			}
+		}
+	}
+
+	return result.finish(), nil
+}
+
+// onePuxValue devolve o tipo e o valor textual de um campo, que no 1PUX é um
+// objeto com uma única chave, ex.: {"concealed": "..."} ou {"email": {"email_address": "..."}}.
+func onePuxValue(value map[string]json.RawMessage) (string, string) {
+	for kind, raw := range value {
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			return kind, s
+		}
+
+		var n json.Number
+		if err := json.Unmarshal(raw, &n); err == nil {
+			return kind, n.String()
+		}
+
+		var object map[string]interface{}
+		if err := json.Unmarshal(raw, &object); err == nil {
+			if email, ok := object["email_address"].(string); ok {
+				return kind, email
+			}
+			keys := make([]string, 0, len(object))
+			for key := range object {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			parts := []string{}
+			for _, key := range keys {
+				if s, ok := object[key].(string); ok && s != "" {
+					parts = append(parts, s)
+				}
+			}
+			return kind, strings.Join(parts, ", ")
+		}
+	}
+	return "", ""
+}
+
+func onePuxCardField(item *items.Item, id, value string) bool {
+	if item.Card == nil {
+		return false
+	}
+	switch id {
+	case "cardholder":
+		item.Card.Holder = value
+	case "type":
+		item.Card.Brand = value
+	case "ccnum":
+		item.Card.Number = value
+	case "cvv":
+		item.Card.Code = value
+	case "expiry":
+		// monthYear vem como AAAAMM.
+		if len(value) == 6 {
+			item.Card.ExpYear, item.Card.ExpMonth = value[:4], value[4:]
+		} else {
+			item.AddField("expiry", value, false)
+		}
+	default:
+		return false
+	}
+	return true
+}
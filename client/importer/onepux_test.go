@@ -0,0 +1,134 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"lembrago.com/lembrago/client/items"
+)
+
+const onePuxFixture = `{
+  "accounts": [{
+    "attrs": {"accountName": "Ana"},
+    "vaults": [{
+      "attrs": {"name": "Personal"},
+      "items": [
+        {
+          "favIndex": 1, "state": "active", "categoryUuid": "001",
+          "overview": {"title": "GitHub", "url": "https://github.com/login", "urls": [{"url": "https://gist.github.com"}], "tags": ["dev"]},
+          "details": {
+            "loginFields": [
+              {"value": "octocat", "name": "username", "fieldType": "T", "designation": "username"},
+              {"value": "gh-s3cret", "name": "password", "fieldType": "P", "designation": "password"},
+              {"value": "1234", "name": "pin", "fieldType": "P", "designation": ""}
+            ],
+            "notesPlain": "2FA on",
+            "sections": [{"title": "Security", "fields": [
+              {"title": "one-time password", "id": "TOTP_1", "value": {"totp": "otpauth://totp/GitHub?secret=JBSWY3DPEHPK3PXP"}},
+              {"title": "recovery email", "id": "email", "value": {"email": {"email_address": "ana@example.com", "provider": null}}}
+            ]}]
+          }
+        },
+        {
+          "state": "active", "categoryUuid": "002",
+          "overview": {"title": "Visa"},
+          "details": {"sections": [{"title": "", "fields": [
+            {"title": "cardholder name", "id": "cardholder", "value": {"string": "Ana Silva"}},
+            {"title": "number", "id": "ccnum", "value": {"creditCardNumber": "4111111111111111"}},
+            {"title": "verification number", "id": "cvv", "value": {"concealed": "123"}},
+            {"title": "expiry date", "id": "expiry", "value": {"monthYear": 203012}}
+          ]}]}
+        },
+        {"state": "archived", "categoryUuid": "001", "overview": {"title": "Old login"}, "details": {"password": "x"}},
+        {
+          "state": "active", "categoryUuid": "006",
+          "overview": {"title": "Scan"},
+          "details": {"notesPlain": "passport scan", "documentAttributes": {"fileName": "scan.pdf"}}
+        }
+      ]
+    }]
+  }]
+}`
+
+func build1PUX(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParse1PUX(t *testing.T) {
+	data := build1PUX(t, map[string]string{
+		"export.attributes": `{"version": 3}`,
+		"export.data":       onePuxFixture,
+		"files/scan.pdf":    "%PDF-1.4",
+	})
+	result, err := parseNoPanic(t, Format1PUX, data, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := result.Report
+	if report.Format != Format1PUX || report.Parsed != 4 || report.Ready != 3 ||
+		report.ByType["login"] != 1 || report.ByType["card"] != 1 || report.ByType["note"] != 1 {
+		t.Fatalf("report = %+v", report)
+	}
+	if len(report.Skipped) != 1 || report.Skipped[0] != (Skipped{Name: "Old login", Reason: "archived"}) {
+		t.Fatalf("skipped = %+v", report.Skipped)
+	}
+	if len(report.Warnings) != 1 || !strings.HasPrefix(report.Warnings[0], `"Scan": document contents`) {
+		t.Fatalf("warnings = %v", report.Warnings)
+	}
+	if strings.Join(report.Folders, ",") != "Personal" {
+		t.Fatalf("folders = %v", report.Folders)
+	}
+
+	github := result.Items[0]
+	if github.Username != "octocat" || github.Password != "gh-s3cret" || !github.Favorite || github.Notes != "2FA on" ||
+		github.TOTP != "otpauth://totp/GitHub?secret=JBSWY3DPEHPK3PXP" || strings.Join(github.Tags, ",") != "dev" ||
+		strings.Join(github.URLs, " ") != "https://github.com/login https://gist.github.com" {
+		t.Fatalf("GitHub = %+v", github)
+	}
+	wantFields := []items.Field{
+		{Name: "pin", Value: "1234", Hidden: true},
+		{Name: "Security / recovery email", Value: "ana@example.com"},
+	}
+	if len(github.Fields) != len(wantFields) || github.Fields[0] != wantFields[0] || github.Fields[1] != wantFields[1] {
+		t.Fatalf("GitHub fields = %+v", github.Fields)
+	}
+
+	card := result.Items[1].Card
+	if card == nil || *card != (items.Card{Holder: "Ana Silva", Number: "4111111111111111", Code: "123", ExpYear: "2030", ExpMonth: "12"}) {
+		t.Fatalf("card = %+v", card)
+	}
+	if scan := result.Items[2]; scan.Type != items.TypeNote || scan.Notes != "passport scan" {
+		t.Fatalf("document = %+v", scan)
+	}
+}
+
+func TestParse1PUXMalformed(t *testing.T) {
+	cases := map[string][]byte{
+		"empty":               {},
+		"not a zip":           []byte(onePuxFixture),
+		"no export.data":      build1PUX(t, map[string]string{"export.attributes": "{}"}),
+		"invalid export.data": build1PUX(t, map[string]string{"export.data": "{\"accounts\": ["}),
+		"wrong types":         build1PUX(t, map[string]string{"export.data": `{"accounts": {"vaults": 1}}`}),
+	}
+	for name, data := range cases {
+		if _, err := parseNoPanic(t, Format1PUX, data, Options{}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
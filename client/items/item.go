@@ -0,0 +1,97 @@
+// Package items define o conteúdo em claro de um item de cofre. O servidor só vê
+// esse JSON cifrado dentro de encryptedItemData.
+package items
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"lembrago.com/lembrago/client/envelope"
+	"lembrago.com/lembrago/models"
+)
+
+const SchemaVersion = 1
+
+type Type string
+
+const (
+	TypeLogin    Type = "login"
+	TypeNote     Type = "note"
+	TypeCard     Type = "card"
+	TypeIdentity Type = "identity"
+)
+
+type Item struct {
+	Version  int      `json:"v"`
+	Type     Type     `json:"type"`
+	Name     string   `json:"name"`
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	URLs     []string `json:"urls,omitempty"`
+	TOTP     string   `json:"totp,omitempty"`
+	Notes    string   `json:"notes,omitempty"`
+	Folder   string   `json:"folder,omitempty"`
+	Favorite bool     `json:"favorite,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Card     *Card    `json:"card,omitempty"`
+	Fields   []Field  `json:"fields,omitempty"`
+	// Origem do item quando importado de outro gerenciador, ex.: "bitwarden".
+	Source string `json:"source,omitempty"`
+}
+
+type Card struct {
+	Holder   string `json:"holder,omitempty"`
+	Brand    string `json:"brand,omitempty"`
+	Number   string `json:"number,omitempty"`
+	ExpMonth string `json:"expMonth,omitempty"`
+	ExpYear  string `json:"expYear,omitempty"`
+	Code     string `json:"code,omitempty"`
+}
+
+type Field struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Hidden bool   `json:"hidden,omitempty"`
+}
+
+func (item *Item) AddField(name, value string, hidden bool) {
+	if value == "" {
+		return
+	}
+	item.Fields = append(item.Fields, Field{Name: name, Value: value, Hidden: hidden})
+}
+
+// IsEmpty indica um item sem nenhum dado além do nome.
+func (item *Item) IsEmpty() bool {
+	return item.Username == "" && item.Password == "" && len(item.URLs) == 0 &&
+		item.TOTP == "" && item.Notes == "" && item.Card == nil && len(item.Fields) == 0
+}
+
+// Encrypt serializa o item e o cifra com a chave do cofre.
+func (item *Item) Encrypt(vaultKey *envelope.Key) (models.EncryptedKeyDto, error) {
+	if item.Version == 0 {
+		item.Version = SchemaVersion
+	}
+
+	plaintext, err := json.Marshal(item)
+	if err != nil {
+		return models.EncryptedKeyDto{}, err
+	}
+	return envelope.Seal(vaultKey, plaintext)
+}
+
+func Decrypt(vaultKey *envelope.Key, data models.EncryptedKeyDto) (*Item, error) {
+	plaintext, err := envelope.Open(vaultKey, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var item Item
+	if err := json.Unmarshal(plaintext, &item); err != nil {
+		return nil, fmt.Errorf("items: invalid payload: %w", err)
+	}
+	if item.Version > SchemaVersion {
+		return nil, fmt.Errorf("items: unsupported schema version %d", item.Version)
+	}
+	return &item, nil
+}
@@ -0,0 +1,37 @@
+package items
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"lembrago.com/lembrago/client/envelope"
+	"lembrago.com/lembrago/models"
+)
+
+// VaultMetadata é o conteúdo em claro de encryptedVaultMetadata.
+type VaultMetadata struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Icon        string `json:"icon,omitempty"`
+}
+
+func (metadata *VaultMetadata) Encrypt(vaultKey *envelope.Key) (models.EncryptedKeyDto, error) {
+	plaintext, err := json.Marshal(metadata)
+	if err != nil {
+		return models.EncryptedKeyDto{}, err
+	}
+	return envelope.Seal(vaultKey, plaintext)
+}
+
+func DecryptVaultMetadata(vaultKey *envelope.Key, data models.EncryptedKeyDto) (*VaultMetadata, error) {
+	plaintext, err := envelope.Open(vaultKey, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var metadata VaultMetadata
+	if err := json.Unmarshal(plaintext, &metadata); err != nil {
+		return nil, fmt.Errorf("items: invalid vault metadata: %w", err)
+	}
+	return &metadata, nil
+}
@@ -0,0 +1,46 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"lembrago.com/lembrago/models"
+)
+
+// MailAdmin examina a fila de e-mails do servidor. Usa o mesmo token de
+// administração (JWT_SECRET_ADMIN) que VersionAdmin.
+type MailAdmin struct {
+	client *Client
+}
+
+func (c *Client) MailAdmin(adminToken string) *MailAdmin {
+	return &MailAdmin{client: c.withToken(adminToken)}
+}
+
+// DeadLetters lista os e-mails que não puderam ser enviados, sem o corpo.
+func (a *MailAdmin) DeadLetters(ctx context.Context, query models.DeadLetterQuery) (*models.DeadLettersResponse, error) {
+	values := url.Values{}
+	if query.Page > 0 {
+		values.Set("page", strconv.Itoa(query.Page))
+	}
+	if query.Limit > 0 {
+		values.Set("limit", strconv.Itoa(query.Limit))
+	}
+
+	var res models.DeadLettersResponse
+	if err := a.client.do(ctx, http.MethodGet, "/mail/dead-letters", values, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// RetryDeadLetter devolve o e-mail à fila, com as tentativas zeradas.
+func (a *MailAdmin) RetryDeadLetter(ctx context.Context, emailID string) (*models.OutboxEmailResponse, error) {
+	var res models.OutboxEmailResponse
+	if err := a.client.do(ctx, http.MethodPost, "/mail/dead-letters/"+url.PathEscape(emailID)+"/retry", nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+
+	"lembrago.com/lembrago/models"
+)
+
+// Users lista os usuários da organização (GET /org/users, exige users:read).
+func (c *Client) Users(ctx context.Context) ([]models.MinimalUserInfoResponse, error) {
+	var res []models.MinimalUserInfoResponse
+	err := c.do(ctx, http.MethodGet, "/org/users", nil, nil, &res)
+	return res, err
+}
+
+// User busca um usuário da organização, ex.: para pegar a chave pública antes de compartilhar um cofre.
+func (c *Client) User(ctx context.Context, userID string) (*models.MinimalUserInfoResponse, error) {
+	var res models.MinimalUserInfoResponse
+	if err := c.do(ctx, http.MethodGet, "/org/users", url.Values{"userId": {userID}}, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) UpdateUserRole(ctx context.Context, userID string, role models.UserRole) error {
+	return c.do(ctx, http.MethodPut, "/org/users", nil, models.UpdateUserRoleRequest{UserID: userID, Role: role}, nil)
+}
+
+func (c *Client) DeleteUser(ctx context.Context, userID string) error {
+	return c.do(ctx, http.MethodDelete, "/org/users", url.Values{"userId": {userID}}, nil, nil)
+}
+
+// UploadMedia envia uma imagem da organização (POST /media, exige org:manage, até 2 MB).
+func (c *Client) UploadMedia(ctx context.Context, filename string, image io.Reader) (*models.SavedMedia, error) {
+	var res models.SavedMedia
+	if err := c.upload(ctx, "/media", "media", filename, image, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// DownloadMedia copia para w a imagem servida em GET /media/:filename.
+func (c *Client) DownloadMedia(ctx context.Context, filename string, w io.Writer) (int64, error) {
+	return c.download(ctx, "/media/"+url.PathEscape(filename), w)
+}
+
+func (c *Client) Medias(ctx context.Context) ([]models.SavedMedia, error) {
+	var res []models.SavedMedia
+	err := c.do(ctx, http.MethodGet, "/vaults/medias", nil, nil, &res)
+	return res, err
+}
+
+func (c *Client) DeleteMedia(ctx context.Context, mediaID string) error {
+	return c.do(ctx, http.MethodDelete, "/vaults/medias", url.Values{"id": {mediaID}}, nil, nil)
+}
+
+// OrgBranding devolve o idioma padrão e a marca dos e-mails da organização (exige org:manage).
+func (c *Client) OrgBranding(ctx context.Context) (*models.OrgBrandingResponse, error) {
+	var res models.OrgBrandingResponse
+	if err := c.do(ctx, http.MethodGet, "/org/branding", nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// UpdateOrgBranding troca tudo de uma vez; campos vazios voltam ao padrão.
+func (c *Client) UpdateOrgBranding(ctx context.Context, req *models.UpdateOrgBrandingRequest) (*models.OrgBrandingResponse, error) {
+	var res models.OrgBrandingResponse
+	if err := c.do(ctx, http.MethodPut, "/org/branding", nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"lembrago.com/lembrago/client/envelope"
+	"lembrago.com/lembrago/models"
+)
+
+// ErrFingerprintMismatch indica que a chave pública recebida do servidor não é a
+// que o usuário informou por outro canal.
+var ErrFingerprintMismatch = errors.New("lembrago: public key fingerprint does not match")
+
+// Fingerprint é a impressão digital da chave pública do usuário da sessão, para
+// ele passar ao admin que vai confirmar o acesso.
+func (s *Session) Fingerprint() string {
+	return models.PublicKeyFingerprint(s.Keys.PublicKey[:])
+}
+
+// PendingShares lista os acessos pendentes dos cofres em que o usuário é admin.
+func (c *Client) PendingShares(ctx context.Context) ([]models.PendingShareResponse, error) {
+	var res []models.PendingShareResponse
+	err := c.do(ctx, http.MethodGet, "/vaults/pending-shares", nil, nil, &res)
+	return res, err
+}
+
+// ShareVaultByEmail concede o cofre a um e-mail, com ou sem conta. O acesso só
+// vale depois de ConfirmPendingShare.
+func (c *Client) ShareVaultByEmail(ctx context.Context, vaultID, email string, permission models.VaultPermission) (*models.PendingShareResponse, error) {
+	var res models.PendingShareResponse
+	err := c.do(ctx, http.MethodPost, "/vaults/pending-shares", nil, models.CreatePendingShareRequest{
+		VaultID:    vaultID,
+		Email:      email,
+		Permission: permission,
+	}, &res)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) ConfirmPendingShare(ctx context.Context, shareID string, req *models.ConfirmPendingShareRequest) (*models.VaultMemberResponse, error) {
+	var res models.VaultMemberResponse
+	if err := c.do(ctx, http.MethodPost, "/vaults/pending-shares/"+url.PathEscape(shareID)+"/confirm", nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) CancelPendingShare(ctx context.Context, shareID string) error {
+	return c.do(ctx, http.MethodDelete, "/vaults/pending-shares/"+url.PathEscape(shareID), nil, nil, nil)
+}
+
+// ConfirmPendingShare embrulha a chave do cofre para o usuário do acesso pendente.
+// fingerprint é a impressão digital que o usuário informou por outro canal; ela
+// é comparada com a da chave recebida aqui, sem confiar no campo do servidor.
+func (s *Session) ConfirmPendingShare(ctx context.Context, share models.PendingShareResponse, fingerprint string) (*models.VaultMemberResponse, error) {
+	if share.Status != models.PendingShareReady {
+		return nil, fmt.Errorf("lembrago: %s has not registered yet", share.Email)
+	}
+
+	actual, err := envelope.Fingerprint(share.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if !models.SameFingerprint(actual, fingerprint) {
+		return nil, ErrFingerprintMismatch
+	}
+
+	key, err := s.vaultKeyByID(ctx, share.VaultID)
+	if err != nil {
+		return nil, err
+	}
+	esvk, err := envelope.SealVaultKey(key, share.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Client.ConfirmPendingShare(ctx, share.ID, &models.ConfirmPendingShareRequest{
+		Fingerprint:    actual,
+		ESVK_PubK_User: esvk,
+	})
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"lembrago.com/lembrago/models"
+)
+
+// Permissions devolve o catálogo de permissões com que as funções são montadas.
+func (c *Client) Permissions(ctx context.Context) ([]models.PermissionInfo, error) {
+	var res []models.PermissionInfo
+	err := c.do(ctx, http.MethodGet, "/org/permissions", nil, nil, &res)
+	return res, err
+}
+
+// Roles lista as funções embutidas e as personalizadas da organização.
+func (c *Client) Roles(ctx context.Context) ([]models.RoleResponse, error) {
+	var res []models.RoleResponse
+	err := c.do(ctx, http.MethodGet, "/org/roles", nil, nil, &res)
+	return res, err
+}
+
+// CreateRole cria uma função personalizada; o Key devolvido é o valor usado em
+// InviteUser e UpdateUserRole.
+func (c *Client) CreateRole(ctx context.Context, req *models.CreateRoleRequest) (*models.RoleResponse, error) {
+	var res models.RoleResponse
+	if err := c.do(ctx, http.MethodPost, "/org/roles", nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) UpdateRole(ctx context.Context, key models.UserRole, req *models.UpdateRoleRequest) (*models.RoleResponse, error) {
+	var res models.RoleResponse
+	if err := c.do(ctx, http.MethodPut, "/org/roles/"+url.PathEscape(string(key)), nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// DeleteRole falha com 409 enquanto algum usuário tiver a função.
+func (c *Client) DeleteRole(ctx context.Context, key models.UserRole) error {
+	return c.do(ctx, http.MethodDelete, "/org/roles/"+url.PathEscape(string(key)), nil, nil, nil)
+}
+
+// UpdateUserStatus suspende ou reativa um usuário; a suspensão derruba também
+// as sessões e tokens já emitidos.
+func (c *Client) UpdateUserStatus(ctx context.Context, userID string, status models.UserStatus) error {
+	return c.do(ctx, http.MethodPut, "/org/users/status", nil, models.UpdateUserStatusRequest{UserID: userID, Status: status}, nil)
+}
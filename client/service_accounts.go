@@ -0,0 +1,194 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"lembrago.com/lembrago/client/envelope"
+	"lembrago.com/lembrago/models"
+)
+
+// ServiceAccountTokenPrefix marca os tokens de acesso de contas de serviço, o
+// que facilita achá-los com scanners de segredos.
+const ServiceAccountTokenPrefix = "lbsa_"
+
+// FormatServiceAccountToken junta o ID da credencial e o segredo numa única
+// string, que é o que se guarda no cofre de segredos do CI.
+func FormatServiceAccountToken(credentialID string, secret []byte) string {
+	return ServiceAccountTokenPrefix + credentialID + "." + base64.RawURLEncoding.EncodeToString(secret)
+}
+
+func parseServiceAccountToken(token string) (string, []byte, error) {
+	id, encoded, ok := strings.Cut(strings.TrimPrefix(strings.TrimSpace(token), ServiceAccountTokenPrefix), ".")
+	if !ok {
+		return "", nil, fmt.Errorf("lembrago: malformed service account token")
+	}
+	secret, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", nil, fmt.Errorf("lembrago: malformed service account token")
+	}
+	return id, secret, nil
+}
+
+// newServiceAccountCredential sorteia um segredo e cifra com ele as chaves da
+// conta. O servidor recebe só a chave de autenticação derivada do segredo.
+func newServiceAccountCredential(name string, keys *envelope.KeyPair, secretKey *envelope.Key) (*models.ServiceAccountCredentialRequest, []byte, error) {
+	secret := make([]byte, envelope.KeySize)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, nil, err
+	}
+	clientSecret, wrapKey, err := envelope.DeriveServiceAccountKeys(secret)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encryptedPrivateKey, err := envelope.Seal(&wrapKey, keys.PrivateKey[:])
+	if err != nil {
+		return nil, nil, err
+	}
+	encryptedSecretKey, err := envelope.Seal(&wrapKey, secretKey[:])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &models.ServiceAccountCredentialRequest{
+		Name:         name,
+		ClientSecret: clientSecret,
+		Keys: models.KeysDTO{
+			PublicKey:           keys.PublicKeyBase64(),
+			EncryptedPrivateKey: encryptedPrivateKey,
+			EncryptedSecretKey:  encryptedSecretKey,
+		},
+	}, secret, nil
+}
+
+// CreateServiceAccount gera o par de chaves da conta localmente, cria a conta
+// com uma primeira credencial e devolve o token de acesso dela. O token só
+// existe aqui: guarde-o, o servidor não consegue mostrá-lo de novo. expiresAt
+// zero = sem validade. Somente admin.
+func (c *Client) CreateServiceAccount(ctx context.Context, name string, scope models.ServiceAccountScopeDTO, expiresAt time.Time) (*models.ServiceAccountResponse, string, error) {
+	keys, err := envelope.GenerateKeyPair()
+	if err != nil {
+		return nil, "", err
+	}
+	secretKey, err := envelope.NewKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	credential, secret, err := newServiceAccountCredential("default", keys, secretKey)
+	if err != nil {
+		return nil, "", err
+	}
+	credential.Scope = scope
+	if !expiresAt.IsZero() {
+		credential.ExpiresAt = expiresAt.Format(time.RFC3339)
+	}
+
+	var res models.ServiceAccountResponse
+	err = c.do(ctx, http.MethodPost, "/org/users/service-accounts", nil, models.CreateServiceAccountRequest{
+		Name:       name,
+		Credential: *credential,
+	}, &res)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(res.Credentials) == 0 {
+		return nil, "", fmt.Errorf("lembrago: server returned no credential")
+	}
+
+	return &res, FormatServiceAccountToken(res.Credentials[0].ID, secret), nil
+}
+
+// ServiceAccounts lista as contas de serviço e suas credenciais (GET /org/users/service-accounts).
+func (c *Client) ServiceAccounts(ctx context.Context) ([]models.ServiceAccountResponse, error) {
+	var res []models.ServiceAccountResponse
+	err := c.do(ctx, http.MethodGet, "/org/users/service-accounts", nil, nil, &res)
+	return res, err
+}
+
+func (c *Client) DeleteServiceAccount(ctx context.Context, accountID string) error {
+	return c.do(ctx, http.MethodDelete, "/org/users/service-accounts/"+url.PathEscape(accountID), nil, nil, nil)
+}
+
+// UpdateServiceAccountCredential troca o escopo de uma credencial. Os tokens
+// já emitidos com o escopo antigo deixam de valer.
+func (c *Client) UpdateServiceAccountCredential(ctx context.Context, accountID, credentialID string, scope models.ServiceAccountScopeDTO) (*models.ServiceAccountCredentialResponse, error) {
+	var res models.ServiceAccountCredentialResponse
+	path := "/org/users/service-accounts/" + url.PathEscape(accountID) + "/credentials/" + url.PathEscape(credentialID)
+	if err := c.do(ctx, http.MethodPut, path, nil, models.UpdateServiceAccountCredentialRequest{Scope: scope}, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) RevokeServiceAccountCredential(ctx context.Context, accountID, credentialID string) error {
+	path := "/org/users/service-accounts/" + url.PathEscape(accountID) + "/credentials/" + url.PathEscape(credentialID)
+	return c.do(ctx, http.MethodDelete, path, nil, nil, nil)
+}
+
+// ServiceAccountLogin troca o token de acesso de uma conta de serviço por um
+// token de API curto (POST /service-accounts/token) e abre as chaves da conta.
+func (c *Client) ServiceAccountLogin(ctx context.Context, accessToken string) (*Session, error) {
+	credentialID, secret, err := parseServiceAccountToken(accessToken)
+	if err != nil {
+		return nil, err
+	}
+	clientSecret, wrapKey, err := envelope.DeriveServiceAccountKeys(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	var res models.ServiceAccountTokenResponse
+	err = c.do(ctx, http.MethodPost, "/service-accounts/token", nil, models.ServiceAccountTokenRequest{
+		ClientID:     credentialID,
+		ClientSecret: clientSecret,
+	}, &res)
+	if err != nil {
+		return nil, err
+	}
+	c.Token = res.Token
+
+	keys, err := envelope.OpenKeyPair(&wrapKey, res.User.Keys.PublicKey, res.User.Keys.EncryptedPrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("lembrago: cannot unlock service account key: %w", err)
+	}
+	secretKey, err := envelope.OpenSecretKey(&wrapKey, res.User.Keys.EncryptedSecretKey)
+	if err != nil {
+		return nil, fmt.Errorf("lembrago: cannot unlock service account secret key: %w", err)
+	}
+
+	return &Session{
+		Client:    c,
+		User:      res.User,
+		Keys:      keys,
+		SecretKey: secretKey,
+		vaultKeys: map[string]*envelope.Key{},
+	}, nil
+}
+
+// RotateCredential troca a credencial de uma sessão de conta de serviço por
+// uma nova e devolve o novo token de acesso. A credencial atual e o token de
+// API da sessão deixam de valer; faça ServiceAccountLogin com o token novo.
+func (s *Session) RotateCredential(ctx context.Context, name string) (string, error) {
+	if s.Keys == nil || s.SecretKey == nil {
+		return "", fmt.Errorf("lembrago: session is locked")
+	}
+
+	credential, secret, err := newServiceAccountCredential(name, s.Keys, s.SecretKey)
+	if err != nil {
+		return "", err
+	}
+
+	var res models.ServiceAccountCredentialResponse
+	if err := s.Client.do(ctx, http.MethodPost, "/service-accounts/credentials/rotate", nil, credential, &res); err != nil {
+		return "", err
+	}
+	return FormatServiceAccountToken(res.ID, secret), nil
+}
@@ -0,0 +1,301 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"time"
+
+	"lembrago.com/lembrago/client/envelope"
+	"lembrago.com/lembrago/client/items"
+	"lembrago.com/lembrago/models"
+)
+
+// Session é um login desbloqueado: o token e as chaves do usuário em memória.
+type Session struct {
+	Client    *Client
+	User      models.UserResponse
+	Keys      *envelope.KeyPair
+	SecretKey *envelope.Key // ESK aberto
+
+	mu        sync.Mutex
+	vaultKeys map[string]*envelope.Key
+}
+
+// Vault é um cofre do usuário com os metadados já decifrados.
+type Vault struct {
+	models.VaultWithMemberInfo
+	Metadata *items.VaultMetadata
+}
+
+// DecryptedItem é um item do servidor junto com o seu conteúdo em claro.
+type DecryptedItem struct {
+	models.PasswordResponse
+	Data *items.Item
+}
+
+// Unlock faz o login com a Senha Mestra e abre as chaves privada e secreta do
+// usuário. org vem de LoginInfo e traz o salt e os parâmetros do verificador.
+func (c *Client) Unlock(ctx context.Context, email, masterPassword string, org models.UserWithOrganizationResponse) (*Session, error) {
+	saltPV, err := base64.StdEncoding.DecodeString(org.PasswordVerifier.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("lembrago: invalid verifier salt")
+	}
+
+	verifier := envelope.DeriveVerifier(masterPassword, saltPV, org.PasswordVerifier.Parameters)
+	login, err := c.Login(ctx, org.OrgID, email, verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.OpenSession(login.User, masterPassword)
+}
+
+// OpenSession abre as chaves de um usuário já autenticado sem falar com o
+// servidor, ex.: a partir de uma sessão salva em disco. Uma Senha Mestra errada
+// resulta em envelope.ErrDecrypt.
+func (c *Client) OpenSession(user models.UserResponse, masterPassword string) (*Session, error) {
+	saltEK, err := base64.StdEncoding.DecodeString(user.Salt_ek)
+	if err != nil {
+		return nil, fmt.Errorf("lembrago: invalid key salt")
+	}
+
+	masterKey := envelope.DeriveMasterKey(masterPassword, saltEK, user.PasswordVerifier.Parameters)
+	keys, err := envelope.OpenKeyPair(&masterKey, user.Keys.PublicKey, user.Keys.EncryptedPrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("lembrago: cannot unlock private key: %w", err)
+	}
+	secretKey, err := envelope.OpenSecretKey(&masterKey, user.Keys.EncryptedSecretKey)
+	if err != nil {
+		return nil, fmt.Errorf("lembrago: cannot unlock secret key: %w", err)
+	}
+
+	return &Session{
+		Client:    c,
+		User:      user,
+		Keys:      keys,
+		SecretKey: secretKey,
+		vaultKeys: map[string]*envelope.Key{},
+	}, nil
+}
+
+// Lock apaga as chaves da memória. O token continua válido até Signout.
+func (s *Session) Lock() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Keys != nil {
+		s.Keys.PrivateKey = [envelope.KeySize]byte{}
+	}
+	if s.SecretKey != nil {
+		*s.SecretKey = envelope.Key{}
+	}
+	for id, key := range s.vaultKeys {
+		*key = envelope.Key{}
+		delete(s.vaultKeys, id)
+	}
+	s.Keys = nil
+	s.SecretKey = nil
+}
+
+// VaultKey abre a chave simétrica de um cofre do usuário.
+func (s *Session) VaultKey(vault models.VaultWithMemberInfo) (*envelope.Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Keys == nil {
+		return nil, fmt.Errorf("lembrago: session is locked")
+	}
+	if key, ok := s.vaultKeys[vault.ID]; ok {
+		return key, nil
+	}
+
+	key, err := s.Keys.OpenVaultKey(vault.ESVK_PubK_User)
+	if err != nil {
+		return nil, err
+	}
+	s.vaultKeys[vault.ID] = key
+	return key, nil
+}
+
+// vaultKeyByID busca o ESVK do cofre no servidor quando a chave ainda não foi aberta.
+func (s *Session) vaultKeyByID(ctx context.Context, vaultID string) (*envelope.Key, error) {
+	s.mu.Lock()
+	key, ok := s.vaultKeys[vaultID]
+	s.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	vaults, err := s.Client.MyVaults(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, vault := range vaults {
+		if vault.ID == vaultID {
+			return s.VaultKey(vault)
+		}
+	}
+	return nil, fmt.Errorf("lembrago: vault %s not found among your vaults", vaultID)
+}
+
+// Vaults lista os cofres do usuário com os metadados decifrados.
+func (s *Session) Vaults(ctx context.Context) ([]Vault, error) {
+	list, err := s.Client.MyVaults(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	vaults := make([]Vault, 0, len(list))
+	for _, vault := range list {
+		key, err := s.VaultKey(vault)
+		if err != nil {
+			return nil, fmt.Errorf("lembrago: cannot open vault %s: %w", vault.ID, err)
+		}
+		metadata, err := items.DecryptVaultMetadata(key, vault.EncryptedVaultMetadata)
+		if err != nil {
+			return nil, fmt.Errorf("lembrago: cannot read vault %s: %w", vault.ID, err)
+		}
+		vaults = append(vaults, Vault{VaultWithMemberInfo: vault, Metadata: metadata})
+	}
+	return vaults, nil
+}
+
+// CreateVault gera uma chave nova para o cofre, cifra os metadados com ela e a
+// embrulha para a chave pública do próprio usuário.
+func (s *Session) CreateVault(ctx context.Context, metadata *items.VaultMetadata) (*models.VaultResponse, error) {
+	if s.Keys == nil {
+		return nil, fmt.Errorf("lembrago: session is locked")
+	}
+
+	key, err := envelope.NewKey()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := metadata.Encrypt(key)
+	if err != nil {
+		return nil, err
+	}
+	esvk, err := envelope.SealVaultKey(key, s.Keys.PublicKeyBase64())
+	if err != nil {
+		return nil, err
+	}
+
+	personal := false
+	vault, err := s.Client.CreateVault(ctx, &models.CreateVaultRequest{
+		EncryptedVaultMetadata: encrypted,
+		ESVK_PubK_User:         esvk,
+		PersonalVault:          &personal,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.vaultKeys[vault.ID] = key
+	s.mu.Unlock()
+	return vault, nil
+}
+
+// ShareVault adiciona um usuário da organização ao cofre, embrulhando a chave do
+// cofre para a chave pública dele. Requer admin na organização e no cofre.
+func (s *Session) ShareVault(ctx context.Context, vaultID, userID string, permission models.VaultPermission) (*models.VaultMemberResponse, error) {
+	return s.ShareVaultUntil(ctx, vaultID, userID, permission, time.Time{})
+}
+
+// ShareVaultUntil é como ShareVault, mas o acesso expira em expiresAt; o zero
+// de time.Time dá acesso sem expiração.
+func (s *Session) ShareVaultUntil(ctx context.Context, vaultID, userID string, permission models.VaultPermission, expiresAt time.Time) (*models.VaultMemberResponse, error) {
+	key, err := s.vaultKeyByID(ctx, vaultID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.Client.User(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	esvk, err := envelope.SealVaultKey(key, user.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &models.CreateVaultMemberRequest{
+		VaultID:        vaultID,
+		UserID:         userID,
+		ESVK_PubK_User: esvk,
+		Permission:     permission,
+	}
+	if !expiresAt.IsZero() {
+		req.ExpiresAt = expiresAt.UTC().Format(time.RFC3339)
+	}
+	return s.Client.AddVaultMember(ctx, req)
+}
+
+// Items lista e decifra os itens de um cofre.
+func (s *Session) Items(ctx context.Context, vaultID string) ([]DecryptedItem, error) {
+	key, err := s.vaultKeyByID(ctx, vaultID)
+	if err != nil {
+		return nil, err
+	}
+
+	passwords, err := s.Client.Passwords(ctx, vaultID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]DecryptedItem, 0, len(passwords))
+	for _, password := range passwords {
+		item, err := items.Decrypt(key, password.EncryptedItemData)
+		if err != nil {
+			return nil, fmt.Errorf("lembrago: cannot decrypt item %s: %w", password.ID, err)
+		}
+		res = append(res, DecryptedItem{PasswordResponse: password, Data: item})
+	}
+	return res, nil
+}
+
+func (s *Session) CreateItem(ctx context.Context, vaultID string, item *items.Item) (*DecryptedItem, error) {
+	key, err := s.vaultKeyByID(ctx, vaultID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := item.Encrypt(key)
+	if err != nil {
+		return nil, err
+	}
+	password, err := s.Client.CreatePassword(ctx, &models.CreatePasswordRequest{
+		VaultID:           vaultID,
+		EncryptedItemData: data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &DecryptedItem{PasswordResponse: *password, Data: item}, nil
+}
+
+// UpdateItem cifra item.Data de novo e envia com item.Revision; se alguém alterou
+// o item nesse meio tempo o servidor responde 409.
+func (s *Session) UpdateItem(ctx context.Context, item *DecryptedItem) (*DecryptedItem, error) {
+	key, err := s.vaultKeyByID(ctx, item.VaultID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := item.Data.Encrypt(key)
+	if err != nil {
+		return nil, err
+	}
+	revision := item.Revision
+	password, err := s.Client.UpdatePassword(ctx, &models.UpdatePasswordRequest{
+		PasswordID:        item.ID,
+		EncryptedItemData: data,
+		Revision:          &revision,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &DecryptedItem{PasswordResponse: *password, Data: item.Data}, nil
+}
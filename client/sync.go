@@ -0,0 +1,92 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"lembrago.com/lembrago/models"
+)
+
+// Sync devolve o que mudou desde o token de um sync anterior. Com token vazio
+// devolve o estado completo; res.Full indica que o cache local deve ser descartado.
+func (c *Client) Sync(ctx context.Context, token string) (*models.SyncResponse, error) {
+	query := url.Values{}
+	if token != "" {
+		query.Set("token", token)
+	}
+
+	var res models.SyncResponse
+	if err := c.do(ctx, http.MethodGet, "/sync", query, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Event é um evento lido do stream SSE de /events: "ready", "ping" ou um models.EventType.
+type Event struct {
+	Type string
+	Data []byte
+}
+
+// Change decodifica o evento de alteração de um cofre. Não vale para "ready" e "ping".
+func (e Event) Change() (*models.ChangeEvent, error) {
+	var change models.ChangeEvent
+	if err := json.Unmarshal(e.Data, &change); err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// EventStream lê os eventos de GET /events. Feche-o (ou cancele o ctx) para encerrar a conexão.
+type EventStream struct {
+	body   io.ReadCloser
+	reader *bufio.Reader
+}
+
+// Events abre o stream de eventos do usuário. A conexão não usa o timeout do
+// HTTPClient, já que fica aberta indefinidamente.
+func (c *Client) Events(ctx context.Context) (*EventStream, error) {
+	streamClient := *c.HTTPClient
+	streamClient.Timeout = 0
+
+	res, err := c.send(ctx, &streamClient, http.MethodGet, "/events", nil, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	return &EventStream{body: res.Body, reader: bufio.NewReader(res.Body)}, nil
+}
+
+// Next bloqueia até o próximo evento completo.
+func (s *EventStream) Next() (*Event, error) {
+	var event Event
+	var data []string
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		switch {
+		case line == "":
+			if event.Type == "" && len(data) == 0 {
+				continue
+			}
+			event.Data = []byte(strings.Join(data, "\n"))
+			return &event, nil
+		case strings.HasPrefix(line, "event:"):
+			event.Type = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+}
+
+func (s *EventStream) Close() error {
+	return s.body.Close()
+}
@@ -0,0 +1,37 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"lembrago.com/lembrago/models"
+)
+
+// PersonalAccessTokens lista os tokens pessoais do usuário (GET /users/tokens).
+// Gerir tokens exige o token da sessão; um token pessoal não cria outros.
+func (c *Client) PersonalAccessTokens(ctx context.Context) ([]models.PersonalAccessTokenResponse, error) {
+	var res []models.PersonalAccessTokenResponse
+	err := c.do(ctx, http.MethodGet, "/users/tokens", nil, nil, &res)
+	return res, err
+}
+
+// CreatePersonalAccessToken cria um token com os escopos e a validade pedidos.
+// O token em claro só vem nesta resposta. Para usá-lo, basta colocá-lo em
+// Client.Token.
+func (c *Client) CreatePersonalAccessToken(ctx context.Context, name string, scopes []models.TokenScope, expiresInDays int) (*models.CreatedPersonalAccessTokenResponse, error) {
+	var res models.CreatedPersonalAccessTokenResponse
+	err := c.do(ctx, http.MethodPost, "/users/tokens", nil, models.CreatePersonalAccessTokenRequest{
+		Name:          name,
+		Scopes:        scopes,
+		ExpiresInDays: expiresInDays,
+	}, &res)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) RevokePersonalAccessToken(ctx context.Context, tokenID string) error {
+	return c.do(ctx, http.MethodDelete, "/users/tokens/"+url.PathEscape(tokenID), nil, nil, nil)
+}
@@ -0,0 +1,155 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"lembrago.com/lembrago/models"
+)
+
+// MyVaults lista os cofres do usuário na organização do token, cada um com o ESVK do usuário.
+func (c *Client) MyVaults(ctx context.Context) ([]models.VaultWithMemberInfo, error) {
+	var res []models.VaultWithMemberInfo
+	err := c.do(ctx, http.MethodGet, "/users/vaults", nil, nil, &res)
+	return res, err
+}
+
+func (c *Client) CreateVault(ctx context.Context, req *models.CreateVaultRequest) (*models.VaultResponse, error) {
+	var res models.VaultResponse
+	if err := c.do(ctx, http.MethodPost, "/vaults", nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// UpdateVault troca os metadados do cofre. Com req.Revision preenchido o servidor
+// responde 409 se outra pessoa alterou o cofre antes.
+func (c *Client) UpdateVault(ctx context.Context, req *models.UpdateVaultRequest) (*models.VaultResponse, error) {
+	var res models.VaultResponse
+	if err := c.do(ctx, http.MethodPut, "/vaults", nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// RemoveVault apaga o cofre, seus membros e itens.
+func (c *Client) RemoveVault(ctx context.Context, vaultID string) error {
+	return c.do(ctx, http.MethodDelete, "/vaults/"+url.PathEscape(vaultID), nil, nil, nil)
+}
+
+// TransferVaultOwnership propõe passar o cofre a outro admin do cofre, que
+// precisa aceitar com AcceptVaultOwnership.
+func (c *Client) TransferVaultOwnership(ctx context.Context, vaultID, newOwnerID string) (*models.VaultOwnershipTransferResponse, error) {
+	var res models.VaultOwnershipTransferResponse
+	err := c.do(ctx, http.MethodPost, "/vaults/ownership", nil, models.TransferVaultOwnershipRequest{VaultID: vaultID, NewOwnerID: newOwnerID}, &res)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) AcceptVaultOwnership(ctx context.Context, vaultID string) (*models.VaultResponse, error) {
+	var res models.VaultResponse
+	if err := c.do(ctx, http.MethodPost, "/vaults/ownership/accept", nil, models.VaultOwnershipRequest{VaultID: vaultID}, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// CancelVaultOwnershipTransfer desiste da transferência (dono) ou a recusa (destinatário).
+func (c *Client) CancelVaultOwnershipTransfer(ctx context.Context, vaultID string) error {
+	return c.do(ctx, http.MethodPost, "/vaults/ownership/cancel", nil, models.VaultOwnershipRequest{VaultID: vaultID}, nil)
+}
+
+// ForceVaultOwnershipTransfer é para admins da organização quando o dono foi desligado.
+func (c *Client) ForceVaultOwnershipTransfer(ctx context.Context, vaultID, newOwnerID string) error {
+	return c.do(ctx, http.MethodPost, "/vaults/ownership/force", nil, models.TransferVaultOwnershipRequest{VaultID: vaultID, NewOwnerID: newOwnerID}, nil)
+}
+
+func (c *Client) VaultMembers(ctx context.Context, vaultID string) ([]models.VaultMemberResponse, error) {
+	var res []models.VaultMemberResponse
+	err := c.do(ctx, http.MethodGet, "/vaults/members", url.Values{"vaultId": {vaultID}}, nil, &res)
+	return res, err
+}
+
+// AddVaultMember adiciona um usuário ao cofre. req.ESVK_PubK_User é a chave do
+// cofre embrulhada para a chave pública do novo membro (envelope.SealVaultKey).
+func (c *Client) AddVaultMember(ctx context.Context, req *models.CreateVaultMemberRequest) (*models.VaultMemberResponse, error) {
+	var res models.VaultMemberResponse
+	if err := c.do(ctx, http.MethodPost, "/vaults/members", nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) UpdateVaultMember(ctx context.Context, req *models.UpdateVaultMemberRequest) error {
+	return c.do(ctx, http.MethodPut, "/vaults/members", nil, req, nil)
+}
+
+func (c *Client) RemoveVaultMember(ctx context.Context, memberID string) error {
+	return c.do(ctx, http.MethodDelete, "/vaults/members", url.Values{"id": {memberID}}, nil, nil)
+}
+
+// Passwords lista os itens cifrados de um cofre.
+func (c *Client) Passwords(ctx context.Context, vaultID string) ([]models.PasswordResponse, error) {
+	var res []models.PasswordResponse
+	err := c.do(ctx, http.MethodGet, "/vaults/passwords", url.Values{"vaultId": {vaultID}}, nil, &res)
+	return res, err
+}
+
+func (c *Client) CreatePassword(ctx context.Context, req *models.CreatePasswordRequest) (*models.PasswordResponse, error) {
+	var res models.PasswordResponse
+	if err := c.do(ctx, http.MethodPost, "/vaults/passwords", nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// UpdatePassword troca os dados cifrados de um item; veja UpdateVault sobre req.Revision.
+func (c *Client) UpdatePassword(ctx context.Context, req *models.UpdatePasswordRequest) (*models.PasswordResponse, error) {
+	var res models.PasswordResponse
+	if err := c.do(ctx, http.MethodPut, "/vaults/passwords", nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) DeletePassword(ctx context.Context, passwordID string) error {
+	return c.do(ctx, http.MethodDelete, "/vaults/passwords", url.Values{"id": {passwordID}}, nil, nil)
+}
+
+// BatchPasswords aplica várias operações numa única transação. Se o lote for
+// rejeitado, o *APIError traz um models.BatchItemsResponse em Details.
+func (c *Client) BatchPasswords(ctx context.Context, req *models.BatchItemsRequest) (*models.BatchItemsResponse, error) {
+	var res models.BatchItemsResponse
+	err := c.do(ctx, http.MethodPost, "/vaults/passwords/batch", nil, req, &res)
+	return &res, err
+}
+
+// ExportVaults baixa o pacote assinado com todos os cofres do usuário. O payload
+// é mantido byte a byte para que a assinatura continue verificável.
+func (c *Client) ExportVaults(ctx context.Context) (*models.ExportBundle, error) {
+	var res models.ExportBundle
+	if err := c.do(ctx, http.MethodGet, "/vaults/export", nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// ExportPublicKey devolve a chave que este servidor usa para assinar exportações.
+func (c *Client) ExportPublicKey(ctx context.Context) (*models.ExportPublicKeyResponse, error) {
+	var res models.ExportPublicKeyResponse
+	if err := c.do(ctx, http.MethodGet, "/vaults/export/public-key", nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) ImportVaults(ctx context.Context, req *models.ImportRequest) (*models.ImportResponse, error) {
+	var res models.ImportResponse
+	if err := c.do(ctx, http.MethodPost, "/vaults/import", nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+
+	"lembrago.com/lembrago/models"
+)
+
+func (c *Client) Versions(ctx context.Context) ([]models.ApplicationVersion, error) {
+	var res []models.ApplicationVersion
+	err := c.do(ctx, http.MethodGet, "/versions", nil, nil, &res)
+	return res, err
+}
+
+func (c *Client) LatestVersion(ctx context.Context) (*models.ApplicationVersion, error) {
+	var res models.ApplicationVersion
+	if err := c.do(ctx, http.MethodGet, "/versions/latest", nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// DownloadDesktopApp copia para w o instalador de target/arch; version pode ser "latest".
+func (c *Client) DownloadDesktopApp(ctx context.Context, target, arch, version string, w io.Writer) (int64, error) {
+	path := "/versions/" + url.PathEscape(target) + "/" + url.PathEscape(arch) + "/" + url.PathEscape(version)
+	return c.download(ctx, path, w)
+}
+
+// VersionAdmin publica versões do app desktop. Usa o token assinado com
+// JWT_SECRET_ADMIN, que é diferente do token de login.
+type VersionAdmin struct {
+	client *Client
+}
+
+func (c *Client) VersionAdmin(adminToken string) *VersionAdmin {
+	return &VersionAdmin{client: c.withToken(adminToken)}
+}
+
+func (a *VersionAdmin) RegisterVersion(ctx context.Context, version *models.ApplicationVersion) (*models.ApplicationVersion, error) {
+	var res models.ApplicationVersion
+	if err := a.client.do(ctx, http.MethodPost, "/versions", nil, version, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (a *VersionAdmin) UpdateVersion(ctx context.Context, version *models.ApplicationVersion) (*models.ApplicationVersion, error) {
+	var res models.ApplicationVersion
+	if err := a.client.do(ctx, http.MethodPut, "/versions", nil, version, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (a *VersionAdmin) RemoveVersion(ctx context.Context, versionID string) error {
+	return a.client.do(ctx, http.MethodDelete, "/versions/"+url.PathEscape(versionID), nil, nil, nil)
+}
+
+// UploadDesktopApp envia o instalador; lang vazio vira "en-US" no servidor.
+func (a *VersionAdmin) UploadDesktopApp(ctx context.Context, target, arch, version, lang, filename string, installer io.Reader) error {
+	fields := map[string]string{"target": target, "arch": arch, "version": version}
+	if lang != "" {
+		fields["lang"] = lang
+	}
+	return a.client.upload(ctx, "/versions/desktop", "file", filename, installer, fields, nil)
+}
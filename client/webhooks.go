@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"lembrago.com/lembrago/models"
+)
+
+func (c *Client) Webhooks(ctx context.Context) ([]models.WebhookResponse, error) {
+	var res []models.WebhookResponse
+	err := c.do(ctx, http.MethodGet, "/org/webhooks", nil, nil, &res)
+	return res, err
+}
+
+// CreateWebhook cadastra o endpoint. O segredo de assinatura só vem nesta resposta.
+func (c *Client) CreateWebhook(ctx context.Context, req *models.CreateWebhookRequest) (*models.CreatedWebhookResponse, error) {
+	var res models.CreatedWebhookResponse
+	if err := c.do(ctx, http.MethodPost, "/org/webhooks", nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) UpdateWebhook(ctx context.Context, webhookID string, req *models.UpdateWebhookRequest) (*models.WebhookResponse, error) {
+	var res models.WebhookResponse
+	if err := c.do(ctx, http.MethodPut, "/org/webhooks/"+url.PathEscape(webhookID), nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// DeleteWebhook apaga o webhook e o seu histórico de entregas.
+func (c *Client) DeleteWebhook(ctx context.Context, webhookID string) error {
+	return c.do(ctx, http.MethodDelete, "/org/webhooks/"+url.PathEscape(webhookID), nil, nil, nil)
+}
+
+// WebhookDeliveries lista as entregas do webhook, da mais nova para a mais antiga.
+func (c *Client) WebhookDeliveries(ctx context.Context, webhookID string, query models.WebhookDeliveryQuery) (*models.WebhookDeliveriesResponse, error) {
+	values := url.Values{}
+	if query.Page > 0 {
+		values.Set("page", strconv.Itoa(query.Page))
+	}
+	if query.Limit > 0 {
+		values.Set("limit", strconv.Itoa(query.Limit))
+	}
+
+	var res models.WebhookDeliveriesResponse
+	if err := c.do(ctx, http.MethodGet, "/org/webhooks/"+url.PathEscape(webhookID)+"/deliveries", values, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// RedeliverWebhook envia de novo o corpo de uma entrega e devolve a nova entrega
+// já com o resultado da primeira tentativa.
+func (c *Client) RedeliverWebhook(ctx context.Context, webhookID, deliveryID string) (*models.WebhookDeliveryResponse, error) {
+	var res models.WebhookDeliveryResponse
+	path := "/org/webhooks/" + url.PathEscape(webhookID) + "/deliveries/" + url.PathEscape(deliveryID) + "/redeliver"
+	if err := c.do(ctx, http.MethodPost, path, nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// VerifyWebhookSignature confere o cabeçalho X-LemBRAGO-Signature de uma entrega
+// recebida. Assinaturas mais velhas que tolerance são recusadas, para que uma
+// entrega capturada não possa ser repetida depois.
+func VerifyWebhookSignature(secret, header string, body []byte, tolerance time.Duration) error {
+	var timestamp, signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signature = value
+		}
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || signature == "" {
+		return fmt.Errorf("malformed webhook signature")
+	}
+	if age := time.Since(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("webhook signature is too old")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return fmt.Errorf("webhook signature does not match")
+	}
+	return nil
+}
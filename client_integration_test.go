@@ -0,0 +1,1286 @@
+//go:build integration
+
+// Testes de ida e volta do pacote client contra o router real, rodando no mesmo
+// processo. Precisam do .env, do MongoDB (replica set, por causa dos lotes) e do
+// Redis do docker-compose:
+//
+//	go test -tags integration -run TestClient .
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"lembrago.com/lembrago/cache"
+	"lembrago.com/lembrago/client"
+	"lembrago.com/lembrago/client/envelope"
+	"lembrago.com/lembrago/client/items"
+	"lembrago.com/lembrago/internal/config"
+	"lembrago.com/lembrago/mailer"
+	"lembrago.com/lembrago/models"
+	"lembrago.com/lembrago/realtime"
+	"lembrago.com/lembrago/repository"
+)
+
+// Parâmetros baixos só para o teste não gastar segundos em cada derivação.
+var testParams = models.Argo2IDParameters{Memory: 8 * 1024, Time: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+var listenOnce sync.Once
+
+// testMailDir recebe os e-mails do servidor de teste, um .eml por mensagem.
+var testMailDir string
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	listenOnce.Do(func() {
+		go realtime.Listen()
+
+		// Os receptores de webhook dos testes são httptest em 127.0.0.1.
+		os.Setenv("WEBHOOK_ALLOW_HTTP", "true")
+		os.Setenv("WEBHOOK_ALLOW_PRIVATE_IPS", "true")
+
+		dir, err := os.MkdirTemp("", "lembrago-mail-")
+		if err != nil {
+			panic(err)
+		}
+		testMailDir = dir
+		if err := mailer.Start(mailer.Config{Driver: mailer.DriverFile, FileDir: dir}); err != nil {
+			panic(err)
+		}
+	})
+
+	server := httptest.NewServer(setupRouter(config.GetServerConfig()))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// unlock faz o login completo; o código enviado por e-mail é lido do Redis.
+func unlock(t *testing.T, baseURL, email, masterPassword, orgID string) *client.Session {
+	t.Helper()
+	return unlockFromDevice(t, baseURL, "", email, masterPassword, orgID)
+}
+
+func unlockFromDevice(t *testing.T, baseURL, deviceID, email, masterPassword, orgID string) *client.Session {
+	t.Helper()
+	ctx := context.Background()
+	api := client.New(baseURL)
+	api.DeviceID = deviceID
+
+	if err := api.RequestAuthCode(ctx, email); err != nil {
+		t.Fatalf("RequestAuthCode: %v", err)
+	}
+
+	var code string
+	for i := 0; i < 50 && code == ""; i++ {
+		code, _ = cache.Get("auth-" + email)
+		time.Sleep(20 * time.Millisecond)
+	}
+	if code == "" {
+		t.Fatal("auth code was not stored")
+	}
+
+	orgs, err := api.LoginInfo(ctx, email, code)
+	if err != nil {
+		t.Fatalf("LoginInfo: %v", err)
+	}
+	for _, org := range orgs {
+		if org.OrgID == orgID {
+			session, err := api.Unlock(ctx, email, masterPassword, org)
+			if err != nil {
+				t.Fatalf("Unlock: %v", err)
+			}
+			return session
+		}
+	}
+	t.Fatalf("organization %s not returned for %s", orgID, email)
+	return nil
+}
+
+// receivedMail é um e-mail de testMailDir com as partes já decodificadas.
+type receivedMail struct {
+	From string
+	HTML string
+	Text string
+}
+
+// waitForMail espera o e-mail com esse assunto para to.
+func waitForMail(t *testing.T, to, subject string) receivedMail {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		files, _ := filepath.Glob(filepath.Join(testMailDir, "*-"+to+".eml"))
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				continue
+			}
+			msg, err := mail.ReadMessage(bytes.NewReader(data))
+			if err != nil {
+				continue
+			}
+			if got, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject")); got != subject {
+				continue
+			}
+			from, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("From"))
+			received := receivedMail{From: from}
+			if err := readMailParts(msg.Header, msg.Body, &received); err != nil {
+				t.Fatalf("read mail %s: %v", file, err)
+			}
+			return received
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("no mail %q to %s", subject, to)
+	return receivedMail{}
+}
+
+// readMailParts guarda em received as partes text/plain e text/html, entrando
+// nas partes multipart. header é um mail.Header ou textproto.MIMEHeader.
+func readMailParts(header interface{ Get(string) string }, body io.Reader, received *receivedMail) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			// NextPart já desfaz o quoted-printable e tira o cabeçalho.
+			if err := readMailParts(part.Header, part, received); err != nil {
+				return err
+			}
+		}
+	}
+
+	if header.Get("Content-Transfer-Encoding") == "quoted-printable" {
+		body = quotedprintable.NewReader(body)
+	}
+	decoded, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	if mediaType == "text/plain" {
+		received.Text = string(decoded)
+	} else {
+		received.HTML = string(decoded)
+	}
+	return nil
+}
+
+func wantStatus(t *testing.T, err error, status int) {
+	t.Helper()
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.Status != status {
+		t.Fatalf("want API error %d, got %v", status, err)
+	}
+}
+
+func TestClientRoundTrip(t *testing.T) {
+	server := newTestServer(t)
+	ctx := context.Background()
+	suffix := primitive.NewObjectID().Hex()
+	adminEmail := fmt.Sprintf("admin-%s@example.com", suffix)
+	memberEmail := fmt.Sprintf("member-%s@example.com", suffix)
+	const adminPassword = "correct horse battery staple"
+	const memberPassword = "another long master password"
+
+	userReq, err := client.NewUserRequest("admin", adminPassword, testParams, &items.VaultMetadata{Name: "Pessoal"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	org, err := client.New(server.URL).CreateOrganization(ctx, &models.CreateOrganizationRequest{
+		Name:             "Org " + suffix,
+		Email:            adminEmail,
+		SubscriptionPlan: string(models.BasicPlan),
+		User:             *userReq,
+	})
+	if err != nil {
+		t.Fatalf("CreateOrganization: %v", err)
+	}
+	t.Cleanup(func() {
+		repository.DeleteOrganization(org.ID)
+		repository.DeleteUser(org.ID)
+	})
+	orgID := org.ID.Hex()
+
+	admin := unlock(t, server.URL, adminEmail, adminPassword, orgID)
+	if admin.SecretKey == nil {
+		t.Fatal("secret key was not unlocked")
+	}
+
+	vaults, err := admin.Vaults(ctx)
+	if err != nil {
+		t.Fatalf("Vaults: %v", err)
+	}
+	if len(vaults) != 1 || !vaults[0].PersonalVault || vaults[0].Metadata.Name != "Pessoal" {
+		t.Fatalf("unexpected personal vault: %+v", vaults)
+	}
+
+	team, err := admin.CreateVault(ctx, &items.VaultMetadata{Name: "Equipe"})
+	if err != nil {
+		t.Fatalf("CreateVault: %v", err)
+	}
+	t.Cleanup(func() { admin.Client.RemoveVault(context.Background(), team.ID) })
+
+	var item *client.DecryptedItem
+	t.Run("items", func(t *testing.T) {
+		streamCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		stream, err := admin.Client.Events(streamCtx)
+		if err != nil {
+			t.Fatalf("Events: %v", err)
+		}
+		defer stream.Close()
+		if event, err := stream.Next(); err != nil || event.Type != "ready" {
+			t.Fatalf("want ready event, got %v %v", event, err)
+		}
+
+		item, err = admin.CreateItem(ctx, team.ID, &items.Item{
+			Type:     items.TypeLogin,
+			Name:     "Servidor",
+			Username: "root",
+			Password: "s3nh4",
+			URLs:     []string{"https://example.com"},
+		})
+		if err != nil {
+			t.Fatalf("CreateItem: %v", err)
+		}
+
+		for {
+			event, err := stream.Next()
+			if err != nil {
+				t.Fatalf("waiting for item.created: %v", err)
+			}
+			if event.Type != string(models.EventItemCreated) {
+				continue
+			}
+			change, err := event.Change()
+			if err != nil || change.ObjectID != item.ID || change.VaultID != team.ID {
+				t.Fatalf("unexpected change event: %+v %v", change, err)
+			}
+			break
+		}
+
+		stale := *item
+		item.Data.Password = "nova-s3nh4"
+		item, err = admin.UpdateItem(ctx, item)
+		if err != nil {
+			t.Fatalf("UpdateItem: %v", err)
+		}
+		_, err = admin.UpdateItem(ctx, &stale)
+		wantStatus(t, err, http.StatusConflict)
+
+		list, err := admin.Items(ctx, team.ID)
+		if err != nil {
+			t.Fatalf("Items: %v", err)
+		}
+		if len(list) != 1 || list[0].Data.Password != "nova-s3nh4" || list[0].Revision != item.Revision {
+			t.Fatalf("unexpected items: %+v", list)
+		}
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		key, err := admin.VaultKey(models.VaultWithMemberInfo{ID: team.ID, ESVK_PubK_User: team.MyMembership.ESVK_PubK_User})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := &models.BatchItemsRequest{}
+		for _, name := range []string{"Banco", "E-mail"} {
+			data, err := (&items.Item{Type: items.TypeLogin, Name: name, Password: name}).Encrypt(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Operations = append(req.Operations, models.BatchItemOperation{
+				Op:                models.BatchOpCreate,
+				VaultID:           team.ID,
+				EncryptedItemData: &data,
+			})
+		}
+		if _, err := admin.Client.BatchPasswords(ctx, req); err != nil {
+			t.Fatalf("BatchPasswords: %v", err)
+		}
+
+		list, err := admin.Items(ctx, team.ID)
+		if err != nil || len(list) != 3 {
+			t.Fatalf("want 3 items after batch, got %d (%v)", len(list), err)
+		}
+	})
+
+	t.Run("attachments", func(t *testing.T) {
+		if item == nil {
+			t.Skip("item was not created")
+		}
+		key, err := admin.VaultKey(models.VaultWithMemberInfo{ID: team.ID, ESVK_PubK_User: team.MyMembership.ESVK_PubK_User})
+		if err != nil {
+			t.Fatal(err)
+		}
+		metadata, err := envelope.Seal(key, []byte(`{"name":"chave.pem"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		chunk := bytes.Repeat([]byte{0xAB}, 1024)
+		before, err := admin.Client.StorageUsage(ctx)
+		if err != nil {
+			t.Fatalf("StorageUsage: %v", err)
+		}
+
+		tooBig := before.Quota - before.Used + 1
+		_, err = admin.Client.CreateAttachment(ctx, &models.CreateAttachmentRequest{
+			PasswordID:        item.ID,
+			EncryptedMetadata: metadata,
+			Size:              tooBig,
+			ChunkCount:        int((tooBig + client.MaxChunkSize - 1) / client.MaxChunkSize),
+		})
+		wantStatus(t, err, http.StatusRequestEntityTooLarge)
+
+		attachment, err := admin.Client.CreateAttachment(ctx, &models.CreateAttachmentRequest{
+			PasswordID:        item.ID,
+			EncryptedMetadata: metadata,
+			Size:              int64(len(chunk)),
+			ChunkCount:        1,
+		})
+		if err != nil {
+			t.Fatalf("CreateAttachment: %v", err)
+		}
+		if usage, err := admin.Client.StorageUsage(ctx); err != nil || usage.Used != before.Used+int64(len(chunk)) {
+			t.Fatalf("StorageUsage after create: %+v %v", usage, err)
+		}
+		if err := admin.Client.UploadAttachmentChunk(ctx, attachment.ID, 0, chunk); err != nil {
+			t.Fatalf("UploadAttachmentChunk: %v", err)
+		}
+		if _, err := admin.Client.CompleteAttachment(ctx, attachment.ID); err != nil {
+			t.Fatalf("CompleteAttachment: %v", err)
+		}
+
+		downloaded, err := admin.Client.DownloadAttachmentChunk(ctx, attachment.ID, 0)
+		if err != nil || !bytes.Equal(downloaded, chunk) {
+			t.Fatalf("chunk round trip failed: %v", err)
+		}
+		if err := admin.Client.DeleteAttachment(ctx, attachment.ID); err != nil {
+			t.Fatalf("DeleteAttachment: %v", err)
+		}
+		if usage, err := admin.Client.StorageUsage(ctx); err != nil || usage.Used != before.Used {
+			t.Fatalf("StorageUsage after delete: %+v %v", usage, err)
+		}
+	})
+
+	var member *client.Session
+	t.Run("invite and share", func(t *testing.T) {
+		if item == nil {
+			t.Skip("item was not created")
+		}
+		code, err := admin.Client.InviteUser(ctx, memberEmail, models.RoleMember)
+		if err != nil {
+			t.Fatalf("InviteUser: %v", err)
+		}
+		var invite *models.MinOrgWithTokenResponse
+		for i := 0; i < 50 && invite == nil; i++ {
+			invite, _ = client.New(server.URL).GetInvite(ctx, code)
+			time.Sleep(20 * time.Millisecond)
+		}
+		if invite == nil || invite.UserEmail != memberEmail {
+			t.Fatalf("GetInvite: %+v", invite)
+		}
+		if mail := waitForMail(t, memberEmail, "Você foi convidado(a)"); !strings.Contains(mail.HTML, code) || !strings.Contains(mail.Text, code) {
+			t.Fatalf("invite e-mail does not carry the code: %+v", mail)
+		}
+
+		memberReq, err := client.NewUserRequest("member", memberPassword, testParams, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		memberReq.Code = code
+		if err := client.New(server.URL).RegisterUser(ctx, invite, memberReq); err != nil {
+			t.Fatalf("RegisterUser: %v", err)
+		}
+
+		member = unlock(t, server.URL, memberEmail, memberPassword, orgID)
+		t.Cleanup(func() { admin.Client.DeleteUser(context.Background(), member.User.ID) })
+
+		if _, err := admin.ShareVault(ctx, team.ID, member.User.ID, models.READ); err != nil {
+			t.Fatalf("ShareVault: %v", err)
+		}
+
+		list, err := member.Items(ctx, team.ID)
+		if err != nil {
+			t.Fatalf("member Items: %v", err)
+		}
+		found := false
+		for _, shared := range list {
+			found = found || (shared.ID == item.ID && shared.Data.Password == "nova-s3nh4")
+		}
+		if !found {
+			t.Fatal("member cannot read the shared item")
+		}
+
+		state, err := member.Client.Sync(ctx, "")
+		if err != nil || !state.Full || len(state.Vaults) != 1 || state.Vaults[0].ID != team.ID {
+			t.Fatalf("unexpected sync: %+v %v", state, err)
+		}
+
+		// O sync entrega os itens cifrados, então entra no log como leitura em massa.
+		var reads *models.AuditLogResponse
+		for i := 0; i < 50; i++ {
+			reads, err = admin.Client.AuditLog(ctx, models.AuditQuery{Action: string(models.AuditItemsRead), ActorID: member.User.ID, VaultID: team.ID})
+			if err == nil && reads.Total >= 2 {
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		if err != nil {
+			t.Fatalf("AuditLog: %v", err)
+		}
+		synced := false
+		for _, entry := range reads.Entries {
+			synced = synced || (entry.Details["source"] == "sync" && entry.Details["count"] == strconv.Itoa(len(state.Items)))
+		}
+		if !synced {
+			t.Fatalf("AuditLog sync read: %+v %v", reads, err)
+		}
+	})
+
+	t.Run("vault permissions", func(t *testing.T) {
+		if member == nil {
+			t.Skip("member was not registered")
+		}
+		members, err := member.Client.VaultMembers(ctx, team.ID)
+		if err != nil {
+			t.Fatalf("VaultMembers as reader: %v", err)
+		}
+		var membership *models.VaultMemberResponse
+		for i := range members {
+			if members[i].UserID == member.User.ID {
+				membership = &members[i]
+			}
+		}
+		if membership == nil {
+			t.Fatal("member is not listed in the vault")
+		}
+
+		_, err = member.CreateItem(ctx, team.ID, &items.Item{Type: items.TypeLogin, Name: "x"})
+		wantStatus(t, err, http.StatusForbidden)
+		err = member.Client.UpdateVaultMember(ctx, &models.UpdateVaultMemberRequest{
+			MemberID:       membership.ID,
+			ESVK_PubK_User: membership.ESVK_PubK_User,
+			Permission:     models.ADMIN,
+		})
+		wantStatus(t, err, http.StatusForbidden)
+
+		setPermission := func(permission models.VaultPermission) {
+			t.Helper()
+			if err := admin.Client.UpdateVaultMember(ctx, &models.UpdateVaultMemberRequest{
+				MemberID:       membership.ID,
+				ESVK_PubK_User: membership.ESVK_PubK_User,
+				Permission:     permission,
+			}); err != nil {
+				t.Fatalf("UpdateVaultMember %s: %v", permission, err)
+			}
+		}
+		setPermission(models.WRITE)
+		defer setPermission(models.READ)
+
+		written, err := member.CreateItem(ctx, team.ID, &items.Item{Type: items.TypeLogin, Name: "Escrito pelo membro"})
+		if err != nil {
+			t.Fatalf("CreateItem as writer: %v", err)
+		}
+		if err := member.Client.DeletePassword(ctx, written.ID); err != nil {
+			t.Fatalf("DeletePassword as writer: %v", err)
+		}
+	})
+
+	t.Run("groups", func(t *testing.T) {
+		if member == nil {
+			t.Skip("member was not registered")
+		}
+		infra, err := admin.CreateVault(ctx, &items.VaultMetadata{Name: "Infra"})
+		if err != nil {
+			t.Fatalf("CreateVault: %v", err)
+		}
+		t.Cleanup(func() { admin.Client.RemoveVault(context.Background(), infra.ID) })
+
+		group, err := admin.Client.CreateGroup(ctx, &models.CreateGroupRequest{Name: "Engenharia"})
+		if err != nil {
+			t.Fatalf("CreateGroup: %v", err)
+		}
+		t.Cleanup(func() { admin.Client.DeleteGroup(context.Background(), group.ID) })
+
+		if _, err := admin.Client.GrantVaultToGroup(ctx, infra.ID, group.ID, models.WRITE); err != nil {
+			t.Fatalf("GrantVaultToGroup: %v", err)
+		}
+		if err := admin.Client.AddGroupMember(ctx, group.ID, member.User.ID); err != nil {
+			t.Fatalf("AddGroupMember: %v", err)
+		}
+
+		shared, err := admin.ProcessKeyShareTasks(ctx, func(task models.KeyShareTaskResponse, fingerprint string) bool {
+			return task.UserID == member.User.ID && fingerprint == member.Fingerprint()
+		})
+		if err != nil || shared != 1 {
+			t.Fatalf("ProcessKeyShareTasks: %d %v", shared, err)
+		}
+		if _, err := member.Items(ctx, infra.ID); err != nil {
+			t.Fatalf("member Items after group grant: %v", err)
+		}
+
+		if err := admin.Client.RemoveGroupMember(ctx, group.ID, member.User.ID); err != nil {
+			t.Fatalf("RemoveGroupMember: %v", err)
+		}
+		_, err = member.Items(ctx, infra.ID)
+		wantStatus(t, err, http.StatusForbidden)
+	})
+
+	t.Run("pending share", func(t *testing.T) {
+		if member == nil {
+			t.Skip("member was not registered")
+		}
+		finance, err := admin.CreateVault(ctx, &items.VaultMetadata{Name: "Financeiro"})
+		if err != nil {
+			t.Fatalf("CreateVault: %v", err)
+		}
+		t.Cleanup(func() { admin.Client.RemoveVault(context.Background(), finance.ID) })
+
+		waiting, err := admin.Client.ShareVaultByEmail(ctx, finance.ID, "later-"+memberEmail, models.READ)
+		if err != nil || waiting.Status != models.PendingShareWaiting {
+			t.Fatalf("ShareVaultByEmail before registration: %+v %v", waiting, err)
+		}
+		if err := admin.Client.CancelPendingShare(ctx, waiting.ID); err != nil {
+			t.Fatalf("CancelPendingShare: %v", err)
+		}
+
+		share, err := admin.Client.ShareVaultByEmail(ctx, finance.ID, memberEmail, models.READ)
+		if err != nil || share.Status != models.PendingShareReady {
+			t.Fatalf("ShareVaultByEmail: %+v %v", share, err)
+		}
+		if _, err := member.Items(ctx, finance.ID); err == nil {
+			t.Fatal("pending share gave access before confirmation")
+		}
+
+		if _, err := admin.ConfirmPendingShare(ctx, *share, admin.Fingerprint()); !errors.Is(err, client.ErrFingerprintMismatch) {
+			t.Fatalf("ConfirmPendingShare with wrong fingerprint: %v", err)
+		}
+		if _, err := admin.ConfirmPendingShare(ctx, *share, member.Fingerprint()); err != nil {
+			t.Fatalf("ConfirmPendingShare: %v", err)
+		}
+		if _, err := member.Items(ctx, finance.ID); err != nil {
+			t.Fatalf("member Items after confirmation: %v", err)
+		}
+		pending, err := admin.Client.PendingShares(ctx)
+		if err != nil || len(pending) != 0 {
+			t.Fatalf("PendingShares: %+v %v", pending, err)
+		}
+	})
+
+	t.Run("membership expiry", func(t *testing.T) {
+		if member == nil {
+			t.Skip("member was not registered")
+		}
+		audit, err := admin.CreateVault(ctx, &items.VaultMetadata{Name: "Auditoria"})
+		if err != nil {
+			t.Fatalf("CreateVault: %v", err)
+		}
+		t.Cleanup(func() { admin.Client.RemoveVault(context.Background(), audit.ID) })
+
+		if _, err := admin.ShareVaultUntil(ctx, audit.ID, member.User.ID, models.READ, time.Now().Add(-time.Minute)); err == nil {
+			t.Fatal("ShareVaultUntil accepted an expiry in the past")
+		}
+		shared, err := admin.ShareVaultUntil(ctx, audit.ID, member.User.ID, models.READ, time.Now().Add(2*time.Second))
+		if err != nil || shared.ExpiresAt == nil {
+			t.Fatalf("ShareVaultUntil: %+v %v", shared, err)
+		}
+		if _, err := member.Items(ctx, audit.ID); err != nil {
+			t.Fatalf("member Items before expiry: %v", err)
+		}
+
+		// Trocar a permissão sem mandar expiresAt não pode tornar o acesso permanente.
+		if err := admin.Client.UpdateVaultMember(ctx, &models.UpdateVaultMemberRequest{
+			MemberID:       shared.ID,
+			ESVK_PubK_User: shared.ESVK_PubK_User,
+			Permission:     models.WRITE,
+		}); err != nil {
+			t.Fatalf("UpdateVaultMember without expiresAt: %v", err)
+		}
+		members, err := admin.Client.VaultMembers(ctx, audit.ID)
+		if err != nil {
+			t.Fatalf("VaultMembers: %v", err)
+		}
+		for _, m := range members {
+			if m.ID == shared.ID && (m.ExpiresAt == nil || *m.ExpiresAt != *shared.ExpiresAt || m.Permission != string(models.WRITE)) {
+				t.Fatalf("update without expiresAt changed the expiry: %+v", m)
+			}
+		}
+
+		time.Sleep(3 * time.Second)
+		vaults, err := member.Client.MyVaults(ctx)
+		if err != nil {
+			t.Fatalf("MyVaults: %v", err)
+		}
+		for _, vault := range vaults {
+			if vault.ID == audit.ID {
+				t.Fatal("expired membership still listed")
+			}
+		}
+		_, err = member.Items(ctx, audit.ID)
+		wantStatus(t, err, http.StatusForbidden)
+
+		// A rodada de expiração pode não ter passado ainda; o acesso vencido não
+		// pode impedir um novo compartilhamento.
+		if _, err := admin.ShareVault(ctx, audit.ID, member.User.ID, models.READ); err != nil {
+			t.Fatalf("ShareVault after expiry: %v", err)
+		}
+		if _, err := member.Items(ctx, audit.ID); err != nil {
+			t.Fatalf("member Items after re-share: %v", err)
+		}
+	})
+
+	t.Run("access request", func(t *testing.T) {
+		if member == nil {
+			t.Skip("member was not registered")
+		}
+		legal, err := admin.CreateVault(ctx, &items.VaultMetadata{Name: "Jurídico"})
+		if err != nil {
+			t.Fatalf("CreateVault: %v", err)
+		}
+		t.Cleanup(func() { admin.Client.RemoveVault(context.Background(), legal.ID) })
+
+		if _, err := member.Client.RequestVaultAccess(ctx, legal.ID, models.READ, "contratos"); err == nil {
+			t.Fatal("requested a vault that is not in the directory")
+		}
+		if _, err := admin.Client.UpdateVaultDirectory(ctx, legal.ID, true, "Jurídico"); err != nil {
+			t.Fatalf("UpdateVaultDirectory: %v", err)
+		}
+		directory, err := member.Client.VaultDirectory(ctx)
+		if err != nil {
+			t.Fatalf("VaultDirectory: %v", err)
+		}
+		listed := false
+		for _, entry := range directory {
+			if entry.VaultID == legal.ID {
+				listed = entry.DisplayName == "Jurídico" && !entry.IsMember
+			}
+		}
+		if !listed {
+			t.Fatalf("vault missing from directory: %+v", directory)
+		}
+
+		request, err := member.Client.RequestVaultAccess(ctx, legal.ID, models.READ, "revisar contratos")
+		if err != nil {
+			t.Fatalf("RequestVaultAccess: %v", err)
+		}
+		_, err = member.Client.RequestVaultAccess(ctx, legal.ID, models.READ, "de novo")
+		wantStatus(t, err, http.StatusConflict)
+		if err := admin.Client.RejectAccessRequest(ctx, request.ID, "fale com o jurídico"); err != nil {
+			t.Fatalf("RejectAccessRequest: %v", err)
+		}
+		mine, err := member.Client.MyAccessRequests(ctx)
+		if err != nil || len(mine) == 0 || mine[0].Status != models.AccessRequestRejected || len(mine[0].History) != 2 {
+			t.Fatalf("MyAccessRequests after reject: %+v %v", mine, err)
+		}
+
+		request, err = member.Client.RequestVaultAccess(ctx, legal.ID, models.READ, "revisar contratos")
+		if err != nil {
+			t.Fatalf("RequestVaultAccess again: %v", err)
+		}
+		pending, err := admin.Client.AccessRequests(ctx)
+		if err != nil || len(pending) != 1 || pending[0].ID != request.ID {
+			t.Fatalf("AccessRequests: %+v %v", pending, err)
+		}
+		if _, err := admin.ApproveAccessRequest(ctx, pending[0], admin.Fingerprint(), ""); !errors.Is(err, client.ErrFingerprintMismatch) {
+			t.Fatalf("ApproveAccessRequest with wrong fingerprint: %v", err)
+		}
+		if _, err := admin.ApproveAccessRequest(ctx, pending[0], member.Fingerprint(), "ok"); err != nil {
+			t.Fatalf("ApproveAccessRequest: %v", err)
+		}
+		if _, err := member.Items(ctx, legal.ID); err != nil {
+			t.Fatalf("member Items after approval: %v", err)
+		}
+	})
+
+	t.Run("vault ownership", func(t *testing.T) {
+		if member == nil {
+			t.Skip("member was not registered")
+		}
+		purchasing, err := admin.CreateVault(ctx, &items.VaultMetadata{Name: "Compras"})
+		if err != nil {
+			t.Fatalf("CreateVault: %v", err)
+		}
+		t.Cleanup(func() { admin.Client.RemoveVault(context.Background(), purchasing.ID) })
+		if purchasing.OwnerID != admin.User.ID {
+			t.Fatalf("OwnerID = %s, want %s", purchasing.OwnerID, admin.User.ID)
+		}
+
+		if _, err := admin.Client.TransferVaultOwnership(ctx, purchasing.ID, member.User.ID); err == nil {
+			t.Fatal("transferred ownership to a non-member")
+		}
+		if _, err := admin.ShareVault(ctx, purchasing.ID, member.User.ID, models.ADMIN); err != nil {
+			t.Fatalf("ShareVault: %v", err)
+		}
+
+		if _, err := admin.Client.TransferVaultOwnership(ctx, purchasing.ID, member.User.ID); err != nil {
+			t.Fatalf("TransferVaultOwnership: %v", err)
+		}
+		err = member.Client.RemoveVault(ctx, purchasing.ID)
+		wantStatus(t, err, http.StatusForbidden)
+		err = admin.Client.ForceVaultOwnershipTransfer(ctx, purchasing.ID, member.User.ID)
+		wantStatus(t, err, http.StatusConflict)
+
+		accepted, err := member.Client.AcceptVaultOwnership(ctx, purchasing.ID)
+		if err != nil || accepted.OwnerID != member.User.ID {
+			t.Fatalf("AcceptVaultOwnership: %+v %v", accepted, err)
+		}
+		err = admin.Client.RemoveVault(ctx, purchasing.ID)
+		wantStatus(t, err, http.StatusForbidden)
+
+		if err := admin.Client.UpdateUserStatus(ctx, member.User.ID, models.StatusSuspended); err != nil {
+			t.Fatalf("UpdateUserStatus suspended: %v", err)
+		}
+		err = admin.Client.ForceVaultOwnershipTransfer(ctx, purchasing.ID, admin.User.ID)
+		if restore := admin.Client.UpdateUserStatus(ctx, member.User.ID, models.StatusActive); restore != nil {
+			t.Fatalf("UpdateUserStatus active: %v", restore)
+		}
+		if err != nil {
+			t.Fatalf("ForceVaultOwnershipTransfer from a suspended owner: %v", err)
+		}
+		err = member.Client.RemoveVault(ctx, purchasing.ID)
+		wantStatus(t, err, http.StatusForbidden)
+		if err := admin.Client.RemoveVault(ctx, purchasing.ID); err != nil {
+			t.Fatalf("RemoveVault by forced owner: %v", err)
+		}
+	})
+
+	t.Run("custom role", func(t *testing.T) {
+		if member == nil {
+			t.Skip("member was not registered")
+		}
+		catalog, err := admin.Client.Permissions(ctx)
+		if err != nil || len(catalog) == 0 {
+			t.Fatalf("Permissions: %+v %v", catalog, err)
+		}
+		_, err = admin.Client.CreateRole(ctx, &models.CreateRoleRequest{Name: "x", Permissions: []models.OrgPermission{"vaults:own"}})
+		wantStatus(t, err, http.StatusBadRequest)
+		role, err := admin.Client.CreateRole(ctx, &models.CreateRoleRequest{
+			Name:        "Leitor de usuários",
+			Permissions: []models.OrgPermission{models.PermUsersRead},
+		})
+		if err != nil {
+			t.Fatalf("CreateRole: %v", err)
+		}
+		t.Cleanup(func() { admin.Client.DeleteRole(context.Background(), role.Key) })
+
+		_, err = member.Client.Users(ctx)
+		wantStatus(t, err, http.StatusForbidden)
+		_, err = member.Client.CreateRole(ctx, &models.CreateRoleRequest{Name: "x", Permissions: []models.OrgPermission{models.PermUsersRead}})
+		wantStatus(t, err, http.StatusForbidden)
+
+		if err := admin.Client.UpdateUserRole(ctx, member.User.ID, role.Key); err != nil {
+			t.Fatalf("UpdateUserRole: %v", err)
+		}
+		// A troca de função revoga as sessões abertas, com precisão de segundo.
+		_, err = member.Client.MyVaults(ctx)
+		wantStatus(t, err, http.StatusUnauthorized)
+		time.Sleep(time.Second)
+		reader := unlock(t, server.URL, memberEmail, memberPassword, orgID)
+		if _, err := reader.Client.Users(ctx); err != nil {
+			t.Fatalf("Users with custom role: %v", err)
+		}
+		err = reader.Client.DeleteUser(ctx, admin.User.ID)
+		wantStatus(t, err, http.StatusForbidden)
+		_, err = reader.Items(ctx, team.ID)
+		wantStatus(t, err, http.StatusForbidden)
+		err = admin.Client.DeleteRole(ctx, role.Key)
+		wantStatus(t, err, http.StatusConflict)
+
+		if err := admin.Client.UpdateUserRole(ctx, member.User.ID, models.RoleMember); err != nil {
+			t.Fatalf("UpdateUserRole back to member: %v", err)
+		}
+		time.Sleep(time.Second)
+		member = unlock(t, server.URL, memberEmail, memberPassword, orgID)
+		if err := admin.Client.DeleteRole(ctx, role.Key); err != nil {
+			t.Fatalf("DeleteRole: %v", err)
+		}
+		err = admin.Client.UpdateUserRole(ctx, member.User.ID, role.Key)
+		wantStatus(t, err, http.StatusBadRequest)
+	})
+
+	t.Run("media", func(t *testing.T) {
+		if member == nil {
+			t.Skip("member was not registered")
+		}
+		png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 64)...)
+		media, err := admin.Client.UploadMedia(ctx, "logo.png", bytes.NewReader(png))
+		if err != nil {
+			t.Fatalf("UploadMedia: %v", err)
+		}
+		_, err = member.Client.UploadMedia(ctx, "logo.png", bytes.NewReader(png))
+		wantStatus(t, err, http.StatusForbidden)
+
+		err = member.Client.DeleteMedia(ctx, media.ID.Hex())
+		wantStatus(t, err, http.StatusForbidden)
+		err = admin.Client.DeleteMedia(ctx, "not-an-id")
+		wantStatus(t, err, http.StatusBadRequest)
+		err = admin.Client.DeleteMedia(ctx, primitive.NewObjectID().Hex())
+		wantStatus(t, err, http.StatusNotFound)
+
+		if err := admin.Client.DeleteMedia(ctx, media.ID.Hex()); err != nil {
+			t.Fatalf("DeleteMedia: %v", err)
+		}
+		medias, err := admin.Client.Medias(ctx)
+		if err != nil {
+			t.Fatalf("Medias: %v", err)
+		}
+		for _, m := range medias {
+			if m.ID == media.ID {
+				t.Fatalf("deleted media is still listed")
+			}
+		}
+		err = admin.Client.DeleteMedia(ctx, media.ID.Hex())
+		wantStatus(t, err, http.StatusNotFound)
+	})
+
+	t.Run("suspension", func(t *testing.T) {
+		if member == nil {
+			t.Skip("member was not registered")
+		}
+		err := admin.Client.UpdateUserStatus(ctx, admin.User.ID, models.StatusSuspended)
+		wantStatus(t, err, http.StatusBadRequest)
+
+		if err := admin.Client.UpdateUserStatus(ctx, member.User.ID, models.StatusSuspended); err != nil {
+			t.Fatalf("UpdateUserStatus suspended: %v", err)
+		}
+		_, err = member.Client.MyVaults(ctx)
+		wantStatus(t, err, http.StatusForbidden)
+
+		if err := admin.Client.UpdateUserStatus(ctx, member.User.ID, models.StatusActive); err != nil {
+			t.Fatalf("UpdateUserStatus active: %v", err)
+		}
+		if _, err := member.Client.MyVaults(ctx); err != nil {
+			t.Fatalf("MyVaults after reactivation: %v", err)
+		}
+	})
+
+	t.Run("service account", func(t *testing.T) {
+		if item == nil {
+			t.Skip("item was not created")
+		}
+		account, accessToken, err := admin.Client.CreateServiceAccount(ctx, "deploy", models.ServiceAccountScopeDTO{
+			ReadOnly: true,
+			VaultIDs: []string{team.ID},
+		}, time.Time{})
+		if err != nil {
+			t.Fatalf("CreateServiceAccount: %v", err)
+		}
+		t.Cleanup(func() { admin.Client.DeleteServiceAccount(context.Background(), account.ID) })
+
+		if _, err := admin.ShareVault(ctx, team.ID, account.ID, models.ADMIN); err == nil {
+			t.Fatal("service account became vault admin")
+		}
+		if _, err := admin.ShareVault(ctx, team.ID, account.ID, models.WRITE); err != nil {
+			t.Fatalf("ShareVault: %v", err)
+		}
+		if err := admin.Client.UpdateUserRole(ctx, account.ID, models.RoleAdmin); err == nil {
+			t.Fatal("service account became org admin")
+		}
+		users, err := admin.Client.Users(ctx)
+		if err != nil {
+			t.Fatalf("Users: %v", err)
+		}
+		for _, user := range users {
+			if user.ID == account.ID {
+				t.Fatal("service account listed among users")
+			}
+		}
+
+		machine, err := client.New(server.URL).ServiceAccountLogin(ctx, accessToken)
+		if err != nil {
+			t.Fatalf("ServiceAccountLogin: %v", err)
+		}
+		list, err := machine.Items(ctx, team.ID)
+		if err != nil || len(list) == 0 {
+			t.Fatalf("service account Items: %d %v", len(list), err)
+		}
+		_, err = machine.CreateItem(ctx, team.ID, &items.Item{Type: items.TypeLogin, Name: "x"})
+		wantStatus(t, err, http.StatusForbidden)
+
+		rotated, err := machine.RotateCredential(ctx, "")
+		if err != nil {
+			t.Fatalf("RotateCredential: %v", err)
+		}
+		if _, err := client.New(server.URL).ServiceAccountLogin(ctx, accessToken); err == nil {
+			t.Fatal("old credential still works after rotation")
+		}
+		if _, err := client.New(server.URL).ServiceAccountLogin(ctx, rotated); err != nil {
+			t.Fatalf("ServiceAccountLogin after rotation: %v", err)
+		}
+	})
+
+	t.Run("personal access token", func(t *testing.T) {
+		created, err := admin.Client.CreatePersonalAccessToken(ctx, "script", []models.TokenScope{models.ScopeVaultsRead, models.ScopeItemsRead}, 1)
+		if err != nil {
+			t.Fatalf("CreatePersonalAccessToken: %v", err)
+		}
+
+		pat := client.New(server.URL)
+		pat.Token = created.Token
+		if _, err := pat.MyVaults(ctx); err != nil {
+			t.Fatalf("MyVaults with token: %v", err)
+		}
+		_, err = pat.Users(ctx)
+		wantStatus(t, err, http.StatusForbidden)
+		_, err = pat.PersonalAccessTokens(ctx)
+		wantStatus(t, err, http.StatusForbidden)
+
+		tokens, err := admin.Client.PersonalAccessTokens(ctx)
+		if err != nil || len(tokens) != 1 || tokens[0].ID != created.PersonalAccessToken.ID {
+			t.Fatalf("PersonalAccessTokens: %+v %v", tokens, err)
+		}
+		if err := admin.Client.RevokePersonalAccessToken(ctx, created.PersonalAccessToken.ID); err != nil {
+			t.Fatalf("RevokePersonalAccessToken: %v", err)
+		}
+		_, err = pat.MyVaults(ctx)
+		wantStatus(t, err, http.StatusUnauthorized)
+	})
+
+	t.Run("export", func(t *testing.T) {
+		bundle, err := admin.Client.ExportVaults(ctx)
+		if err != nil {
+			t.Fatalf("ExportVaults: %v", err)
+		}
+		key, err := client.New(server.URL).ExportPublicKey(ctx)
+		if err != nil {
+			t.Fatalf("ExportPublicKey: %v", err)
+		}
+		if key.PublicKey != bundle.Signature.PublicKey {
+			t.Fatal("bundle was not signed with the server key")
+		}
+
+		pub, _ := base64.StdEncoding.DecodeString(bundle.Signature.PublicKey)
+		sig, _ := base64.StdEncoding.DecodeString(bundle.Signature.Value)
+		if !ed25519.Verify(ed25519.PublicKey(pub), bundle.Payload, sig) {
+			t.Fatal("export signature does not verify")
+		}
+
+		// Reimportar o cofre do time cria uma cópia que entra no log de auditoria.
+		var payload models.ExportPayload
+		if err := json.Unmarshal(bundle.Payload, &payload); err != nil {
+			t.Fatal(err)
+		}
+		req := &models.ImportRequest{Bundle: *bundle}
+		for _, vault := range payload.Vaults {
+			req.Vaults = append(req.Vaults, models.ImportVaultKeys{ExportedVaultID: vault.ID, Skip: vault.ID != team.ID})
+		}
+		imported, err := admin.Client.ImportVaults(ctx, req)
+		if err != nil || len(imported.Vaults) != 1 {
+			t.Fatalf("ImportVaults: %+v %v", imported, err)
+		}
+		copyID := imported.Vaults[0].VaultID
+		t.Cleanup(func() { admin.Client.RemoveVault(context.Background(), copyID) })
+
+		var entries *models.AuditLogResponse
+		for i := 0; i < 50; i++ {
+			entries, err = admin.Client.AuditLog(ctx, models.AuditQuery{VaultID: copyID, Limit: 200})
+			if err == nil && entries.Total >= int64(2+imported.Vaults[0].Items) {
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		if err != nil {
+			t.Fatalf("AuditLog of the imported vault: %v", err)
+		}
+		counts := map[models.AuditAction]int{}
+		for _, entry := range entries.Entries {
+			if entry.Details["source"] == "import" {
+				counts[entry.Action]++
+			}
+		}
+		if counts[models.AuditVaultCreated] != 1 || counts[models.AuditMemberAdded] != 1 || counts[models.AuditItemCreated] != imported.Vaults[0].Items {
+			t.Fatalf("import audit entries: %v", counts)
+		}
+	})
+
+	t.Run("audit log", func(t *testing.T) {
+		var created *models.AuditLogResponse
+		for i := 0; i < 50; i++ {
+			created, err = admin.Client.AuditLog(ctx, models.AuditQuery{Action: string(models.AuditVaultCreated), VaultID: team.ID})
+			if err == nil && created.Total > 0 {
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		if err != nil || created.Total != 1 || created.Entries[0].ActorID != admin.User.ID || created.Entries[0].Hash == "" {
+			t.Fatalf("AuditLog vault.created: %+v %v", created, err)
+		}
+
+		logins, err := admin.Client.AuditLog(ctx, models.AuditQuery{Action: string(models.AuditLogin), Limit: 1})
+		if err != nil || logins.Total == 0 || len(logins.Entries) != 1 || logins.Entries[0].IP == "" {
+			t.Fatalf("AuditLog auth.login: %+v %v", logins, err)
+		}
+		_, err = admin.Client.AuditLog(ctx, models.AuditQuery{From: "ontem"})
+		wantStatus(t, err, http.StatusBadRequest)
+
+		verified, err := admin.Client.VerifyAuditLog(ctx)
+		if err != nil || !verified.Valid || verified.Checked == 0 {
+			t.Fatalf("VerifyAuditLog: %+v %v", verified, err)
+		}
+
+		if member != nil {
+			_, err = member.Client.AuditLog(ctx, models.AuditQuery{})
+			wantStatus(t, err, http.StatusForbidden)
+		}
+	})
+
+	t.Run("webhooks", func(t *testing.T) {
+		received := make(chan *http.Request, 4)
+		bodies := make(chan []byte, 4)
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			received <- r
+			bodies <- body
+		}))
+		defer receiver.Close()
+
+		_, err := admin.Client.CreateWebhook(ctx, &models.CreateWebhookRequest{URL: "ftp://example.com", Events: []models.WebhookEvent{models.WebhookVaultCreated}})
+		wantStatus(t, err, http.StatusBadRequest)
+
+		os.Setenv("WEBHOOK_ALLOW_PRIVATE_IPS", "false")
+		for _, target := range []string{"https://169.254.169.254/latest/meta-data", "https://127.0.0.1:27017/", "https://10.0.0.1/"} {
+			_, err = admin.Client.CreateWebhook(ctx, &models.CreateWebhookRequest{URL: target, Events: []models.WebhookEvent{models.WebhookVaultCreated}})
+			wantStatus(t, err, http.StatusBadRequest)
+		}
+		os.Setenv("WEBHOOK_ALLOW_PRIVATE_IPS", "true")
+
+		created, err := admin.Client.CreateWebhook(ctx, &models.CreateWebhookRequest{
+			URL:    receiver.URL,
+			Events: []models.WebhookEvent{models.WebhookVaultCreated},
+		})
+		if err != nil || created.Secret == "" || !created.Webhook.Active {
+			t.Fatalf("CreateWebhook: %+v %v", created, err)
+		}
+		defer admin.Client.DeleteWebhook(ctx, created.Webhook.ID)
+
+		vault, err := admin.CreateVault(ctx, &items.VaultMetadata{Name: "Webhooks"})
+		if err != nil {
+			t.Fatalf("CreateVault: %v", err)
+		}
+
+		var req *http.Request
+		select {
+		case req = <-received:
+		case <-time.After(5 * time.Second):
+			t.Fatal("webhook was not delivered")
+		}
+		body := <-bodies
+		if err := client.VerifyWebhookSignature(created.Secret, req.Header.Get(models.WebhookSignatureHeader), body, time.Minute); err != nil {
+			t.Fatalf("VerifyWebhookSignature: %v", err)
+		}
+		var payload models.WebhookPayload
+		if err := json.Unmarshal(body, &payload); err != nil || payload.Event != models.WebhookVaultCreated || payload.Data["vaultId"] != vault.ID {
+			t.Fatalf("webhook payload: %s %v", body, err)
+		}
+
+		var deliveries *models.WebhookDeliveriesResponse
+		for i := 0; i < 50; i++ {
+			deliveries, err = admin.Client.WebhookDeliveries(ctx, created.Webhook.ID, models.WebhookDeliveryQuery{})
+			if err == nil && deliveries.Total == 1 && deliveries.Deliveries[0].Status == models.WebhookDeliverySucceeded {
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		if err != nil || deliveries.Total != 1 || deliveries.Deliveries[0].Status != models.WebhookDeliverySucceeded {
+			t.Fatalf("WebhookDeliveries: %+v %v", deliveries, err)
+		}
+
+		redelivered, err := admin.Client.RedeliverWebhook(ctx, created.Webhook.ID, deliveries.Deliveries[0].ID)
+		if err != nil || redelivered.Status != models.WebhookDeliverySucceeded || redelivered.RedeliveryOf != deliveries.Deliveries[0].ID {
+			t.Fatalf("RedeliverWebhook: %+v %v", redelivered, err)
+		}
+		<-received
+		if again := <-bodies; !bytes.Equal(again, body) {
+			t.Fatalf("redelivery changed the payload: %s", again)
+		}
+
+		if member != nil {
+			_, err = member.Client.Webhooks(ctx)
+			wantStatus(t, err, http.StatusForbidden)
+		}
+	})
+
+	t.Run("new device alert", func(t *testing.T) {
+		if member == nil {
+			t.Skip("member was not registered")
+		}
+		unlockFromDevice(t, server.URL, "laptop-"+suffix, memberEmail, memberPassword, orgID)
+
+		var alerts *models.AuditLogResponse
+		for i := 0; i < 50; i++ {
+			alerts, err = admin.Client.AuditLog(ctx, models.AuditQuery{Action: string(models.AuditNewDeviceLogin), TargetID: member.User.ID})
+			if err == nil && alerts.Total > 0 {
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		if err != nil || alerts.Total != 1 || alerts.Entries[0].Details["deviceId"] != "laptop-"+suffix {
+			t.Fatalf("AuditLog auth.new_device: %+v %v", alerts, err)
+		}
+
+		// O link do e-mail aponta para SELF_URL; aqui só o caminho interessa.
+		body := waitForMail(t, memberEmail, "Novo acesso à sua conta").HTML
+		link := regexp.MustCompile(`href="[^"]*(/environment/devices/report/[^"]+)"`).FindStringSubmatch(body)
+		if link == nil {
+			t.Fatalf("new device e-mail without report link: %s", body)
+		}
+		reportURL := server.URL + link[1]
+
+		// Abrir o link (ou um leitor de e-mail pré-carregá-lo) só mostra a confirmação.
+		for i := 0; i < 2; i++ {
+			res, err := http.Get(reportURL)
+			if err != nil || res.StatusCode != http.StatusOK {
+				t.Fatalf("report page: %v %v", res, err)
+			}
+			page, _ := io.ReadAll(res.Body)
+			res.Body.Close()
+			if !strings.Contains(string(page), `<form method="post"`) || res.Header.Get("Referrer-Policy") != "no-referrer" {
+				t.Fatalf("report page without confirmation form: %s", page)
+			}
+		}
+		if _, err := member.Client.MyVaults(ctx); err != nil {
+			t.Fatalf("opening the report link locked the account: %v", err)
+		}
+		created, err := member.Client.CreatePersonalAccessToken(ctx, "script", []models.TokenScope{models.ScopeVaultsRead}, 30)
+		if err != nil {
+			t.Fatalf("CreatePersonalAccessToken: %v", err)
+		}
+		pat := client.New(server.URL)
+		pat.Token = created.Token
+		if _, err := pat.MyVaults(ctx); err != nil {
+			t.Fatalf("MyVaults with token before report: %v", err)
+		}
+
+		res, err := http.Post(reportURL, "application/x-www-form-urlencoded", nil)
+		if err != nil || res.StatusCode != http.StatusOK {
+			t.Fatalf("report device: %v %v", res, err)
+		}
+		res.Body.Close()
+		if res, err := http.Post(reportURL, "application/x-www-form-urlencoded", nil); err != nil || res.StatusCode != http.StatusNotFound {
+			t.Fatalf("report link was accepted twice: %v %v", res, err)
+		}
+		if res, err := http.Get(reportURL); err != nil || res.StatusCode != http.StatusNotFound {
+			t.Fatalf("used report link still shows the form: %v %v", res, err)
+		}
+
+		_, err = member.Client.MyVaults(ctx)
+		wantStatus(t, err, http.StatusForbidden)
+
+		// Verificar o e-mail de novo destrava a conta; as sessões antigas continuam
+		// revogadas. A revogação vale por segundo inteiro, por isso a espera.
+		time.Sleep(time.Second)
+		revoked := member.Client
+		member = unlock(t, server.URL, memberEmail, memberPassword, orgID)
+		if _, err := member.Client.MyVaults(ctx); err != nil {
+			t.Fatalf("MyVaults after unlock: %v", err)
+		}
+		_, err = revoked.MyVaults(ctx)
+		wantStatus(t, err, http.StatusUnauthorized)
+		_, err = pat.MyVaults(ctx)
+		wantStatus(t, err, http.StatusUnauthorized)
+	})
+
+	t.Run("branded email", func(t *testing.T) {
+		branding, err := admin.Client.UpdateOrgBranding(ctx, &models.UpdateOrgBrandingRequest{
+			Locale:       models.LocaleEnUS,
+			ImageUrl:     "https://cdn.example.com/acme.png",
+			SenderName:   "Acme IT",
+			PrimaryColor: "#112233",
+		})
+		if err != nil || branding.Locale != models.LocaleEnUS || branding.SenderName != "Acme IT" {
+			t.Fatalf("UpdateOrgBranding: %+v %v", branding, err)
+		}
+		// Os outros testes esperam os e-mails em português e sem marca.
+		t.Cleanup(func() {
+			admin.Client.UpdateOrgBranding(context.Background(), &models.UpdateOrgBrandingRequest{})
+		})
+		_, err = admin.Client.UpdateOrgBranding(ctx, &models.UpdateOrgBrandingRequest{SenderName: "Acme\r\nBcc: x@example.com"})
+		wantStatus(t, err, http.StatusBadRequest)
+
+		brandedEmail := fmt.Sprintf("branded-%s@example.com", suffix)
+		code, err := admin.Client.InviteUser(ctx, brandedEmail, models.RoleMember)
+		if err != nil {
+			t.Fatalf("InviteUser: %v", err)
+		}
+		mail := waitForMail(t, brandedEmail, "You have been invited")
+		if !strings.Contains(mail.From, "Acme IT") || !strings.Contains(mail.HTML, `src="https://cdn.example.com/acme.png"`) ||
+			!strings.Contains(mail.HTML, "#112233") || !strings.Contains(mail.Text, code) {
+			t.Fatalf("invite e-mail is not branded: %+v", mail)
+		}
+
+		var invite *models.MinOrgWithTokenResponse
+		for i := 0; i < 50 && invite == nil; i++ {
+			invite, _ = client.New(server.URL).GetInvite(ctx, code)
+			time.Sleep(20 * time.Millisecond)
+		}
+		if invite == nil {
+			t.Fatal("invite was not stored")
+		}
+		// O idioma do usuário vale mais que o da organização, e o nome dele é escapado no HTML.
+		req, err := client.NewUserRequest("<b>Bruno</b>", memberPassword, testParams, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Code = code
+		req.Locale = models.LocalePtBR
+		if err := client.New(server.URL).RegisterUser(ctx, invite, req); err != nil {
+			t.Fatalf("RegisterUser: %v", err)
+		}
+		if users, err := admin.Client.Users(ctx); err == nil {
+			for _, user := range users {
+				if user.Email == brandedEmail {
+					t.Cleanup(func() { admin.Client.DeleteUser(context.Background(), user.ID) })
+				}
+			}
+		}
+
+		welcome := waitForMail(t, brandedEmail, "Boas vindas")
+		if strings.Contains(welcome.HTML, "<b>Bruno</b>") || !strings.Contains(welcome.HTML, "&lt;b&gt;Bruno&lt;/b&gt;") {
+			t.Fatalf("username was not escaped: %s", welcome.HTML)
+		}
+		if !strings.Contains(welcome.Text, "Olá <b>Bruno</b>,") {
+			t.Fatalf("unexpected text part: %s", welcome.Text)
+		}
+	})
+
+	t.Run("signout", func(t *testing.T) {
+		if member == nil {
+			t.Skip("member was not registered")
+		}
+		if err := member.Client.Signout(ctx); err != nil {
+			t.Fatalf("Signout: %v", err)
+		}
+		_, err := member.Client.MyVaults(ctx)
+		wantStatus(t, err, http.StatusUnauthorized)
+	})
+}
@@ -0,0 +1,221 @@
+// Comando lembrago-import: importa exportações do Bitwarden, KeePass (KDBX 4),
+// 1Password (1PUX) ou CSV para um cofre do LemBRAGO. O arquivo é lido e cifrado
+// localmente; o servidor só recebe os itens já cifrados com a chave do cofre.
+//
+//	lembrago-import -server https://lembrago.exemplo.com -vault <id> export.json
+//	lembrago-import -dry-run -format kdbx senhas.kdbx
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"lembrago.com/lembrago/client"
+	"lembrago.com/lembrago/client/importer"
+	"lembrago.com/lembrago/internal/prompt"
+	"lembrago.com/lembrago/models"
+)
+
+func main() {
+	server := flag.String("server", os.Getenv("LEMBRAGO_SERVER"), "LemBRAGO server URL (or LEMBRAGO_SERVER)")
+	email := flag.String("email", os.Getenv("LEMBRAGO_EMAIL"), "account e-mail")
+	orgID := flag.String("org", "", "organization ID, asked when the e-mail belongs to several")
+	vaultID := flag.String("vault", "", "destination vault ID")
+	format := flag.String("format", "", "bitwarden, kdbx, 1pux or csv (default: by file extension)")
+	keyFile := flag.String("keyfile", "", "KeePass key file")
+	dryRun := flag.Bool("dry-run", false, "only parse the file and print the report")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	batchSize := flag.Int("batch-size", 100, "items per request (max 500)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: lembrago-import [flags] <file>\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(flag.Arg(0), *format, *keyFile, *server, *email, *orgID, *vaultID, *dryRun, *asJSON, *batchSize); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(path, format, keyFile, server, email, orgID, vaultID string, dryRun, asJSON bool, batchSize int) error {
+	fileFormat := importer.Format(format)
+	if fileFormat == "" {
+		detected, err := importer.DetectFormat(path)
+		if err != nil {
+			return err
+		}
+		fileFormat = detected
+	}
+
+	if !dryRun && (server == "" || vaultID == "") {
+		return fmt.Errorf("-server and -vault are required unless -dry-run is set")
+	}
+	if batchSize < 1 || batchSize > 500 {
+		return fmt.Errorf("-batch-size must be between 1 and 500")
+	}
+
+	var opts importer.Options
+	if fileFormat == importer.FormatKDBX {
+		if keyFile != "" {
+			data, err := os.ReadFile(keyFile)
+			if err != nil {
+				return err
+			}
+			opts.KeyFile = data
+		}
+		password, err := prompt.ReadSecret("KeePass database password: ")
+		if err != nil {
+			return err
+		}
+		opts.Password = password
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	result, err := importer.Parse(fileFormat, file, opts)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		return printReport(result, asJSON)
+	}
+
+	ctx := context.Background()
+	api := client.New(server)
+	session, _, err := prompt.Login(ctx, api, email, orgID)
+	if err != nil {
+		return err
+	}
+
+	vaults, err := api.MyVaults(ctx)
+	if err != nil {
+		return err
+	}
+
+	var vault *models.VaultWithMemberInfo
+	for i := range vaults {
+		if vaults[i].ID == vaultID {
+			vault = &vaults[i]
+		}
+	}
+	if vault == nil {
+		return fmt.Errorf("vault %s not found among your vaults", vaultID)
+	}
+	if vault.Permission != string(models.ADMIN) {
+		return fmt.Errorf("creating items requires admin permission on the vault")
+	}
+
+	vaultKey, err := session.VaultKey(*vault)
+	if err != nil {
+		return fmt.Errorf("cannot open vault key: %w", err)
+	}
+
+	imported := 0
+	for start := 0; start < len(result.Items); start += batchSize {
+		end := min(start+batchSize, len(result.Items))
+
+		req := models.BatchItemsRequest{}
+		for i := start; i < end; i++ {
+			data, err := result.Items[i].Encrypt(vaultKey)
+			if err != nil {
+				return err
+			}
+			req.Operations = append(req.Operations, models.BatchItemOperation{
+				Op:                models.BatchOpCreate,
+				VaultID:           vault.ID,
+				EncryptedItemData: &data,
+			})
+		}
+
+		if _, err := api.BatchPasswords(ctx, &req); err != nil {
+			var apiErr *client.APIError
+			if errors.As(err, &apiErr) {
+				var batch models.BatchItemsResponse
+				if apiErr.DecodeDetails(&batch) == nil {
+					for _, op := range batch.Results {
+						if op.Status >= 400 && op.Status != 424 {
+							fmt.Fprintf(os.Stderr, "item %q: %s\n", result.Items[start+op.Index].Name, op.Error)
+						}
+					}
+				}
+			}
+			return fmt.Errorf("import stopped after %d of %d items: %w", imported, len(result.Items), err)
+		}
+
+		imported += end - start
+		fmt.Fprintf(os.Stderr, "imported %d/%d\n", imported, len(result.Items))
+	}
+
+	result.Report.Ready = imported
+	return printReport(result, asJSON)
+}
+
+// printReport nunca imprime senhas ou outros valores dos itens.
+func printReport(result *importer.Result, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result.Report)
+	}
+
+	report := result.Report
+	fmt.Printf("Format:  %s\n", report.Format)
+	fmt.Printf("Entries: %d parsed, %d ready, %d skipped\n", report.Parsed, report.Ready, len(report.Skipped))
+	kinds := make([]string, 0, len(report.ByType))
+	for kind := range report.ByType {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		fmt.Printf("  %-9s %d\n", kind, report.ByType[kind])
+	}
+	if len(report.Folders) > 0 {
+		fmt.Printf("Folders: %s\n", strings.Join(report.Folders, ", "))
+	}
+
+	if len(result.Items) > 0 {
+		fmt.Println()
+		w := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tTYPE\tFOLDER\tUSERNAME\tURL")
+		for _, item := range result.Items {
+			url := ""
+			if len(item.URLs) > 0 {
+				url = item.URLs[0]
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", item.Name, item.Type, item.Folder, item.Username, url)
+		}
+		w.Flush()
+	}
+
+	if len(report.Skipped) > 0 {
+		fmt.Println("\nSkipped:")
+		for _, skipped := range report.Skipped {
+			fmt.Printf("  %s: %s\n", skipped.Name, skipped.Reason)
+		}
+	}
+	if len(report.Warnings) > 0 {
+		fmt.Println("\nWarnings:")
+		for _, warning := range report.Warnings {
+			fmt.Printf("  %s\n", warning)
+		}
+	}
+	return nil
+}
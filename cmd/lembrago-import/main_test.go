@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"lembrago.com/lembrago/client/importer"
+)
+
+func captureStdout(t *testing.T, fn func() error) (string, error) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	done := make(chan string)
+	go func() {
+		out, _ := io.ReadAll(r)
+		done <- string(out)
+	}()
+	runErr := fn()
+	w.Close()
+	return <-done, runErr
+}
+
+// O -dry-run só lê o arquivo: não pede servidor nem cofre, e o relatório não
+// mostra senhas.
+func TestDryRunReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.csv")
+	csv := "name,url,username,password,grouping\n" +
+		"GitHub,https://github.com,octocat,gh-s3cret,Work\n" +
+		",,,,\n"
+	if err := os.WriteFile(path, []byte(csv), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := captureStdout(t, func() error {
+		return run(path, "", "", "", "", "", "", true, true, 100)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report importer.Report
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("JSON report: %v\n%s", err, out)
+	}
+	if report.Format != importer.FormatCSV || report.Parsed != 2 || report.Ready != 1 || len(report.Skipped) != 1 ||
+		report.ByType["login"] != 1 || strings.Join(report.Folders, ",") != "Work" {
+		t.Fatalf("report = %+v", report)
+	}
+
+	out, err = captureStdout(t, func() error {
+		return run(path, "csv", "", "", "", "", "", true, false, 100)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Entries: 2 parsed, 1 ready, 1 skipped", "GitHub", "octocat", "(unnamed): empty entry"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("text report misses %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "gh-s3cret") {
+		t.Errorf("text report leaks the password:\n%s", out)
+	}
+
+	if err := run(path, "", "", "", "", "", "", false, false, 100); err == nil {
+		t.Error("import without -dry-run, -server and -vault: expected an error")
+	}
+}
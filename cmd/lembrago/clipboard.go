@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// clipboardTool escolhe os comandos do sistema para escrever e ler a área de
+// transferência. Não há dependência nativa: sem uma dessas ferramentas o copy falha.
+func clipboardTool() (write, read []string, err error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"pbcopy"}, []string{"pbpaste"}, nil
+	case "windows":
+		return []string{"powershell.exe", "-NoProfile", "-Command", "Set-Clipboard -Value ([Console]::In.ReadToEnd())"},
+			[]string{"powershell.exe", "-NoProfile", "-Command", "Get-Clipboard -Raw"}, nil
+	}
+
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		if _, err := exec.LookPath("wl-copy"); err == nil {
+			return []string{"wl-copy"}, []string{"wl-paste", "--no-newline"}, nil
+		}
+	}
+	if _, err := exec.LookPath("xclip"); err == nil {
+		return []string{"xclip", "-selection", "clipboard"}, []string{"xclip", "-selection", "clipboard", "-o"}, nil
+	}
+	if _, err := exec.LookPath("xsel"); err == nil {
+		return []string{"xsel", "--clipboard", "--input"}, []string{"xsel", "--clipboard", "--output"}, nil
+	}
+	return nil, nil, fmt.Errorf("no clipboard tool found (install wl-clipboard, xclip or xsel)")
+}
+
+func writeClipboard(value string) error {
+	write, _, err := clipboardTool()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(write[0], write[1:]...)
+	cmd.Stdin = bytes.NewBufferString(value)
+	return cmd.Run()
+}
+
+func readClipboard() (string, error) {
+	_, read, err := clipboardTool()
+	if err != nil {
+		return "", err
+	}
+	out, err := exec.Command(read[0], read[1:]...).Output()
+	if err != nil {
+		return "", err
+	}
+	return string(bytes.TrimRight(out, "\r\n")), nil
+}
+
+// clearClipboard só limpa se o conteúdo ainda for o segredo copiado, para não
+// apagar algo que o usuário copiou depois.
+func clearClipboard(secret string) error {
+	current, err := readClipboard()
+	if err == nil && current != strings.TrimRight(secret, "\r\n") {
+		return nil
+	}
+	return writeClipboard("")
+}
@@ -0,0 +1,659 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"lembrago.com/lembrago/client"
+	"lembrago.com/lembrago/client/items"
+	"lembrago.com/lembrago/internal/prompt"
+)
+
+// stringList é uma flag que pode ser repetida, ex.: -url a -url b.
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ",") }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
+func vaultFlag(flags *flag.FlagSet) *string {
+	return flags.String("vault", os.Getenv("LEMBRAGO_VAULT"), "vault ID or name (or LEMBRAGO_VAULT)")
+}
+
+func vaultName(vault client.Vault) string {
+	if vault.Metadata != nil && vault.Metadata.Name != "" {
+		return vault.Metadata.Name
+	}
+	return vault.ID
+}
+
+// resolveVaults devolve o cofre pedido ou, com query vazia, todos os cofres.
+func resolveVaults(ctx context.Context, session *client.Session, query string) ([]client.Vault, error) {
+	vaults, err := session.Vaults(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if query == "" {
+		return vaults, nil
+	}
+
+	var matches []client.Vault
+	for _, vault := range vaults {
+		if vault.ID == query {
+			return []client.Vault{vault}, nil
+		}
+		if strings.EqualFold(vaultName(vault), query) {
+			matches = append(matches, vault)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("vault %q not found", query)
+	case 1:
+		return matches, nil
+	}
+	return nil, fmt.Errorf("more than one vault named %q, use the vault ID", query)
+}
+
+// targetVault escolhe onde criar ou listar: o cofre pedido, o único cofre ou o pessoal.
+func targetVault(ctx context.Context, session *client.Session, query string) (*client.Vault, error) {
+	vaults, err := resolveVaults(ctx, session, query)
+	if err != nil {
+		return nil, err
+	}
+	if len(vaults) == 1 {
+		return &vaults[0], nil
+	}
+	for i := range vaults {
+		if vaults[i].PersonalVault {
+			return &vaults[i], nil
+		}
+	}
+	return nil, fmt.Errorf("you have several vaults, choose one with -vault")
+}
+
+// findItem procura pelo ID ou pelo nome (sem diferenciar maiúsculas) nos cofres indicados.
+func findItem(ctx context.Context, session *client.Session, vaultQuery, query string) (*client.DecryptedItem, *client.Vault, error) {
+	vaults, err := resolveVaults(ctx, session, vaultQuery)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	type match struct {
+		item  client.DecryptedItem
+		vault client.Vault
+	}
+	var matches []match
+	for _, vault := range vaults {
+		list, err := session.Items(ctx, vault.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, item := range list {
+			if item.ID == query {
+				return &item, &vault, nil
+			}
+			if strings.EqualFold(item.Data.Name, query) {
+				matches = append(matches, match{item, vault})
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, nil, fmt.Errorf("item %q not found", query)
+	case 1:
+		return &matches[0].item, &matches[0].vault, nil
+	}
+
+	names := make([]string, 0, len(matches))
+	for _, m := range matches {
+		names = append(names, fmt.Sprintf("%s (vault %s)", m.item.ID, vaultName(m.vault)))
+	}
+	return nil, nil, fmt.Errorf("more than one item named %q: %s", query, strings.Join(names, ", "))
+}
+
+// fieldValue devolve um campo do item pelo nome usado em -field.
+func fieldValue(item *items.Item, name string) (string, error) {
+	switch strings.ToLower(name) {
+	case "name":
+		return item.Name, nil
+	case "username", "user":
+		return item.Username, nil
+	case "password":
+		return item.Password, nil
+	case "url":
+		if len(item.URLs) == 0 {
+			return "", nil
+		}
+		return item.URLs[0], nil
+	case "totp":
+		return item.TOTP, nil
+	case "notes":
+		return item.Notes, nil
+	}
+	if item.Card != nil {
+		switch strings.ToLower(name) {
+		case "number":
+			return item.Card.Number, nil
+		case "code", "cvv":
+			return item.Card.Code, nil
+		case "holder":
+			return item.Card.Holder, nil
+		case "expiry":
+			return item.Card.ExpMonth + "/" + item.Card.ExpYear, nil
+		}
+	}
+	for _, field := range item.Fields {
+		if strings.EqualFold(field.Name, name) {
+			return field.Value, nil
+		}
+	}
+	return "", fmt.Errorf("item %q has no field %q", item.Name, name)
+}
+
+// itemSummary é o que list -json mostra: nada que seja segredo.
+type itemSummary struct {
+	ID        string     `json:"id"`
+	VaultID   string     `json:"vaultId"`
+	Name      string     `json:"name"`
+	Type      items.Type `json:"type"`
+	Username  string     `json:"username,omitempty"`
+	URLs      []string   `json:"urls,omitempty"`
+	Folder    string     `json:"folder,omitempty"`
+	Revision  int64      `json:"revision"`
+	UpdatedAt string     `json:"updatedAt"`
+}
+
+// itemDetail é o que get -json mostra: o item inteiro, com os segredos.
+type itemDetail struct {
+	ID        string `json:"id"`
+	VaultID   string `json:"vaultId"`
+	Revision  int64  `json:"revision"`
+	CreatedAt string `json:"createdAt"`
+	UpdatedAt string `json:"updatedAt"`
+	*items.Item
+}
+
+func runVaults(args []string) error {
+	flags := flag.NewFlagSet("vaults", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print as JSON")
+	flags.Parse(args)
+
+	session, err := unlock()
+	if err != nil {
+		return err
+	}
+	vaults, err := session.Vaults(context.Background())
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		type vaultJSON struct {
+			ID         string               `json:"id"`
+			Metadata   *items.VaultMetadata `json:"metadata"`
+			Personal   bool                 `json:"personal"`
+			Permission string               `json:"permission"`
+			Revision   int64                `json:"revision"`
+		}
+		res := make([]vaultJSON, 0, len(vaults))
+		for _, vault := range vaults {
+			res = append(res, vaultJSON{vault.ID, vault.Metadata, vault.PersonalVault, vault.Permission, vault.VaultRevision})
+		}
+		return printJSON(res)
+	}
+
+	rows := make([][]string, 0, len(vaults))
+	for _, vault := range vaults {
+		personal := ""
+		if vault.PersonalVault {
+			personal = "yes"
+		}
+		rows = append(rows, []string{vault.ID, vaultName(vault), vault.Permission, personal})
+	}
+	return printTable([]string{"ID", "NAME", "PERMISSION", "PERSONAL"}, rows)
+}
+
+func runList(args []string) error {
+	flags := flag.NewFlagSet("list", flag.ExitOnError)
+	vaultQuery := vaultFlag(flags)
+	asJSON := flags.Bool("json", false, "print as JSON")
+	search := flags.String("search", "", "only items whose name, username or URL contain this text")
+	flags.Parse(args)
+
+	session, err := unlock()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	vault, err := targetVault(ctx, session, *vaultQuery)
+	if err != nil {
+		return err
+	}
+	list, err := session.Items(ctx, vault.ID)
+	if err != nil {
+		return err
+	}
+
+	needle := strings.ToLower(*search)
+	summaries := []itemSummary{}
+	for _, item := range list {
+		data := item.Data
+		if needle != "" && !strings.Contains(strings.ToLower(data.Name+" "+data.Username+" "+strings.Join(data.URLs, " ")), needle) {
+			continue
+		}
+		summaries = append(summaries, itemSummary{
+			ID:        item.ID,
+			VaultID:   item.VaultID,
+			Name:      data.Name,
+			Type:      data.Type,
+			Username:  data.Username,
+			URLs:      data.URLs,
+			Folder:    data.Folder,
+			Revision:  item.Revision,
+			UpdatedAt: item.UpdatedAt,
+		})
+	}
+	sort.SliceStable(summaries, func(i, j int) bool {
+		return strings.ToLower(summaries[i].Name) < strings.ToLower(summaries[j].Name)
+	})
+
+	if *asJSON {
+		return printJSON(summaries)
+	}
+
+	rows := make([][]string, 0, len(summaries))
+	for _, item := range summaries {
+		url := ""
+		if len(item.URLs) > 0 {
+			url = item.URLs[0]
+		}
+		rows = append(rows, []string{item.ID, item.Name, string(item.Type), item.Username, url, item.Folder})
+	}
+	return printTable([]string{"ID", "NAME", "TYPE", "USERNAME", "URL", "FOLDER"}, rows)
+}
+
+func runGet(args []string) error {
+	flags := flag.NewFlagSet("get", flag.ExitOnError)
+	vaultQuery := vaultFlag(flags)
+	asJSON := flags.Bool("json", false, "print as JSON, secrets included")
+	field := flags.String("field", "", "print only this field (password, username, url, totp, notes or a custom field)")
+	reveal := flags.Bool("reveal", false, "show hidden values in the table")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: lembrago get [flags] <item ID or name>")
+	}
+
+	session, err := unlock()
+	if err != nil {
+		return err
+	}
+	item, _, err := findItem(context.Background(), session, *vaultQuery, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *field != "" {
+		value, err := fieldValue(item.Data, *field)
+		if err != nil {
+			return err
+		}
+		fmt.Println(value)
+		return nil
+	}
+
+	if *asJSON {
+		return printJSON(itemDetail{
+			ID:        item.ID,
+			VaultID:   item.VaultID,
+			Revision:  item.Revision,
+			CreatedAt: item.CreatedAt,
+			UpdatedAt: item.UpdatedAt,
+			Item:      item.Data,
+		})
+	}
+
+	hidden := func(value string) string {
+		if value == "" || *reveal {
+			return value
+		}
+		return "********"
+	}
+
+	data := item.Data
+	rows := [][]string{
+		{"id", item.ID},
+		{"name", data.Name},
+		{"type", string(data.Type)},
+		{"username", data.Username},
+		{"password", hidden(data.Password)},
+		{"url", strings.Join(data.URLs, " ")},
+		{"totp", hidden(data.TOTP)},
+		{"folder", data.Folder},
+		{"notes", data.Notes},
+	}
+	if data.Card != nil {
+		rows = append(rows,
+			[]string{"card holder", data.Card.Holder},
+			[]string{"card number", hidden(data.Card.Number)},
+			[]string{"card expiry", data.Card.ExpMonth + "/" + data.Card.ExpYear},
+			[]string{"card code", hidden(data.Card.Code)},
+		)
+	}
+	for _, custom := range data.Fields {
+		value := custom.Value
+		if custom.Hidden {
+			value = hidden(value)
+		}
+		rows = append(rows, []string{custom.Name, value})
+	}
+
+	filtered := rows[:0]
+	for _, row := range rows {
+		if row[1] != "" {
+			filtered = append(filtered, row)
+		}
+	}
+	return printTable([]string{"FIELD", "VALUE"}, filtered)
+}
+
+// itemFlags são as flags de create e edit.
+type itemFlags struct {
+	name, itemType, username, notes, folder, totp *string
+	urls, fields, tags                            stringList
+	generate                                      *int
+	favorite                                      *bool
+}
+
+func addItemFlags(flags *flag.FlagSet) *itemFlags {
+	f := &itemFlags{
+		name:     flags.String("name", "", "item name"),
+		itemType: flags.String("type", string(items.TypeLogin), "login, note, card or identity"),
+		username: flags.String("username", "", "username"),
+		notes:    flags.String("notes", "", "notes"),
+		folder:   flags.String("folder", "", "folder"),
+		totp:     flags.String("totp", "", "TOTP secret or otpauth:// URI"),
+		generate: flags.Int("generate", 0, "generate a random password with this length"),
+		favorite: flags.Bool("favorite", false, "mark as favorite"),
+	}
+	flags.Var(&f.urls, "url", "URL (repeatable)")
+	flags.Var(&f.fields, "field", "custom field as name=value (repeatable)")
+	flags.Var(&f.tags, "tag", "tag (repeatable)")
+	return f
+}
+
+// apply copia para o item só as flags que foram passadas na linha de comando.
+func (f *itemFlags) apply(flags *flag.FlagSet, item *items.Item) error {
+	var err error
+	flags.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "name":
+			item.Name = *f.name
+		case "type":
+			item.Type = items.Type(*f.itemType)
+		case "username":
+			item.Username = *f.username
+		case "notes":
+			item.Notes = *f.notes
+		case "folder":
+			item.Folder = *f.folder
+		case "totp":
+			item.TOTP = *f.totp
+		case "favorite":
+			item.Favorite = *f.favorite
+		case "url":
+			item.URLs = f.urls
+		case "tag":
+			item.Tags = f.tags
+		case "field":
+			for _, pair := range f.fields {
+				name, value, ok := strings.Cut(pair, "=")
+				if !ok || name == "" {
+					err = fmt.Errorf("invalid -field %q, use name=value", pair)
+					return
+				}
+				setField(item, name, value)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	switch item.Type {
+	case items.TypeLogin, items.TypeNote, items.TypeCard, items.TypeIdentity:
+	default:
+		return fmt.Errorf("invalid -type %q", item.Type)
+	}
+	return nil
+}
+
+func setField(item *items.Item, name, value string) {
+	for i := range item.Fields {
+		if strings.EqualFold(item.Fields[i].Name, name) {
+			if value == "" {
+				item.Fields = append(item.Fields[:i], item.Fields[i+1:]...)
+			} else {
+				item.Fields[i].Value = value
+			}
+			return
+		}
+	}
+	item.AddField(name, value, false)
+}
+
+// readNewPassword gera uma senha com -generate ou pede duas vezes.
+func readNewPassword(generate int) (string, error) {
+	if generate > 0 {
+		return generatePassword(generate)
+	}
+	password, err := prompt.ReadSecret("Item password (empty for none): ")
+	if err != nil || password == "" {
+		return password, err
+	}
+	again, err := prompt.ReadSecret("Repeat item password: ")
+	if err != nil {
+		return "", err
+	}
+	if again != password {
+		return "", fmt.Errorf("passwords do not match")
+	}
+	return password, nil
+}
+
+const passwordAlphabet = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789!@#$%&*-_=+?"
+
+func generatePassword(length int) (string, error) {
+	if length < 8 || length > 256 {
+		return "", fmt.Errorf("-generate must be between 8 and 256")
+	}
+	password := make([]byte, length)
+	max := big.NewInt(int64(len(passwordAlphabet)))
+	for i := range password {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		password[i] = passwordAlphabet[n.Int64()]
+	}
+	return string(password), nil
+}
+
+func runCreate(args []string) error {
+	flags := flag.NewFlagSet("create", flag.ExitOnError)
+	vaultQuery := vaultFlag(flags)
+	itemFlags := addItemFlags(flags)
+	noPassword := flags.Bool("no-password", false, "do not ask for a password")
+	asJSON := flags.Bool("json", false, "print the created item as JSON")
+	flags.Parse(args)
+
+	item := &items.Item{Type: items.TypeLogin}
+	if err := itemFlags.apply(flags, item); err != nil {
+		return err
+	}
+	if item.Name == "" {
+		return fmt.Errorf("-name is required")
+	}
+
+	session, err := unlock()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	vault, err := targetVault(ctx, session, *vaultQuery)
+	if err != nil {
+		return err
+	}
+
+	if item.Type == items.TypeLogin && !*noPassword {
+		if item.Password, err = readNewPassword(*itemFlags.generate); err != nil {
+			return err
+		}
+	}
+
+	created, err := session.CreateItem(ctx, vault.ID, item)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return printJSON(itemSummary{ID: created.ID, VaultID: created.VaultID, Name: item.Name, Type: item.Type,
+			Username: item.Username, URLs: item.URLs, Folder: item.Folder, Revision: created.Revision, UpdatedAt: created.UpdatedAt})
+	}
+	fmt.Fprintf(os.Stderr, "Created %q in %s (%s)\n", item.Name, vaultName(*vault), created.ID)
+	return nil
+}
+
+func runEdit(args []string) error {
+	flags := flag.NewFlagSet("edit", flag.ExitOnError)
+	vaultQuery := vaultFlag(flags)
+	itemFlags := addItemFlags(flags)
+	setPassword := flags.Bool("set-password", false, "ask for a new password")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: lembrago edit [flags] <item ID or name>")
+	}
+
+	session, err := unlock()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	item, _, err := findItem(ctx, session, *vaultQuery, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if err := itemFlags.apply(flags, item.Data); err != nil {
+		return err
+	}
+	if *setPassword || *itemFlags.generate > 0 {
+		if item.Data.Password, err = readNewPassword(*itemFlags.generate); err != nil {
+			return err
+		}
+	}
+
+	updated, err := session.UpdateItem(ctx, item)
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) && apiErr.Status == 409 {
+		return fmt.Errorf("the item was changed by someone else in the meantime, run the command again")
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Updated %q (revision %d)\n", updated.Data.Name, updated.Revision)
+	return nil
+}
+
+func runDelete(args []string) error {
+	flags := flag.NewFlagSet("delete", flag.ExitOnError)
+	vaultQuery := vaultFlag(flags)
+	yes := flags.Bool("yes", false, "do not ask for confirmation")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: lembrago delete [flags] <item ID or name>")
+	}
+
+	session, err := unlock()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	item, vault, err := findItem(ctx, session, *vaultQuery, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if !*yes {
+		ok, err := prompt.Confirm(fmt.Sprintf("Delete %q from %s?", item.Data.Name, vaultName(*vault)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("cancelled")
+		}
+	}
+
+	if err := session.Client.DeletePassword(ctx, item.ID); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Deleted %q\n", item.Data.Name)
+	return nil
+}
+
+func runCopy(args []string) error {
+	flags := flag.NewFlagSet("copy", flag.ExitOnError)
+	vaultQuery := vaultFlag(flags)
+	field := flags.String("field", "password", "field to copy")
+	timeout := flags.Duration("timeout", 30*time.Second, "clear the clipboard after this long (0 keeps it)")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: lembrago copy [flags] <item ID or name>")
+	}
+
+	session, err := unlock()
+	if err != nil {
+		return err
+	}
+	item, _, err := findItem(context.Background(), session, *vaultQuery, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	value, err := fieldValue(item.Data, *field)
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		return fmt.Errorf("field %q of %q is empty", *field, item.Data.Name)
+	}
+	session.Lock()
+
+	if err := writeClipboard(value); err != nil {
+		return err
+	}
+	if *timeout <= 0 {
+		fmt.Fprintf(os.Stderr, "Copied %s of %q\n", *field, item.Data.Name)
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Copied %s of %q, clearing the clipboard in %s (Ctrl+C clears now)\n", *field, item.Data.Name, *timeout)
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	select {
+	case <-time.After(*timeout):
+	case <-interrupt:
+	}
+	return clearClipboard(value)
+}
@@ -0,0 +1,84 @@
+// Comando lembrago: acesso do dia a dia aos cofres pelo terminal. O login fica
+// salvo em disco trancado (token e chaves ainda cifradas); cada comando pede a
+// Senha Mestra e decifra tudo localmente.
+//
+//	lembrago login -server https://lembrago.exemplo.com
+//	lembrago list -vault Equipe
+//	lembrago copy "Servidor de produção"
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"lembrago.com/lembrago/client"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{"login", "log in and save a locked session", runLogin},
+	{"logout", "revoke the token and remove the saved session", runLogout},
+	{"status", "show the saved session", runStatus},
+	{"vaults", "list your vaults", runVaults},
+	{"list", "list the items of a vault", runList},
+	{"get", "show an item", runGet},
+	{"create", "create an item", runCreate},
+	{"edit", "change an item", runEdit},
+	{"delete", "delete an item", runDelete},
+	{"copy", "copy a field to the clipboard and clear it after a timeout", runCopy},
+	{"run", "run a command with secrets injected as environment variables", runRun},
+	{"token", "print the saved token or manage personal access tokens", runToken},
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: lembrago <command> [flags]\n\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun 'lembrago <command> -h' for the flags of a command.\n")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	if name == "-h" || name == "-help" || name == "help" {
+		usage()
+		return
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		if err := cmd.run(os.Args[2:]); err != nil {
+			var code exitCode
+			if errors.As(err, &code) {
+				if code < 0 {
+					code = 1
+				}
+				os.Exit(int(code))
+			}
+			var apiErr *client.APIError
+			if errors.As(err, &apiErr) && apiErr.Status == 401 && name != "login" {
+				err = fmt.Errorf("%w (session expired? run 'lembrago login')", err)
+			}
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
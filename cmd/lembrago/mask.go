@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"sync"
+)
+
+const maskText = "*****"
+
+// shortSecretLength é o tamanho abaixo do qual um segredo tende a aparecer por
+// acaso na saída. Ele é mascarado mesmo assim; runChild só avisa.
+const shortSecretLength = 4
+
+// maskWriter troca os segredos por ***** antes de repassar a saída do processo
+// filho. Um segredo pode chegar dividido entre duas escritas, então o final do
+// buffer que ainda pode ser o começo de um segredo fica retido até a próxima
+// escrita ou até Close.
+type maskWriter struct {
+	mu      sync.Mutex
+	out     io.Writer
+	secrets [][]byte
+	pending []byte
+}
+
+func newMaskWriter(out io.Writer, secrets []string) *maskWriter {
+	w := &maskWriter{out: out}
+	seen := map[string]bool{}
+	for _, secret := range secrets {
+		if secret == "" || seen[secret] {
+			continue
+		}
+		seen[secret] = true
+		w.secrets = append(w.secrets, []byte(secret))
+	}
+	// Os mais longos primeiro, para um segredo que contém outro ser mascarado inteiro.
+	sort.Slice(w.secrets, func(i, j int) bool { return len(w.secrets[i]) > len(w.secrets[j]) })
+	return w
+}
+
+func (w *maskWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.pending = append(w.pending, p...)
+	masked, rest := w.mask(w.pending, false)
+	w.pending = append(w.pending[:0], rest...)
+	if _, err := w.out.Write(masked); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close descarrega o que estava retido.
+func (w *maskWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	masked, _ := w.mask(w.pending, true)
+	w.pending = nil
+	_, err := w.out.Write(masked)
+	return err
+}
+
+func (w *maskWriter) mask(data []byte, final bool) (masked, rest []byte) {
+	var out bytes.Buffer
+	i := 0
+scan:
+	for i < len(data) {
+		// O resto do buffer ainda pode virar um segredo (inclusive um mais longo
+		// que outro que já casou): espera pela próxima escrita.
+		if !final {
+			for _, secret := range w.secrets {
+				if len(data)-i < len(secret) && bytes.HasPrefix(secret, data[i:]) {
+					return out.Bytes(), data[i:]
+				}
+			}
+		}
+		for _, secret := range w.secrets {
+			if bytes.HasPrefix(data[i:], secret) {
+				out.WriteString(maskText)
+				i += len(secret)
+				continue scan
+			}
+		}
+		out.WriteByte(data[i])
+		i++
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func maskAll(t *testing.T, secrets []string, writes ...string) string {
+	t.Helper()
+	var out bytes.Buffer
+	w := newMaskWriter(&out, secrets)
+	for _, p := range writes {
+		if n, err := w.Write([]byte(p)); err != nil || n != len(p) {
+			t.Fatalf("Write(%q) = %d, %v", p, n, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestMaskWriter(t *testing.T) {
+	cases := []struct {
+		name    string
+		secrets []string
+		writes  []string
+		want    string
+	}{
+		{"whole secret", []string{"hunter2"}, []string{"password=hunter2\n"}, "password=*****\n"},
+		{"split across two writes", []string{"hunter2"}, []string{"password=hun", "ter2\n"}, "password=*****\n"},
+		{"one byte per write", []string{"hunter2"}, []string{"[", "h", "u", "n", "t", "e", "r", "2", "]"}, "[*****]"},
+		{"secret containing another", []string{"abc", "xxabcxx"}, []string{"1 xxabcxx 2 abc 3"}, "1 ***** 2 ***** 3"},
+		{"longer secret split after the shorter one matched", []string{"abc", "abcdef"}, []string{"abc", "def abc!"}, "***** *****!"},
+		{"prefix that never completes", []string{"hunter2"}, []string{"hunt", "ing"}, "hunting"},
+		{"short secrets are masked too", []string{"ab", "7"}, []string{"cab 17"}, "c***** 1*****"},
+		{"empty and repeated secrets", []string{"", "hunter2", "hunter2"}, []string{"x hunter2"}, "x *****"},
+		{"no secrets", nil, []string{"plain ", "output"}, "plain output"},
+	}
+	for _, c := range cases {
+		if got := maskAll(t, c.secrets, c.writes...); got != c.want {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+// O final que ainda pode virar um segredo fica retido até Close, que o
+// descarrega sem perder nada.
+func TestMaskWriterFlushesOnClose(t *testing.T) {
+	var out bytes.Buffer
+	w := newMaskWriter(&out, []string{"hunter2"})
+	w.Write([]byte("done: hunt"))
+	if got := out.String(); got != "done: " {
+		t.Fatalf("before Close: %q", got)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "done: hunt" {
+		t.Fatalf("after Close: %q", got)
+	}
+}
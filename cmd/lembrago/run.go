@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/template"
+
+	"lembrago.com/lembrago/client"
+)
+
+// exitCode faz o lembrago sair com o código do processo filho, sem mensagem.
+type exitCode int
+
+func (code exitCode) Error() string {
+	return fmt.Sprintf("exit status %d", int(code))
+}
+
+// secretRef é uma referência "item" ou "item#campo"; o campo padrão é password.
+type secretRef struct {
+	item  string
+	field string
+}
+
+func parseSecretRef(ref string) secretRef {
+	if i := strings.LastIndex(ref, "#"); i > 0 {
+		return secretRef{item: ref[:i], field: ref[i+1:]}
+	}
+	return secretRef{item: ref, field: "password"}
+}
+
+// vaultSecrets resolve referências contra os itens de um único cofre, já decifrados.
+type vaultSecrets struct {
+	items  []client.DecryptedItem
+	values []string
+}
+
+func (v *vaultSecrets) lookup(ref secretRef) (string, error) {
+	var found *client.DecryptedItem
+	for i := range v.items {
+		item := &v.items[i]
+		if item.ID == ref.item {
+			found = item
+			break
+		}
+		if strings.EqualFold(item.Data.Name, ref.item) {
+			if found != nil {
+				return "", fmt.Errorf("more than one item named %q, use the item ID", ref.item)
+			}
+			found = item
+		}
+	}
+	if found == nil {
+		return "", fmt.Errorf("item %q not found", ref.item)
+	}
+
+	value, err := fieldValue(found.Data, ref.field)
+	if err != nil {
+		return "", err
+	}
+	v.values = append(v.values, value)
+	return value, nil
+}
+
+// renderTemplate aceita {{ .NOME }} para as variáveis de -env e
+// {{ secret "item" }} ou {{ secret "item" "campo" }} para qualquer item do cofre.
+func renderTemplate(src, dst string, env map[string]string, secrets *vaultSecrets) error {
+	text, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	tmpl, err := template.New(src).Option("missingkey=error").Funcs(template.FuncMap{
+		"secret": func(item string, field ...string) (string, error) {
+			ref := secretRef{item: item, field: "password"}
+			if len(field) > 0 {
+				ref.field = field[0]
+			}
+			return secrets.lookup(ref)
+		},
+	}).Parse(string(text))
+	if err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, env); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, out.Bytes(), 0o600)
+}
+
+func runRun(args []string) error {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	vaultQuery := vaultFlag(flags)
+	var envs, templates stringList
+	flags.Var(&envs, "env", "NAME=item or NAME=item#field to export (repeatable)")
+	flags.Var(&templates, "template", "src=dst template to render (repeatable)")
+	keepFiles := flags.Bool("keep-files", false, "keep rendered templates after the command exits")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: lembrago run [flags] [--] command [args...]\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	command := flags.Args()
+	if len(envs) == 0 && len(templates) == 0 {
+		return fmt.Errorf("nothing to inject, use -env or -template")
+	}
+	if len(command) == 0 && len(envs) > 0 {
+		return fmt.Errorf("-env needs a command to run")
+	}
+
+	session, err := unlock()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	vault, err := targetVault(ctx, session, *vaultQuery)
+	if err != nil {
+		return err
+	}
+	list, err := session.Items(ctx, vault.ID)
+	if err != nil {
+		return err
+	}
+	session.Lock()
+	secrets := &vaultSecrets{items: list}
+
+	env := map[string]string{}
+	for _, pair := range envs {
+		name, ref, ok := strings.Cut(pair, "=")
+		if !ok || name == "" || ref == "" {
+			return fmt.Errorf("invalid -env %q, use NAME=item or NAME=item#field", pair)
+		}
+		value, err := secrets.lookup(parseSecretRef(ref))
+		if err != nil {
+			return fmt.Errorf("-env %s: %w", name, err)
+		}
+		env[name] = value
+	}
+
+	var rendered []string
+	defer func() {
+		if !*keepFiles && len(command) > 0 {
+			for _, path := range rendered {
+				os.Remove(path)
+			}
+		}
+	}()
+	for _, pair := range templates {
+		src, dst, ok := strings.Cut(pair, "=")
+		if !ok || src == "" || dst == "" {
+			return fmt.Errorf("invalid -template %q, use src=dst", pair)
+		}
+		if err := renderTemplate(src, dst, env, secrets); err != nil {
+			return fmt.Errorf("template %s: %w", src, err)
+		}
+		rendered = append(rendered, dst)
+	}
+
+	if len(command) == 0 {
+		return nil
+	}
+	return runChild(command, env, secrets.values)
+}
+
+// runChild executa o comando com os segredos no ambiente e a saída mascarada.
+func runChild(command []string, env map[string]string, secrets []string) error {
+	for _, secret := range secrets {
+		if secret != "" && len(secret) < shortSecretLength {
+			fmt.Fprintf(os.Stderr, "warning: a secret has fewer than %d characters, every occurrence of it in the output will be masked\n", shortSecretLength)
+			break
+		}
+	}
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Env = childEnv(env)
+	cmd.Stdin = os.Stdin
+	stdout := newMaskWriter(os.Stdout, secrets)
+	stderr := newMaskWriter(os.Stderr, secrets)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		for sig := range signals {
+			cmd.Process.Signal(sig)
+		}
+	}()
+
+	err := cmd.Wait()
+	stdout.Close()
+	stderr.Close()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitCode(exitErr.ExitCode())
+	}
+	return err
+}
+
+// childEnv repassa o ambiente atual sem as credenciais do próprio lembrago.
+func childEnv(secrets map[string]string) []string {
+	env := make([]string, 0, len(os.Environ())+len(secrets))
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if _, overridden := secrets[name]; overridden {
+			continue
+		}
+		switch name {
+		case "LEMBRAGO_TOKEN", "LEMBRAGO_SERVICE_TOKEN", "LEMBRAGO_MASTER_PASSWORD", "LEMBRAGO_MASTER_PASSWORD_FILE":
+			continue
+		}
+		env = append(env, kv)
+	}
+	for name, value := range secrets {
+		env = append(env, name+"="+value)
+	}
+	return env
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/term"
+
+	"lembrago.com/lembrago/client"
+	"lembrago.com/lembrago/client/envelope"
+	"lembrago.com/lembrago/internal/prompt"
+	"lembrago.com/lembrago/models"
+)
+
+// savedSession é o que fica em disco entre os comandos. As chaves do usuário
+// continuam cifradas com a Senha Mestra; só o token permite acesso sem ela.
+type savedSession struct {
+	Server  string              `json:"server"`
+	Email   string              `json:"email"`
+	OrgID   string              `json:"orgId"`
+	OrgName string              `json:"orgName"`
+	Token   string              `json:"token"`
+	User    models.UserResponse `json:"user"`
+}
+
+func sessionPath() (string, error) {
+	if path := os.Getenv("LEMBRAGO_SESSION"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "lembrago", "session.json"), nil
+}
+
+func loadSession() (*savedSession, error) {
+	path, err := sessionPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("not logged in, run 'lembrago login'")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var saved savedSession
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("invalid session file %s: %w", path, err)
+	}
+	return &saved, nil
+}
+
+func (saved *savedSession) save() error {
+	path, err := sessionPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+func (saved *savedSession) client() *client.Client {
+	api := client.New(saved.Server)
+	api.Token = saved.Token
+	return api
+}
+
+// unlock pede a Senha Mestra e abre as chaves salvas, sem ir ao servidor. Com
+// LEMBRAGO_TOKEN (ex.: em CI) a sessão salva é ignorada e o usuário do token é
+// buscado em /users/me. Com LEMBRAGO_SERVICE_TOKEN entra como conta de serviço,
+// sem Senha Mestra.
+func unlock() (*client.Session, error) {
+	if token := os.Getenv("LEMBRAGO_SERVICE_TOKEN"); token != "" {
+		server := os.Getenv("LEMBRAGO_SERVER")
+		if server == "" {
+			return nil, fmt.Errorf("LEMBRAGO_SERVER is required with LEMBRAGO_SERVICE_TOKEN")
+		}
+		return client.New(server).ServiceAccountLogin(context.Background(), token)
+	}
+
+	api, user, err := currentUser()
+	if err != nil {
+		return nil, err
+	}
+
+	masterPassword, err := readMasterPassword()
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := api.OpenSession(*user, masterPassword)
+	if errors.Is(err, envelope.ErrDecrypt) {
+		return nil, fmt.Errorf("wrong master password")
+	}
+	return session, err
+}
+
+func currentUser() (*client.Client, *models.UserResponse, error) {
+	if token := os.Getenv("LEMBRAGO_TOKEN"); token != "" {
+		server := os.Getenv("LEMBRAGO_SERVER")
+		if server == "" {
+			return nil, nil, fmt.Errorf("LEMBRAGO_SERVER is required with LEMBRAGO_TOKEN")
+		}
+		api := client.New(server)
+		api.Token = token
+		user, err := api.Me(context.Background())
+		if err != nil {
+			return nil, nil, err
+		}
+		return api, user, nil
+	}
+
+	saved, err := loadSession()
+	if err != nil {
+		return nil, nil, err
+	}
+	return saved.client(), &saved.User, nil
+}
+
+// readMasterPassword usa LEMBRAGO_MASTER_PASSWORD_FILE ou LEMBRAGO_MASTER_PASSWORD
+// quando definidas, para uso sem terminal; senão pergunta.
+func readMasterPassword() (string, error) {
+	if path := os.Getenv("LEMBRAGO_MASTER_PASSWORD_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	if password, ok := os.LookupEnv("LEMBRAGO_MASTER_PASSWORD"); ok {
+		return password, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("no terminal to ask for the master password, set LEMBRAGO_MASTER_PASSWORD_FILE")
+	}
+	return prompt.ReadSecret("Master password: ")
+}
+
+func runLogin(args []string) error {
+	flags := flag.NewFlagSet("login", flag.ExitOnError)
+	server := flags.String("server", os.Getenv("LEMBRAGO_SERVER"), "LemBRAGO server URL (or LEMBRAGO_SERVER)")
+	email := flags.String("email", os.Getenv("LEMBRAGO_EMAIL"), "account e-mail (or LEMBRAGO_EMAIL)")
+	orgID := flags.String("org", "", "organization ID, asked when the e-mail belongs to several")
+	flags.Parse(args)
+
+	if *server == "" {
+		if saved, err := loadSession(); err == nil {
+			*server = saved.Server
+		}
+	}
+	if *server == "" {
+		return fmt.Errorf("-server is required")
+	}
+
+	api := client.New(*server)
+	session, org, err := prompt.Login(context.Background(), api, *email, *orgID)
+	if err != nil {
+		return err
+	}
+	session.Lock()
+
+	saved := &savedSession{
+		Server:  api.BaseURL,
+		Email:   session.User.Email,
+		OrgID:   org.OrgID,
+		OrgName: org.OrganizationName,
+		Token:   api.Token,
+		User:    session.User,
+	}
+	if err := saved.save(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Logged in as %s in %s\n", saved.Email, saved.OrgName)
+	return nil
+}
+
+func runLogout(args []string) error {
+	flags := flag.NewFlagSet("logout", flag.ExitOnError)
+	flags.Parse(args)
+
+	saved, err := loadSession()
+	if err != nil {
+		return err
+	}
+
+	// O arquivo é removido mesmo que o token já tenha expirado.
+	revokeErr := saved.client().Signout(context.Background())
+
+	path, err := sessionPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+
+	var apiErr *client.APIError
+	if revokeErr != nil && !(errors.As(revokeErr, &apiErr) && apiErr.Status == 401) {
+		return fmt.Errorf("session removed, but the token could not be revoked: %w", revokeErr)
+	}
+	return nil
+}
+
+func runStatus(args []string) error {
+	flags := flag.NewFlagSet("status", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print as JSON")
+	flags.Parse(args)
+
+	saved, err := loadSession()
+	if err != nil {
+		return err
+	}
+
+	// A impressão digital é o que o admin confere antes de liberar um cofre.
+	fingerprint, _ := envelope.Fingerprint(saved.User.Keys.PublicKey)
+
+	status := map[string]string{
+		"server":       saved.Server,
+		"email":        saved.Email,
+		"organization": saved.OrgName,
+		"orgId":        saved.OrgID,
+		"userId":       saved.User.ID,
+		"role":         string(saved.User.Role),
+		"fingerprint":  fingerprint,
+	}
+	if *asJSON {
+		return printJSON(status)
+	}
+	return printTable([]string{"SERVER", "E-MAIL", "ORGANIZATION", "ROLE", "FINGERPRINT"},
+		[][]string{{saved.Server, saved.Email, saved.OrgName, string(saved.User.Role), fingerprint}})
+}
+
+// runToken imprime o token da sessão salva, para guardá-lo como LEMBRAGO_TOKEN num
+// CI. Com -create, -list ou -revoke gere os tokens pessoais, que têm escopos e
+// validade próprios e são a opção indicada para scripts.
+func runToken(args []string) error {
+	flags := flag.NewFlagSet("token", flag.ExitOnError)
+	create := flags.String("create", "", "create a personal access token with this name")
+	var scopes stringList
+	flags.Var(&scopes, "scope", "scope of the new token, e.g. items:read (repeatable)")
+	days := flags.Int("days", 30, "days until the new token expires")
+	list := flags.Bool("list", false, "list your personal access tokens")
+	revoke := flags.String("revoke", "", "revoke the personal access token with this ID")
+	asJSON := flags.Bool("json", false, "print as JSON")
+	flags.Parse(args)
+
+	saved, err := loadSession()
+	if err != nil {
+		return err
+	}
+	api := saved.client()
+	ctx := context.Background()
+
+	switch {
+	case *create != "":
+		if len(scopes) == 0 {
+			return fmt.Errorf("-scope is required with -create")
+		}
+		tokenScopes := make([]models.TokenScope, 0, len(scopes))
+		for _, scope := range scopes {
+			for _, part := range strings.Split(scope, ",") {
+				tokenScopes = append(tokenScopes, models.TokenScope(strings.TrimSpace(part)))
+			}
+		}
+		created, err := api.CreatePersonalAccessToken(ctx, *create, tokenScopes, *days)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return printJSON(created)
+		}
+		fmt.Fprintf(os.Stderr, "Token %s expires at %s; it will not be shown again.\n", created.PersonalAccessToken.ID, created.PersonalAccessToken.ExpiresAt)
+		fmt.Println(created.Token)
+		return nil
+
+	case *list:
+		tokens, err := api.PersonalAccessTokens(ctx)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return printJSON(tokens)
+		}
+		rows := make([][]string, 0, len(tokens))
+		for _, token := range tokens {
+			scopeNames := make([]string, 0, len(token.Scopes))
+			for _, scope := range token.Scopes {
+				scopeNames = append(scopeNames, string(scope))
+			}
+			lastUsed := "never"
+			if token.LastUsedAt != nil {
+				lastUsed = *token.LastUsedAt
+			}
+			rows = append(rows, []string{token.ID, token.Name, strings.Join(scopeNames, ","), token.ExpiresAt, lastUsed})
+		}
+		return printTable([]string{"ID", "NAME", "SCOPES", "EXPIRES", "LAST USED"}, rows)
+
+	case *revoke != "":
+		return api.RevokePersonalAccessToken(ctx, *revoke)
+	}
+
+	fmt.Println(saved.Token)
+	return nil
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"lembrago.com/lembrago/models"
+	"lembrago.com/lembrago/services"
+	"lembrago.com/lembrago/utils"
+)
+
+// requestMeta separa da requisição o que vai para o log de auditoria.
+func requestMeta(c *gin.Context) models.RequestMeta {
+	return models.RequestMeta{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+func GetAuditLog(c *gin.Context) {
+	orgIDRaw, exists := c.Get("orgID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	orgID, ok := orgIDRaw.(string)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Erro interno (orgID type)"})
+		return
+	}
+
+	var query models.AuditQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := utils.GetValidator().Struct(query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validação falhou", "details": err.Error()})
+		return
+	}
+
+	res, err := services.GetAuditLog(orgID, &query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func VerifyAuditLog(c *gin.Context) {
+	orgIDRaw, exists := c.Get("orgID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	orgID, ok := orgIDRaw.(string)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Erro interno (orgID type)"})
+		return
+	}
+
+	res, err := services.VerifyAuditLog(orgID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"lembrago.com/lembrago/services"
+)
+
+// ReportDevicePage é o destino do link "não fui eu" do e-mail de novo aparelho:
+// mostra o formulário que confirma a denúncia.
+func ReportDevicePage(c *gin.Context) {
+	page, err := services.DeviceReportPage(c.Param("token"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	writeDeviceReportPage(c, page)
+}
+
+// ReportDevice recebe a confirmação do formulário e trava a conta.
+func ReportDevice(c *gin.Context) {
+	page, err := services.ReportDevice(c.Param("token"), requestMeta(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	writeDeviceReportPage(c, page)
+}
+
+// O token vai no caminho: a página não pode ficar em cache nem vazar no
+// Referer ao carregar o logo da organização.
+func writeDeviceReportPage(c *gin.Context, page string) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(page))
+}
@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"lembrago.com/lembrago/realtime"
+)
+
+const eventsHeartbeat = 25 * time.Second
+
+func StreamEvents(c *gin.Context) {
+	userIDRaw, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID, ok := userIDRaw.(string)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Erro interno (userID type)"})
+		return
+	}
+
+	events, unsubscribe := realtime.Subscribe(userID)
+	defer unsubscribe()
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	c.SSEvent("ready", gin.H{"userId": userID})
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event := <-events:
+			c.SSEvent(string(event.Type), event)
+			return true
+		case <-heartbeat.C:
+			c.SSEvent("ping", time.Now().Format(time.RFC3339))
+			return true
+		}
+	})
+}
@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"lembrago.com/lembrago/models"
+	"lembrago.com/lembrago/services"
+	"lembrago.com/lembrago/utils"
+)
+
+func GetDeadLetters(c *gin.Context) {
+	var query models.DeadLetterQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := utils.GetValidator().Struct(query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validação falhou", "details": err.Error()})
+		return
+	}
+
+	res, err := services.GetDeadLetters(&query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func RetryDeadLetter(c *gin.Context) {
+	res, err := services.RetryDeadLetter(c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
@@ -9,8 +9,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"lembrago.com/lembrago/authz"
 	"lembrago.com/lembrago/models"
-	"lembrago.com/lembrago/repository"
 	"lembrago.com/lembrago/services"
 )
 
@@ -29,30 +29,30 @@ var allowedImageTypes = map[string]bool{
 }
 
 func HandleUploadFile(c *gin.Context) {
-	orgIDRaw, exists := c.Get("orgID")
+	userIDRaw, exists := c.Get("userID")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
-	orgID, ok := orgIDRaw.(string)
+	userID, ok := userIDRaw.(string)
 	if !ok {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Erro interno (orgID type)"})
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Erro interno (userID type)"})
 		return
 	}
 
-	roleRaw, exists := c.Get("role")
+	permissionsRaw, exists := c.Get("permissions")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 
-	role, ok := roleRaw.(models.UserRole)
+	permissions, ok := permissionsRaw.([]models.OrgPermission)
 	if !ok {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Erro interno (role type)"})
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Erro interno (permissions type)"})
 		return
 	}
-	if role != models.RoleAdmin {
-		c.AbortWithStatusJSON(403, gin.H{"error": "Invalid Permission"})
+	if err := authz.Check(authz.MediaUpload, authz.Subject{Permissions: permissions}); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -104,9 +104,9 @@ func HandleUploadFile(c *gin.Context) {
 	rndFilename = strings.ReplaceAll(rndFilename, " ", "_")
 	rndFilename = strings.ToLower(rndFilename)
 
-	svMedia, err := services.SaveMedia(orgID, rndFilename, header, header.Size, c)
+	svMedia, err := services.SaveMedia(userID, rndFilename, header, header.Size, c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -126,7 +126,10 @@ func DeleteMedia(c *gin.Context) {
 	}
 
 	mID := c.Query("id")
-	services.DeleteMediaByID(userIDStr, mID)
+	if err := services.DeleteMediaByID(userIDStr, mID); err != nil {
+		c.Error(err)
+		return
+	}
 
 	c.Status(200)
 }
@@ -150,32 +153,22 @@ func HandleServeFile(c *gin.Context) {
 }
 
 func GetAllMediasFromTheOrg(c *gin.Context) {
-	orgIDRaw, exists := c.Get("orgID")
+	userIDRaw, exists := c.Get("userID")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
-	orgID, ok := orgIDRaw.(string)
+	userID, ok := userIDRaw.(string)
 	if !ok {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Erro interno (orgID type)"})
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Erro interno (userID type)"})
 		return
 	}
 
-	orgObjID, err := primitive.ObjectIDFromHex(orgID)
-	if err != nil {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Erro interno (orgID type)"})
-		return
-	}
-	medias, err := repository.GetAllMediaByOrgID(orgObjID)
+	medias, err := services.GetAllMediaFromTheOrg(userID)
 	if err != nil {
 		c.Error(err)
 		return
 	}
-	
-	if medias == nil {
-		c.JSON(200, []models.SavedMedia{})
-		return
-	}
 
 	c.JSON(200, medias)
 }
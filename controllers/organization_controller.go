@@ -63,7 +63,7 @@ func InviteUser(c *gin.Context) {
 		return
 	}
 
-	token, err := services.InviteUser(userID, orgID, req.Email, req.Role)
+	token, err := services.InviteUser(userID, orgID, req.Email, req.Role, requestMeta(c))
 	if err != nil {
 		c.Error(err)
 		return
@@ -87,3 +87,56 @@ func GetInvitedCodeToken(c *gin.Context) {
 
 	c.JSON(http.StatusOK, minOrgWithTokenResponse)
 }
+
+func GetOrgBranding(c *gin.Context) {
+	orgIDRaw, exists := c.Get("orgID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	orgID, ok := orgIDRaw.(string)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Erro interno (orgID type)"})
+		return
+	}
+
+	branding, err := services.GetOrgBranding(orgID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, branding)
+}
+
+func UpdateOrgBranding(c *gin.Context) {
+	orgIDRaw, exists := c.Get("orgID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	orgID, ok := orgIDRaw.(string)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Erro interno (orgID type)"})
+		return
+	}
+
+	var req models.UpdateOrgBrandingRequest
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, 4<<10)
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := utils.GetValidator().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validação falhou", "details": err.Error()})
+		return
+	}
+
+	branding, err := services.UpdateOrgBranding(orgID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, branding)
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"lembrago.com/lembrago/authz"
 	"lembrago.com/lembrago/cache"
 	"lembrago.com/lembrago/models"
 	"lembrago.com/lembrago/services"
@@ -29,7 +30,7 @@ func SendAuthCode(c *gin.Context) {
 		return
 	}
 
-	err = services.SendAuthCode(req.Email)
+	err = services.SendAuthCode(req.Email, requestMeta(c))
 	if err != nil {
 		c.Error(err)
 		return
@@ -56,7 +57,7 @@ func GetLoginInfoFromUser(c *gin.Context) {
 		return
 	}
 
-	loginInfo, err := services.GetLoginInfoFromUser(req.Email, req.Code)
+	loginInfo, err := services.GetLoginInfoFromUser(req.Email, req.Code, requestMeta(c))
 	if err != nil {
 		c.Error(err)
 		return
@@ -72,7 +73,13 @@ func UserLogin(c *gin.Context) {
 		return
 	}
 
-	user, err := services.UserLogin(&req)
+	err := utils.GetValidator().Struct(req)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := services.UserLogin(&req, requestMeta(c))
 	if err != nil {
 		c.Error(err)
 		return
@@ -173,7 +180,7 @@ func UpdateUserRole(c *gin.Context) {
 		return
 	}
 
-	err = services.UpdateUserRole(userID, req.UserID, string(req.Role))
+	err = services.UpdateUserRole(userID, req.UserID, string(req.Role), requestMeta(c))
 	if err != nil {
 		c.Error(err)
 		return
@@ -201,7 +208,7 @@ func DeleteUser(c *gin.Context) {
 		return
 	}
 
-	err := services.DeleteUser(strOwnerID, userID)
+	err := services.DeleteUser(strOwnerID, userID, requestMeta(c))
 	if err != nil {
 		c.Error(err)
 	}
@@ -212,6 +219,17 @@ func DeleteUser(c *gin.Context) {
 func GetUsers(c *gin.Context) {
 	id := c.Query("userId")
 
+	userIDRaw, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID, ok := userIDRaw.(string)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Erro interno (userID type)"})
+		return
+	}
+
 	orgIDRaw, exists := c.Get("orgID")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
@@ -223,19 +241,19 @@ func GetUsers(c *gin.Context) {
 		return
 	}
 
-	roleRaw, exists := c.Get("role")
+	permissionsRaw, exists := c.Get("permissions")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 
-	role, ok := roleRaw.(models.UserRole)
+	permissions, ok := permissionsRaw.([]models.OrgPermission)
 	if !ok {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Erro interno (role type)"})
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Erro interno (permissions type)"})
 		return
 	}
-	if role != models.RoleAdmin {
-		c.AbortWithStatusJSON(403, gin.H{"error": "Invalid Permission"})
+	if err := authz.Check(authz.UserList, authz.Subject{Permissions: permissions}); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -253,7 +271,7 @@ func GetUsers(c *gin.Context) {
 		c.JSON(200, user)
 		return
 	} else {
-		users, err := services.GetUsersByOrgID(orgID)
+		users, err := services.GetUsersByOrgID(userID)
 		if err != nil {
 			c.Error(err)
 			return
@@ -263,6 +281,59 @@ func GetUsers(c *gin.Context) {
 	}
 }
 
+func GetMe(c *gin.Context) {
+	userIDRaw, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID, ok := userIDRaw.(string)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Erro interno (userID type)"})
+		return
+	}
+
+	me, err := services.GetMe(userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(200, me)
+}
+
+func UpdateUserPreferences(c *gin.Context) {
+	userIDRaw, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID, ok := userIDRaw.(string)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Erro interno (userID type)"})
+		return
+	}
+
+	var req models.UpdateUserPreferencesRequest
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, 1<<10)
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := utils.GetValidator().Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validação falhou", "details": err.Error()})
+		return
+	}
+
+	me, err := services.UpdateUserPreferences(userID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, me)
+}
+
 func GetAllMembersFromTheVault(c *gin.Context) {
 	vaultId := c.Query("vaultId")
 
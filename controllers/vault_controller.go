@@ -1,10 +1,11 @@
 package controllers
 
 import (
-	"fmt"
 	"net/http"
+	"slices"
 
 	"github.com/gin-gonic/gin"
+	"lembrago.com/lembrago/authz"
 	"lembrago.com/lembrago/models"
 	"lembrago.com/lembrago/services"
 	"lembrago.com/lembrago/utils"
@@ -35,29 +36,30 @@ func CreateVault(c *gin.Context) {
 		return
 	}
 
-	roleRaw, exists := c.Get("role")
+	permissionsRaw, exists := c.Get("permissions")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 
-	role, ok := roleRaw.(models.UserRole)
+	permissions, ok := permissionsRaw.([]models.OrgPermission)
 	if !ok {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Erro interno (role type)"})
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Erro interno (permissions type)"})
 		return
 	}
 
-	if role != models.RoleAdmin {
-		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Invalid Role"})
+	if err := authz.Check(authz.VaultCreate, authz.Subject{Permissions: permissions}); err != nil {
+		c.Error(err)
 		return
 	}
 
-	vault, err := services.CreateVault(userID, &req)
+	vault, err := services.CreateVault(userID, &req, requestMeta(c))
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
+	c.Header("ETag", utils.FormatETag(vault.Revision))
 	c.JSON(201, vault)
 }
 
@@ -75,7 +77,14 @@ func UpdateVault(c *gin.Context) {
 		return
 	}
 
-	fmt.Println(req)
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" {
+		revision, err := utils.ParseIfMatch(ifMatch)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		req.Revision = &revision
+	}
 
 	userIDRaw, exists := c.Get("userID")
 	if !exists {
@@ -88,12 +97,13 @@ func UpdateVault(c *gin.Context) {
 		return
 	}
 
-	vault, err := services.UpdateVault(userID, &req)
+	vault, err := services.UpdateVault(userID, &req, requestMeta(c))
 	if err != nil {
 		_ = c.Error(err)
 		return
 	}
 
+	c.Header("ETag", utils.FormatETag(vault.Revision))
 	c.JSON(200, vault)
 }
 
@@ -115,7 +125,7 @@ func RemoveVault(c *gin.Context) {
 		return
 	}
 
-	err := services.RemoveVault(userID, vaultID)
+	err := services.RemoveVault(userID, vaultID, requestMeta(c))
 	if err != nil {
 		c.Error(err)
 		return
@@ -154,6 +164,15 @@ func GetMyVaultsByOrgID(c *gin.Context) {
 		return
 	}
 
+	// Token de conta de serviço restrito a alguns cofres só enxerga esses.
+	if scopeRaw, exists := c.Get("serviceScope"); exists {
+		if scope, ok := scopeRaw.(models.ServiceAccountScopeDTO); ok && len(scope.VaultIDs) > 0 {
+			vaults = slices.DeleteFunc(vaults, func(vault models.VaultWithMemberInfo) bool {
+				return !slices.Contains(scope.VaultIDs, vault.ID)
+			})
+		}
+	}
+
 	c.JSON(200, vaults)
 }
 
@@ -182,7 +201,7 @@ func AddMemberToVault(c *gin.Context) {
 		return
 	}
 
-	vaultWithMemberResponse, err := services.AddMemberToVault(userID, &req)
+	vaultWithMemberResponse, err := services.AddMemberToVault(userID, &req, requestMeta(c))
 	if err != nil {
 		c.Error(err)
 		return
@@ -209,7 +228,7 @@ func RemoveMemberFromTheVault(c *gin.Context) {
 		return
 	}
 
-	err := services.RemoveMemberFromVault(userID, memberId)
+	err := services.RemoveMemberFromVault(userID, memberId, requestMeta(c))
 	if err != nil {
 		c.Error(err)
 		return
@@ -231,17 +250,6 @@ func UpdateMemberPermission(c *gin.Context) {
 		return
 	}
 
-	orgIDRaw, exists := c.Get("orgID")
-	if !exists {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Unauthorized"})
-		return
-	}
-	orgID, ok := orgIDRaw.(string)
-	if !ok {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Erro interno (orgID type)"})
-		return
-	}
-
 	var req models.UpdateVaultMemberRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
@@ -254,7 +262,7 @@ func UpdateMemberPermission(c *gin.Context) {
 		return
 	}
 
-	err = services.UpdateMemberPermission(userID, orgID, &req)
+	err = services.UpdateMemberPermission(userID, &req, requestMeta(c))
 	if err != nil {
 		c.Error(err)
 		return
@@ -288,12 +296,13 @@ func CreatePassword(c *gin.Context) {
 		return
 	}
 
-	password, err := services.AddPasswordToVault(userID, &req)
+	password, err := services.AddPasswordToVault(userID, &req, requestMeta(c))
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
+	c.Header("ETag", utils.FormatETag(password.Revision))
 	c.JSON(201, password)
 }
 
@@ -315,7 +324,7 @@ func DeletePassword(c *gin.Context) {
 		return
 	}
 
-	err := services.DeletePasswordFromVault(userID, passwordId)
+	err := services.DeletePasswordFromVault(userID, passwordId, requestMeta(c))
 	if err != nil {
 		c.Error(err)
 		return
@@ -340,6 +349,7 @@ func UpdatePasswordInVault(c *gin.Context) {
 	var req models.UpdatePasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
+		return
 	}
 
 	err := utils.GetValidator().Struct(req)
@@ -348,12 +358,22 @@ func UpdatePasswordInVault(c *gin.Context) {
 		return
 	}
 
-	pRes, err := services.UpdatePasswordInVault(userID, &req)
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" {
+		revision, err := utils.ParseIfMatch(ifMatch)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		req.Revision = &revision
+	}
+
+	pRes, err := services.UpdatePasswordInVault(userID, &req, requestMeta(c))
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
+	c.Header("ETag", utils.FormatETag(pRes.Revision))
 	c.JSON(200, pRes)
 }
 
@@ -375,7 +395,7 @@ func GetAllPasswordsFromVault(c *gin.Context) {
 		return
 	}
 
-	passwords, err := services.GetAllPasswordsFromVault(userID, vaultId)
+	passwords, err := services.GetAllPasswordsFromVault(userID, vaultId, requestMeta(c))
 	if err != nil {
 		c.Error(err)
 		return
@@ -383,3 +403,37 @@ func GetAllPasswordsFromVault(c *gin.Context) {
 
 	c.JSON(200, passwords)
 }
+
+func BatchPasswords(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, 4<<20)
+	userIDRaw, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID, ok := userIDRaw.(string)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Erro interno (userID type)"})
+		return
+	}
+
+	var req models.BatchItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := utils.GetValidator().Struct(req)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := services.ApplyItemsBatch(userID, &req, requestMeta(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(200, res)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"time"
 
@@ -25,7 +26,7 @@ func ConnectDB() error {
 	mongoPort := os.Getenv("MONGO_PORT")
 	mongoUser := os.Getenv("MONGO_USER")
 	mongoPassword := os.Getenv("MONGO_PASSWORD")
-	mongoURI := fmt.Sprintf("mongodb://%s:%s@%s:%s", mongoUser, mongoPassword, mongoHost, mongoPort)
+	mongoURI := fmt.Sprintf("mongodb://%s:%s@%s:%s/?%s", mongoUser, mongoPassword, mongoHost, mongoPort, mongoTopology())
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -38,10 +39,42 @@ func ConnectDB() error {
 	return nil
 }
 
+// mongoTopology diz ao driver como achar o replica set que as transações exigem.
+// MONGO_REPLICA_SET vazio conecta direto no nó informado, para quando o nome do
+// membro gravado no replica set não resolve de onde o servidor roda.
+func mongoTopology() string {
+	replicaSet, ok := os.LookupEnv("MONGO_REPLICA_SET")
+	if !ok {
+		replicaSet = "rs0"
+	}
+	if replicaSet == "" {
+		return "directConnection=true"
+	}
+	return "replicaSet=" + url.QueryEscape(replicaSet)
+}
+
 func GetCollection(name string) *mongo.Collection {
 	return MongoClient.Database("LemBraGO").Collection(name)
 }
 
+// WithTransaction executa fn numa transação. Requer que o MongoDB esteja
+// rodando como replica set. fn pode ser repetida em erros transitórios.
+func WithTransaction(fn func(ctx mongo.SessionContext) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	session, err := MongoClient.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
+
 func createIndexes() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -87,4 +120,204 @@ func createIndexes() {
 	if err != nil {
 		log.Fatal("Erro ao criar índice:", err)
 	}
+
+	indexModel = mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetSparse(true),
+	}
+
+	_, err = vaultMembersCollection.Indexes().CreateOne(ctx, indexModel)
+	if err != nil {
+		log.Fatal("Erro ao criar índice:", err)
+	}
+
+	attachmentsCollection := GetCollection("attachments")
+
+	_, err = attachmentsCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "passwordId", Value: 1}}},
+		{Keys: bson.D{{Key: "vaultId", Value: 1}}},
+		{Keys: bson.D{{Key: "orgId", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "createdAt", Value: 1}}},
+	})
+	if err != nil {
+		log.Fatal("Erro ao criar índice:", err)
+	}
+
+	tombstonesCollection := GetCollection("tombstones")
+
+	_, err = tombstonesCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "deletedAt", Value: 1}}},
+		{Keys: bson.D{{Key: "vaultId", Value: 1}, {Key: "deletedAt", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "deletedAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32((90 * 24 * time.Hour).Seconds())),
+		},
+	})
+	if err != nil {
+		log.Fatal("Erro ao criar índice:", err)
+	}
+
+	credentialsCollection := GetCollection("service_account_credentials")
+
+	_, err = credentialsCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "serviceAccountId", Value: 1}}},
+		{Keys: bson.D{{Key: "orgId", Value: 1}}},
+	})
+	if err != nil {
+		log.Fatal("Erro ao criar índice:", err)
+	}
+
+	tokensCollection := GetCollection("personal_access_tokens")
+
+	_, err = tokensCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		log.Fatal("Erro ao criar índice:", err)
+	}
+
+	groupsCollection := GetCollection("groups")
+
+	_, err = groupsCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "orgId", Value: 1}},
+	})
+	if err != nil {
+		log.Fatal("Erro ao criar índice:", err)
+	}
+
+	groupMembersCollection := GetCollection("group_members")
+
+	_, err = groupMembersCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "groupId", Value: 1}, {Key: "userId", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "userId", Value: 1}}},
+	})
+	if err != nil {
+		log.Fatal("Erro ao criar índice:", err)
+	}
+
+	grantsCollection := GetCollection("group_vault_grants")
+
+	_, err = grantsCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "groupId", Value: 1}, {Key: "vaultId", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "vaultId", Value: 1}}},
+	})
+	if err != nil {
+		log.Fatal("Erro ao criar índice:", err)
+	}
+
+	tasksCollection := GetCollection("key_share_tasks")
+
+	_, err = tasksCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "vaultId", Value: 1}, {Key: "userId", Value: 1}, {Key: "groupId", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "orgId", Value: 1}}},
+	})
+	if err != nil {
+		log.Fatal("Erro ao criar índice:", err)
+	}
+
+	pendingSharesCollection := GetCollection("pending_shares")
+
+	_, err = pendingSharesCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "vaultId", Value: 1}, {Key: "email", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		log.Fatal("Erro ao criar índice:", err)
+	}
+
+	accessRequestsCollection := GetCollection("access_requests")
+
+	_, err = accessRequestsCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "vaultId", Value: 1}, {Key: "userId", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": "pending"}),
+		},
+		{Keys: bson.D{{Key: "userId", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "expiresAt", Value: 1}}},
+	})
+	if err != nil {
+		log.Fatal("Erro ao criar índice:", err)
+	}
+
+	rolesCollection := GetCollection("roles")
+
+	_, err = rolesCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "orgId", Value: 1}, {Key: "key", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Fatal("Erro ao criar índice:", err)
+	}
+
+	auditLogsCollection := GetCollection("audit_logs")
+
+	_, err = auditLogsCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "orgId", Value: 1}, {Key: "seq", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "orgId", Value: 1}, {Key: "createdAt", Value: -1}}},
+	})
+	if err != nil {
+		log.Fatal("Erro ao criar índice:", err)
+	}
+
+	webhooksCollection := GetCollection("webhooks")
+
+	_, err = webhooksCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "orgId", Value: 1}, {Key: "events", Value: 1}},
+	})
+	if err != nil {
+		log.Fatal("Erro ao criar índice:", err)
+	}
+
+	webhookDeliveriesCollection := GetCollection("webhook_deliveries")
+
+	_, err = webhookDeliveriesCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "webhookId", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}}},
+	})
+	if err != nil {
+		log.Fatal("Erro ao criar índice:", err)
+	}
+
+	userDevicesCollection := GetCollection("user_devices")
+
+	_, err = userDevicesCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "fingerprint", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Fatal("Erro ao criar índice:", err)
+	}
+
+	mailOutboxCollection := GetCollection("mail_outbox")
+
+	_, err = mailOutboxCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "updatedAt", Value: -1}}},
+	})
+	if err != nil {
+		log.Fatal("Erro ao criar índice:", err)
+	}
 }
@@ -1,8 +1,9 @@
 package errors
 
 type AppError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
 }
 
 func (e *AppError) Error() string {
@@ -15,3 +16,13 @@ func NewAppError(code int, message string) *AppError {
 		Message: message,
 	}
 }
+
+// NewAppErrorWithDetails anexa um corpo extra à resposta, por exemplo a versão
+// atual do recurso em um conflito de revisão.
+func NewAppErrorWithDetails(code int, message string, details interface{}) *AppError {
+	return &AppError{
+		Code:    code,
+		Message: message,
+		Details: details,
+	}
+}
@@ -19,9 +19,13 @@ func ErrorHandler() gin.HandlerFunc {
 
 			if appErr, ok := err.(*errors.AppError); ok {
 				log.Printf("[AppError] Ocorreu um erro: Code=%d, Message=%s\n", appErr.Code, appErr.Message)
-				c.AbortWithStatusJSON(appErr.Code, gin.H{
+				body := gin.H{
 					"message": appErr.Message,
-				})
+				}
+				if appErr.Details != nil {
+					body["details"] = appErr.Details
+				}
+				c.AbortWithStatusJSON(appErr.Code, body)
 				return
 			}
 
@@ -3,9 +3,12 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
 
 	"github.com/joho/godotenv"
 	"lembrago.com/lembrago/database"
+	"lembrago.com/lembrago/mailer"
+	"lembrago.com/lembrago/siem"
 )
 
 type ServerConfig struct {
@@ -17,6 +20,7 @@ type ServerConfig struct {
 	Port                  string
 	SELF_URL              string
 	SELF_PAGE_URL         string
+	ExportSigningSeed     string
 }
 
 func init() {
@@ -34,6 +38,11 @@ func init() {
 		panic(err)
 	}
 
+	err = os.MkdirAll("attachments", os.ModePerm)
+	if err != nil {
+		panic(err)
+	}
+
 	if _, err := os.Stat("releases"); os.IsNotExist(err) {
 		_ = os.MkdirAll("releases, os.ModePerm", os.ModePerm)
 
@@ -54,11 +63,62 @@ func GetServerConfig() *ServerConfig {
 		Port:                  os.Getenv("PORT"),
 		SELF_URL:              os.Getenv("SELF_URL"),
 		SELF_PAGE_URL:         os.Getenv("SELF_PAGE"),
+		ExportSigningSeed:     os.Getenv("EXPORT_SIGNING_SEED"),
 	}
 
 	return cfg
 }
 
+// GetSIEMConfig lê o destino dos eventos de segurança. Valores ausentes ou
+// inválidos ficam zerados e o pacote siem usa os padrões.
+func GetSIEMConfig() siem.Config {
+	maxMB, _ := strconv.ParseInt(os.Getenv("SIEM_FILE_MAX_MB"), 10, 64)
+	backups, _ := strconv.Atoi(os.Getenv("SIEM_FILE_BACKUPS"))
+	bufferSize, _ := strconv.Atoi(os.Getenv("SIEM_BUFFER_SIZE"))
+
+	return siem.Config{
+		Target:       os.Getenv("SIEM_TARGET"),
+		Format:       os.Getenv("SIEM_FORMAT"),
+		BufferSize:   bufferSize,
+		FileMaxBytes: maxMB << 20,
+		FileBackups:  backups,
+		TLSCAFile:    os.Getenv("SIEM_TLS_CA_FILE"),
+		Version:      GetServerVersion(),
+	}
+}
+
+// GetMailConfig lê o driver e as credenciais de envio de e-mail. Sem
+// EMAIL_DRIVER, o envio é por SMTP quando EMAIL_HOST existe e só vai para o
+// log quando não existe.
+func GetMailConfig() mailer.Config {
+	port, _ := strconv.Atoi(os.Getenv("EMAIL_PORT"))
+
+	return mailer.Config{
+		Driver:   os.Getenv("EMAIL_DRIVER"),
+		Host:     os.Getenv("EMAIL_HOST"),
+		Port:     port,
+		Username: os.Getenv("EMAIL_AUTH_USER"),
+		Password: os.Getenv("EMAIL_AUTH_PASS"),
+		From:     os.Getenv("EMAIL_FROM"),
+		FromName: os.Getenv("EMAIL_FROM_NAME"),
+		FileDir:  os.Getenv("EMAIL_FILE_DIR"),
+	}
+}
+
+// WebhookConfig diz que destinos de webhook o servidor aceita. Em produção
+// fica tudo desligado: só https e só endereços públicos.
+type WebhookConfig struct {
+	AllowHTTP       bool
+	AllowPrivateIPs bool // loopback, redes privadas e link-local; só para desenvolvimento e testes
+}
+
+func GetWebhookConfig() WebhookConfig {
+	allowHTTP, _ := strconv.ParseBool(os.Getenv("WEBHOOK_ALLOW_HTTP"))
+	allowPrivate, _ := strconv.ParseBool(os.Getenv("WEBHOOK_ALLOW_PRIVATE_IPS"))
+
+	return WebhookConfig{AllowHTTP: allowHTTP, AllowPrivateIPs: allowPrivate}
+}
+
 func GetServerVersion() string {
 	return "0.8.0"
 }
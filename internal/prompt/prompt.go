@@ -0,0 +1,153 @@
+// Package prompt reúne as perguntas de terminal usadas pelos comandos em cmd/:
+// leitura de linhas, de segredos sem eco e o login interativo.
+package prompt
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+	"lembrago.com/lembrago/client"
+	"lembrago.com/lembrago/models"
+)
+
+var stdin = bufio.NewReader(os.Stdin)
+
+// ReadLine escreve o prompt em stderr e lê uma linha de stdin.
+func ReadLine(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// ReadSecret lê sem eco quando stdin é um terminal.
+func ReadSecret(prompt string) (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return ReadLine(prompt)
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	secret, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	return string(secret), err
+}
+
+// Confirm pergunta sim/não; qualquer coisa além de "y" ou "yes" é não.
+func Confirm(prompt string) (bool, error) {
+	answer, err := ReadLine(prompt + " [y/N] ")
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes", nil
+}
+
+// Login faz o fluxo completo: código por e-mail, escolha da organização e Senha Mestra.
+func Login(ctx context.Context, api *client.Client, email, orgID string) (*client.Session, *models.UserWithOrganizationResponse, error) {
+	if api.DeviceID == "" {
+		api.DeviceID = DeviceID()
+	}
+
+	var err error
+	if email == "" {
+		if email, err = ReadLine("E-mail: "); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := api.RequestAuthCode(ctx, email); err != nil {
+		return nil, nil, err
+	}
+	code, err := ReadLine("Code sent to " + email + ": ")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	orgs, err := api.LoginInfo(ctx, email, code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	org, err := ChooseOrganization(orgs, orgID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	masterPassword, err := ReadSecret("Master password: ")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session, err := api.Unlock(ctx, email, masterPassword, *org)
+	if err != nil {
+		return nil, nil, err
+	}
+	return session, org, nil
+}
+
+// ChooseOrganization usa orgID quando informado, a única organização quando só
+// houver uma, ou pergunta ao usuário.
+func ChooseOrganization(orgs []models.UserWithOrganizationResponse, orgID string) (*models.UserWithOrganizationResponse, error) {
+	if len(orgs) == 0 {
+		return nil, fmt.Errorf("no organization found for this e-mail")
+	}
+	if orgID != "" {
+		for i := range orgs {
+			if orgs[i].OrgID == orgID {
+				return &orgs[i], nil
+			}
+		}
+		return nil, fmt.Errorf("organization %s not found for this e-mail", orgID)
+	}
+	if len(orgs) == 1 {
+		return &orgs[0], nil
+	}
+
+	for i, org := range orgs {
+		fmt.Fprintf(os.Stderr, "  %d) %s (%s)\n", i+1, org.OrganizationName, org.OrgID)
+	}
+	answer, err := ReadLine("Organization: ")
+	if err != nil {
+		return nil, err
+	}
+	index, err := strconv.Atoi(answer)
+	if err != nil || index < 1 || index > len(orgs) {
+		return nil, fmt.Errorf("invalid choice")
+	}
+	return &orgs[index-1], nil
+}
+
+// DeviceID devolve o identificador desta máquina, criado no primeiro uso e
+// guardado ao lado da sessão. Sem onde guardar, o login segue sem ele.
+func DeviceID() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	path := filepath.Join(dir, "lembrago", "device-id")
+	if data, err := os.ReadFile(path); err == nil {
+		return strings.TrimSpace(string(data))
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return ""
+	}
+	deviceID := hex.EncodeToString(id)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return ""
+	}
+	if err := os.WriteFile(path, []byte(deviceID+"\n"), 0o600); err != nil {
+		return ""
+	}
+	return deviceID
+}
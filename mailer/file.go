@@ -0,0 +1,49 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// fileMailer grava cada mensagem como um .eml completo em dir, com o
+// destinatário no nome do arquivo.
+type fileMailer struct {
+	dir      string
+	from     string
+	fromName string
+}
+
+func newFileMailer(dir, from, fromName string) (*fileMailer, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("mailer: %w", err)
+	}
+	if from == "" {
+		from = "lembrago@localhost"
+	}
+	return &fileMailer{dir: dir, from: from, fromName: fromName}, nil
+}
+
+func (m *fileMailer) Send(msg Message) error {
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), sanitizeFileName(msg.To))
+	file, err := os.OpenFile(filepath.Join(m.dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := newGomailMessage(m.from, m.fromName, msg).WriteTo(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func sanitizeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '@' || r == '.' || r == '-' || r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,12 @@
+package mailer
+
+import "log"
+
+// logMailer só registra o envio. O corpo não vai para o log porque pode ter
+// códigos de acesso; para lê-lo em desenvolvimento, use o driver file.
+type logMailer struct{}
+
+func (logMailer) Send(msg Message) error {
+	log.Printf("mail %s to=%s subject=%q (%d bytes)", msg.Kind, msg.To, msg.Subject, len(msg.HTML))
+	return nil
+}
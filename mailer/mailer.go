@@ -0,0 +1,100 @@
+// Package mailer entrega e-mails já montados por SMTP, grava em arquivos .eml
+// (para desenvolvimento e testes) ou só registra no log. O driver vem da
+// configuração; a fila com novas tentativas fica no serviço, que chama Send.
+package mailer
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+const (
+	DriverSMTP = "smtp"
+	DriverFile = "file"
+	DriverLog  = "log"
+
+	defaultFileDir = "mails"
+)
+
+// Message é um e-mail pronto para envio. Kind diz qual modelo o gerou e só
+// serve para identificá-lo na fila. Text, se houver, vai como alternativa em
+// texto puro; FromName troca o nome do remetente da configuração.
+type Message struct {
+	Kind     string
+	To       string
+	FromName string
+	Subject  string
+	HTML     string
+	Text     string
+}
+
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// Config vem do ambiente (veja config.GetMailConfig).
+type Config struct {
+	Driver   string // smtp, file ou log; vazio escolhe smtp se houver Host, senão log
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string // padrão: Username
+	FromName string
+	FileDir  string // driver file; padrão "mails"
+}
+
+var current atomic.Pointer[Mailer]
+
+// New monta o Mailer do driver configurado.
+func New(cfg Config) (Mailer, error) {
+	driver := cfg.Driver
+	if driver == "" {
+		driver = DriverLog
+		if cfg.Host != "" {
+			driver = DriverSMTP
+		}
+	}
+	if cfg.From == "" {
+		cfg.From = cfg.Username
+	}
+
+	switch driver {
+	case DriverSMTP:
+		if cfg.Host == "" || cfg.Port == 0 {
+			return nil, fmt.Errorf("mailer: smtp needs a host and a port")
+		}
+		if cfg.From == "" {
+			return nil, fmt.Errorf("mailer: smtp needs a sender address")
+		}
+		return &smtpMailer{cfg: cfg}, nil
+	case DriverFile:
+		if cfg.FileDir == "" {
+			cfg.FileDir = defaultFileDir
+		}
+		return newFileMailer(cfg.FileDir, cfg.From, cfg.FromName)
+	case DriverLog:
+		return logMailer{}, nil
+	default:
+		return nil, fmt.Errorf("mailer: unknown driver %q", driver)
+	}
+}
+
+// Start troca o Mailer usado por Send.
+func Start(cfg Config) error {
+	m, err := New(cfg)
+	if err != nil {
+		return err
+	}
+	current.Store(&m)
+	return nil
+}
+
+// Send entrega com o Mailer de Start. Antes de Start, falha; a fila tenta de novo.
+func Send(msg Message) error {
+	m := current.Load()
+	if m == nil {
+		return fmt.Errorf("mailer: not started")
+	}
+	return (*m).Send(msg)
+}
@@ -0,0 +1,127 @@
+package mailer
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewPicksDriver(t *testing.T) {
+	if m, err := New(Config{}); err != nil {
+		t.Fatal(err)
+	} else if _, ok := m.(logMailer); !ok {
+		t.Fatalf("no host should fall back to log, got %T", m)
+	}
+	if m, err := New(Config{Host: "smtp.example.com", Port: 465, Username: "noreply@example.com"}); err != nil {
+		t.Fatal(err)
+	} else if smtp, ok := m.(*smtpMailer); !ok || smtp.cfg.From != "noreply@example.com" {
+		t.Fatalf("host should select smtp with the user as sender, got %#v", m)
+	}
+
+	for _, cfg := range []Config{
+		{Driver: DriverSMTP, Host: "smtp.example.com"},
+		{Driver: DriverSMTP, Host: "smtp.example.com", Port: 465},
+		{Driver: "pigeon"},
+	} {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("New(%+v) accepted an invalid config", cfg)
+		}
+	}
+}
+
+func TestFileMailerWritesEML(t *testing.T) {
+	dir := t.TempDir()
+	m, err := New(Config{Driver: DriverFile, FileDir: dir, From: "noreply@example.com", FromName: "LemBRAGO"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = m.Send(Message{Kind: "welcome", To: "ana@example.com", Subject: "Boas vindas à equipe", HTML: "<p>Olá, " + strings.Repeat("a", 100) + "</p>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*-ana@example.com.eml"))
+	if len(files) != 1 {
+		t.Fatalf("want one .eml, got %v", files)
+	}
+	file, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	msg, err := mail.ReadMessage(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if subject != "Boas vindas à equipe" || msg.Header.Get("To") != "ana@example.com" || !strings.Contains(msg.Header.Get("From"), "LemBRAGO") {
+		t.Fatalf("unexpected headers: %v", msg.Header)
+	}
+	body, _ := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if !strings.HasPrefix(string(body), "<p>Olá, aaaa") {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+func TestTextAlternativeAndSenderName(t *testing.T) {
+	dir := t.TempDir()
+	m, err := New(Config{Driver: DriverFile, FileDir: dir, From: "noreply@example.com", FromName: "LemBRAGO"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = m.Send(Message{Kind: "invite", To: "bia@example.com", FromName: "Acme TI", Subject: "Convite", HTML: "<p>Olá</p>", Text: "Olá"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*-bia@example.com.eml"))
+	if len(files) != 1 {
+		t.Fatalf("want one .eml, got %v", files)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from := msg.Header.Get("From"); !strings.Contains(from, "Acme TI") || !strings.Contains(from, "noreply@example.com") {
+		t.Fatalf("sender name not overridden: %s", from)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("want multipart/alternative, got %s (%v)", mediaType, err)
+	}
+
+	var types []string
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		types = append(types, partType)
+	}
+	// O cliente mostra a última alternativa que entende, por isso o HTML vem depois.
+	if strings.Join(types, ",") != "text/plain,text/html" {
+		t.Fatalf("unexpected parts: %v", types)
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	if got := sanitizeFileName("../a b@example.com"); got != ".._a_b@example.com" {
+		t.Fatalf("sanitizeFileName: %s", got)
+	}
+}
@@ -0,0 +1,39 @@
+package mailer
+
+import (
+	"crypto/tls"
+
+	"gopkg.in/gomail.v2"
+)
+
+type smtpMailer struct {
+	cfg Config
+}
+
+func (m *smtpMailer) Send(msg Message) error {
+	d := gomail.NewDialer(m.cfg.Host, m.cfg.Port, m.cfg.Username, m.cfg.Password)
+	d.TLSConfig = &tls.Config{ServerName: m.cfg.Host}
+	return d.DialAndSend(newGomailMessage(m.cfg.From, m.cfg.FromName, msg))
+}
+
+func newGomailMessage(from, fromName string, msg Message) *gomail.Message {
+	if msg.FromName != "" {
+		fromName = msg.FromName
+	}
+
+	m := gomail.NewMessage()
+	if fromName != "" {
+		m.SetAddressHeader("From", from, fromName)
+	} else {
+		m.SetHeader("From", from)
+	}
+	m.SetHeader("To", msg.To)
+	m.SetHeader("Subject", msg.Subject)
+	if msg.Text != "" {
+		m.SetBody("text/plain", msg.Text)
+		m.AddAlternative("text/html", msg.HTML)
+	} else {
+		m.SetBody("text/html", msg.HTML)
+	}
+	return m
+}
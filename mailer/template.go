@@ -0,0 +1,122 @@
+package mailer
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"path/filepath"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+)
+
+// Templates monta e-mails e páginas a partir de <Dir>/<idioma>/: layout.html e
+// <kind>.html com html/template, que escapa o que vem de usuários, e layout.txt
+// e <kind>.txt com text/template para o assunto e a parte em texto. Cada
+// conjunto é lido do disco na primeira vez que o idioma e o tipo aparecem.
+type Templates struct {
+	Dir string
+	// Funcs dá as funções dos modelos de cada idioma (ex.: formato de datas).
+	Funcs func(locale string) map[string]interface{}
+
+	mu   sync.Mutex
+	html map[string]*htmltemplate.Template
+	text map[string]*texttemplate.Template
+}
+
+// Render monta o e-mail kind no idioma locale. O modelo de texto define o
+// bloco "subject"; o assunto sai numa linha só, mesmo que algum dado traga quebras.
+func (t *Templates) Render(locale, kind, to, fromName string, vars map[string]interface{}) (*Message, error) {
+	htmlTmpl, err := t.htmlSet(locale, kind)
+	if err != nil {
+		return nil, err
+	}
+	textTmpl, err := t.textSet(locale, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	var subject, html, text bytes.Buffer
+	if err := textTmpl.ExecuteTemplate(&subject, "subject", vars); err != nil {
+		return nil, err
+	}
+	if err := htmlTmpl.Execute(&html, vars); err != nil {
+		return nil, err
+	}
+	if err := textTmpl.Execute(&text, vars); err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		Kind:     kind,
+		To:       to,
+		FromName: fromName,
+		Subject:  strings.Join(strings.Fields(subject.String()), " "),
+		HTML:     html.String(),
+		Text:     text.String(),
+	}, nil
+}
+
+// Page monta só a parte HTML, para páginas servidas com o layout dos e-mails.
+func (t *Templates) Page(locale, kind string, vars map[string]interface{}) (string, error) {
+	tmpl, err := t.htmlSet(locale, kind)
+	if err != nil {
+		return "", err
+	}
+
+	var page bytes.Buffer
+	if err := tmpl.Execute(&page, vars); err != nil {
+		return "", err
+	}
+	return page.String(), nil
+}
+
+// Os conjuntos já lidos só são executados, o que html/template e text/template
+// permitem em paralelo. Um erro de leitura não fica guardado.
+func (t *Templates) htmlSet(locale, kind string) (*htmltemplate.Template, error) {
+	key := locale + "/" + kind
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if tmpl, ok := t.html[key]; ok {
+		return tmpl, nil
+	}
+
+	dir := filepath.Join(t.Dir, locale)
+	tmpl, err := htmltemplate.New("layout.html").Funcs(t.funcs(locale)).Option("missingkey=error").
+		ParseFiles(filepath.Join(dir, "layout.html"), filepath.Join(dir, kind+".html"))
+	if err != nil {
+		return nil, err
+	}
+	if t.html == nil {
+		t.html = make(map[string]*htmltemplate.Template)
+	}
+	t.html[key] = tmpl
+	return tmpl, nil
+}
+
+func (t *Templates) textSet(locale, kind string) (*texttemplate.Template, error) {
+	key := locale + "/" + kind
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if tmpl, ok := t.text[key]; ok {
+		return tmpl, nil
+	}
+
+	dir := filepath.Join(t.Dir, locale)
+	tmpl, err := texttemplate.New("layout.txt").Funcs(t.funcs(locale)).Option("missingkey=error").
+		ParseFiles(filepath.Join(dir, "layout.txt"), filepath.Join(dir, kind+".txt"))
+	if err != nil {
+		return nil, err
+	}
+	if t.text == nil {
+		t.text = make(map[string]*texttemplate.Template)
+	}
+	t.text[key] = tmpl
+	return tmpl, nil
+}
+
+func (t *Templates) funcs(locale string) map[string]interface{} {
+	if t.Funcs == nil {
+		return nil
+	}
+	return t.Funcs(locale)
+}
@@ -0,0 +1,113 @@
+package mailer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const hostile = `<script>alert("x")</script>`
+
+func testTemplates() *Templates {
+	return &Templates{
+		Dir: "../templates",
+		Funcs: func(string) map[string]interface{} {
+			return map[string]interface{}{
+				"datetime": func(t time.Time) string { return t.Format(time.RFC3339) },
+			}
+		},
+	}
+}
+
+// commonVars imita o que utils acrescenta a todo e-mail.
+func commonVars(vars map[string]interface{}) map[string]interface{} {
+	vars["Brand"] = map[string]interface{}{
+		"OrgName":         "Acme",
+		"LogoURL":         "",
+		"PrimaryColor":    "#007bff",
+		"BackgroundColor": "#f4f4f4",
+	}
+	vars["ProjectName"] = "LEMBRAGO"
+	vars["SelfURL"] = "https://lembrago.example.com"
+	vars["FAQURL"] = "https://lembrago.example.com/faq"
+	vars["Year"] = 2026
+	return vars
+}
+
+func TestRenderEscapesUserData(t *testing.T) {
+	templates := testTemplates()
+	for _, locale := range []string{"pt-BR", "en-US"} {
+		for _, tc := range []struct {
+			kind string
+			vars map[string]interface{}
+		}{
+			{"welcome", map[string]interface{}{"Username": hostile}},
+			{"access_request_decision", map[string]interface{}{
+				"VaultName": "Cofre " + hostile,
+				"Decision":  "approved",
+				"Note":      "ok",
+			}},
+			{"member_expired", map[string]interface{}{
+				"VaultName":   "Cofre " + hostile,
+				"MemberEmail": "bia@example.com",
+			}},
+		} {
+			msg, err := templates.Render(locale, tc.kind, "bia@example.com", "Acme TI", commonVars(tc.vars))
+			if err != nil {
+				t.Fatalf("%s/%s: %v", locale, tc.kind, err)
+			}
+			if strings.Contains(msg.HTML, "<script>") || !strings.Contains(msg.HTML, "&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;") {
+				t.Errorf("%s/%s: user data not escaped in HTML:\n%s", locale, tc.kind, msg.HTML)
+			}
+			if !strings.Contains(msg.Text, hostile) {
+				t.Errorf("%s/%s: text part missing the user data:\n%s", locale, tc.kind, msg.Text)
+			}
+			if msg.Subject == "" || strings.ContainsAny(msg.Subject, "\r\n") {
+				t.Errorf("%s/%s: subject is not a single line: %q", locale, tc.kind, msg.Subject)
+			}
+			if msg.Kind != tc.kind || msg.To != "bia@example.com" || msg.FromName != "Acme TI" {
+				t.Errorf("%s/%s: unexpected envelope %+v", locale, tc.kind, msg)
+			}
+		}
+	}
+}
+
+func TestTemplatesAreCached(t *testing.T) {
+	templates := testTemplates()
+	first, err := templates.htmlSet("pt-BR", "welcome")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := templates.htmlSet("pt-BR", "welcome")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Fatal("template set parsed twice")
+	}
+	if other, _ := templates.htmlSet("en-US", "welcome"); other == first {
+		t.Fatal("locales share a template set")
+	}
+
+	if _, err := templates.Render("pt-BR", "missing", "bia@example.com", "", commonVars(map[string]interface{}{})); err == nil {
+		t.Fatal("rendered a kind without templates")
+	}
+	if _, ok := templates.html["pt-BR/missing"]; ok {
+		t.Fatal("failed parse was cached")
+	}
+}
+
+func TestPageRendersWithoutTextTemplate(t *testing.T) {
+	page, err := testTemplates().Page("en-US", "device_report", commonVars(map[string]interface{}{
+		"UserAgent": hostile,
+		"IP":        "203.0.113.7",
+		"SeenAt":    time.Now(),
+		"Done":      false,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(page, "<script>") || !strings.Contains(page, `method="post"`) {
+		t.Fatalf("unexpected page:\n%s", page)
+	}
+}
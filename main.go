@@ -9,27 +9,55 @@ import (
 	"lembrago.com/lembrago/controllers"
 	"lembrago.com/lembrago/handlers"
 	"lembrago.com/lembrago/internal/config"
+	"lembrago.com/lembrago/mailer"
 	"lembrago.com/lembrago/middlewares"
 	"lembrago.com/lembrago/models"
+	"lembrago.com/lembrago/realtime"
+	"lembrago.com/lembrago/services"
+	"lembrago.com/lembrago/siem"
 )
 
 func main() {
 	appConfig := config.GetServerConfig()
 
+	if err := siem.Start(config.GetSIEMConfig()); err != nil {
+		panic(err)
+	}
+	if err := mailer.Start(config.GetMailConfig()); err != nil {
+		panic(err)
+	}
+
+	go realtime.Listen()
+	go services.RunExpiryJob()
+	go services.RunWebhookJob()
+	go services.RunMailJob()
+
+	router := setupRouter(appConfig)
+
+	host := appConfig.Host
+	port := appConfig.Port
+	rt := fmt.Sprintf("%s:%s", host, port)
+	fmt.Println("Server running on", rt)
+	router.Run(rt)
+}
+
+func setupRouter(appConfig *config.ServerConfig) *gin.Engine {
 	router := gin.Default()
 	router.Use(handlers.ErrorHandler())
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "If-Match"},
+		ExposeHeaders:    []string{"ETag"},
 		AllowCredentials: true,
 	}))
 
 	router.StaticFile("/favicon.ico", "./uploads/favicon.ico")
 	router.Static("/uploads", "./uploads")
 
-	adminOnly := []models.UserRole{models.RoleAdmin}
-	adminOrMember := []models.UserRole{models.RoleAdmin, models.RoleMember}
+	orgManage := []models.OrgPermission{models.PermOrgManage}
+	vaultsUse := []models.OrgPermission{models.PermVaultsUse}
+	vaultsManage := []models.OrgPermission{models.PermVaultsManage}
 
 	public := router.Group("/")
 	public.Use(middlewares.NewRateLimiterMiddleware(time.Minute, 100))
@@ -38,69 +66,177 @@ func main() {
 		public.POST("/auth", middlewares.DictionaryPreviewMiddleware(), controllers.SendAuthCode)
 		public.POST("/login", controllers.GetLoginInfoFromUser)
 		public.POST("/environment/login", controllers.UserLogin)
+		public.GET("/environment/devices/report/:token", controllers.ReportDevicePage)
+		public.POST("/environment/devices/report/:token", controllers.ReportDevice)
 		public.GET("/invites/:id", controllers.GetInvitedCodeToken)
 	}
 
 	media := router.Group("/media")
 	{
 		media.GET("/:filename", controllers.HandleServeFile)
-		media.POST("", middlewares.AuthMiddleware(appConfig.JWTSecret, []models.UserRole{}), controllers.HandleUploadFile)
+		media.POST("", middlewares.AuthMiddleware(appConfig.JWTSecret, []models.OrgPermission{}, models.ScopeOrgManage), controllers.HandleUploadFile)
 	}
 
 	organization := router.Group("/org")
-	organization.Use(
-		middlewares.NewRateLimiterMiddleware(time.Minute, 100),
-		middlewares.AuthMiddleware(appConfig.JWTSecret, []models.UserRole{models.RoleAdmin}),
-	)
+	organization.Use(middlewares.NewRateLimiterMiddleware(time.Minute, 100))
 	{
-		organization.GET("/users", controllers.GetUsers)
-		organization.DELETE("/users", controllers.DeleteUser)
-		organization.PUT("/users", controllers.UpdateUserRole)
+		orgAuth := func(permission models.OrgPermission) gin.HandlerFunc {
+			return middlewares.AuthMiddleware(appConfig.JWTSecret, []models.OrgPermission{permission}, models.ScopeOrgManage)
+		}
+
+		organization.GET("/users", orgAuth(models.PermUsersRead), controllers.GetUsers)
+		organization.DELETE("/users", orgAuth(models.PermUsersDelete), controllers.DeleteUser)
+		organization.PUT("/users", orgAuth(models.PermRolesManage), controllers.UpdateUserRole)
+		organization.PUT("/users/status", orgAuth(models.PermUsersSuspend), controllers.UpdateUserStatus)
+
+		organization.GET("/permissions", orgAuth(models.PermRolesManage), controllers.GetPermissionCatalog)
+		organization.GET("/roles", middlewares.AuthMiddleware(appConfig.JWTSecret, []models.OrgPermission{}, models.ScopeOrgManage), controllers.GetRoles)
+		organization.POST("/roles", orgAuth(models.PermRolesManage), controllers.CreateRole)
+		organization.PUT("/roles/:key", orgAuth(models.PermRolesManage), controllers.UpdateRole)
+		organization.DELETE("/roles/:key", orgAuth(models.PermRolesManage), controllers.DeleteRole)
+
+		organization.GET("/users/service-accounts", orgAuth(models.PermServiceAccountsManage), controllers.GetServiceAccounts)
+		organization.POST("/users/service-accounts", orgAuth(models.PermServiceAccountsManage), controllers.CreateServiceAccount)
+		organization.DELETE("/users/service-accounts/:id", orgAuth(models.PermServiceAccountsManage), controllers.DeleteServiceAccount)
+		organization.PUT("/users/service-accounts/:id/credentials/:credentialId", orgAuth(models.PermServiceAccountsManage), controllers.UpdateServiceAccountCredential)
+		organization.DELETE("/users/service-accounts/:id/credentials/:credentialId", orgAuth(models.PermServiceAccountsManage), controllers.DeleteServiceAccountCredential)
+
+		organization.GET("/groups", orgAuth(models.PermGroupsManage), controllers.GetGroups)
+		organization.POST("/groups", orgAuth(models.PermGroupsManage), controllers.CreateGroup)
+		organization.PUT("/groups/:id", orgAuth(models.PermGroupsManage), controllers.UpdateGroup)
+		organization.DELETE("/groups/:id", orgAuth(models.PermGroupsManage), controllers.DeleteGroup)
+		organization.POST("/groups/:id/members", orgAuth(models.PermGroupsManage), controllers.AddGroupMember)
+		organization.DELETE("/groups/:id/members/:userId", orgAuth(models.PermGroupsManage), controllers.RemoveGroupMember)
+
+		auditAuth := middlewares.AuthMiddleware(appConfig.JWTSecret, []models.OrgPermission{models.PermAuditRead}, models.ScopeAuditRead)
+		organization.GET("/audit", auditAuth, controllers.GetAuditLog)
+		organization.GET("/audit/verify", auditAuth, controllers.VerifyAuditLog)
+
+		organization.GET("/branding", orgAuth(models.PermOrgManage), controllers.GetOrgBranding)
+		organization.PUT("/branding", orgAuth(models.PermOrgManage), controllers.UpdateOrgBranding)
+
+		organization.GET("/webhooks", orgAuth(models.PermOrgManage), controllers.GetWebhooks)
+		organization.POST("/webhooks", orgAuth(models.PermOrgManage), controllers.CreateWebhook)
+		organization.PUT("/webhooks/:id", orgAuth(models.PermOrgManage), controllers.UpdateWebhook)
+		organization.DELETE("/webhooks/:id", orgAuth(models.PermOrgManage), controllers.DeleteWebhook)
+		organization.GET("/webhooks/:id/deliveries", orgAuth(models.PermOrgManage), controllers.GetWebhookDeliveries)
+		organization.POST("/webhooks/:id/deliveries/:deliveryId/redeliver", orgAuth(models.PermOrgManage), controllers.RedeliverWebhook)
+	}
+
+	serviceAccounts := router.Group("/service-accounts")
+	serviceAccounts.Use(middlewares.NewRateLimiterMiddleware(time.Minute, 100))
+	{
+		serviceAccounts.POST("/token", controllers.IssueServiceAccountToken)
+		serviceAccounts.POST("/credentials/rotate", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse), controllers.RotateServiceAccountCredential)
 	}
 
 	invites := router.Group("/invites")
 	invites.Use(middlewares.NewRateLimiterMiddleware(time.Minute, 100))
 	{
-		invites.POST("", middlewares.AuthMiddleware(appConfig.JWTSecret, adminOnly), controllers.InviteUser)
+		invites.POST("", middlewares.AuthMiddleware(appConfig.JWTSecret, []models.OrgPermission{models.PermUsersInvite}, models.ScopeOrgManage), controllers.InviteUser)
 	}
 
 	creation := router.Group("/users/creation")
 	creation.Use(
 		middlewares.NewRateLimiterMiddleware(time.Minute, 100),
-		middlewares.AuthMiddleware(appConfig.JWTSecretUserCreation, []models.UserRole{}),
+		middlewares.AuthMiddleware(appConfig.JWTSecretUserCreation, []models.OrgPermission{}),
 	)
 	{
 		creation.POST("", controllers.UserRegister)
 	}
 
 	user := router.Group("/users")
-	user.Use(
-		middlewares.NewRateLimiterMiddleware(time.Minute, 100),
-		middlewares.AuthMiddleware(appConfig.JWTSecret, []models.UserRole{}),
-	)
+	user.Use(middlewares.NewRateLimiterMiddleware(time.Minute, 100))
 	{
-		user.GET("/vaults", controllers.GetMyVaultsByOrgID)
+		user.GET("/me", middlewares.AuthMiddleware(appConfig.JWTSecret, []models.OrgPermission{}, models.ScopeVaultsRead), controllers.GetMe)
+		user.PUT("/me/preferences", middlewares.AuthMiddleware(appConfig.JWTSecret, []models.OrgPermission{}), controllers.UpdateUserPreferences)
+		user.GET("/vaults", middlewares.AuthMiddleware(appConfig.JWTSecret, []models.OrgPermission{}, models.ScopeVaultsRead), controllers.GetMyVaultsByOrgID)
+
+		// Tokens pessoais só são geridos com a sessão, nunca com outro token pessoal.
+		user.GET("/tokens", middlewares.AuthMiddleware(appConfig.JWTSecret, []models.OrgPermission{}), controllers.GetPersonalAccessTokens)
+		user.POST("/tokens", middlewares.AuthMiddleware(appConfig.JWTSecret, []models.OrgPermission{}), controllers.CreatePersonalAccessToken)
+		user.DELETE("/tokens/:id", middlewares.AuthMiddleware(appConfig.JWTSecret, []models.OrgPermission{}), controllers.RevokePersonalAccessToken)
 	}
 
 	vaults := router.Group("/vaults")
 	vaults.Use(middlewares.NewRateLimiterMiddleware(time.Minute, 100))
 	{
-		vaults.POST("", middlewares.AuthMiddleware(appConfig.JWTSecret, adminOnly), controllers.CreateVault)
-		vaults.PUT("", middlewares.AuthMiddleware(appConfig.JWTSecret, adminOrMember), controllers.UpdateVault)
-		vaults.DELETE("/:id", middlewares.AuthMiddleware(appConfig.JWTSecret, adminOnly), controllers.RemoveVault)
-
-		vaults.GET("/members", middlewares.AuthMiddleware(appConfig.JWTSecret, adminOnly), controllers.GetAllMembersFromTheVault)
-		vaults.POST("/members", middlewares.AuthMiddleware(appConfig.JWTSecret, adminOnly), controllers.AddMemberToVault)
-		vaults.DELETE("/members", middlewares.AuthMiddleware(appConfig.JWTSecret, adminOnly), controllers.RemoveMemberFromTheVault)
-		vaults.PUT("/members", middlewares.AuthMiddleware(appConfig.JWTSecret, adminOrMember), controllers.UpdateMemberPermission)
-
-		vaults.GET("/passwords", middlewares.AuthMiddleware(appConfig.JWTSecret, adminOrMember), controllers.GetAllPasswordsFromVault)
-		vaults.POST("/passwords", middlewares.AuthMiddleware(appConfig.JWTSecret, adminOrMember), controllers.CreatePassword)
-		vaults.PUT("/passwords", middlewares.AuthMiddleware(appConfig.JWTSecret, adminOrMember), controllers.UpdatePasswordInVault)
-		vaults.DELETE("/passwords", middlewares.AuthMiddleware(appConfig.JWTSecret, adminOrMember), controllers.DeletePassword)
-
-		vaults.GET("/medias", middlewares.AuthMiddleware(appConfig.JWTSecret, adminOnly), controllers.GetAllMediasFromTheOrg)
-		vaults.DELETE("/medias", middlewares.AuthMiddleware(appConfig.JWTSecret, adminOrMember), controllers.DeleteMedia)
+		vaults.POST("", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsManage, models.ScopeVaultsWrite), controllers.CreateVault)
+		vaults.PUT("", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeVaultsWrite), controllers.UpdateVault)
+		vaults.DELETE("/:id", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeVaultsWrite), controllers.RemoveVault)
+
+		vaults.POST("/ownership", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeVaultsWrite), controllers.RequestVaultOwnershipTransfer)
+		vaults.POST("/ownership/accept", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeVaultsWrite), controllers.AcceptVaultOwnershipTransfer)
+		vaults.POST("/ownership/cancel", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeVaultsWrite), controllers.CancelVaultOwnershipTransfer)
+		vaults.POST("/ownership/force", middlewares.AuthMiddleware(appConfig.JWTSecret, orgManage, models.ScopeOrgManage), controllers.ForceVaultOwnershipTransfer)
+
+		vaults.GET("/members", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeMembersManage), controllers.GetAllMembersFromTheVault)
+		vaults.POST("/members", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeMembersManage), controllers.AddMemberToVault)
+		vaults.DELETE("/members", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeMembersManage), controllers.RemoveMemberFromTheVault)
+		vaults.PUT("/members", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeMembersManage), controllers.UpdateMemberPermission)
+
+		vaults.GET("/groups", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeMembersManage), controllers.GetGroupVaultGrants)
+		vaults.POST("/groups", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeMembersManage), controllers.GrantVaultToGroup)
+		vaults.DELETE("/groups/:id", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeMembersManage), controllers.RevokeGroupVaultGrant)
+		vaults.GET("/key-share-tasks", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeMembersManage), controllers.GetKeyShareTasks)
+		vaults.POST("/key-share-tasks/:id", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeMembersManage), controllers.CompleteKeyShareTask)
+
+		vaults.GET("/pending-shares", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeMembersManage), controllers.GetPendingShares)
+		vaults.POST("/pending-shares", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeMembersManage), controllers.CreatePendingShare)
+		vaults.POST("/pending-shares/:id/confirm", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeMembersManage), controllers.ConfirmPendingShare)
+		vaults.DELETE("/pending-shares/:id", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeMembersManage), controllers.CancelPendingShare)
+
+		vaults.GET("/directory", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeVaultsRead), controllers.GetVaultDirectory)
+		vaults.PUT("/directory", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeMembersManage), controllers.UpdateVaultDirectory)
+		vaults.GET("/access-requests", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeMembersManage), controllers.GetAccessRequests)
+		vaults.GET("/access-requests/mine", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeVaultsRead), controllers.GetMyAccessRequests)
+		vaults.POST("/access-requests", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeVaultsWrite), controllers.CreateAccessRequest)
+		vaults.POST("/access-requests/:id/approve", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeMembersManage), controllers.ApproveAccessRequest)
+		vaults.POST("/access-requests/:id/reject", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeMembersManage), controllers.RejectAccessRequest)
+		vaults.DELETE("/access-requests/:id", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeVaultsWrite), controllers.CancelAccessRequest)
+
+		vaults.GET("/passwords", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeItemsRead), controllers.GetAllPasswordsFromVault)
+		vaults.POST("/passwords", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeItemsWrite), controllers.CreatePassword)
+		vaults.PUT("/passwords", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeItemsWrite), controllers.UpdatePasswordInVault)
+		vaults.DELETE("/passwords", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeItemsWrite), controllers.DeletePassword)
+		vaults.POST("/passwords/batch", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeItemsWrite), controllers.BatchPasswords)
+
+		vaults.GET("/export", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeVaultsRead, models.ScopeItemsRead), controllers.ExportVaults)
+		vaults.GET("/export/public-key", controllers.GetExportPublicKey)
+		vaults.POST("/import", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeVaultsWrite, models.ScopeItemsWrite), controllers.ImportVaults)
+
+		vaults.GET("/medias", middlewares.AuthMiddleware(appConfig.JWTSecret, orgManage, models.ScopeOrgManage), controllers.GetAllMediasFromTheOrg)
+		vaults.DELETE("/medias", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeOrgManage), controllers.DeleteMedia)
+	}
+
+	attachments := router.Group("/vaults/attachments")
+	attachments.Use(middlewares.NewRateLimiterMiddleware(time.Minute, 600))
+	{
+		attachments.GET("", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeItemsRead), controllers.GetAllAttachmentsFromPassword)
+		attachments.POST("", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeItemsWrite), controllers.CreateAttachment)
+		attachments.GET("/usage", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeItemsRead), controllers.GetStorageUsage)
+		attachments.PUT("/:id/chunks/:index", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeItemsWrite), controllers.UploadAttachmentChunk)
+		attachments.GET("/:id/chunks/:index", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeItemsRead), controllers.DownloadAttachmentChunk)
+		attachments.POST("/:id/complete", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeItemsWrite), controllers.CompleteAttachment)
+		attachments.DELETE("/:id", middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeItemsWrite), controllers.DeleteAttachment)
+	}
+
+	sync := router.Group("/sync")
+	sync.Use(
+		middlewares.NewRateLimiterMiddleware(time.Minute, 100),
+		middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeVaultsRead, models.ScopeItemsRead),
+	)
+	{
+		sync.GET("", controllers.Sync)
+	}
+
+	events := router.Group("/events")
+	events.Use(
+		middlewares.NewRateLimiterMiddleware(time.Minute, 100),
+		middlewares.AuthMiddleware(appConfig.JWTSecret, vaultsUse, models.ScopeVaultsRead, models.ScopeItemsRead),
+	)
+	{
+		events.GET("", controllers.StreamEvents)
 	}
 
 	versions := router.Group("/versions")
@@ -108,19 +244,22 @@ func main() {
 	{
 		versions.GET("", controllers.GetAllVersions)
 		versions.GET("/latest", controllers.GetLatestAppVersion)
-		versions.POST("", middlewares.AuthMiddleware(appConfig.JWTSecretAdmin, adminOnly), controllers.RegisterVersion)
-		versions.PUT("", middlewares.AuthMiddleware(appConfig.JWTSecretAdmin, adminOnly), controllers.UpdateVersion)
-		versions.DELETE("/:id", middlewares.AuthMiddleware(appConfig.JWTSecretAdmin, adminOnly), controllers.RemoveVersion)
+		versions.POST("", middlewares.AuthMiddleware(appConfig.JWTSecretAdmin, orgManage), controllers.RegisterVersion)
+		versions.PUT("", middlewares.AuthMiddleware(appConfig.JWTSecretAdmin, orgManage), controllers.UpdateVersion)
+		versions.DELETE("/:id", middlewares.AuthMiddleware(appConfig.JWTSecretAdmin, orgManage), controllers.RemoveVersion)
 
 		versions.GET("/:target/:arch/:version", controllers.DownloadDesktopApp)
-		versions.POST("/desktop", middlewares.AuthMiddleware(appConfig.JWTSecretAdmin, adminOnly), controllers.UploadDesktopApp)
+		versions.POST("/desktop", middlewares.AuthMiddleware(appConfig.JWTSecretAdmin, orgManage), controllers.UploadDesktopApp)
+	}
+
+	mail := router.Group("/mail")
+	mail.Use(middlewares.NewRateLimiterMiddleware(time.Minute, 100))
+	{
+		mail.GET("/dead-letters", middlewares.AuthMiddleware(appConfig.JWTSecretAdmin, orgManage), controllers.GetDeadLetters)
+		mail.POST("/dead-letters/:id/retry", middlewares.AuthMiddleware(appConfig.JWTSecretAdmin, orgManage), controllers.RetryDeadLetter)
 	}
 
-	router.DELETE("/signout", middlewares.AuthMiddleware(appConfig.JWTSecret, adminOrMember), controllers.Signout)
+	router.DELETE("/signout", middlewares.AuthMiddleware(appConfig.JWTSecret, []models.OrgPermission{}), controllers.Signout)
 
-	host := appConfig.Host
-	port := appConfig.Port
-	rt := fmt.Sprintf("%s:%s", host, port)
-	fmt.Println("Server running on", rt)
-	router.Run(rt)
+	return router
 }
@@ -5,18 +5,22 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 
-	"slices"
-
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"lembrago.com/lembrago/cache"
 	"lembrago.com/lembrago/models"
+	"lembrago.com/lembrago/services"
 	"lembrago.com/lembrago/utils"
 )
 
-func AuthMiddleware(jwtSecretParam []byte, requiredRoles []models.UserRole) gin.HandlerFunc {
+// AuthMiddleware aceita o JWT da sessão e, nas rotas que declaram
+// requiredScopes, também tokens de acesso pessoal com todos esses escopos. A
+// função do usuário é resolvida em permissões, e a rota exige todas as de
+// requiredPermissions.
+func AuthMiddleware(jwtSecretParam []byte, requiredPermissions []models.OrgPermission, requiredScopes ...models.TokenScope) gin.HandlerFunc {
 	if len(jwtSecretParam) == 0 {
 		return func(c *gin.Context) {
 			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Configuração de segurança interna inválida"})
@@ -36,6 +40,12 @@ func AuthMiddleware(jwtSecretParam []byte, requiredRoles []models.UserRole) gin.
 			return
 		}
 		tokenString := parts[1]
+
+		if strings.HasPrefix(tokenString, models.PersonalAccessTokenPrefix) {
+			authenticatePersonalAccessToken(c, tokenString, requiredPermissions, requiredScopes)
+			return
+		}
+
 		tExist, err := cache.Get(tokenString)
 
 		if tExist != "" || err != nil {
@@ -84,11 +94,43 @@ func AuthMiddleware(jwtSecretParam []byte, requiredRoles []models.UserRole) gin.
 			c.Set("email", *claims.Email)
 		}
 
-		if len(requiredRoles) > 0 {
-			roleFound := slices.Contains(requiredRoles, models.UserRole(currentUserRole))
+		if claims.Kind == models.UserTypeService {
+			revokedAt, _ := cache.Get(utils.RevokedCredentialKey(claims.CredentialID))
+			if revoked, err := strconv.ParseInt(revokedAt, 10, 64); err == nil && claims.IssuedAt != nil && claims.IssuedAt.Unix() <= revoked {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token is not valid"})
+				return
+			}
+
+			scope := models.ServiceAccountScopeDTO{}
+			if claims.Scope != nil {
+				scope = *claims.Scope
+			}
+			c.Set("kind", claims.Kind)
+			c.Set("credentialID", claims.CredentialID)
+			c.Set("serviceScope", scope)
+		}
 
-			if !roleFound {
-				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Permission denied: insufficient role"})
+		if suspended, _ := cache.Exists(utils.SuspendedUserKey(claims.UserID)); suspended {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "User is suspended"})
+			return
+		}
+		if locked, _ := cache.Exists(utils.LockedUserKey(claims.UserID)); locked {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Account is locked"})
+			return
+		}
+		revokedAt, _ := cache.Get(utils.RevokedSessionsKey(claims.UserID))
+		if revoked, err := strconv.ParseInt(revokedAt, 10, 64); err == nil && claims.IssuedAt != nil && claims.IssuedAt.Unix() <= revoked {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token is not valid"})
+			return
+		}
+
+		if !checkPermissions(c, claims.OrgID, currentUserRole, requiredPermissions) {
+			return
+		}
+
+		if scope, ok := c.Get("serviceScope"); ok {
+			serviceScope := scope.(models.ServiceAccountScopeDTO)
+			if !checkServiceAccountScope(c, &serviceScope) {
 				return
 			}
 		}
@@ -96,3 +138,64 @@ func AuthMiddleware(jwtSecretParam []byte, requiredRoles []models.UserRole) gin.
 		c.Next()
 	}
 }
+
+func authenticatePersonalAccessToken(c *gin.Context, tokenString string, requiredPermissions []models.OrgPermission, requiredScopes []models.TokenScope) {
+	if len(requiredScopes) == 0 {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Personal access tokens are not accepted on this route"})
+		return
+	}
+
+	token, user, err := services.AuthenticatePersonalAccessToken(tokenString)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token is not valid"})
+		return
+	}
+	revokedAt, _ := cache.Get(utils.RevokedSessionsKey(user.ID.Hex()))
+	if revoked, err := strconv.ParseInt(revokedAt, 10, 64); err == nil && token.CreatedAt.Time().Unix() <= revoked {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token is not valid"})
+		return
+	}
+
+	for _, scope := range requiredScopes {
+		if !models.HasScope(token.Scopes, scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Permission denied: token lacks scope %s", scope)})
+			return
+		}
+	}
+
+	if !checkPermissions(c, user.OrgID.Hex(), user.Role, requiredPermissions) {
+		return
+	}
+
+	c.Set("userID", user.ID.Hex())
+	c.Set("orgID", user.OrgID.Hex())
+	c.Set("role", user.Role)
+	c.Set("tokenScopes", token.Scopes)
+
+	c.Next()
+}
+
+// checkPermissions guarda as permissões da função no contexto, para os handlers
+// que decidem por conta própria, e aborta se faltar alguma exigida pela rota.
+func checkPermissions(c *gin.Context, orgID string, role models.UserRole, requiredPermissions []models.OrgPermission) bool {
+	permissions := []models.OrgPermission{}
+	if role != "" {
+		resolved, err := services.RolePermissions(orgID, role)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token is not valid"})
+			return false
+		}
+		if resolved != nil {
+			permissions = resolved
+		}
+	}
+	c.Set("permissions", permissions)
+
+	for _, permission := range requiredPermissions {
+		if !models.HasPermission(permissions, permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Permission denied: missing permission %s", permission)})
+			return false
+		}
+	}
+	return true
+}
@@ -6,6 +6,8 @@ import (
 	"github.com/JGLTechnologies/gin-rate-limit"
 	"github.com/gin-gonic/gin"
 	"lembrago.com/lembrago/cache"
+	"lembrago.com/lembrago/models"
+	"lembrago.com/lembrago/services"
 )
 
 func keyFunc(c *gin.Context) string {
@@ -13,6 +15,7 @@ func keyFunc(c *gin.Context) string {
 }
 
 func errorHandler(c *gin.Context, info ratelimit.Info) {
+	services.ReportRateLimitHit(models.RequestMeta{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}, c.Request.Method, c.FullPath())
 	c.String(429, "Too many requests. Try again in "+time.Until(info.ResetTime).String())
 }
 
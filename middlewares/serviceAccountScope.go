@@ -0,0 +1,135 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"lembrago.com/lembrago/models"
+	"lembrago.com/lembrago/services"
+)
+
+// Rotas que um token de conta de serviço pode chamar mesmo com escopo de
+// leitura ou restrito a alguns cofres.
+var serviceAccountFreeRoutes = []string{
+	"GET /users/me",
+	"GET /users/vaults",
+	"DELETE /signout",
+	"POST /service-accounts/credentials/rotate",
+}
+
+type scopedRequestBody struct {
+	VaultID    string `json:"vaultId"`
+	PasswordID string `json:"passwordId"`
+	Operations []struct {
+		VaultID    string `json:"vaultId"`
+		PasswordID string `json:"passwordId"`
+	} `json:"operations"`
+}
+
+type scopedObjects struct {
+	vaults, passwords, attachments []string
+}
+
+// vaultScopedRoutes diz de onde tirar os cofres tocados por cada rota aceita
+// para tokens restritos a cofres. Rotas fora da lista são negadas.
+var vaultScopedRoutes = map[string]func(c *gin.Context, body *scopedRequestBody) scopedObjects{
+	"PUT /vaults": func(c *gin.Context, body *scopedRequestBody) scopedObjects {
+		return scopedObjects{vaults: []string{body.VaultID}}
+	},
+	"GET /vaults/passwords": func(c *gin.Context, body *scopedRequestBody) scopedObjects {
+		return scopedObjects{vaults: []string{c.Query("vaultId")}}
+	},
+	"POST /vaults/passwords": func(c *gin.Context, body *scopedRequestBody) scopedObjects {
+		return scopedObjects{vaults: []string{body.VaultID}}
+	},
+	"PUT /vaults/passwords": func(c *gin.Context, body *scopedRequestBody) scopedObjects {
+		return scopedObjects{passwords: []string{body.PasswordID}}
+	},
+	"DELETE /vaults/passwords": func(c *gin.Context, body *scopedRequestBody) scopedObjects {
+		return scopedObjects{passwords: []string{c.Query("id")}}
+	},
+	"POST /vaults/passwords/batch": func(c *gin.Context, body *scopedRequestBody) scopedObjects {
+		var objects scopedObjects
+		for _, operation := range body.Operations {
+			if operation.VaultID != "" {
+				objects.vaults = append(objects.vaults, operation.VaultID)
+			}
+			if operation.PasswordID != "" {
+				objects.passwords = append(objects.passwords, operation.PasswordID)
+			}
+		}
+		return objects
+	},
+	"GET /vaults/attachments": func(c *gin.Context, body *scopedRequestBody) scopedObjects {
+		return scopedObjects{passwords: []string{c.Query("passwordId")}}
+	},
+	"POST /vaults/attachments": func(c *gin.Context, body *scopedRequestBody) scopedObjects {
+		return scopedObjects{passwords: []string{body.PasswordID}}
+	},
+	"PUT /vaults/attachments/:id/chunks/:index": attachmentFromPath,
+	"GET /vaults/attachments/:id/chunks/:index": attachmentFromPath,
+	"POST /vaults/attachments/:id/complete":     attachmentFromPath,
+	"DELETE /vaults/attachments/:id":            attachmentFromPath,
+}
+
+func attachmentFromPath(c *gin.Context, body *scopedRequestBody) scopedObjects {
+	return scopedObjects{attachments: []string{c.Param("id")}}
+}
+
+// checkServiceAccountScope aplica o escopo da credencial que gerou o token.
+// Devolve false quando a requisição já foi abortada.
+func checkServiceAccountScope(c *gin.Context, scope *models.ServiceAccountScopeDTO) bool {
+	route := c.Request.Method + " " + c.FullPath()
+	if slices.Contains(serviceAccountFreeRoutes, route) {
+		return true
+	}
+
+	if scope.ReadOnly && c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Permission denied: read-only token"})
+		return false
+	}
+	if len(scope.VaultIDs) == 0 {
+		return true
+	}
+
+	extract, ok := vaultScopedRoutes[route]
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Permission denied: token is limited to specific vaults"})
+		return false
+	}
+
+	var body scopedRequestBody
+	if c.Request.Body != nil && (c.Request.Method == http.MethodPost || c.Request.Method == http.MethodPut) && c.ContentType() == "application/json" {
+		data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, 4<<20))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return false
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(data))
+		json.Unmarshal(data, &body)
+	}
+
+	objects := extract(c, &body)
+	vaultIDs, err := services.ResolveRequestVaultIDs(objects.vaults, objects.passwords, objects.attachments)
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return false
+	}
+
+	if len(vaultIDs) == 0 {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Permission denied: token is limited to specific vaults"})
+		return false
+	}
+	for _, vaultID := range vaultIDs {
+		if !slices.Contains(scope.VaultIDs, vaultID) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Permission denied: vault is outside the token scope"})
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,99 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type AccessRequestStatus string
+
+const (
+	AccessRequestPending   AccessRequestStatus = "pending"
+	AccessRequestApproved  AccessRequestStatus = "approved"
+	AccessRequestRejected  AccessRequestStatus = "rejected"
+	AccessRequestCancelled AccessRequestStatus = "cancelled"
+	AccessRequestExpired   AccessRequestStatus = "expired"
+)
+
+// AccessRequest é o pedido de um membro da organização para entrar num cofre do
+// diretório. History guarda cada mudança de estado, com quem fez e quando.
+type AccessRequest struct {
+	ID            primitive.ObjectID   `bson:"_id"`
+	OrgID         primitive.ObjectID   `bson:"orgId"`
+	VaultID       primitive.ObjectID   `bson:"vaultId"`
+	VaultName     string               `bson:"vaultName"` // DirectoryName no momento do pedido
+	UserID        primitive.ObjectID   `bson:"userId"`
+	Permission    VaultPermission      `bson:"permission"`
+	Justification string               `bson:"justification"`
+	Status        AccessRequestStatus  `bson:"status"`
+	DecidedBy     *primitive.ObjectID  `bson:"decidedBy,omitempty"`
+	DecidedAt     *primitive.DateTime  `bson:"decidedAt,omitempty"`
+	DecisionNote  string               `bson:"decisionNote,omitempty"`
+	CreatedAt     primitive.DateTime   `bson:"createdAt"`
+	ExpiresAt     primitive.DateTime   `bson:"expiresAt"`
+	History       []AccessRequestEvent `bson:"history"`
+}
+
+type AccessRequestEvent struct {
+	Status  AccessRequestStatus `bson:"status"`
+	ActorID *primitive.ObjectID `bson:"actorId,omitempty"`
+	Note    string              `bson:"note,omitempty"`
+	At      primitive.DateTime  `bson:"at"`
+}
+
+type UpdateVaultDirectoryRequest struct {
+	VaultID     string `json:"vaultId" validate:"required"`
+	Requestable bool   `json:"requestable"`
+	DisplayName string `json:"displayName" validate:"max=100"`
+}
+
+// DirectoryVaultResponse é o que membros da organização veem de um cofre
+// que não é deles: só o nome escolhido pelo admin.
+type DirectoryVaultResponse struct {
+	VaultID          string `json:"vaultId"`
+	DisplayName      string `json:"displayName"`
+	IsMember         bool   `json:"isMember"`
+	PendingRequestID string `json:"pendingRequestId,omitempty"`
+}
+
+// Pedidos não dão permissão de admin do cofre.
+type CreateAccessRequestRequest struct {
+	VaultID       string          `json:"vaultId" validate:"required"`
+	Permission    VaultPermission `json:"permission" validate:"required,oneof=write read"`
+	Justification string          `json:"justification" validate:"required,max=1000"`
+}
+
+// ApproveAccessRequestRequest segue ConfirmPendingShareRequest: o ESVK vem
+// embrulhado para a chave cuja impressão digital o admin conferiu.
+type ApproveAccessRequestRequest struct {
+	Fingerprint    string `json:"fingerprint" validate:"required"`
+	ESVK_PubK_User string `json:"esvk_pubK_user" validate:"required"`
+	Note           string `json:"note" validate:"max=1000"`
+}
+
+type RejectAccessRequestRequest struct {
+	Note string `json:"note" validate:"max=1000"`
+}
+
+type AccessRequestResponse struct {
+	ID            string                       `json:"id"`
+	VaultID       string                       `json:"vaultId"`
+	VaultName     string                       `json:"vaultName"`
+	UserID        string                       `json:"userId"`
+	Email         string                       `json:"email,omitempty"`
+	PublicKey     string                       `json:"publicKey,omitempty"`
+	Fingerprint   string                       `json:"fingerprint,omitempty"`
+	Permission    string                       `json:"permission"`
+	Justification string                       `json:"justification"`
+	Status        AccessRequestStatus          `json:"status"`
+	DecidedBy     string                       `json:"decidedBy,omitempty"`
+	DecidedAt     string                       `json:"decidedAt,omitempty"`
+	DecisionNote  string                       `json:"decisionNote,omitempty"`
+	CreatedAt     string                       `json:"createdAt"`
+	ExpiresAt     string                       `json:"expiresAt"`
+	History       []AccessRequestEventResponse `json:"history"`
+}
+
+type AccessRequestEventResponse struct {
+	Status  AccessRequestStatus `json:"status"`
+	ActorID string              `json:"actorId,omitempty"`
+	Note    string              `json:"note,omitempty"`
+	At      string              `json:"at"`
+}
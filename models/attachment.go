@@ -0,0 +1,53 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Attachment guarda apenas os metadados de um arquivo anexado a um item.
+// O conteúdo é cifrado pelo cliente em chunks e salvo em disco pelo servidor.
+type Attachment struct {
+	ID                primitive.ObjectID `bson:"_id"`
+	OrgID             primitive.ObjectID `bson:"orgId"`
+	VaultID           primitive.ObjectID `bson:"vaultId"`
+	PasswordID        primitive.ObjectID `bson:"passwordId"`
+	EncryptedMetadata EncryptedKey       `bson:"encryptedMetadata"` // nome, mime type etc.
+	Size              int64              `bson:"size"`              // Bytes cifrados
+	ChunkCount        int                `bson:"chunkCount"`
+	ChunkSizes        []int64            `bson:"chunkSizes"`
+	Status            AttachmentStatus   `bson:"status"`
+	CreatedBy         primitive.ObjectID `bson:"createdBy"`
+	CreatedAt         primitive.DateTime `bson:"createdAt"`
+	UpdatedAt         primitive.DateTime `bson:"updatedAt"`
+}
+
+type CreateAttachmentRequest struct {
+	PasswordID        string          `json:"passwordId" validate:"required"`
+	EncryptedMetadata EncryptedKeyDto `json:"encryptedMetadata" validate:"required"`
+	Size              int64           `json:"size" validate:"required,min=1"`
+	ChunkCount        int             `json:"chunkCount" validate:"required,min=1"`
+}
+
+type AttachmentResponse struct {
+	ID                string          `json:"id"`
+	VaultID           string          `json:"vaultId"`
+	PasswordID        string          `json:"passwordId"`
+	EncryptedMetadata EncryptedKeyDto `json:"encryptedMetadata"`
+	Size              int64           `json:"size"`
+	ChunkCount        int             `json:"chunkCount"`
+	ChunkSizes        []int64         `json:"chunkSizes"`
+	Status            string          `json:"status"`
+	CreatedBy         string          `json:"createdBy"`
+	CreatedAt         string          `json:"createdAt"`
+	UpdatedAt         string          `json:"updatedAt"`
+}
+
+type StorageUsageResponse struct {
+	Used  int64 `json:"used"`  // Bytes
+	Quota int64 `json:"quota"` // Bytes
+}
+
+type AttachmentStatus string
+
+const (
+	AttachmentUploading AttachmentStatus = "uploading"
+	AttachmentAvailable AttachmentStatus = "available"
+)
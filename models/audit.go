@@ -0,0 +1,120 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RequestMeta é o que os controllers repassam da requisição para os serviços
+// registrarem de onde veio cada ação.
+type RequestMeta struct {
+	IP        string
+	UserAgent string
+}
+
+type AuditAction string
+
+const (
+	AuditLogin             AuditAction = "auth.login"
+	AuditLoginFailed       AuditAction = "auth.login_failed"
+	AuditAuthCodeRequested AuditAction = "auth.code_requested"
+	AuditAuthCodeVerified  AuditAction = "auth.code_verified"
+	AuditNewDeviceLogin    AuditAction = "auth.new_device"
+	AuditAccountLocked     AuditAction = "user.locked"
+	AuditAccountUnlocked   AuditAction = "user.unlocked"
+	AuditUserInvited       AuditAction = "user.invited"
+	AuditUserRoleChanged   AuditAction = "user.role_changed"
+	AuditUserDeleted       AuditAction = "user.deleted"
+	AuditVaultCreated      AuditAction = "vault.created"
+	AuditVaultUpdated      AuditAction = "vault.updated"
+	AuditVaultDeleted      AuditAction = "vault.deleted"
+	AuditMemberAdded       AuditAction = "member.added"
+	AuditMemberUpdated     AuditAction = "member.updated"
+	AuditMemberRemoved     AuditAction = "member.removed"
+	AuditItemCreated       AuditAction = "item.created"
+	AuditItemUpdated       AuditAction = "item.updated"
+	AuditItemDeleted       AuditAction = "item.deleted"
+	AuditItemsRead         AuditAction = "items.read"
+)
+
+const (
+	AuditTargetUser   = "user"
+	AuditTargetInvite = "invite"
+	AuditTargetVault  = "vault"
+	AuditTargetMember = "member"
+	AuditTargetItem   = "item"
+)
+
+// AuditEntry é um registro do log de auditoria. Os registros de cada
+// organização formam uma cadeia: Hash cobre o conteúdo e o Hash anterior, então
+// alterar ou apagar um registro do meio quebra a verificação.
+type AuditEntry struct {
+	ID         primitive.ObjectID  `bson:"_id"`
+	OrgID      primitive.ObjectID  `bson:"orgId"`
+	Seq        int64               `bson:"seq"`
+	Action     AuditAction         `bson:"action"`
+	ActorID    *primitive.ObjectID `bson:"actorId,omitempty"`
+	TargetType string              `bson:"targetType,omitempty"`
+	TargetID   string              `bson:"targetId,omitempty"`
+	VaultID    *primitive.ObjectID `bson:"vaultId,omitempty"`
+	IP         string              `bson:"ip,omitempty"`
+	UserAgent  string              `bson:"userAgent,omitempty"`
+	Details    map[string]string   `bson:"details,omitempty"`
+	CreatedAt  primitive.DateTime  `bson:"createdAt"`
+	PrevHash   []byte              `bson:"prevHash,omitempty"`
+	Hash       []byte              `bson:"hash"`
+}
+
+// AuditFilter são os filtros já convertidos que o repositório entende.
+type AuditFilter struct {
+	OrgID    primitive.ObjectID
+	Action   AuditAction
+	ActorID  *primitive.ObjectID
+	TargetID string
+	VaultID  *primitive.ObjectID
+	From     *time.Time
+	To       *time.Time
+}
+
+type AuditQuery struct {
+	Action   string `form:"action" validate:"max=64"`
+	ActorID  string `form:"actorId"`
+	TargetID string `form:"targetId" validate:"max=320"`
+	VaultID  string `form:"vaultId"`
+	From     string `form:"from"` // RFC3339
+	To       string `form:"to"`   // RFC3339
+	Page     int    `form:"page" validate:"min=0"`
+	Limit    int    `form:"limit" validate:"min=0,max=200"`
+}
+
+type AuditEntryResponse struct {
+	ID         string            `json:"id"`
+	Seq        int64             `json:"seq"`
+	Action     AuditAction       `json:"action"`
+	ActorID    string            `json:"actorId,omitempty"`
+	TargetType string            `json:"targetType,omitempty"`
+	TargetID   string            `json:"targetId,omitempty"`
+	VaultID    string            `json:"vaultId,omitempty"`
+	IP         string            `json:"ip,omitempty"`
+	UserAgent  string            `json:"userAgent,omitempty"`
+	Details    map[string]string `json:"details,omitempty"`
+	CreatedAt  string            `json:"createdAt"`
+	Hash       string            `json:"hash"`
+}
+
+type AuditLogResponse struct {
+	Entries []AuditEntryResponse `json:"entries"`
+	Page    int                  `json:"page"`
+	Limit   int                  `json:"limit"`
+	Total   int64                `json:"total"`
+}
+
+// AuditVerifyResponse traz o resultado da verificação da cadeia; quando ela
+// está quebrada, FirstInvalidSeq aponta o primeiro registro que não confere.
+type AuditVerifyResponse struct {
+	Valid           bool   `json:"valid"`
+	Checked         int64  `json:"checked"`
+	FirstInvalidSeq *int64 `json:"firstInvalidSeq,omitempty"`
+	Reason          string `json:"reason,omitempty"`
+}
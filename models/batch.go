@@ -0,0 +1,33 @@
+package models
+
+type BatchItemOperation struct {
+	Op                string           `json:"op" validate:"required,oneof=create update delete"`
+	VaultID           string           `json:"vaultId"`           // create
+	PasswordID        string           `json:"passwordId"`        // update e delete
+	EncryptedItemData *EncryptedKeyDto `json:"encryptedItemData"` // create e update
+	Revision          *int64           `json:"revision"`          // obrigatório no update, opcional no delete
+}
+
+type BatchItemsRequest struct {
+	Operations []BatchItemOperation `json:"operations" validate:"required,min=1,max=500,dive"`
+}
+
+type BatchOperationResult struct {
+	Index      int               `json:"index"`
+	Op         string            `json:"op"`
+	Status     int               `json:"status"`
+	PasswordID string            `json:"passwordId,omitempty"`
+	Item       *PasswordResponse `json:"item,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+type BatchItemsResponse struct {
+	Applied bool                   `json:"applied"`
+	Results []BatchOperationResult `json:"results"`
+}
+
+const (
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpDelete = "delete"
+)
@@ -0,0 +1,25 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// UserDevice é um aparelho de onde o usuário já entrou. Fingerprint identifica o
+// aparelho pelo deviceId enviado pelo cliente ou, sem ele, pelo user agent; o IP
+// só é guardado para mostrar no alerta, porque muda com frequência.
+type UserDevice struct {
+	ID          primitive.ObjectID `bson:"_id"`
+	UserID      primitive.ObjectID `bson:"userId"`
+	OrgID       primitive.ObjectID `bson:"orgId"`
+	Fingerprint string             `bson:"fingerprint"`
+	DeviceID    string             `bson:"deviceId,omitempty"`
+	UserAgent   string             `bson:"userAgent"`
+	IP          string             `bson:"ip"`
+	FirstSeenAt primitive.DateTime `bson:"firstSeenAt"`
+	LastSeenAt  primitive.DateTime `bson:"lastSeenAt"`
+}
+
+// DeviceReport é o que o link "não fui eu" do alerta de novo aparelho guarda no
+// cache até ser usado.
+type DeviceReport struct {
+	UserID   string `json:"userId"`
+	DeviceID string `json:"deviceId"`
+}
@@ -0,0 +1,31 @@
+package models
+
+type ChangeEvent struct {
+	Type     EventType `json:"type"`
+	OrgID    string    `json:"orgId"`
+	VaultID  string    `json:"vaultId"`
+	ObjectID string    `json:"objectId,omitempty"`
+	ActorID  string    `json:"actorId,omitempty"`
+	At       string    `json:"at"`
+}
+
+// EventEnvelope é o que trafega no pub/sub do Redis entre as instâncias.
+type EventEnvelope struct {
+	Recipients []string    `json:"recipients"`
+	Event      ChangeEvent `json:"event"`
+}
+
+type EventType string
+
+const (
+	EventVaultCreated  EventType = "vault.created"
+	EventVaultUpdated  EventType = "vault.updated"
+	EventVaultRemoved  EventType = "vault.removed"
+	EventMemberAdded   EventType = "member.added"
+	EventMemberUpdated EventType = "member.updated"
+	EventMemberRemoved EventType = "member.removed"
+	EventMemberExpired EventType = "member.expired"
+	EventItemCreated   EventType = "item.created"
+	EventItemUpdated   EventType = "item.updated"
+	EventItemDeleted   EventType = "item.deleted"
+)
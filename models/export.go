@@ -0,0 +1,93 @@
+package models
+
+import "encoding/json"
+
+const (
+	ExportFormat        = "lembrago-export"
+	ExportFormatVersion = 1
+	ExportSignatureAlg  = "ed25519"
+)
+
+// ExportBundle é o arquivo entregue ao usuário. A assinatura cobre exatamente
+// os bytes de Payload, por isso o arquivo deve ser reenviado sem reformatação.
+type ExportBundle struct {
+	Payload   json.RawMessage `json:"payload" validate:"required"`
+	Signature ExportSignature `json:"signature" validate:"required"`
+}
+
+type ExportSignature struct {
+	Algorithm string `json:"algorithm" validate:"required"`
+	KeyID     string `json:"keyId"`
+	PublicKey string `json:"publicKey" validate:"required"` // base64
+	Value     string `json:"value" validate:"required"`     // base64
+}
+
+type ExportPayload struct {
+	Format     string         `json:"format"`
+	Version    int            `json:"version"`
+	ExportedAt string         `json:"exportedAt"`
+	Server     string         `json:"server"`
+	Exporter   ExportExporter `json:"exporter"`
+	Vaults     []ExportVault  `json:"vaults"`
+}
+
+type ExportExporter struct {
+	UserID    string `json:"userId"`
+	OrgID     string `json:"orgId"`
+	Email     string `json:"email"`
+	PublicKey string `json:"publicKey"` // chave que abre os ESVK_PubK_User do bundle
+}
+
+type ExportVault struct {
+	ID                     string          `json:"id"`
+	PersonalVault          bool            `json:"personalVault"`
+	Permission             string          `json:"permission"`
+	EncryptedVaultMetadata EncryptedKeyDto `json:"encryptedVaultMetadata"`
+	ESVK_PubK_User         string          `json:"esvkPubKUser"`
+	Revision               int64           `json:"revision"`
+	CreatedAt              string          `json:"createdAt"`
+	UpdatedAt              string          `json:"updatedAt"`
+	Items                  []ExportItem    `json:"items"`
+}
+
+type ExportItem struct {
+	ID                string          `json:"id"`
+	EncryptedItemData EncryptedKeyDto `json:"encryptedItemData"`
+	Revision          int64           `json:"revision"`
+	CreatedAt         string          `json:"createdAt"`
+	UpdatedAt         string          `json:"updatedAt"`
+}
+
+type ExportPublicKeyResponse struct {
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"keyId"`
+	PublicKey string `json:"publicKey"`
+}
+
+type ImportRequest struct {
+	Bundle ExportBundle `json:"bundle" validate:"required"`
+	// Chave pública do servidor de origem (GET /vaults/export/public-key de lá).
+	// Desnecessária quando o bundle foi gerado por este servidor.
+	TrustedPublicKey string            `json:"trustedPublicKey"`
+	Vaults           []ImportVaultKeys `json:"vaults" validate:"dive"`
+}
+
+// ImportVaultKeys traz o ESVK reembrulhado pelo cliente para a chave pública atual
+// do usuário. Só é obrigatório quando essa chave difere da do exportador.
+type ImportVaultKeys struct {
+	ExportedVaultID string `json:"exportedVaultId" validate:"required"`
+	ESVK_PubK_User  string `json:"esvkPubKUser"`
+	Skip            bool   `json:"skip"`
+}
+
+type ImportedVault struct {
+	ExportedVaultID string `json:"exportedVaultId"`
+	VaultID         string `json:"vaultId"`
+	PersonalVault   bool   `json:"personalVault"`
+	Items           int    `json:"items"`
+}
+
+type ImportResponse struct {
+	Vaults  []ImportedVault `json:"vaults"`
+	Skipped []string        `json:"skipped"`
+}
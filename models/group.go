@@ -0,0 +1,108 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Group reúne usuários da organização para dar acesso a vários cofres de uma vez.
+type Group struct {
+	ID          primitive.ObjectID `bson:"_id"`
+	OrgID       primitive.ObjectID `bson:"orgId"`
+	Name        string             `bson:"name"`
+	Description string             `bson:"description,omitempty"`
+	CreatedBy   primitive.ObjectID `bson:"createdBy"`
+	CreatedAt   primitive.DateTime `bson:"createdAt"`
+	UpdatedAt   primitive.DateTime `bson:"updatedAt"`
+}
+
+type GroupMember struct {
+	ID      primitive.ObjectID `bson:"_id"`
+	GroupID primitive.ObjectID `bson:"groupId"`
+	OrgID   primitive.ObjectID `bson:"orgId"`
+	UserID  primitive.ObjectID `bson:"userId"`
+	AddedBy primitive.ObjectID `bson:"addedBy"`
+	AddAt   primitive.DateTime `bson:"addAt"`
+}
+
+// GroupVaultGrant dá a todos os membros do grupo acesso ao cofre com a permissão indicada.
+type GroupVaultGrant struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	GroupID    primitive.ObjectID `bson:"groupId"`
+	VaultID    primitive.ObjectID `bson:"vaultId"`
+	OrgID      primitive.ObjectID `bson:"orgId"`
+	Permission VaultPermission    `bson:"permission"`
+	GrantedBy  primitive.ObjectID `bson:"grantedBy"`
+	GrantedAt  primitive.DateTime `bson:"grantedAt"`
+}
+
+// KeyShareTask é um acesso vindo de um grupo que ainda espera o ESVK do usuário.
+// O servidor não tem a chave do cofre: um admin do cofre embrulha a chave para a
+// chave pública do usuário e conclui a tarefa, criando o VaultMember.
+type KeyShareTask struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	OrgID      primitive.ObjectID `bson:"orgId"`
+	VaultID    primitive.ObjectID `bson:"vaultId"`
+	UserID     primitive.ObjectID `bson:"userId"`
+	GroupID    primitive.ObjectID `bson:"groupId"`
+	Permission VaultPermission    `bson:"permission"`
+	CreatedAt  primitive.DateTime `bson:"createdAt"`
+}
+
+type CreateGroupRequest struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description" validate:"max=500"`
+}
+
+type UpdateGroupRequest struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description" validate:"max=500"`
+}
+
+type AddGroupMemberRequest struct {
+	UserID string `json:"userId" validate:"required"`
+}
+
+// Grupos não dão permissão de admin do cofre; admins continuam sendo adicionados um a um.
+type CreateGroupVaultGrantRequest struct {
+	VaultID    string          `json:"vaultId" validate:"required"`
+	GroupID    string          `json:"groupId" validate:"required"`
+	Permission VaultPermission `json:"permission" validate:"required,oneof=write read"`
+}
+
+// CompleteKeyShareTaskRequest leva, como em ConfirmPendingShareRequest, a
+// impressão digital da chave para a qual o ESVK foi embrulhado.
+type CompleteKeyShareTaskRequest struct {
+	Fingerprint    string `json:"fingerprint" validate:"required"`
+	ESVK_PubK_User string `json:"esvk_pubK_user" validate:"required"`
+}
+
+type GroupResponse struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	MemberIDs   []string `json:"memberIds"`
+	CreatedBy   string   `json:"createdBy"`
+	CreatedAt   string   `json:"createdAt"`
+	UpdatedAt   string   `json:"updatedAt"`
+}
+
+type GroupVaultGrantResponse struct {
+	ID         string `json:"id"`
+	GroupID    string `json:"groupId"`
+	GroupName  string `json:"groupName"`
+	VaultID    string `json:"vaultId"`
+	Permission string `json:"permission"`
+	GrantedBy  string `json:"grantedBy"`
+	GrantedAt  string `json:"grantedAt"`
+}
+
+// KeyShareTaskResponse traz a chave pública do usuário para o admin embrulhar o ESVK.
+type KeyShareTaskResponse struct {
+	ID          string `json:"id"`
+	VaultID     string `json:"vaultId"`
+	UserID      string `json:"userId"`
+	Email       string `json:"email"`
+	PublicKey   string `json:"publicKey"`
+	Fingerprint string `json:"fingerprint"`
+	GroupID     string `json:"groupId"`
+	Permission  string `json:"permission"`
+	CreatedAt   string `json:"createdAt"`
+}
@@ -0,0 +1,75 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Tipos de e-mail, com o mesmo nome do modelo em templates/<idioma>/.
+const (
+	MailWelcome               = "welcome"
+	MailInvite                = "invite"
+	MailAuthCode              = "auth_code"
+	MailAccessRequest         = "access_request"
+	MailAccessRequestDecision = "access_request_decision"
+	MailNewDevice             = "new_device"
+	MailMemberExpired         = "member_expired"
+)
+
+// Idiomas com modelos de e-mail.
+const (
+	LocalePtBR    = "pt-BR"
+	LocaleEnUS    = "en-US"
+	DefaultLocale = LocalePtBR
+)
+
+type OutboxStatus string
+
+const (
+	OutboxPending OutboxStatus = "pending"
+	OutboxDead    OutboxStatus = "dead"
+)
+
+// OutboxEmail é um e-mail na fila de saída. Enviado, ele sai da fila; depois da
+// última tentativa, ou se perder a validade antes de sair, fica como "dead"
+// para os administradores examinarem. NextAttemptAt também serve de trava,
+// como em WebhookDelivery.
+type OutboxEmail struct {
+	ID            primitive.ObjectID  `bson:"_id"`
+	Kind          string              `bson:"kind"`
+	To            string              `bson:"to"`
+	Subject       string              `bson:"subject"`
+	FromName      string              `bson:"fromName,omitempty"`
+	HTML          string              `bson:"html"`
+	Text          string              `bson:"text,omitempty"`
+	Status        OutboxStatus        `bson:"status"`
+	Attempts      int                 `bson:"attempts"`
+	NextAttemptAt primitive.DateTime  `bson:"nextAttemptAt"`
+	ExpiresAt     *primitive.DateTime `bson:"expiresAt,omitempty"`
+	LastError     string              `bson:"lastError,omitempty"`
+	CreatedAt     primitive.DateTime  `bson:"createdAt"`
+	UpdatedAt     primitive.DateTime  `bson:"updatedAt"`
+}
+
+type DeadLetterQuery struct {
+	Page  int `form:"page" validate:"min=0"`
+	Limit int `form:"limit" validate:"min=0,max=100"`
+}
+
+// OutboxEmailResponse não traz o corpo, que pode conter códigos de acesso.
+type OutboxEmailResponse struct {
+	ID        string       `json:"id"`
+	Kind      string       `json:"kind"`
+	To        string       `json:"to"`
+	Subject   string       `json:"subject"`
+	Status    OutboxStatus `json:"status"`
+	Attempts  int          `json:"attempts"`
+	LastError string       `json:"lastError,omitempty"`
+	ExpiresAt *string      `json:"expiresAt,omitempty"`
+	CreatedAt string       `json:"createdAt"`
+	UpdatedAt string       `json:"updatedAt"`
+}
+
+type DeadLettersResponse struct {
+	Messages []OutboxEmailResponse `json:"messages"`
+	Page     int                   `json:"page"`
+	Limit    int                   `json:"limit"`
+	Total    int64                 `json:"total"`
+}
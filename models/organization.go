@@ -11,6 +11,10 @@ type Organization struct {
 	ImageUrl           string             `bson:"imageUrl,omitempty" json:"imageUrl"`
 	SubscriptionPlan   SubscriptionPlan   `bson:"subscriptionPlan" json:"subscriptionPlan" validate:"required"`
 	SubscriptionStatus string             `bson:"subscriptionStatus" json:"subscriptionStatus"`
+	StorageQuota       int64              `bson:"storageQuota,omitempty" json:"storageQuota,omitempty"` // Bytes, sobrescreve o limite do plano
+	StorageUsed        *int64             `bson:"storageUsed,omitempty" json:"-"`                       // Bytes reservados pelos anexos; nil até o primeiro uso
+	Locale             string             `bson:"locale,omitempty" json:"locale,omitempty"`             // idioma padrão dos e-mails
+	Branding           OrgBranding        `bson:"branding,omitempty" json:"branding"`
 	UpdatedAt          primitive.DateTime `bson:"updatedAt" json:"updatedAt"`
 	CreatedAt          primitive.DateTime `bson:"createdAt" json:"createdAt"`
 }
@@ -21,6 +25,31 @@ type CreateOrganizationRequest struct {
 	SubscriptionPlan string            `json:"subscriptionPlan" validate:"required"`
 	User             CreateUserRequest `json:"user" validate:"required"`
 	ImageUrl         string            `json:"imageUrl,omitempty"`
+	Locale           string            `json:"locale,omitempty" validate:"omitempty,oneof=pt-BR en-US"`
+}
+
+// OrgBranding personaliza os e-mails enviados em nome da organização; o logo é
+// o ImageUrl. Campos vazios ficam com o visual do LemBRAGO.
+type OrgBranding struct {
+	SenderName      string `bson:"senderName,omitempty" json:"senderName"`
+	PrimaryColor    string `bson:"primaryColor,omitempty" json:"primaryColor"`
+	BackgroundColor string `bson:"backgroundColor,omitempty" json:"backgroundColor"`
+}
+
+type UpdateOrgBrandingRequest struct {
+	Locale          string `json:"locale" validate:"omitempty,oneof=pt-BR en-US"`
+	ImageUrl        string `json:"imageUrl" validate:"omitempty,url,max=2048"`
+	SenderName      string `json:"senderName" validate:"max=64"`
+	PrimaryColor    string `json:"primaryColor" validate:"omitempty,hexcolor"`
+	BackgroundColor string `json:"backgroundColor" validate:"omitempty,hexcolor"`
+}
+
+type OrgBrandingResponse struct {
+	Locale          string `json:"locale"`
+	ImageUrl        string `json:"imageUrl"`
+	SenderName      string `json:"senderName"`
+	PrimaryColor    string `json:"primaryColor"`
+	BackgroundColor string `json:"backgroundColor"`
 }
 
 type MinOrgWithTokenResponse struct {
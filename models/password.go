@@ -8,6 +8,7 @@ type Password struct {
 	EncryptedItemData EncryptedKey       `bson:"encryptedItemData"`
 	CreatedBy         primitive.ObjectID `bson:"createdBy"`
 	LastModifiedBy    primitive.ObjectID `bson:"lastModifiedBy"`
+	Revision          int64              `bson:"revision"`
 	CreatedAt         primitive.DateTime `bson:"createdAt"`
 	UpdatedAt         primitive.DateTime `bson:"updatedAt"`
 }
@@ -20,12 +21,14 @@ type CreatePasswordRequest struct {
 type UpdatePasswordRequest struct {
 	PasswordID        string          `json:"passwordId" validate:"required"`
 	EncryptedItemData EncryptedKeyDto `json:"encryptedItemData" validate:"required"`
+	Revision          *int64          `json:"revision"` // Ou o header If-Match
 }
 
 type PasswordResponse struct {
 	ID                string          `json:"id"`
 	VaultID           string          `json:"vaultId"`
 	EncryptedItemData EncryptedKeyDto `json:"encryptedItemData"`
+	Revision          int64           `json:"revision"`
 	CreatedAt         string          `json:"createdAt"`
 	UpdatedAt         string          `json:"updatedAt"`
 }
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PendingShare é um acesso a cofre concedido por e-mail antes de o usuário ter
+// par de chaves. Só vira VaultMember quando um admin do cofre confere a impressão
+// digital da chave pública e envia o ESVK.
+type PendingShare struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	OrgID      primitive.ObjectID `bson:"orgId"`
+	VaultID    primitive.ObjectID `bson:"vaultId"`
+	Email      string             `bson:"email"`
+	Permission VaultPermission    `bson:"permission"`
+	CreatedBy  primitive.ObjectID `bson:"createdBy"`
+	CreatedAt  primitive.DateTime `bson:"createdAt"`
+	ExpiresAt  primitive.DateTime `bson:"expiresAt"`
+}
+
+type PendingShareStatus string
+
+const (
+	// PendingShareWaiting: o e-mail ainda não tem conta na organização.
+	PendingShareWaiting PendingShareStatus = "waiting"
+	// PendingShareReady: a chave pública existe e espera a confirmação do admin.
+	PendingShareReady PendingShareStatus = "ready"
+)
+
+type CreatePendingShareRequest struct {
+	VaultID    string          `json:"vaultId" validate:"required"`
+	Email      string          `json:"email" validate:"required,email"`
+	Permission VaultPermission `json:"permission" validate:"required,oneof=admin write read"`
+}
+
+// ConfirmPendingShareRequest leva a impressão digital que o admin conferiu com o
+// usuário; se a chave no servidor mudou desde então, a confirmação é recusada.
+type ConfirmPendingShareRequest struct {
+	Fingerprint    string `json:"fingerprint" validate:"required"`
+	ESVK_PubK_User string `json:"esvk_pubK_user" validate:"required"`
+}
+
+type PendingShareResponse struct {
+	ID          string             `json:"id"`
+	VaultID     string             `json:"vaultId"`
+	Email       string             `json:"email"`
+	Permission  string             `json:"permission"`
+	Status      PendingShareStatus `json:"status"`
+	UserID      string             `json:"userId,omitempty"`
+	PublicKey   string             `json:"publicKey,omitempty"`
+	Fingerprint string             `json:"fingerprint,omitempty"`
+	CreatedBy   string             `json:"createdBy"`
+	CreatedAt   string             `json:"createdAt"`
+	ExpiresAt   string             `json:"expiresAt"`
+}
+
+// PublicKeyFingerprint resume a chave pública para ser conferida por outro canal:
+// os primeiros 20 bytes do SHA-256 em hexadecimal, em grupos de 4, ex.:
+// "3FA2 91C0 ...". Cliente e servidor usam a mesma função.
+func PublicKeyFingerprint(publicKey []byte) string {
+	sum := sha256.Sum256(publicKey)
+	encoded := strings.ToUpper(hex.EncodeToString(sum[:20]))
+
+	groups := make([]string, 0, len(encoded)/4)
+	for i := 0; i < len(encoded); i += 4 {
+		groups = append(groups, encoded[i:i+4])
+	}
+	return strings.Join(groups, " ")
+}
+
+// SameFingerprint compara impressões digitais ignorando espaços e caixa, já que
+// costumam ser digitadas ou coladas à mão.
+func SameFingerprint(a, b string) bool {
+	normalize := func(s string) string {
+		return strings.ToUpper(strings.Join(strings.Fields(s), ""))
+	}
+	return normalize(a) != "" && normalize(a) == normalize(b)
+}
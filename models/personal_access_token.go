@@ -0,0 +1,71 @@
+package models
+
+import (
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PersonalAccessToken é um token que o usuário cria para scripts, com escopos
+// e validade próprios. Só o hash do segredo fica no banco.
+type PersonalAccessToken struct {
+	ID         primitive.ObjectID  `bson:"_id"`
+	UserID     primitive.ObjectID  `bson:"userId"`
+	OrgID      primitive.ObjectID  `bson:"orgId"`
+	Name       string              `bson:"name"`
+	SecretHash []byte              `bson:"secretHash"`
+	Scopes     []TokenScope        `bson:"scopes"`
+	CreatedAt  primitive.DateTime  `bson:"createdAt"`
+	ExpiresAt  primitive.DateTime  `bson:"expiresAt"`
+	LastUsedAt *primitive.DateTime `bson:"lastUsedAt,omitempty"`
+}
+
+// PersonalAccessTokenPrefix distingue os tokens pessoais dos JWT no header
+// Authorization e facilita achá-los com scanners de segredos.
+const PersonalAccessTokenPrefix = "lbpat_"
+
+type TokenScope string
+
+const (
+	ScopeVaultsRead    TokenScope = "vaults:read"
+	ScopeVaultsWrite   TokenScope = "vaults:write"
+	ScopeItemsRead     TokenScope = "items:read"
+	ScopeItemsWrite    TokenScope = "items:write"
+	ScopeMembersManage TokenScope = "members:manage"
+	ScopeOrgManage     TokenScope = "org:manage"
+	ScopeAuditRead     TokenScope = "audit:read"
+)
+
+// HasScope diz se os escopos cobrem o pedido; "x:write" inclui "x:read".
+func HasScope(scopes []TokenScope, required TokenScope) bool {
+	for _, scope := range scopes {
+		if scope == required {
+			return true
+		}
+		if resource, ok := strings.CutSuffix(string(required), ":read"); ok && string(scope) == resource+":write" {
+			return true
+		}
+	}
+	return false
+}
+
+type CreatePersonalAccessTokenRequest struct {
+	Name          string       `json:"name" validate:"required,max=100"`
+	Scopes        []TokenScope `json:"scopes" validate:"required,min=1,dive,oneof=vaults:read vaults:write items:read items:write members:manage org:manage audit:read"`
+	ExpiresInDays int          `json:"expiresInDays" validate:"required,min=1,max=365"`
+}
+
+type PersonalAccessTokenResponse struct {
+	ID         string       `json:"id"`
+	Name       string       `json:"name"`
+	Scopes     []TokenScope `json:"scopes"`
+	CreatedAt  string       `json:"createdAt"`
+	ExpiresAt  string       `json:"expiresAt"`
+	LastUsedAt *string      `json:"lastUsedAt"`
+}
+
+// CreatedPersonalAccessTokenResponse traz o token em claro, mostrado uma única vez.
+type CreatedPersonalAccessTokenResponse struct {
+	Token               string                      `json:"token"`
+	PersonalAccessToken PersonalAccessTokenResponse `json:"personalAccessToken"`
+}
@@ -0,0 +1,127 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// OrgPermission é uma permissão da organização. Funções (UserRole) são só
+// conjuntos delas; as rotas e serviços conferem permissões, não nomes de função.
+type OrgPermission string
+
+const (
+	PermOrgManage             OrgPermission = "org:manage"
+	PermUsersRead             OrgPermission = "users:read"
+	PermUsersInvite           OrgPermission = "users:invite"
+	PermUsersSuspend          OrgPermission = "users:suspend"
+	PermUsersDelete           OrgPermission = "users:delete"
+	PermRolesManage           OrgPermission = "roles:manage"
+	PermGroupsManage          OrgPermission = "groups:manage"
+	PermServiceAccountsManage OrgPermission = "service-accounts:manage"
+	PermVaultsUse             OrgPermission = "vaults:use"
+	PermVaultsManage          OrgPermission = "vaults:manage"
+	PermAuditRead             OrgPermission = "audit:read"
+	PermReportsRead           OrgPermission = "reports:read"
+)
+
+type PermissionInfo struct {
+	Permission  OrgPermission `json:"permission"`
+	Description string        `json:"description"`
+}
+
+// PermissionCatalog é a lista fechada de permissões com que as funções
+// personalizadas são montadas.
+var PermissionCatalog = []PermissionInfo{
+	{PermOrgManage, "Configurar a organização, mídias e transferências forçadas de cofres"},
+	{PermUsersRead, "Listar os usuários da organização"},
+	{PermUsersInvite, "Convidar usuários"},
+	{PermUsersSuspend, "Suspender e reativar usuários"},
+	{PermUsersDelete, "Remover usuários"},
+	{PermRolesManage, "Criar funções e atribuí-las a usuários"},
+	{PermGroupsManage, "Gerir grupos"},
+	{PermServiceAccountsManage, "Gerir contas de serviço"},
+	{PermVaultsUse, "Usar os cofres de que é membro e o cofre pessoal"},
+	{PermVaultsManage, "Criar cofres compartilhados e gerir seus membros"},
+	{PermAuditRead, "Ler o log de auditoria"},
+	{PermReportsRead, "Ler relatórios"},
+}
+
+const (
+	RoleAuditor      UserRole = "auditor"
+	RoleUserManager  UserRole = "user_manager"
+	RoleVaultManager UserRole = "vault_manager"
+)
+
+// BuiltInRoles existem em toda organização e não podem ser alterados. admin e
+// member mantêm o comportamento de antes das funções personalizadas, então
+// usuários existentes não precisam de migração.
+var BuiltInRoles = []Role{
+	{Key: RoleAdmin, Name: "Admin", BuiltIn: true, Permissions: allPermissions()},
+	{Key: RoleMember, Name: "Membro", BuiltIn: true, Permissions: []OrgPermission{PermVaultsUse}},
+	{Key: RoleAuditor, Name: "Auditor", BuiltIn: true, Permissions: []OrgPermission{PermAuditRead, PermReportsRead}},
+	{Key: RoleUserManager, Name: "Gestor de usuários", BuiltIn: true, Permissions: []OrgPermission{PermVaultsUse, PermUsersRead, PermUsersInvite, PermUsersSuspend}},
+	{Key: RoleVaultManager, Name: "Gestor de cofres", BuiltIn: true, Permissions: []OrgPermission{PermVaultsUse, PermVaultsManage}},
+}
+
+func allPermissions() []OrgPermission {
+	permissions := make([]OrgPermission, 0, len(PermissionCatalog))
+	for _, info := range PermissionCatalog {
+		permissions = append(permissions, info.Permission)
+	}
+	return permissions
+}
+
+func IsCatalogPermission(permission OrgPermission) bool {
+	for _, info := range PermissionCatalog {
+		if info.Permission == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func HasPermission(permissions []OrgPermission, required OrgPermission) bool {
+	for _, permission := range permissions {
+		if permission == required {
+			return true
+		}
+	}
+	return false
+}
+
+// Role é uma função da organização. Nas personalizadas, Key é o ID em
+// hexadecimal e é o valor gravado em User.Role.
+type Role struct {
+	ID          primitive.ObjectID `bson:"_id"`
+	OrgID       primitive.ObjectID `bson:"orgId"`
+	Key         UserRole           `bson:"key"`
+	Name        string             `bson:"name"`
+	Description string             `bson:"description,omitempty"`
+	Permissions []OrgPermission    `bson:"permissions"`
+	BuiltIn     bool               `bson:"-"`
+	CreatedBy   primitive.ObjectID `bson:"createdBy"`
+	CreatedAt   primitive.DateTime `bson:"createdAt"`
+	UpdatedAt   primitive.DateTime `bson:"updatedAt"`
+}
+
+type CreateRoleRequest struct {
+	Name        string          `json:"name" validate:"required,max=100"`
+	Description string          `json:"description" validate:"max=500"`
+	Permissions []OrgPermission `json:"permissions" validate:"required,min=1"`
+}
+
+type UpdateRoleRequest struct {
+	Name        string          `json:"name" validate:"required,max=100"`
+	Description string          `json:"description" validate:"max=500"`
+	Permissions []OrgPermission `json:"permissions" validate:"required,min=1"`
+}
+
+type RoleResponse struct {
+	Key         UserRole        `json:"key"`
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Permissions []OrgPermission `json:"permissions"`
+	BuiltIn     bool            `json:"builtIn"`
+}
+
+type UpdateUserStatusRequest struct {
+	UserID string     `json:"userId" validate:"required"`
+	Status UserStatus `json:"status" validate:"required,oneof=active suspended"`
+}
@@ -0,0 +1,83 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// ServiceAccountCredential é um par ID + segredo de uma conta de serviço. O
+// servidor guarda só o hash do segredo; as chaves da conta vêm cifradas com uma
+// chave derivada do mesmo segredo, então cada credencial tem a sua cópia.
+type ServiceAccountCredential struct {
+	ID               primitive.ObjectID  `bson:"_id"`
+	ServiceAccountID primitive.ObjectID  `bson:"serviceAccountId"`
+	OrgID            primitive.ObjectID  `bson:"orgId"`
+	Name             string              `bson:"name"`
+	SecretHash       []byte              `bson:"secretHash"`
+	Keys             Keys                `bson:"keys"`
+	Scope            ServiceAccountScope `bson:"scope"`
+	CreatedBy        primitive.ObjectID  `bson:"createdBy"`
+	CreatedAt        primitive.DateTime  `bson:"createdAt"`
+	ExpiresAt        *primitive.DateTime `bson:"expiresAt,omitempty"`
+	LastUsedAt       *primitive.DateTime `bson:"lastUsedAt,omitempty"`
+}
+
+// ServiceAccountScope limita o que os tokens de uma credencial podem fazer além
+// das permissões da conta nos cofres. VaultIDs vazio = todos os cofres da conta.
+type ServiceAccountScope struct {
+	ReadOnly bool                 `bson:"readOnly"`
+	VaultIDs []primitive.ObjectID `bson:"vaultIds"`
+}
+
+type ServiceAccountScopeDTO struct {
+	ReadOnly bool     `json:"readOnly"`
+	VaultIDs []string `json:"vaultIds,omitempty"`
+}
+
+type ServiceAccountCredentialRequest struct {
+	Name         string                 `json:"name"`
+	ClientSecret string                 `json:"clientSecret" validate:"required"` // chave de autenticação derivada do segredo, base64
+	Keys         KeysDTO                `json:"keys" validate:"required"`
+	Scope        ServiceAccountScopeDTO `json:"scope"`
+	ExpiresAt    string                 `json:"expiresAt"` // RFC3339, opcional
+}
+
+type CreateServiceAccountRequest struct {
+	Name       string                          `json:"name" validate:"required"`
+	Credential ServiceAccountCredentialRequest `json:"credential" validate:"required"`
+}
+
+type UpdateServiceAccountCredentialRequest struct {
+	Scope ServiceAccountScopeDTO `json:"scope"`
+}
+
+type ServiceAccountTokenRequest struct {
+	ClientID     string `json:"clientId" validate:"required"`
+	ClientSecret string `json:"clientSecret" validate:"required"`
+}
+
+type ServiceAccountResponse struct {
+	ID          string                             `json:"id"`
+	OrgID       string                             `json:"orgId"`
+	Name        string                             `json:"name"`
+	PublicKey   string                             `json:"publicKey"`
+	Status      UserStatus                         `json:"status"`
+	Credentials []ServiceAccountCredentialResponse `json:"credentials"`
+	CreatedAt   string                             `json:"createdAt"`
+	UpdatedAt   string                             `json:"updatedAt"`
+}
+
+type ServiceAccountCredentialResponse struct {
+	ID         string                 `json:"id"`
+	Name       string                 `json:"name"`
+	Scope      ServiceAccountScopeDTO `json:"scope"`
+	CreatedBy  string                 `json:"createdBy"`
+	CreatedAt  string                 `json:"createdAt"`
+	ExpiresAt  *string                `json:"expiresAt"`
+	LastUsedAt *string                `json:"lastUsedAt"`
+}
+
+// ServiceAccountTokenResponse traz o token curto e a conta com as chaves
+// cifradas para a credencial usada.
+type ServiceAccountTokenResponse struct {
+	Token     string       `json:"token"`
+	ExpiresAt string       `json:"expiresAt"`
+	User      UserResponse `json:"user"`
+}
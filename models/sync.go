@@ -0,0 +1,41 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Tombstone registra a remoção de um objeto para que clientes com cache offline
+// possam aplicá-la no próximo /sync. Quando UserID está presente, o tombstone só
+// é entregue a esse usuário (ex.: participação revogada).
+type Tombstone struct {
+	ID        primitive.ObjectID  `bson:"_id"`
+	OrgID     primitive.ObjectID  `bson:"orgId"`
+	VaultID   primitive.ObjectID  `bson:"vaultId"`
+	UserID    *primitive.ObjectID `bson:"userId,omitempty"`
+	Kind      TombstoneKind       `bson:"kind"`
+	ObjectID  primitive.ObjectID  `bson:"objectId"`
+	DeletedAt primitive.DateTime  `bson:"deletedAt"`
+}
+
+type TombstoneResponse struct {
+	Kind      string `json:"kind"`
+	ID        string `json:"id"`
+	VaultID   string `json:"vaultId"`
+	DeletedAt string `json:"deletedAt"`
+}
+
+type SyncResponse struct {
+	Token       string                `json:"token"`
+	Full        bool                  `json:"full"` // true quando o cliente deve descartar o cache local
+	Vaults      []VaultResponse       `json:"vaults"`
+	Memberships []VaultMemberResponse `json:"memberships"`
+	Items       []PasswordResponse    `json:"items"`
+	Tombstones  []TombstoneResponse   `json:"tombstones"`
+}
+
+type TombstoneKind string
+
+const (
+	TombstoneVault      TombstoneKind = "vault"
+	TombstoneMember     TombstoneKind = "member"
+	TombstoneItem       TombstoneKind = "item"
+	TombstoneAttachment TombstoneKind = "attachment"
+)
@@ -18,8 +18,10 @@ type User struct {
 	SaltEk []byte `bson:"saltEk" json:"saltEk"` // salt_ek
 	Keys   Keys   `bson:"keys" json:"keys"`
 
-	Role   UserRole   `bson:"role", json:"role"`
+	Role   UserRole   `bson:"role" json:"Role"` // "Role": a chave que o JSON sempre teve
 	Status UserStatus `bson:"status"`
+	Type   UserType   `bson:"type,omitempty" json:"type,omitempty"`     // vazio = pessoa
+	Locale string     `bson:"locale,omitempty" json:"locale,omitempty"` // vazio = idioma da organização
 
 	CreatedAt primitive.DateTime `bson:"createdAt" json:"createdAt"`
 	UpdatedAt primitive.DateTime `bson:"updatedAt" json:"updatedAt"`
@@ -47,6 +49,7 @@ type SavedMedia struct {
 
 type UserRole string
 type UserStatus string
+type UserType string
 
 const (
 	RoleAdmin  UserRole = "admin"
@@ -55,4 +58,13 @@ const (
 	StatusActive    UserStatus = "active"
 	StatusInvited   UserStatus = "invited"
 	StatusSuspended UserStatus = "suspended"
+	StatusLocked    UserStatus = "locked" // bloqueado pelo próprio usuário até verificar o e-mail
+
+	UserTypeService UserType = "service"
 )
+
+// IsServiceAccount indica uma conta de máquina, que não tem e-mail real nem
+// Senha Mestra e só entra com credenciais de cliente.
+func (u *User) IsServiceAccount() bool {
+	return u.Type == UserTypeService
+}
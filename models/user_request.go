@@ -15,11 +15,17 @@ type CreateUserRequest struct {
 	Keys KeysDTO `json:"keys"`
 
 	MyVault *CreateVaultRequest `json:"myVault"` // `json:"myVault" validate:"required"`
+
+	Locale string `json:"locale,omitempty" validate:"omitempty,oneof=pt-BR en-US"`
+}
+
+type UpdateUserPreferencesRequest struct {
+	Locale string `json:"locale" validate:"omitempty,oneof=pt-BR en-US"` // vazio = idioma da organização
 }
 
 type UpdateUserRoleRequest struct {
 	UserID string `json:"userId" validate:"required"`
-	Role   UserRole `json:"role" validate:"required,oneof=admin member"`
+	Role   UserRole `json:"role" validate:"required,max=64"`
 }
 
 type UserResponse struct {
@@ -28,6 +34,7 @@ type UserResponse struct {
 	OrgId    string   `json:"orgId"`
 	Username string   `bson:"username" json:"username" validate:"required"`
 	Role     UserRole `json:"role"`
+	Locale   string   `json:"locale,omitempty"`
 
 	PasswordVerifier PasswordVerifierResponse `json:"passwordVerifier"`
 	Salt_ek          string                   `json:"salt_ek" validate:"required"`
@@ -56,11 +63,12 @@ type UserLoginComparison struct {
 	OrgID    string `json:"orgId" validate:"required"`
 	Email    string `json:"email" validate:"required,email"`
 	Verifier string `json:"verifier" validate:"required"`
+	DeviceID string `json:"deviceId" validate:"max=128"`
 }
 
 type InviteUserRequest struct {
 	Email string   `json:"email" validate:"required,email"`
-	Role  UserRole `json:"role" validate:"required,oneof=admin member"`
+	Role  UserRole `json:"role" validate:"required,max=64"`
 }
 
 type MinimalUserInfoResponse struct {
@@ -71,6 +79,7 @@ type MinimalUserInfoResponse struct {
 	PublicKey string     `json:"publicKey"`
 	Role      UserRole   `json:"role"`
 	Status    UserStatus `json:"status"`
+	Type      UserType   `json:"type,omitempty"`
 	CreatedAt string     `json:"createdAt"`
 	UpdatedAt string     `json:"updatedAt"`
 }
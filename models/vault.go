@@ -8,8 +8,35 @@ type Vault struct {
 	EncryptedVaultMetadata EncryptedKey       `bson:"encryptedVaultMetadata"`
 	PersonalVault          bool               `bson:"personalVault"`
 	CreatedBy              primitive.ObjectID `bson:"createdBy"`
-	UpdatedAt              primitive.DateTime `bson:"updatedAt"`
-	CreatedAt              primitive.DateTime `bson:"createdAt"`
+	// OwnerID é quem pode atualizar e remover o cofre. Cofres antigos não têm o
+	// campo; para eles o dono é CreatedBy (ver Owner).
+	OwnerID   primitive.ObjectID `bson:"ownerId,omitempty"`
+	Revision  int64              `bson:"revision"`
+	UpdatedAt primitive.DateTime `bson:"updatedAt"`
+	CreatedAt primitive.DateTime `bson:"createdAt"`
+	// NeedsKeyRotation marca o cofre depois que alguém perdeu o acesso por
+	// expiração: essa pessoa ainda pode ter a chave do cofre em cache.
+	NeedsKeyRotation bool `bson:"needsKeyRotation,omitempty"`
+	// Requestable põe o cofre no diretório da organização com DirectoryName em
+	// texto claro, para que membros peçam acesso. Os metadados seguem cifrados.
+	Requestable   bool   `bson:"requestable,omitempty"`
+	DirectoryName string `bson:"directoryName,omitempty"`
+	// OwnershipTransfer é a transferência proposta pelo dono e ainda não aceita.
+	OwnershipTransfer *VaultOwnershipTransfer `bson:"ownershipTransfer,omitempty"`
+}
+
+func (v *Vault) Owner() primitive.ObjectID {
+	if v.OwnerID.IsZero() {
+		return v.CreatedBy
+	}
+	return v.OwnerID
+}
+
+type VaultOwnershipTransfer struct {
+	ToUserID    primitive.ObjectID `bson:"toUserId"`
+	RequestedBy primitive.ObjectID `bson:"requestedBy"`
+	RequestedAt primitive.DateTime `bson:"requestedAt"`
+	ExpiresAt   primitive.DateTime `bson:"expiresAt"`
 }
 
 type VaultMember struct {
@@ -21,6 +48,12 @@ type VaultMember struct {
 	Permission     VaultPermission    `bson:"permission"`
 	AddedBy        primitive.ObjectID `bson:"addedBy"`
 	AddAt          primitive.DateTime `bson:"addAt"`
+	UpdatedAt      primitive.DateTime `bson:"updatedAt"`
+	// GroupIDs lista os grupos que deram este acesso; vazio quando o membro foi
+	// adicionado diretamente.
+	GroupIDs []primitive.ObjectID `bson:"groupIds,omitempty"`
+	// ExpiresAt encerra o acesso automaticamente (prestadores, auditores).
+	ExpiresAt *primitive.DateTime `bson:"expiresAt,omitempty"`
 }
 
 type VaultWithMemberInfo struct {
@@ -29,6 +62,8 @@ type VaultWithMemberInfo struct {
 	EncryptedVaultMetadata EncryptedKeyDto `json:"encryptedVaultMetadata"` // String base64 no json?
 	PersonalVault          bool            `json:"personalVault"`
 	VaultCreatedBy         string          `json:"vaultCreatedBy"`
+	VaultOwnerID           string          `json:"vaultOwnerId"`
+	VaultRevision          int64           `json:"vaultRevision"`
 	VaultUpdatedAt         string          `json:"vaultUpdatedAt"` // Ou time.Time
 	VaultCreatedAt         string          `json:"vaultCreatedAt"` // Ou time.Time
 
@@ -37,6 +72,11 @@ type VaultWithMemberInfo struct {
 	ESVK_PubK_User string `json:"esvkPubKUser"` // Nome do campo como no json
 	AddedBy        string `json:"addedBy"`
 	AddAt          string `json:"addAt"` // Ou time.Time se preferir
+
+	ExpiresAt        *string `json:"expiresAt,omitempty"`
+	NeedsKeyRotation bool    `json:"needsKeyRotation"`
+
+	OwnershipTransfer *VaultOwnershipTransferResponse `json:"ownershipTransfer,omitempty"`
 }
 
 type VaultPermission string
@@ -6,21 +6,26 @@ type VaultResponse struct {
 	EncryptedVaultMetadata EncryptedKeyDto     `json:"encryptedVaultMetadata"`
 	MyMembership           VaultMemberResponse `json:"myMembership"`
 	PersonalVault          bool                `json:"personalVault"`
+	NeedsKeyRotation       bool                `json:"needsKeyRotation"`
 	CreatedBy              string              `json:"createdBy"`
+	OwnerID                string              `json:"ownerId"`
+	Revision               int64               `json:"revision"`
 	UpdatedAt              string              `json:"updatedAt"`
 	CreatedAt              string              `json:"createdAt"`
 }
 
 type VaultMemberResponse struct {
-	ID             string  `json:"id"`
-	VaultID        string  `json:"vaultId"`
-	UserID         string  `json:"userId"`
-	Username       *string `json:"username,omitempty"`
-	Email          string  `json:"email"`
-	ESVK_PubK_User string  `json:"esvk_pubK_user"`
-	Permission     string  `json:"permission"`
-	AddedBy        string  `json:"addedBy"`
-	AddAt          string  `json:"addAt"`
+	ID             string   `json:"id"`
+	VaultID        string   `json:"vaultId"`
+	UserID         string   `json:"userId"`
+	Username       *string  `json:"username,omitempty"`
+	Email          string   `json:"email"`
+	ESVK_PubK_User string   `json:"esvk_pubK_user"`
+	Permission     string   `json:"permission"`
+	AddedBy        string   `json:"addedBy"`
+	AddAt          string   `json:"addAt"`
+	GroupIDs       []string `json:"groupIds,omitempty"`
+	ExpiresAt      *string  `json:"expiresAt,omitempty"`
 }
 
 type CreateVaultRequest struct {
@@ -33,6 +38,9 @@ type UpdateVaultRequest struct {
 	VaultId                string          `json:"vaultId" validate:"required"`
 	EncryptedVaultMetadata EncryptedKeyDto `json:"e_vaultmetadata" validate:"required"`
 	ESVK_PubK_User         string          `json:"esvk_pubK_user" validate:"required"`
+	Revision               *int64          `json:"revision"` // Ou o header If-Match
+	// KeyRotated limpa NeedsKeyRotation depois que o cliente trocou a chave do cofre.
+	KeyRotated bool `json:"keyRotated"`
 }
 
 type CreateVaultMemberRequest struct {
@@ -40,10 +48,29 @@ type CreateVaultMemberRequest struct {
 	UserID         string          `json:"userId" validate:"required"`
 	ESVK_PubK_User string          `json:"esvk_pubK_user" validate:"required"`
 	Permission     VaultPermission `json:"permission" validate:"required,oneof=admin write read"`
+	ExpiresAt      string          `json:"expiresAt"` // RFC3339, opcional
 }
 
 type UpdateVaultMemberRequest struct {
 	MemberID       string          `json:"memberId" validate:"required"`
 	ESVK_PubK_User string          `json:"esvk_pubK_user" validate:"required"`
 	Permission     VaultPermission `json:"permission" validate:"required,oneof=admin write read"`
+	ExpiresAt      *string         `json:"expiresAt,omitempty"` // RFC3339; omitido mantém a expiração, vazio a remove
+}
+
+type TransferVaultOwnershipRequest struct {
+	VaultID    string `json:"vaultId" validate:"required"`
+	NewOwnerID string `json:"newOwnerId" validate:"required"`
+}
+
+type VaultOwnershipRequest struct {
+	VaultID string `json:"vaultId" validate:"required"`
+}
+
+type VaultOwnershipTransferResponse struct {
+	VaultID     string `json:"vaultId"`
+	ToUserID    string `json:"toUserId"`
+	RequestedBy string `json:"requestedBy"`
+	RequestedAt string `json:"requestedAt"`
+	ExpiresAt   string `json:"expiresAt"`
 }
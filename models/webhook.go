@@ -0,0 +1,129 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type WebhookEvent string
+
+const (
+	WebhookUserInvited     WebhookEvent = "user.invited"
+	WebhookUserRegistered  WebhookEvent = "user.registered"
+	WebhookUserRoleChanged WebhookEvent = "user.role_changed"
+	WebhookVaultCreated    WebhookEvent = "vault.created"
+	WebhookMemberAdded     WebhookEvent = "member.added"
+)
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+const (
+	WebhookSignatureHeader = "X-LemBRAGO-Signature"
+	WebhookEventHeader     = "X-LemBRAGO-Event"
+	WebhookDeliveryHeader  = "X-LemBRAGO-Delivery"
+)
+
+// Webhook é um endpoint da organização que recebe os eventos assinados. O
+// segredo fica guardado em claro porque o servidor precisa dele para assinar.
+type Webhook struct {
+	ID          primitive.ObjectID `bson:"_id"`
+	OrgID       primitive.ObjectID `bson:"orgId"`
+	URL         string             `bson:"url"`
+	Description string             `bson:"description,omitempty"`
+	Events      []WebhookEvent     `bson:"events"`
+	Secret      string             `bson:"secret"`
+	Active      bool               `bson:"active"`
+	CreatedBy   primitive.ObjectID `bson:"createdBy"`
+	CreatedAt   primitive.DateTime `bson:"createdAt"`
+	UpdatedAt   primitive.DateTime `bson:"updatedAt"`
+}
+
+// WebhookPayload é o corpo enviado ao endpoint. ID se mantém nas reentregas,
+// para o receptor descartar duplicatas.
+type WebhookPayload struct {
+	ID        string            `json:"id"`
+	Event     WebhookEvent      `json:"event"`
+	OrgID     string            `json:"orgId"`
+	CreatedAt string            `json:"createdAt"`
+	Data      map[string]string `json:"data"`
+}
+
+// WebhookDelivery é uma tentativa de entrega de um evento a um webhook, com o
+// corpo exato que foi assinado. NextAttemptAt também serve de trava: quem vai
+// tentar a entrega empurra o horário para frente antes de enviar.
+type WebhookDelivery struct {
+	ID             primitive.ObjectID    `bson:"_id"`
+	OrgID          primitive.ObjectID    `bson:"orgId"`
+	WebhookID      primitive.ObjectID    `bson:"webhookId"`
+	Event          WebhookEvent          `bson:"event"`
+	Payload        []byte                `bson:"payload"`
+	Status         WebhookDeliveryStatus `bson:"status"`
+	Attempts       int                   `bson:"attempts"`
+	NextAttemptAt  primitive.DateTime    `bson:"nextAttemptAt"`
+	LastStatusCode int                   `bson:"lastStatusCode,omitempty"`
+	LastError      string                `bson:"lastError,omitempty"`
+	RedeliveryOf   *primitive.ObjectID   `bson:"redeliveryOf,omitempty"`
+	CreatedAt      primitive.DateTime    `bson:"createdAt"`
+	UpdatedAt      primitive.DateTime    `bson:"updatedAt"`
+	DeliveredAt    *primitive.DateTime   `bson:"deliveredAt,omitempty"`
+}
+
+type CreateWebhookRequest struct {
+	URL         string         `json:"url" validate:"required,url,max=2048"`
+	Description string         `json:"description" validate:"max=200"`
+	Events      []WebhookEvent `json:"events" validate:"required,min=1,dive,oneof=user.invited user.registered user.role_changed vault.created member.added"`
+}
+
+type UpdateWebhookRequest struct {
+	URL         string         `json:"url" validate:"required,url,max=2048"`
+	Description string         `json:"description" validate:"max=200"`
+	Events      []WebhookEvent `json:"events" validate:"required,min=1,dive,oneof=user.invited user.registered user.role_changed vault.created member.added"`
+	Active      bool           `json:"active"`
+}
+
+type WebhookDeliveryQuery struct {
+	Page  int `form:"page" validate:"min=0"`
+	Limit int `form:"limit" validate:"min=0,max=100"`
+}
+
+type WebhookResponse struct {
+	ID          string         `json:"id"`
+	URL         string         `json:"url"`
+	Description string         `json:"description"`
+	Events      []WebhookEvent `json:"events"`
+	Active      bool           `json:"active"`
+	CreatedBy   string         `json:"createdBy"`
+	CreatedAt   string         `json:"createdAt"`
+	UpdatedAt   string         `json:"updatedAt"`
+}
+
+// CreatedWebhookResponse traz o segredo de assinatura, mostrado uma única vez.
+type CreatedWebhookResponse struct {
+	Secret  string          `json:"secret"`
+	Webhook WebhookResponse `json:"webhook"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID             string                `json:"id"`
+	WebhookID      string                `json:"webhookId"`
+	Event          WebhookEvent          `json:"event"`
+	Payload        string                `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  *string               `json:"nextAttemptAt,omitempty"`
+	LastStatusCode int                   `json:"lastStatusCode,omitempty"`
+	LastError      string                `json:"lastError,omitempty"`
+	RedeliveryOf   string                `json:"redeliveryOf,omitempty"`
+	CreatedAt      string                `json:"createdAt"`
+	DeliveredAt    *string               `json:"deliveredAt,omitempty"`
+}
+
+type WebhookDeliveriesResponse struct {
+	Deliveries []WebhookDeliveryResponse `json:"deliveries"`
+	Page       int                       `json:"page"`
+	Limit      int                       `json:"limit"`
+	Total      int64                     `json:"total"`
+}
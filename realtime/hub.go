@@ -0,0 +1,92 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"lembrago.com/lembrago/cache"
+	"lembrago.com/lembrago/models"
+)
+
+const channel = "lembrago:events"
+
+var ctx = context.Background()
+
+var hub = struct {
+	sync.RWMutex
+	clients map[string]map[chan models.ChangeEvent]struct{}
+}{clients: make(map[string]map[chan models.ChangeEvent]struct{})}
+
+// Publish envia o evento para todas as instâncias através do Redis.
+// Cada instância entrega apenas aos destinatários conectados nela.
+func Publish(recipients []string, event models.ChangeEvent) error {
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	if event.At == "" {
+		event.At = time.Now().Format(time.RFC3339)
+	}
+
+	payload, err := json.Marshal(models.EventEnvelope{Recipients: recipients, Event: event})
+	if err != nil {
+		return err
+	}
+
+	return cache.RedisClient.Publish(ctx, channel, payload).Err()
+}
+
+// Listen assina o canal de eventos e só retorna se a assinatura for encerrada.
+func Listen() {
+	pubsub := cache.RedisClient.Subscribe(ctx, channel)
+	defer pubsub.Close()
+
+	for msg := range pubsub.Channel() {
+		var envelope models.EventEnvelope
+		if err := json.Unmarshal([]byte(msg.Payload), &envelope); err != nil {
+			log.Printf("[realtime] invalid event payload: %v\n", err)
+			continue
+		}
+		deliver(envelope)
+	}
+}
+
+// Subscribe registra uma conexão do usuário. A função retornada deve ser
+// chamada quando a conexão terminar.
+func Subscribe(userID string) (<-chan models.ChangeEvent, func()) {
+	ch := make(chan models.ChangeEvent, 32)
+
+	hub.Lock()
+	if hub.clients[userID] == nil {
+		hub.clients[userID] = make(map[chan models.ChangeEvent]struct{})
+	}
+	hub.clients[userID][ch] = struct{}{}
+	hub.Unlock()
+
+	return ch, func() {
+		hub.Lock()
+		delete(hub.clients[userID], ch)
+		if len(hub.clients[userID]) == 0 {
+			delete(hub.clients, userID)
+		}
+		hub.Unlock()
+	}
+}
+
+func deliver(envelope models.EventEnvelope) {
+	hub.RLock()
+	defer hub.RUnlock()
+
+	for _, userID := range envelope.Recipients {
+		for ch := range hub.clients[userID] {
+			select {
+			case ch <- envelope.Event:
+			default:
+				// Cliente lento: o evento é descartado e ele se recupera pelo /sync.
+			}
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"lembrago.com/lembrago/database"
+	"lembrago.com/lembrago/models"
+)
+
+// CreateAccessRequest devolve false quando o usuário já tem um pedido pendente para o cofre.
+func CreateAccessRequest(request *models.AccessRequest) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("access_requests")
+	if request.ID == primitive.NilObjectID {
+		request.ID = primitive.NewObjectID()
+	}
+
+	_, err := collection.InsertOne(ctx, request)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func FindAccessRequestByID(id primitive.ObjectID) (*models.AccessRequest, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("access_requests")
+
+	var request models.AccessRequest
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	return &request, nil
+}
+
+func FindAccessRequestsByUserID(userID primitive.ObjectID) ([]models.AccessRequest, error) {
+	return findAccessRequests(bson.M{"userId": userID})
+}
+
+func FindPendingAccessRequestsByVaultIDs(vaultIDs []primitive.ObjectID) ([]models.AccessRequest, error) {
+	return findAccessRequests(bson.M{
+		"vaultId":   bson.M{"$in": vaultIDs},
+		"status":    models.AccessRequestPending,
+		"expiresAt": bson.M{"$gt": primitive.NewDateTimeFromTime(time.Now())},
+	})
+}
+
+// FindExpiredAccessRequests lista os pedidos pendentes cujo prazo já passou.
+func FindExpiredAccessRequests(now time.Time) ([]models.AccessRequest, error) {
+	return findAccessRequests(bson.M{
+		"status":    models.AccessRequestPending,
+		"expiresAt": bson.M{"$lte": primitive.NewDateTimeFromTime(now)},
+	})
+}
+
+func findAccessRequests(filter bson.M) ([]models.AccessRequest, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("access_requests")
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var requests []models.AccessRequest
+	if err = cursor.All(ctx, &requests); err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
+
+// CloseAccessRequest tira o pedido de pending e registra a mudança no histórico.
+// Devolve false se o pedido já tinha sido decidido, cancelado ou expirado.
+func CloseAccessRequest(id primitive.ObjectID, event models.AccessRequestEvent) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	set := bson.M{
+		"status":    event.Status,
+		"decidedAt": event.At,
+	}
+	if event.ActorID != nil {
+		set["decidedBy"] = *event.ActorID
+	}
+	if event.Note != "" {
+		set["decisionNote"] = event.Note
+	}
+
+	collection := database.GetCollection("access_requests")
+	res, err := collection.UpdateOne(ctx,
+		bson.M{"_id": id, "status": models.AccessRequestPending},
+		bson.M{"$set": set, "$push": bson.M{"history": event}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+func DeleteAccessRequestsByVaultID(vaultID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("access_requests")
+	_, err := collection.DeleteMany(ctx, bson.M{"vaultId": vaultID})
+	return err
+}
+
+func DeleteAccessRequestsByUserID(userID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("access_requests")
+	_, err := collection.DeleteMany(ctx, bson.M{"userId": userID})
+	return err
+}
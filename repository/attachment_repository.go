@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"lembrago.com/lembrago/database"
+	"lembrago.com/lembrago/errors"
+	"lembrago.com/lembrago/models"
+)
+
+func CreateAttachment(attachment *models.Attachment) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("attachments")
+	if attachment.ID == primitive.NilObjectID {
+		attachment.ID = primitive.NewObjectID()
+	}
+
+	_, err := collection.InsertOne(ctx, attachment)
+	return err
+}
+
+func FindAttachmentByID(id primitive.ObjectID) (*models.Attachment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("attachments")
+
+	var attachment models.Attachment
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&attachment)
+	if err != nil {
+		return nil, err
+	}
+
+	return &attachment, nil
+}
+
+func FindAllAttachmentsByPasswordID(passwordID primitive.ObjectID) ([]models.Attachment, error) {
+	return findAttachments(bson.M{"passwordId": passwordID})
+}
+
+func FindAllAttachmentsByVaultID(vaultID primitive.ObjectID) ([]models.Attachment, error) {
+	return findAttachments(bson.M{"vaultId": vaultID})
+}
+
+// FindStaleUploadingAttachments acha os anexos criados até before que nunca
+// terminaram o upload.
+func FindStaleUploadingAttachments(before time.Time) ([]models.Attachment, error) {
+	return findAttachments(bson.M{
+		"status":    models.AttachmentUploading,
+		"createdAt": bson.M{"$lte": primitive.NewDateTimeFromTime(before)},
+	})
+}
+
+func findAttachments(filter bson.M) ([]models.Attachment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("attachments")
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var attachments []models.Attachment
+	if err = cursor.All(ctx, &attachments); err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+// SumAttachmentSizeByOrgID soma o tamanho declarado de todos os anexos da organização,
+// incluindo os que ainda estão em upload. Serve de ponto de partida para o contador
+// de uso das organizações que ainda não o têm.
+func SumAttachmentSizeByOrgID(orgID primitive.ObjectID) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("attachments")
+	pipeline := []bson.M{
+		{"$match": bson.M{"orgId": orgID}},
+		{"$group": bson.M{"_id": nil, "total": bson.M{"$sum": "$size"}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []struct {
+		Total int64 `bson:"total"`
+	}
+	if err = cursor.All(ctx, &result); err != nil {
+		return 0, err
+	}
+
+	if len(result) == 0 {
+		return 0, nil
+	}
+
+	return result[0].Total, nil
+}
+
+func CompleteAttachment(id primitive.ObjectID, chunkSizes []int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	updateFields := bson.M{
+		"chunkSizes": chunkSizes,
+		"status":     models.AttachmentAvailable,
+		"updatedAt":  primitive.NewDateTimeFromTime(time.Now()),
+	}
+
+	collection := database.GetCollection("attachments")
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": updateFields})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return errors.NewAppError(404, "Attachment not found")
+	}
+
+	return nil
+}
+
+// DeleteAttachment devolve false se o anexo já tinha sido apagado.
+func DeleteAttachment(id primitive.ObjectID) (bool, error) {
+	return deleteAttachment(bson.M{"_id": id})
+}
+
+// DeleteStaleUploadingAttachment só apaga se o anexo continua em upload desde
+// antes de before, para não levar um que acabou de ser concluído.
+func DeleteStaleUploadingAttachment(id primitive.ObjectID, before time.Time) (bool, error) {
+	return deleteAttachment(bson.M{
+		"_id":       id,
+		"status":    models.AttachmentUploading,
+		"createdAt": bson.M{"$lte": primitive.NewDateTimeFromTime(before)},
+	})
+}
+
+func deleteAttachment(filter bson.M) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("attachments")
+	result, err := collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"lembrago.com/lembrago/database"
+	"lembrago.com/lembrago/models"
+)
+
+// O log de auditoria só cresce: não há funções para alterar ou apagar registros.
+
+// InsertAuditEntry devolve false quando outro registro já ocupou o mesmo seq da
+// organização; quem chama relê o último e tenta de novo.
+func InsertAuditEntry(entry *models.AuditEntry) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("audit_logs")
+	_, err := collection.InsertOne(ctx, entry)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// FindLastAuditEntry devolve nil, sem erro, quando a organização ainda não tem registros.
+func FindLastAuditEntry(orgID primitive.ObjectID) (*models.AuditEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("audit_logs")
+	opts := options.FindOne().SetSort(bson.D{{Key: "seq", Value: -1}})
+
+	var entry models.AuditEntry
+	err := collection.FindOne(ctx, bson.M{"orgId": orgID}, opts).Decode(&entry)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// FindAuditEntries pagina os registros do filtro, do mais novo para o mais antigo.
+func FindAuditEntries(filter models.AuditFilter, page, limit int) ([]models.AuditEntry, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("audit_logs")
+	query := auditFilter(filter)
+
+	total, err := collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "seq", Value: -1}}).
+		SetSkip(int64(page * limit)).
+		SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []models.AuditEntry{}
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+// ForEachAuditEntry percorre a cadeia da organização em ordem de seq, sem
+// carregar tudo na memória. Para no primeiro erro devolvido por fn.
+func ForEachAuditEntry(orgID primitive.ObjectID, fn func(entry *models.AuditEntry) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	collection := database.GetCollection("audit_logs")
+	opts := options.Find().SetSort(bson.D{{Key: "seq", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.M{"orgId": orgID}, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var entry models.AuditEntry
+		if err := cursor.Decode(&entry); err != nil {
+			return err
+		}
+		if err := fn(&entry); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func auditFilter(filter models.AuditFilter) bson.M {
+	query := bson.M{"orgId": filter.OrgID}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+	if filter.ActorID != nil {
+		query["actorId"] = *filter.ActorID
+	}
+	if filter.TargetID != "" {
+		query["targetId"] = filter.TargetID
+	}
+	if filter.VaultID != nil {
+		query["vaultId"] = *filter.VaultID
+	}
+	createdAt := bson.M{}
+	if filter.From != nil {
+		createdAt["$gte"] = primitive.NewDateTimeFromTime(*filter.From)
+	}
+	if filter.To != nil {
+		createdAt["$lte"] = primitive.NewDateTimeFromTime(*filter.To)
+	}
+	if len(createdAt) > 0 {
+		query["createdAt"] = createdAt
+	}
+	return query
+}
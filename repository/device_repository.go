@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"lembrago.com/lembrago/database"
+	"lembrago.com/lembrago/models"
+)
+
+func HasUserDevices(userID primitive.ObjectID) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("user_devices")
+	count, err := collection.CountDocuments(ctx, bson.M{"userId": userID}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// SaveUserDevice registra o acesso do aparelho e diz se ele ainda não era conhecido.
+func SaveUserDevice(device *models.UserDevice) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"userId": device.UserID, "fingerprint": device.Fingerprint}
+	update := bson.M{
+		"$set": bson.M{
+			"userAgent":  device.UserAgent,
+			"ip":         device.IP,
+			"lastSeenAt": device.LastSeenAt,
+		},
+		"$setOnInsert": bson.M{
+			"_id":         device.ID,
+			"orgId":       device.OrgID,
+			"deviceId":    device.DeviceID,
+			"firstSeenAt": device.FirstSeenAt,
+		},
+	}
+
+	collection := database.GetCollection("user_devices")
+	result, err := collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return false, err
+	}
+	return result.UpsertedCount == 1, nil
+}
+
+func FindUserDeviceByID(id primitive.ObjectID) (*models.UserDevice, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var device models.UserDevice
+	collection := database.GetCollection("user_devices")
+	if err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&device); err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
+
+func DeleteUserDevice(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("user_devices")
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func DeleteUserDevicesByUserID(userID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("user_devices")
+	_, err := collection.DeleteMany(ctx, bson.M{"userId": userID})
+	return err
+}
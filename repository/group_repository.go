@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"lembrago.com/lembrago/database"
+	"lembrago.com/lembrago/errors"
+	"lembrago.com/lembrago/models"
+)
+
+func CreateGroup(group *models.Group) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("groups")
+	if group.ID == primitive.NilObjectID {
+		group.ID = primitive.NewObjectID()
+	}
+
+	_, err := collection.InsertOne(ctx, group)
+	return err
+}
+
+func FindGroupByID(id primitive.ObjectID) (*models.Group, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("groups")
+
+	var group models.Group
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&group)
+	if err != nil {
+		return nil, err
+	}
+
+	return &group, nil
+}
+
+func FindGroupsByOrgID(orgID primitive.ObjectID) ([]models.Group, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("groups")
+	cursor, err := collection.Find(ctx, bson.M{"orgId": orgID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var groups []models.Group
+	if err = cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+func UpdateGroup(id primitive.ObjectID, name, description string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	updateDoc := bson.M{"$set": bson.M{
+		"name":        name,
+		"description": description,
+		"updatedAt":   primitive.NewDateTimeFromTime(time.Now()),
+	}}
+
+	collection := database.GetCollection("groups")
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": id}, updateDoc)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.NewAppError(404, "Group not found")
+	}
+
+	return nil
+}
+
+func DeleteGroup(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("groups")
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// AddGroupMember devolve false quando o usuário já está no grupo.
+func AddGroupMember(member *models.GroupMember) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("group_members")
+	if member.ID == primitive.NilObjectID {
+		member.ID = primitive.NewObjectID()
+	}
+
+	_, err := collection.InsertOne(ctx, member)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func findGroupMembers(filter bson.M) ([]models.GroupMember, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("group_members")
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var members []models.GroupMember
+	if err = cursor.All(ctx, &members); err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+func FindGroupMembersByGroupID(groupID primitive.ObjectID) ([]models.GroupMember, error) {
+	return findGroupMembers(bson.M{"groupId": groupID})
+}
+
+func FindGroupMembersByOrgID(orgID primitive.ObjectID) ([]models.GroupMember, error) {
+	return findGroupMembers(bson.M{"orgId": orgID})
+}
+
+func FindGroupMembersByUserID(userID primitive.ObjectID) ([]models.GroupMember, error) {
+	return findGroupMembers(bson.M{"userId": userID})
+}
+
+func DeleteGroupMember(groupID, userID primitive.ObjectID) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("group_members")
+	res, err := collection.DeleteOne(ctx, bson.M{"groupId": groupID, "userId": userID})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
+
+func DeleteGroupMembersByGroupID(groupID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("group_members")
+	_, err := collection.DeleteMany(ctx, bson.M{"groupId": groupID})
+	return err
+}
+
+// CreateGroupVaultGrant devolve false quando o grupo já tem acesso ao cofre.
+func CreateGroupVaultGrant(grant *models.GroupVaultGrant) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("group_vault_grants")
+	if grant.ID == primitive.NilObjectID {
+		grant.ID = primitive.NewObjectID()
+	}
+
+	_, err := collection.InsertOne(ctx, grant)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func FindGroupVaultGrantByID(id primitive.ObjectID) (*models.GroupVaultGrant, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("group_vault_grants")
+
+	var grant models.GroupVaultGrant
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&grant)
+	if err != nil {
+		return nil, err
+	}
+
+	return &grant, nil
+}
+
+func findGroupVaultGrants(filter bson.M) ([]models.GroupVaultGrant, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("group_vault_grants")
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var grants []models.GroupVaultGrant
+	if err = cursor.All(ctx, &grants); err != nil {
+		return nil, err
+	}
+
+	return grants, nil
+}
+
+func FindGroupVaultGrantsByGroupID(groupID primitive.ObjectID) ([]models.GroupVaultGrant, error) {
+	return findGroupVaultGrants(bson.M{"groupId": groupID})
+}
+
+func FindGroupVaultGrantsByVaultID(vaultID primitive.ObjectID) ([]models.GroupVaultGrant, error) {
+	return findGroupVaultGrants(bson.M{"vaultId": vaultID})
+}
+
+func DeleteGroupVaultGrant(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("group_vault_grants")
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func DeleteGroupVaultGrantsByVaultID(vaultID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("group_vault_grants")
+	_, err := collection.DeleteMany(ctx, bson.M{"vaultId": vaultID})
+	return err
+}
+
+func DeleteGroupMembersByUserID(userID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("group_members")
+	_, err := collection.DeleteMany(ctx, bson.M{"userId": userID})
+	return err
+}
+
+func DeleteGroupVaultGrantsByGroupID(groupID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("group_vault_grants")
+	_, err := collection.DeleteMany(ctx, bson.M{"groupId": groupID})
+	return err
+}
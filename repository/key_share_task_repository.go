@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"lembrago.com/lembrago/database"
+	"lembrago.com/lembrago/models"
+)
+
+// UpsertKeyShareTask cria a tarefa ou, se já houver uma para o mesmo cofre,
+// usuário e grupo, só atualiza a permissão.
+func UpsertKeyShareTask(task *models.KeyShareTask) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("key_share_tasks")
+	if task.ID == primitive.NilObjectID {
+		task.ID = primitive.NewObjectID()
+	}
+
+	filter := bson.M{"vaultId": task.VaultID, "userId": task.UserID, "groupId": task.GroupID}
+	update := bson.M{
+		"$set": bson.M{"permission": task.Permission},
+		"$setOnInsert": bson.M{
+			"_id":       task.ID,
+			"orgId":     task.OrgID,
+			"createdAt": task.CreatedAt,
+		},
+	}
+	_, err := collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+func FindKeyShareTaskByID(id primitive.ObjectID) (*models.KeyShareTask, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("key_share_tasks")
+
+	var task models.KeyShareTask
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&task)
+	if err != nil {
+		return nil, err
+	}
+
+	return &task, nil
+}
+
+func FindKeyShareTasksByVaultIDs(vaultIDs []primitive.ObjectID) ([]models.KeyShareTask, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("key_share_tasks")
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.M{"vaultId": bson.M{"$in": vaultIDs}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tasks []models.KeyShareTask
+	if err = cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+func FindKeyShareTasksByVaultUser(vaultID, userID primitive.ObjectID) ([]models.KeyShareTask, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("key_share_tasks")
+	cursor, err := collection.Find(ctx, bson.M{"vaultId": vaultID, "userId": userID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tasks []models.KeyShareTask
+	if err = cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+func deleteKeyShareTasks(filter bson.M) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("key_share_tasks")
+	_, err := collection.DeleteMany(ctx, filter)
+	return err
+}
+
+func DeleteKeyShareTasksByGroupUser(groupID, userID primitive.ObjectID) error {
+	return deleteKeyShareTasks(bson.M{"groupId": groupID, "userId": userID})
+}
+
+func DeleteKeyShareTasksByGroupVault(groupID, vaultID primitive.ObjectID) error {
+	return deleteKeyShareTasks(bson.M{"groupId": groupID, "vaultId": vaultID})
+}
+
+func DeleteKeyShareTasksByVaultUser(vaultID, userID primitive.ObjectID) error {
+	return deleteKeyShareTasks(bson.M{"vaultId": vaultID, "userId": userID})
+}
+
+func DeleteKeyShareTasksByGroupID(groupID primitive.ObjectID) error {
+	return deleteKeyShareTasks(bson.M{"groupId": groupID})
+}
+
+func DeleteKeyShareTasksByVaultID(vaultID primitive.ObjectID) error {
+	return deleteKeyShareTasks(bson.M{"vaultId": vaultID})
+}
+
+func DeleteKeyShareTasksByUserID(userID primitive.ObjectID) error {
+	return deleteKeyShareTasks(bson.M{"userId": userID})
+}
@@ -7,6 +7,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"lembrago.com/lembrago/database"
+	"lembrago.com/lembrago/errors"
 	"lembrago.com/lembrago/models"
 )
 
@@ -65,3 +66,76 @@ func FindOrganizationByID(id primitive.ObjectID) (*models.Organization, error) {
 
 	return &org, nil
 }
+
+func UpdateOrganizationBranding(id primitive.ObjectID, req *models.UpdateOrgBrandingRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	updateDoc := bson.M{"$set": bson.M{
+		"locale":   req.Locale,
+		"imageUrl": req.ImageUrl,
+		"branding": models.OrgBranding{
+			SenderName:      req.SenderName,
+			PrimaryColor:    req.PrimaryColor,
+			BackgroundColor: req.BackgroundColor,
+		},
+		"updatedAt": primitive.NewDateTimeFromTime(time.Now()),
+	}}
+
+	collection := database.GetCollection("organizations")
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": id}, updateDoc)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.NewAppError(404, "Organization not found")
+	}
+
+	return nil
+}
+
+// InitOrgStorageUsed grava o uso de armazenamento só se a organização ainda não
+// tem o contador, que a partir daí só muda por ReserveOrgStorage e ReleaseOrgStorage.
+func InitOrgStorageUsed(id primitive.ObjectID, used int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("organizations")
+	_, err := collection.UpdateOne(ctx,
+		bson.M{"_id": id, "storageUsed": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"storageUsed": used}},
+	)
+	return err
+}
+
+// ReserveOrgStorage soma size ao uso numa única operação, só se o total couber
+// em quota. Devolve false se não couber.
+func ReserveOrgStorage(id primitive.ObjectID, size, quota int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":         id,
+		"storageUsed": bson.M{"$exists": true, "$lte": quota - size},
+	}
+
+	collection := database.GetCollection("organizations")
+	result, err := collection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"storageUsed": size}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// ReleaseOrgStorage devolve à cota o espaço reservado por um anexo removido.
+func ReleaseOrgStorage(id primitive.ObjectID, size int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("organizations")
+	_, err := collection.UpdateOne(ctx,
+		bson.M{"_id": id, "storageUsed": bson.M{"$exists": true}},
+		bson.M{"$inc": bson.M{"storageUsed": -size}},
+	)
+	return err
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"lembrago.com/lembrago/database"
+	"lembrago.com/lembrago/errors"
+	"lembrago.com/lembrago/models"
+)
+
+func EnqueueEmail(email *models.OutboxEmail) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("mail_outbox")
+	_, err := collection.InsertOne(ctx, email)
+	return err
+}
+
+func FindOutboxEmailByID(id primitive.ObjectID) (*models.OutboxEmail, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("mail_outbox")
+
+	var email models.OutboxEmail
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&email)
+	if err != nil {
+		return nil, err
+	}
+
+	return &email, nil
+}
+
+// ClaimDueEmail pega um e-mail pendente já vencido e adia a próxima tentativa
+// para now+lease, como ClaimDueWebhookDelivery. Devolve nil, sem erro, quando
+// a fila está vazia.
+func ClaimDueEmail(now time.Time, lease time.Duration) (*models.OutboxEmail, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("mail_outbox")
+	filter := bson.M{
+		"status":        models.OutboxPending,
+		"nextAttemptAt": bson.M{"$lte": primitive.NewDateTimeFromTime(now)},
+	}
+	update := bson.M{"$set": bson.M{"nextAttemptAt": primitive.NewDateTimeFromTime(now.Add(lease))}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "nextAttemptAt", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var email models.OutboxEmail
+	err := collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&email)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &email, nil
+}
+
+// SaveEmailAttempt grava o resultado de uma tentativa que falhou.
+func SaveEmailAttempt(email *models.OutboxEmail) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	updateDoc := bson.M{"$set": bson.M{
+		"status":        email.Status,
+		"attempts":      email.Attempts,
+		"nextAttemptAt": email.NextAttemptAt,
+		"lastError":     email.LastError,
+		"updatedAt":     email.UpdatedAt,
+	}}
+
+	collection := database.GetCollection("mail_outbox")
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": email.ID}, updateDoc)
+	return err
+}
+
+func DeleteOutboxEmail(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("mail_outbox")
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// FindDeadEmails pagina os e-mails que desistimos de enviar, do mais recente para o mais antigo.
+func FindDeadEmails(page, limit int) ([]models.OutboxEmail, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("mail_outbox")
+	filter := bson.M{"status": models.OutboxDead}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "updatedAt", Value: -1}}).
+		SetSkip(int64(page * limit)).
+		SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	emails := []models.OutboxEmail{}
+	if err = cursor.All(ctx, &emails); err != nil {
+		return nil, 0, err
+	}
+	return emails, total, nil
+}
+
+// RequeueDeadEmail devolve à fila um e-mail morto, com as tentativas zeradas.
+func RequeueDeadEmail(id primitive.ObjectID, now time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "status": models.OutboxDead}
+	updateDoc := bson.M{"$set": bson.M{
+		"status":        models.OutboxPending,
+		"attempts":      0,
+		"nextAttemptAt": primitive.NewDateTimeFromTime(now),
+		"updatedAt":     primitive.NewDateTimeFromTime(now),
+	}}
+
+	collection := database.GetCollection("mail_outbox")
+	result, err := collection.UpdateOne(ctx, filter, updateDoc)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.NewAppError(404, "Dead letter not found")
+	}
+	return nil
+}
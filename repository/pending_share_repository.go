@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"lembrago.com/lembrago/database"
+	"lembrago.com/lembrago/models"
+)
+
+// CreatePendingShare devolve false quando o e-mail já tem um acesso pendente ao cofre.
+func CreatePendingShare(share *models.PendingShare) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("pending_shares")
+	if share.ID == primitive.NilObjectID {
+		share.ID = primitive.NewObjectID()
+	}
+
+	_, err := collection.InsertOne(ctx, share)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func FindPendingShareByID(id primitive.ObjectID) (*models.PendingShare, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("pending_shares")
+
+	var share models.PendingShare
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&share)
+	if err != nil {
+		return nil, err
+	}
+
+	return &share, nil
+}
+
+func FindPendingSharesByVaultIDs(vaultIDs []primitive.ObjectID) ([]models.PendingShare, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("pending_shares")
+	filter := bson.M{
+		"vaultId":   bson.M{"$in": vaultIDs},
+		"expiresAt": bson.M{"$gt": primitive.NewDateTimeFromTime(time.Now())},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var shares []models.PendingShare
+	if err = cursor.All(ctx, &shares); err != nil {
+		return nil, err
+	}
+
+	return shares, nil
+}
+
+func DeletePendingShare(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("pending_shares")
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func DeletePendingSharesByVaultID(vaultID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("pending_shares")
+	_, err := collection.DeleteMany(ctx, bson.M{"vaultId": vaultID})
+	return err
+}
+
+func DeletePendingSharesByEmailOrgID(email string, orgID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("pending_shares")
+	_, err := collection.DeleteMany(ctx, bson.M{"email": email, "orgId": orgID})
+	return err
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"lembrago.com/lembrago/database"
+	"lembrago.com/lembrago/models"
+)
+
+func CreatePersonalAccessToken(token *models.PersonalAccessToken) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("personal_access_tokens")
+	if token.ID == primitive.NilObjectID {
+		token.ID = primitive.NewObjectID()
+	}
+
+	_, err := collection.InsertOne(ctx, token)
+	return err
+}
+
+func FindPersonalAccessTokenByID(id primitive.ObjectID) (*models.PersonalAccessToken, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("personal_access_tokens")
+
+	var token models.PersonalAccessToken
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func FindPersonalAccessTokensByUserID(userID primitive.ObjectID) ([]models.PersonalAccessToken, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("personal_access_tokens")
+	cursor, err := collection.Find(ctx, bson.M{"userId": userID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tokens []models.PersonalAccessToken
+	if err = cursor.All(ctx, &tokens); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func TouchPersonalAccessToken(id primitive.ObjectID, at primitive.DateTime) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("personal_access_tokens")
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"lastUsedAt": at}})
+	return err
+}
+
+func DeletePersonalAccessToken(id, userID primitive.ObjectID) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("personal_access_tokens")
+	res, err := collection.DeleteOne(ctx, bson.M{"_id": id, "userId": userID})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
+
+func DeletePersonalAccessTokensByUserID(userID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("personal_access_tokens")
+	_, err := collection.DeleteMany(ctx, bson.M{"userId": userID})
+	return err
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"lembrago.com/lembrago/database"
+	"lembrago.com/lembrago/models"
+)
+
+func CreateRole(role *models.Role) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("roles")
+	if role.ID == primitive.NilObjectID {
+		role.ID = primitive.NewObjectID()
+	}
+
+	_, err := collection.InsertOne(ctx, role)
+	return err
+}
+
+func FindRoleByKeyOrgID(key models.UserRole, orgID primitive.ObjectID) (*models.Role, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("roles")
+
+	var role models.Role
+	err := collection.FindOne(ctx, bson.M{"key": key, "orgId": orgID}).Decode(&role)
+	if err != nil {
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+func FindRolesByOrgID(orgID primitive.ObjectID) ([]models.Role, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("roles")
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.M{"orgId": orgID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var roles []models.Role
+	if err = cursor.All(ctx, &roles); err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func UpdateRole(id primitive.ObjectID, name, description string, permissions []models.OrgPermission) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("roles")
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"name":        name,
+		"description": description,
+		"permissions": permissions,
+		"updatedAt":   primitive.NewDateTimeFromTime(time.Now()),
+	}})
+	return err
+}
+
+func DeleteRole(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("roles")
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
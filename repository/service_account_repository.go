@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"lembrago.com/lembrago/database"
+	"lembrago.com/lembrago/models"
+)
+
+func FindServiceAccountsByOrgID(orgID primitive.ObjectID) ([]models.User, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("users")
+	cursor, err := collection.Find(ctx, bson.M{"orgId": orgID, "type": models.UserTypeService})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var accounts []models.User
+	if err = cursor.All(ctx, &accounts); err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+func CreateServiceAccountCredential(credential *models.ServiceAccountCredential) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("service_account_credentials")
+	if credential.ID == primitive.NilObjectID {
+		credential.ID = primitive.NewObjectID()
+	}
+
+	_, err := collection.InsertOne(ctx, credential)
+	return err
+}
+
+func FindServiceAccountCredentialByID(id primitive.ObjectID) (*models.ServiceAccountCredential, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("service_account_credentials")
+
+	var credential models.ServiceAccountCredential
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&credential)
+	if err != nil {
+		return nil, err
+	}
+
+	return &credential, nil
+}
+
+func FindServiceAccountCredentialsByOrgID(orgID primitive.ObjectID) ([]models.ServiceAccountCredential, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("service_account_credentials")
+	cursor, err := collection.Find(ctx, bson.M{"orgId": orgID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var credentials []models.ServiceAccountCredential
+	if err = cursor.All(ctx, &credentials); err != nil {
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+func FindServiceAccountCredentialsByAccountID(accountID primitive.ObjectID) ([]models.ServiceAccountCredential, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("service_account_credentials")
+	cursor, err := collection.Find(ctx, bson.M{"serviceAccountId": accountID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var credentials []models.ServiceAccountCredential
+	if err = cursor.All(ctx, &credentials); err != nil {
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+func UpdateServiceAccountCredentialScope(id primitive.ObjectID, scope models.ServiceAccountScope) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("service_account_credentials")
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"scope": scope}})
+	return err
+}
+
+func TouchServiceAccountCredential(id primitive.ObjectID, at primitive.DateTime) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("service_account_credentials")
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"lastUsedAt": at}})
+	return err
+}
+
+func DeleteServiceAccountCredential(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("service_account_credentials")
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func DeleteServiceAccountCredentialsByAccountID(accountID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("service_account_credentials")
+	_, err := collection.DeleteMany(ctx, bson.M{"serviceAccountId": accountID})
+	return err
+}
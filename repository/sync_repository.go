@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"lembrago.com/lembrago/database"
+	"lembrago.com/lembrago/models"
+)
+
+func CreateTombstone(tombstone *models.Tombstone) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("tombstones")
+	if tombstone.ID == primitive.NilObjectID {
+		tombstone.ID = primitive.NewObjectID()
+	}
+
+	_, err := collection.InsertOne(ctx, tombstone)
+	return err
+}
+
+// FindTombstonesSince retorna os tombstones visíveis ao usuário: os endereçados a ele
+// e os gerais dos cofres dos quais ele ainda participa.
+func FindTombstonesSince(userID primitive.ObjectID, vaultIDs []primitive.ObjectID, since time.Time) ([]models.Tombstone, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"deletedAt": bson.M{"$gt": primitive.NewDateTimeFromTime(since)},
+		"$or": []bson.M{
+			{"userId": userID},
+			{"vaultId": bson.M{"$in": vaultIDs}, "userId": bson.M{"$exists": false}},
+		},
+	}
+
+	collection := database.GetCollection("tombstones")
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tombstones []models.Tombstone
+	if err = cursor.All(ctx, &tombstones); err != nil {
+		return nil, err
+	}
+
+	return tombstones, nil
+}
+
+func FindAllVaultMembersByUserOrgID(orgID, userID primitive.ObjectID) ([]models.VaultMember, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("vault_members")
+	cursor, err := collection.Find(ctx, notExpired(bson.M{"orgId": orgID, "userId": userID}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var vaultMembers []models.VaultMember
+	if err = cursor.All(ctx, &vaultMembers); err != nil {
+		return nil, err
+	}
+
+	return vaultMembers, nil
+}
+
+func FindVaultsByIDs(ids []primitive.ObjectID) ([]models.Vault, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("vaults")
+	cursor, err := collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var vaults []models.Vault
+	if err = cursor.All(ctx, &vaults); err != nil {
+		return nil, err
+	}
+
+	return vaults, nil
+}
+
+// FindPasswordsByVaultIDsSince com since zero retorna todos os itens dos cofres.
+func FindPasswordsByVaultIDsSince(vaultIDs []primitive.ObjectID, since time.Time) ([]models.Password, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{"vaultId": bson.M{"$in": vaultIDs}}
+	if !since.IsZero() {
+		filter["updatedAt"] = bson.M{"$gt": primitive.NewDateTimeFromTime(since)}
+	}
+
+	collection := database.GetCollection("passwords_items")
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var passwords []models.Password
+	if err = cursor.All(ctx, &passwords); err != nil {
+		return nil, err
+	}
+
+	return passwords, nil
+}
@@ -53,7 +53,7 @@ func FindAllUsersByEmail(email string) ([]models.User, error) {
 	defer cancel()
 
 	collection := database.GetCollection("users")
-	cursor, err := collection.Find(ctx, bson.M{"email": email})
+	cursor, err := collection.Find(ctx, bson.M{"email": email, "type": bson.M{"$ne": models.UserTypeService}})
 	if err != nil {
 		return nil, err
 	}
@@ -87,7 +87,7 @@ func FindUsersByOrgID(orgID primitive.ObjectID) ([]models.User, error) {
 	defer cancel()
 
 	collection := database.GetCollection("users")
-	cursor, err := collection.Find(ctx, bson.M{"orgId": orgID})
+	cursor, err := collection.Find(ctx, bson.M{"orgId": orgID, "type": bson.M{"$ne": models.UserTypeService}})
 	if err != nil {
 		return nil, err
 	}
@@ -111,6 +111,34 @@ func UpdateUserRole(id primitive.ObjectID, role string) error {
 	return err
 }
 
+func UpdateUserStatus(id primitive.ObjectID, status models.UserStatus) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("users")
+
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"status": status, "updatedAt": primitive.NewDateTimeFromTime(time.Now())}})
+	return err
+}
+
+func UpdateUserLocale(id primitive.ObjectID, locale string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("users")
+
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"locale": locale, "updatedAt": primitive.NewDateTimeFromTime(time.Now())}})
+	return err
+}
+
+func CountUsersByRole(orgID primitive.ObjectID, role models.UserRole) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("users")
+	return collection.CountDocuments(ctx, bson.M{"orgId": orgID, "role": role})
+}
+
 func SaveMediaInRepo(svMedia *models.SavedMedia) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -24,6 +24,12 @@ func AddVaultMember(vaultMember *models.VaultMember) error {
 	return err
 }
 
+func AddVaultMemberTx(ctx context.Context, vaultMember *models.VaultMember) error {
+	collection := database.GetCollection("vault_members")
+	_, err := collection.InsertOne(ctx, vaultMember)
+	return err
+}
+
 func DeleteVaultMember(memberID primitive.ObjectID) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -40,7 +46,7 @@ func UpdateVaultMember(memberID primitive.ObjectID, esvkBytes []byte, Permission
 	updateFields := bson.M{
 		"esvk_pubK_user": esvkBytes,
 		"permission":     Permission,
-		"updatedAt":      time.Now(),
+		"updatedAt":      primitive.NewDateTimeFromTime(time.Now()),
 	}
 	updateDoc := bson.M{"$set": updateFields}
 
@@ -82,7 +88,7 @@ func FindMemberByUserVaultID(vaultId primitive.ObjectID, userID primitive.Object
 
 	collection := database.GetCollection("vault_members")
 	var vaultMember models.VaultMember
-	err := collection.FindOne(ctx, bson.M{"vaultId": vaultId, "userId": userID}).Decode(&vaultMember)
+	err := collection.FindOne(ctx, notExpired(bson.M{"vaultId": vaultId, "userId": userID})).Decode(&vaultMember)
 	if err != nil {
 		return nil, err
 	}
@@ -103,3 +109,139 @@ func FindMemberById(vaultMemberID primitive.ObjectID) (*models.VaultMember, erro
 
 	return &vaultMember, nil
 }
+
+// AddVaultMemberGroup registra que o grupo também dá este acesso e ajusta a permissão.
+func AddVaultMemberGroup(memberID, groupID primitive.ObjectID, permission models.VaultPermission) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	updateDoc := bson.M{
+		"$addToSet": bson.M{"groupIds": groupID},
+		"$set": bson.M{
+			"permission": permission,
+			"updatedAt":  primitive.NewDateTimeFromTime(time.Now()),
+		},
+	}
+
+	collection := database.GetCollection("vault_members")
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": memberID}, updateDoc)
+	return err
+}
+
+// RemoveVaultMemberGroup tira o grupo da lista de origens do acesso e ajusta a
+// permissão ao que os grupos restantes dão.
+func RemoveVaultMemberGroup(memberID, groupID primitive.ObjectID, permission models.VaultPermission) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	updateDoc := bson.M{
+		"$pull": bson.M{"groupIds": groupID},
+		"$set": bson.M{
+			"permission": permission,
+			"updatedAt":  primitive.NewDateTimeFromTime(time.Now()),
+		},
+	}
+
+	collection := database.GetCollection("vault_members")
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": memberID}, updateDoc)
+	return err
+}
+
+func FindVaultMembersByGroupID(groupID primitive.ObjectID) ([]models.VaultMember, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("vault_members")
+	cursor, err := collection.Find(ctx, bson.M{"groupIds": groupID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var vaultMembers []models.VaultMember
+	if err = cursor.All(ctx, &vaultMembers); err != nil {
+		return nil, err
+	}
+
+	return vaultMembers, nil
+}
+
+// notExpired restringe o filtro aos acessos sem expiração ou ainda válidos.
+func notExpired(filter bson.M) bson.M {
+	filter["$or"] = []bson.M{
+		{"expiresAt": nil},
+		{"expiresAt": bson.M{"$gt": primitive.NewDateTimeFromTime(time.Now())}},
+	}
+	return filter
+}
+
+// SetVaultMemberExpiry define ou, com nil, remove a expiração do acesso.
+func SetVaultMemberExpiry(memberID primitive.ObjectID, expiresAt *primitive.DateTime) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	updateDoc := bson.M{"$unset": bson.M{"expiresAt": ""}}
+	if expiresAt != nil {
+		updateDoc = bson.M{"$set": bson.M{"expiresAt": *expiresAt}}
+	}
+
+	collection := database.GetCollection("vault_members")
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": memberID}, updateDoc)
+	return err
+}
+
+func FindExpiredVaultMembers(now time.Time) ([]models.VaultMember, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("vault_members")
+	cursor, err := collection.Find(ctx, bson.M{"expiresAt": bson.M{"$lte": primitive.NewDateTimeFromTime(now)}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var vaultMembers []models.VaultMember
+	if err = cursor.All(ctx, &vaultMembers); err != nil {
+		return nil, err
+	}
+
+	return vaultMembers, nil
+}
+
+// FindExpiredVaultMember acha o acesso já vencido do usuário ao cofre que a
+// rodada de expiração ainda não apagou. Ele ainda ocupa o índice único.
+func FindExpiredVaultMember(vaultID, userID primitive.ObjectID, now time.Time) (*models.VaultMember, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("vault_members")
+	var vaultMember models.VaultMember
+	err := collection.FindOne(ctx, bson.M{
+		"vaultId":   vaultID,
+		"userId":    userID,
+		"expiresAt": bson.M{"$lte": primitive.NewDateTimeFromTime(now)},
+	}).Decode(&vaultMember)
+	if err != nil {
+		return nil, err
+	}
+
+	return &vaultMember, nil
+}
+
+// DeleteExpiredVaultMember só apaga se o acesso continua expirado, para não
+// remover alguém cuja expiração acabou de ser prorrogada. Devolve false nesse caso.
+func DeleteExpiredVaultMember(memberID primitive.ObjectID, now time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("vault_members")
+	res, err := collection.DeleteOne(ctx, bson.M{
+		"_id":       memberID,
+		"expiresAt": bson.M{"$lte": primitive.NewDateTimeFromTime(now)},
+	})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"lembrago.com/lembrago/database"
 	"lembrago.com/lembrago/errors"
 	"lembrago.com/lembrago/models"
@@ -60,20 +61,31 @@ func FindVaultByID(id primitive.ObjectID) (*models.Vault, error) {
 	return &vault, nil
 }
 
-func UpdateVaultById(id primitive.ObjectID, vault models.Vault) (*models.Vault, error) {
+// UpdateVaultById só aplica a alteração se a revisão no banco ainda for expectedRevision.
+func UpdateVaultById(id primitive.ObjectID, vault models.Vault, expectedRevision int64) (*models.Vault, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	updateDoc := bson.M{
+		"$set": bson.M{
+			"encryptedVaultMetadata": vault.EncryptedVaultMetadata,
+			"needsKeyRotation":       vault.NeedsKeyRotation,
+			"updatedAt":              vault.UpdatedAt,
+		},
+		"$inc": bson.M{"revision": 1},
+	}
+
 	collection := database.GetCollection("vaults")
-	result, err := collection.UpdateOne(ctx, bson.M{"_id": id}, vault)
+	result, err := collection.UpdateOne(ctx, revisionFilter(id, expectedRevision), updateDoc)
 	if err != nil {
 		return nil, err
 	}
 
 	if result.MatchedCount == 0 {
-		return nil, errors.NewAppError(404, "Vault not found")
+		return nil, errors.NewAppError(409, "Vault was modified by someone else")
 	}
 
+	vault.Revision = expectedRevision + 1
 	return &vault, nil
 }
 
@@ -91,7 +103,8 @@ func FindAllVaultsByUserOrgID(orgID primitive.ObjectID, userID primitive.ObjectI
 	defer cancel()
 
 	collection := database.GetCollection("vault_members")
-	cursor, err := collection.Find(ctx, bson.M{"orgId": orgID, "userId": userID})
+	// Acessos expirados ficam de fora mesmo antes de o job removê-los.
+	cursor, err := collection.Find(ctx, notExpired(bson.M{"orgId": orgID, "userId": userID}))
 	if err != nil {
 		return nil, err
 	}
@@ -114,6 +127,8 @@ func FindAllVaultsByUserOrgID(orgID primitive.ObjectID, userID primitive.ObjectI
 			EncryptedVaultMetadata: utils.FacEncryptedKeyDto(vault.EncryptedVaultMetadata.Ciphertext, vault.EncryptedVaultMetadata.Nonce),
 			PersonalVault:          vault.PersonalVault,
 			VaultCreatedBy:         vault.CreatedBy.Hex(),
+			VaultOwnerID:           vault.Owner().Hex(),
+			VaultRevision:          vault.Revision,
 			VaultUpdatedAt:         vault.UpdatedAt.Time().Format(time.RFC3339),
 			VaultCreatedAt:         vault.CreatedAt.Time().Format(time.RFC3339),
 
@@ -122,10 +137,19 @@ func FindAllVaultsByUserOrgID(orgID primitive.ObjectID, userID primitive.ObjectI
 			ESVK_PubK_User: base64.StdEncoding.EncodeToString(vaultMember.ESVK_PubK_User),
 			AddedBy:        vaultMember.AddedBy.Hex(),
 			AddAt:          vaultMember.AddAt.Time().Format(time.RFC3339),
+
+			NeedsKeyRotation: vault.NeedsKeyRotation,
 		}
 		if err != nil {
 			return nil, err
 		}
+		if vaultMember.ExpiresAt != nil {
+			expiresAt := vaultMember.ExpiresAt.Time().Format(time.RFC3339)
+			vaultWithMemberInfo.ExpiresAt = &expiresAt
+		}
+		if vault.OwnershipTransfer != nil {
+			vaultWithMemberInfo.OwnershipTransfer = utils.FacVaultOwnershipTransferRes(vault.ID, vault.OwnershipTransfer)
+		}
 		vaults = append(vaults, vaultWithMemberInfo)
 	}
 
@@ -169,24 +193,26 @@ func RemovePasswordFromVault(passwordID primitive.ObjectID) error {
 	return err
 }
 
+// UpdatePasswordInVault só aplica a alteração se a revisão no banco ainda for expectedRevision.
 func UpdatePasswordInVault(
 	passwordID primitive.ObjectID,
 	newEncryptedData models.EncryptedKey,
 	modifiedByID primitive.ObjectID,
+	expectedRevision int64,
 ) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	collection := database.GetCollection("passwords_items")
 
-	filter := bson.M{"_id": passwordID}
+	filter := revisionFilter(passwordID, expectedRevision)
 
 	updateFields := bson.M{
 		"encryptedItemData": newEncryptedData,
 		"lastModifiedBy":    modifiedByID,
 		"updatedAt":         primitive.NewDateTimeFromTime(time.Now()),
 	}
-	updateDoc := bson.M{"$set": updateFields}
+	updateDoc := bson.M{"$set": updateFields, "$inc": bson.M{"revision": 1}}
 
 	result, err := collection.UpdateOne(ctx, filter, updateDoc)
 	if err != nil {
@@ -194,12 +220,100 @@ func UpdatePasswordInVault(
 	}
 
 	if result.MatchedCount == 0 {
-		return errors.NewAppError(404, "Password not found")
+		return errors.NewAppError(409, "Password was modified by someone else")
 	}
 
 	return nil
 }
 
+// As variantes *Tx usam o contexto da sessão para participar de uma transação.
+
+func AddPasswordToVaultTx(ctx context.Context, password models.Password) error {
+	collection := database.GetCollection("passwords_items")
+	_, err := collection.InsertOne(ctx, password)
+	return err
+}
+
+func AddPasswordsToVaultTx(ctx context.Context, passwords []models.Password) error {
+	if len(passwords) == 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, len(passwords))
+	for i := range passwords {
+		docs[i] = passwords[i]
+	}
+
+	collection := database.GetCollection("passwords_items")
+	_, err := collection.InsertMany(ctx, docs)
+	return err
+}
+
+func CreateVaultTx(ctx context.Context, vault *models.Vault) error {
+	collection := database.GetCollection("vaults")
+	_, err := collection.InsertOne(ctx, vault)
+	return err
+}
+
+func UpdatePasswordInVaultTx(
+	ctx context.Context,
+	passwordID primitive.ObjectID,
+	newEncryptedData models.EncryptedKey,
+	modifiedByID primitive.ObjectID,
+	expectedRevision int64,
+) error {
+	collection := database.GetCollection("passwords_items")
+
+	updateFields := bson.M{
+		"encryptedItemData": newEncryptedData,
+		"lastModifiedBy":    modifiedByID,
+		"updatedAt":         primitive.NewDateTimeFromTime(time.Now()),
+	}
+	updateDoc := bson.M{"$set": updateFields, "$inc": bson.M{"revision": 1}}
+
+	result, err := collection.UpdateOne(ctx, revisionFilter(passwordID, expectedRevision), updateDoc)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return errors.NewAppError(409, "Password was modified by someone else")
+	}
+
+	return nil
+}
+
+func RemovePasswordFromVaultTx(ctx context.Context, passwordID primitive.ObjectID, expectedRevision *int64) error {
+	collection := database.GetCollection("passwords_items")
+
+	filter := bson.M{"_id": passwordID}
+	if expectedRevision != nil {
+		filter = revisionFilter(passwordID, *expectedRevision)
+	}
+
+	result, err := collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return errors.NewAppError(409, "Password was modified by someone else")
+	}
+
+	return nil
+}
+
+// revisionFilter também casa documentos gravados antes do campo revision existir.
+func revisionFilter(id primitive.ObjectID, revision int64) bson.M {
+	if revision == 0 {
+		return bson.M{"_id": id, "$or": []bson.M{
+			{"revision": 0},
+			{"revision": bson.M{"$exists": false}},
+		}}
+	}
+	return bson.M{"_id": id, "revision": revision}
+}
+
 func FindAllPasswordsByVaultID(vaultID primitive.ObjectID) ([]models.Password, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -218,3 +332,117 @@ func FindAllPasswordsByVaultID(vaultID primitive.ObjectID) ([]models.Password, e
 
 	return passwords, nil
 }
+
+// MarkVaultNeedsKeyRotation sobe a revisão: needsKeyRotation faz parte da versão
+// do cofre que o cliente guarda.
+func MarkVaultNeedsKeyRotation(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	updateDoc := bson.M{
+		"$set": bson.M{
+			"needsKeyRotation": true,
+			"updatedAt":        primitive.NewDateTimeFromTime(time.Now()),
+		},
+		"$inc": bson.M{"revision": 1},
+	}
+
+	collection := database.GetCollection("vaults")
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, updateDoc)
+	return err
+}
+
+// SetVaultDirectory publica ou retira o cofre do diretório da organização.
+func SetVaultDirectory(id primitive.ObjectID, requestable bool, displayName string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	updateDoc := bson.M{
+		"$set":   bson.M{"updatedAt": now},
+		"$unset": bson.M{"requestable": "", "directoryName": ""},
+	}
+	if requestable {
+		updateDoc = bson.M{"$set": bson.M{"requestable": true, "directoryName": displayName, "updatedAt": now}}
+	}
+
+	collection := database.GetCollection("vaults")
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, updateDoc)
+	return err
+}
+
+func FindRequestableVaultsByOrgID(orgID primitive.ObjectID) ([]models.Vault, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("vaults")
+	opts := options.Find().SetSort(bson.D{{Key: "directoryName", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.M{"orgId": orgID, "requestable": true}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var vaults []models.Vault
+	if err = cursor.All(ctx, &vaults); err != nil {
+		return nil, err
+	}
+
+	return vaults, nil
+}
+
+// SetVaultOwnershipTransfer grava a transferência proposta ou, com nil, a descarta.
+func SetVaultOwnershipTransfer(id primitive.ObjectID, transfer *models.VaultOwnershipTransfer) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	updateDoc := bson.M{"$unset": bson.M{"ownershipTransfer": ""}}
+	if transfer != nil {
+		updateDoc = bson.M{"$set": bson.M{"ownershipTransfer": transfer}}
+	}
+
+	collection := database.GetCollection("vaults")
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, updateDoc)
+	return err
+}
+
+// AcceptVaultOwnershipTransfer troca o dono só se a transferência para toUserID
+// ainda estiver valendo. Devolve false caso contrário.
+func AcceptVaultOwnershipTransfer(id, toUserID primitive.ObjectID) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":                         id,
+		"ownershipTransfer.toUserId":  toUserID,
+		"ownershipTransfer.expiresAt": bson.M{"$gt": primitive.NewDateTimeFromTime(time.Now())},
+	}
+	updateDoc := bson.M{
+		"$set":   bson.M{"ownerId": toUserID, "updatedAt": primitive.NewDateTimeFromTime(time.Now())},
+		"$unset": bson.M{"ownershipTransfer": ""},
+		"$inc":   bson.M{"revision": 1},
+	}
+
+	collection := database.GetCollection("vaults")
+	res, err := collection.UpdateOne(ctx, filter, updateDoc)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// SetVaultOwner troca o dono sem confirmação e descarta transferências pendentes.
+func SetVaultOwner(id, ownerID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	updateDoc := bson.M{
+		"$set":   bson.M{"ownerId": ownerID, "updatedAt": primitive.NewDateTimeFromTime(time.Now())},
+		"$unset": bson.M{"ownershipTransfer": ""},
+		"$inc":   bson.M{"revision": 1},
+	}
+
+	collection := database.GetCollection("vaults")
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, updateDoc)
+	return err
+}
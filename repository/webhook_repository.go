@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"lembrago.com/lembrago/database"
+	"lembrago.com/lembrago/errors"
+	"lembrago.com/lembrago/models"
+)
+
+func CreateWebhook(webhook *models.Webhook) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("webhooks")
+	_, err := collection.InsertOne(ctx, webhook)
+	return err
+}
+
+func FindWebhookByID(id primitive.ObjectID) (*models.Webhook, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("webhooks")
+
+	var webhook models.Webhook
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&webhook)
+	if err != nil {
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+func findWebhooks(filter bson.M) ([]models.Webhook, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("webhooks")
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	webhooks := []models.Webhook{}
+	if err = cursor.All(ctx, &webhooks); err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func FindWebhooksByOrgID(orgID primitive.ObjectID) ([]models.Webhook, error) {
+	return findWebhooks(bson.M{"orgId": orgID})
+}
+
+// FindActiveWebhooksByEvent devolve os webhooks ativos da organização que assinam o evento.
+func FindActiveWebhooksByEvent(orgID primitive.ObjectID, event models.WebhookEvent) ([]models.Webhook, error) {
+	return findWebhooks(bson.M{"orgId": orgID, "active": true, "events": event})
+}
+
+func UpdateWebhook(id primitive.ObjectID, req *models.UpdateWebhookRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	updateDoc := bson.M{"$set": bson.M{
+		"url":         req.URL,
+		"description": req.Description,
+		"events":      req.Events,
+		"active":      req.Active,
+		"updatedAt":   primitive.NewDateTimeFromTime(time.Now()),
+	}}
+
+	collection := database.GetCollection("webhooks")
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": id}, updateDoc)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.NewAppError(404, "Webhook not found")
+	}
+
+	return nil
+}
+
+func DeleteWebhook(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("webhooks")
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func CreateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("webhook_deliveries")
+	_, err := collection.InsertOne(ctx, delivery)
+	return err
+}
+
+func FindWebhookDeliveryByID(id primitive.ObjectID) (*models.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("webhook_deliveries")
+
+	var delivery models.WebhookDelivery
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&delivery)
+	if err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+// FindWebhookDeliveries pagina as entregas do webhook, da mais nova para a mais antiga.
+func FindWebhookDeliveries(webhookID primitive.ObjectID, page, limit int) ([]models.WebhookDelivery, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("webhook_deliveries")
+	filter := bson.M{"webhookId": webhookID}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetSkip(int64(page * limit)).
+		SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	deliveries := []models.WebhookDelivery{}
+	if err = cursor.All(ctx, &deliveries); err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+// ClaimDueWebhookDelivery pega uma entrega pendente já vencida e adia a próxima
+// tentativa para now+lease, para que nenhuma outra instância a pegue enquanto
+// ela é enviada. Devolve nil, sem erro, quando não há nada a entregar.
+func ClaimDueWebhookDelivery(now time.Time, lease time.Duration) (*models.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("webhook_deliveries")
+	filter := bson.M{
+		"status":        models.WebhookDeliveryPending,
+		"nextAttemptAt": bson.M{"$lte": primitive.NewDateTimeFromTime(now)},
+	}
+	update := bson.M{"$set": bson.M{"nextAttemptAt": primitive.NewDateTimeFromTime(now.Add(lease))}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "nextAttemptAt", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var delivery models.WebhookDelivery
+	err := collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&delivery)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// SaveWebhookDeliveryAttempt grava o resultado da última tentativa.
+func SaveWebhookDeliveryAttempt(delivery *models.WebhookDelivery) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	updateDoc := bson.M{"$set": bson.M{
+		"status":         delivery.Status,
+		"attempts":       delivery.Attempts,
+		"nextAttemptAt":  delivery.NextAttemptAt,
+		"lastStatusCode": delivery.LastStatusCode,
+		"lastError":      delivery.LastError,
+		"deliveredAt":    delivery.DeliveredAt,
+		"updatedAt":      delivery.UpdatedAt,
+	}}
+
+	collection := database.GetCollection("webhook_deliveries")
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": delivery.ID}, updateDoc)
+	return err
+}
+
+func DeleteWebhookDeliveriesByWebhookID(webhookID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := database.GetCollection("webhook_deliveries")
+	_, err := collection.DeleteMany(ctx, bson.M{"webhookId": webhookID})
+	return err
+}
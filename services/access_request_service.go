@@ -0,0 +1,411 @@
+package services
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"lembrago.com/lembrago/authz"
+	"lembrago.com/lembrago/errors"
+	"lembrago.com/lembrago/models"
+	"lembrago.com/lembrago/repository"
+	"lembrago.com/lembrago/utils"
+)
+
+const accessRequestTTL = 7 * 24 * time.Hour
+
+// UpdateVaultDirectory publica ou retira o cofre do diretório. O nome exibido é
+// escolhido pelo admin e fica em texto claro; os metadados do cofre não mudam.
+func UpdateVaultDirectory(userID string, req *models.UpdateVaultDirectoryRequest) (*models.DirectoryVaultResponse, error) {
+	admin, err := findVaultAdmin(authz.VaultDirectory, userID, req.VaultID)
+	if err != nil {
+		return nil, err
+	}
+
+	vault, err := repository.FindVaultByID(admin.VaultID)
+	if err != nil {
+		return nil, errors.NewAppError(404, "Vault not found")
+	}
+	if vault.PersonalVault {
+		return nil, errors.NewAppError(400, "Personal vaults cannot be requested")
+	}
+
+	displayName := strings.TrimSpace(req.DisplayName)
+	if req.Requestable && displayName == "" {
+		return nil, errors.NewAppError(400, "displayName is required")
+	}
+	if err := repository.SetVaultDirectory(vault.ID, req.Requestable, displayName); err != nil {
+		return nil, err
+	}
+
+	return &models.DirectoryVaultResponse{
+		VaultID:     vault.ID.Hex(),
+		DisplayName: displayName,
+		IsMember:    true,
+	}, nil
+}
+
+// GetVaultDirectory lista os cofres que a organização deixou serem pedidos.
+func GetVaultDirectory(userID, orgID string) ([]models.DirectoryVaultResponse, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid userID format")
+	}
+	orgObjID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid orgID format")
+	}
+
+	vaults, err := repository.FindRequestableVaultsByOrgID(orgObjID)
+	if err != nil {
+		return nil, err
+	}
+	memberships, err := repository.FindAllVaultMembersByUserOrgID(orgObjID, userObjID)
+	if err != nil {
+		return nil, err
+	}
+	requests, err := repository.FindAccessRequestsByUserID(userObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	isMember := make(map[primitive.ObjectID]bool, len(memberships))
+	for _, membership := range memberships {
+		isMember[membership.VaultID] = true
+	}
+	pending := make(map[primitive.ObjectID]string)
+	for _, request := range requests {
+		if accessRequestOpen(&request) {
+			pending[request.VaultID] = request.ID.Hex()
+		}
+	}
+
+	res := make([]models.DirectoryVaultResponse, 0, len(vaults))
+	for _, vault := range vaults {
+		res = append(res, models.DirectoryVaultResponse{
+			VaultID:          vault.ID.Hex(),
+			DisplayName:      vault.DirectoryName,
+			IsMember:         isMember[vault.ID],
+			PendingRequestID: pending[vault.ID],
+		})
+	}
+	return res, nil
+}
+
+// CreateAccessRequest registra o pedido e avisa os admins do cofre por e-mail.
+func CreateAccessRequest(userID string, req *models.CreateAccessRequestRequest) (*models.AccessRequestResponse, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid userID")
+	}
+	vaultObjID, err := primitive.ObjectIDFromHex(req.VaultID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid vaultID")
+	}
+
+	user, err := repository.FindUserByID(userObjID)
+	if err != nil {
+		return nil, errors.NewAppError(404, "User not found")
+	}
+	if user.IsServiceAccount() {
+		return nil, errors.NewAppError(403, "Service accounts cannot request vault access")
+	}
+
+	vault, err := repository.FindVaultByID(vaultObjID)
+	if err != nil || vault.OrgID != user.OrgID || !vault.Requestable {
+		return nil, errors.NewAppError(404, "Vault not found")
+	}
+	if _, err := repository.FindMemberByUserVaultID(vault.ID, user.ID); err == nil {
+		return nil, errors.NewAppError(409, "User is already a member of the vault")
+	}
+
+	// Um pedido vencido que o job ainda não fechou bloquearia o novo pelo índice único.
+	previous, err := repository.FindAccessRequestsByUserID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, request := range previous {
+		if request.VaultID == vault.ID && request.Status == models.AccessRequestPending && !accessRequestOpen(&request) {
+			expireAccessRequest(&request, user)
+		}
+	}
+
+	now := time.Now()
+	request := models.AccessRequest{
+		ID:            primitive.NewObjectID(),
+		OrgID:         vault.OrgID,
+		VaultID:       vault.ID,
+		VaultName:     vault.DirectoryName,
+		UserID:        user.ID,
+		Permission:    req.Permission,
+		Justification: strings.TrimSpace(req.Justification),
+		Status:        models.AccessRequestPending,
+		CreatedAt:     primitive.NewDateTimeFromTime(now),
+		ExpiresAt:     primitive.NewDateTimeFromTime(now.Add(accessRequestTTL)),
+		History: []models.AccessRequestEvent{{
+			Status:  models.AccessRequestPending,
+			ActorID: &user.ID,
+			Note:    strings.TrimSpace(req.Justification),
+			At:      primitive.NewDateTimeFromTime(now),
+		}},
+	}
+	created, err := repository.CreateAccessRequest(&request)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, errors.NewAppError(409, "There is already a pending request for this vault")
+	}
+
+	go emailAccessRequestAdmins(&request, user)
+
+	res := utils.FacAccessRequestRes(&request, nil)
+	return &res, nil
+}
+
+// GetMyAccessRequests lista os pedidos feitos pelo usuário, inclusive os já decididos.
+func GetMyAccessRequests(userID string) ([]models.AccessRequestResponse, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid userID format")
+	}
+
+	requests, err := repository.FindAccessRequestsByUserID(userObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]models.AccessRequestResponse, 0, len(requests))
+	for _, request := range requests {
+		if request.Status == models.AccessRequestPending && !accessRequestOpen(&request) {
+			request.Status = models.AccessRequestExpired
+		}
+		res = append(res, utils.FacAccessRequestRes(&request, nil))
+	}
+	return res, nil
+}
+
+// GetAccessRequests lista os pedidos pendentes dos cofres em que o usuário é admin,
+// com a chave pública e a impressão digital de cada solicitante.
+func GetAccessRequests(userID, orgID string) ([]models.AccessRequestResponse, error) {
+	vaultIDs, err := adminVaultIDs(userID, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]models.AccessRequestResponse, 0)
+	if len(vaultIDs) == 0 {
+		return res, nil
+	}
+
+	requests, err := repository.FindPendingAccessRequestsByVaultIDs(vaultIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, request := range requests {
+		user, err := repository.FindUserByID(request.UserID)
+		if err != nil {
+			continue
+		}
+		res = append(res, utils.FacAccessRequestRes(&request, user))
+	}
+	return res, nil
+}
+
+// ApproveAccessRequest cria o acesso com a permissão pedida e o ESVK embrulhado
+// pelo admin, conferindo a impressão digital como em ConfirmPendingShare.
+func ApproveAccessRequest(userID, requestID string, req *models.ApproveAccessRequestRequest, meta models.RequestMeta) (*models.VaultMemberResponse, error) {
+	request, err := findOpenAccessRequest(requestID)
+	if err != nil {
+		return nil, err
+	}
+	admin, err := findVaultAdmin(authz.MemberAdd, userID, request.VaultID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := repository.FindUserByID(request.UserID)
+	if err != nil {
+		return nil, errors.NewAppError(404, "User not found")
+	}
+	if !models.SameFingerprint(req.Fingerprint, models.PublicKeyFingerprint(user.Keys.PublicKey)) {
+		return nil, errors.NewAppError(409, "Public key fingerprint does not match")
+	}
+	if _, err := repository.FindMemberByUserVaultID(request.VaultID, user.ID); err == nil {
+		return nil, errors.NewAppError(409, "User is already a member of the vault")
+	}
+
+	esvkBytes, err := base64.StdEncoding.DecodeString(req.ESVK_PubK_User)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid eskv")
+	}
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	member := models.VaultMember{
+		ID:             primitive.NewObjectID(),
+		VaultID:        request.VaultID,
+		OrgID:          request.OrgID,
+		UserID:         user.ID,
+		ESVK_PubK_User: esvkBytes,
+		Permission:     request.Permission,
+		AddedBy:        admin.UserID,
+		AddAt:          now,
+		UpdatedAt:      now,
+	}
+	if err := addVaultMember(&member); err != nil {
+		return nil, err
+	}
+
+	// O pedido só fecha depois que o acesso existe. Se outro admin o recusou ou
+	// ele venceu nesse meio tempo, o acesso recém-criado é desfeito.
+	closed, err := repository.CloseAccessRequest(request.ID, models.AccessRequestEvent{
+		Status:  models.AccessRequestApproved,
+		ActorID: &admin.UserID,
+		Note:    strings.TrimSpace(req.Note),
+		At:      now,
+	})
+	if err == nil && !closed {
+		err = errors.NewAppError(409, "Access request is no longer pending")
+	}
+	if err != nil {
+		repository.DeleteVaultMember(member.ID)
+		return nil, err
+	}
+
+	go notifyVaultMembers(models.EventMemberAdded, member.OrgID, member.VaultID, member.ID, admin.UserID)
+	go dispatchMemberAdded(&member, "access_request")
+	go recordAudit(meta, memberAuditEntry(models.AuditMemberAdded, &member, &admin.UserID, "access_request"))
+	go queueEmail(utils.AccessRequestDecisionEmail(userMailBrand(user), user.Email, request.VaultName, models.AccessRequestApproved, strings.TrimSpace(req.Note)))
+
+	res := newVaultMemberResponse(&member, user)
+	return &res, nil
+}
+
+func RejectAccessRequest(userID, requestID string, req *models.RejectAccessRequestRequest) error {
+	request, err := findOpenAccessRequest(requestID)
+	if err != nil {
+		return err
+	}
+	admin, err := findVaultAdmin(authz.MemberAdd, userID, request.VaultID.Hex())
+	if err != nil {
+		return err
+	}
+
+	closed, err := repository.CloseAccessRequest(request.ID, models.AccessRequestEvent{
+		Status:  models.AccessRequestRejected,
+		ActorID: &admin.UserID,
+		Note:    strings.TrimSpace(req.Note),
+		At:      primitive.NewDateTimeFromTime(time.Now()),
+	})
+	if err != nil {
+		return err
+	}
+	if !closed {
+		return errors.NewAppError(409, "Access request is no longer pending")
+	}
+
+	if user, err := repository.FindUserByID(request.UserID); err == nil {
+		go queueEmail(utils.AccessRequestDecisionEmail(userMailBrand(user), user.Email, request.VaultName, models.AccessRequestRejected, strings.TrimSpace(req.Note)))
+	}
+	return nil
+}
+
+// CancelAccessRequest só pode ser feito por quem pediu.
+func CancelAccessRequest(userID, requestID string) error {
+	request, err := findOpenAccessRequest(requestID)
+	if err != nil {
+		return err
+	}
+	if request.UserID.Hex() != userID {
+		return errors.NewAppError(403, "Invalid Permission")
+	}
+
+	closed, err := repository.CloseAccessRequest(request.ID, models.AccessRequestEvent{
+		Status:  models.AccessRequestCancelled,
+		ActorID: &request.UserID,
+		At:      primitive.NewDateTimeFromTime(time.Now()),
+	})
+	if err != nil {
+		return err
+	}
+	if !closed {
+		return errors.NewAppError(409, "Access request is no longer pending")
+	}
+	return nil
+}
+
+// expireAccessRequests fecha os pedidos vencidos e avisa os solicitantes.
+func expireAccessRequests(now time.Time) {
+	requests, err := repository.FindExpiredAccessRequests(now)
+	if err != nil {
+		fmt.Printf("Failed to find expired access requests: %v\n", err)
+		return
+	}
+
+	for _, request := range requests {
+		user, err := repository.FindUserByID(request.UserID)
+		if err != nil {
+			user = nil
+		}
+		expireAccessRequest(&request, user)
+	}
+}
+
+func expireAccessRequest(request *models.AccessRequest, user *models.User) {
+	closed, err := repository.CloseAccessRequest(request.ID, models.AccessRequestEvent{
+		Status: models.AccessRequestExpired,
+		At:     primitive.NewDateTimeFromTime(time.Now()),
+	})
+	if err != nil {
+		fmt.Printf("Failed to expire access request %s: %v\n", request.ID.Hex(), err)
+		return
+	}
+	if closed && user != nil {
+		go queueEmail(utils.AccessRequestDecisionEmail(userMailBrand(user), user.Email, request.VaultName, models.AccessRequestExpired, ""))
+	}
+}
+
+func emailAccessRequestAdmins(request *models.AccessRequest, requester *models.User) {
+	members, err := repository.FindAllVaultMembersByVaultID(request.VaultID)
+	if err != nil {
+		fmt.Printf("Failed to find admins of vault %s: %v\n", request.VaultID.Hex(), err)
+		return
+	}
+	org, err := repository.FindOrganizationByID(request.OrgID)
+	if err != nil {
+		org = nil
+	}
+
+	for _, member := range members {
+		if member.Permission != models.ADMIN {
+			continue
+		}
+		admin, err := repository.FindUserByID(member.UserID)
+		if err != nil {
+			continue
+		}
+		queueEmail(utils.AccessRequestEmail(orgMailBrand(org, admin), admin.Email, requester.Email, request.VaultName, request.Permission, request.Justification, request.ExpiresAt.Time()))
+	}
+}
+
+func findOpenAccessRequest(requestID string) (*models.AccessRequest, error) {
+	requestObjID, err := primitive.ObjectIDFromHex(requestID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid requestID")
+	}
+
+	request, err := repository.FindAccessRequestByID(requestObjID)
+	if err != nil {
+		return nil, errors.NewAppError(404, "Access request not found")
+	}
+	if !accessRequestOpen(request) {
+		return nil, errors.NewAppError(409, "Access request is no longer pending")
+	}
+	return request, nil
+}
+
+func accessRequestOpen(request *models.AccessRequest) bool {
+	return request.Status == models.AccessRequestPending && request.ExpiresAt.Time().After(time.Now())
+}
@@ -0,0 +1,421 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"lembrago.com/lembrago/authz"
+	"lembrago.com/lembrago/errors"
+	"lembrago.com/lembrago/models"
+	"lembrago.com/lembrago/repository"
+	"lembrago.com/lembrago/utils"
+)
+
+const (
+	attachmentDir       = "./attachments"
+	MaxChunkSize        = 8 * 1024 * 1024 // 8 MB por chunk cifrado
+	maxChunkCount       = 10000
+	attachmentUploadTTL = 24 * time.Hour // uploads não concluídos depois disso são apagados
+)
+
+var planStorageQuota = map[models.SubscriptionPlan]int64{
+	models.BasicPlan:  1 * 1024 * 1024 * 1024,  // 1 GB
+	models.UniquePlan: 10 * 1024 * 1024 * 1024, // 10 GB
+}
+
+func CreateAttachment(userID string, req *models.CreateAttachmentRequest) (*models.AttachmentResponse, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid userID")
+	}
+
+	passwordObjID, err := primitive.ObjectIDFromHex(req.PasswordID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid passwordID")
+	}
+
+	password, err := repository.FindPasswordByID(passwordObjID)
+	if err != nil {
+		return nil, errors.NewAppError(404, "Password not found")
+	}
+
+	permission, err := authorizeVault(authz.AttachmentCreate, userObjID, password.VaultID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ChunkCount > maxChunkCount || int64(req.ChunkCount) > req.Size {
+		return nil, errors.NewAppError(400, "Invalid chunkCount")
+	}
+	if req.Size > int64(req.ChunkCount)*MaxChunkSize {
+		return nil, errors.NewAppError(400, fmt.Sprintf("Chunks can not exceed %d bytes", MaxChunkSize))
+	}
+
+	cipherBytes, err := utils.Base64ToBytes(req.EncryptedMetadata.Ciphertext)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid base64 Ciphertext format")
+	}
+	nonceBytes, err := utils.Base64ToBytes(req.EncryptedMetadata.Nonce)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid base64 Nonce format")
+	}
+
+	attachment := models.Attachment{
+		ID:         primitive.NewObjectID(),
+		OrgID:      permission.OrgID,
+		VaultID:    password.VaultID,
+		PasswordID: password.ID,
+		EncryptedMetadata: models.EncryptedKey{
+			Ciphertext: cipherBytes,
+			Nonce:      nonceBytes,
+		},
+		Size:       req.Size,
+		ChunkCount: req.ChunkCount,
+		ChunkSizes: []int64{},
+		Status:     models.AttachmentUploading,
+		CreatedBy:  userObjID,
+		CreatedAt:  primitive.NewDateTimeFromTime(time.Now()),
+		UpdatedAt:  primitive.NewDateTimeFromTime(time.Now()),
+	}
+
+	if err := reserveStorage(permission.OrgID, req.Size); err != nil {
+		return nil, err
+	}
+	err = repository.CreateAttachment(&attachment)
+	if err != nil {
+		repository.ReleaseOrgStorage(permission.OrgID, req.Size)
+		return nil, errors.NewAppError(500, "Unknown Error")
+	}
+
+	res := NewAttachmentResponse(attachment)
+	return &res, nil
+}
+
+func UploadAttachmentChunk(userID, attachmentID string, index int, body io.Reader) error {
+	attachment, err := findAttachmentWithPermission(authz.AttachmentCreate, userID, attachmentID)
+	if err != nil {
+		return err
+	}
+
+	if attachment.Status != models.AttachmentUploading {
+		return errors.NewAppError(409, "Attachment upload already completed")
+	}
+	if index < 0 || index >= attachment.ChunkCount {
+		return errors.NewAppError(400, "Invalid chunk index")
+	}
+
+	dir := filepath.Join(attachmentDir, attachment.ID.Hex())
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create attachment dir: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "chunk-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create chunk file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, io.LimitReader(body, MaxChunkSize+1))
+	tmp.Close()
+	if err != nil {
+		return errors.NewAppError(400, "Failed to read chunk")
+	}
+	if written == 0 {
+		return errors.NewAppError(400, "Empty chunk")
+	}
+	if written > MaxChunkSize {
+		return errors.NewAppError(413, fmt.Sprintf("Chunks can not exceed %d bytes", MaxChunkSize))
+	}
+
+	// O total enviado nunca pode passar do tamanho reservado na criação,
+	// senão a cota da organização poderia ser contornada.
+	sizes := readChunkSizes(dir, attachment.ChunkCount)
+	var total int64
+	for i, size := range sizes {
+		if i != index {
+			total += size
+		}
+	}
+	if total+written > attachment.Size {
+		return errors.NewAppError(413, "Chunk exceeds the declared attachment size")
+	}
+
+	if err := os.Rename(tmp.Name(), chunkPath(attachment.ID, index)); err != nil {
+		return fmt.Errorf("failed to save chunk: %v", err)
+	}
+
+	return nil
+}
+
+func CompleteAttachment(userID, attachmentID string) (*models.AttachmentResponse, error) {
+	attachment, err := findAttachmentWithPermission(authz.AttachmentCreate, userID, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if attachment.Status != models.AttachmentUploading {
+		return nil, errors.NewAppError(409, "Attachment upload already completed")
+	}
+
+	dir := filepath.Join(attachmentDir, attachment.ID.Hex())
+	sizes := readChunkSizes(dir, attachment.ChunkCount)
+
+	var total int64
+	for i, size := range sizes {
+		if size == 0 {
+			return nil, errors.NewAppError(400, fmt.Sprintf("Chunk %d is missing", i))
+		}
+		total += size
+	}
+	if total != attachment.Size {
+		return nil, errors.NewAppError(400, "Uploaded size does not match the declared size")
+	}
+
+	err = repository.CompleteAttachment(attachment.ID, sizes)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment.ChunkSizes = sizes
+	attachment.Status = models.AttachmentAvailable
+	res := NewAttachmentResponse(*attachment)
+	return &res, nil
+}
+
+// OpenAttachmentChunk valida a participação do usuário no cofre a cada download.
+func OpenAttachmentChunk(userID, attachmentID string, index int) (*os.File, int64, error) {
+	attachment, err := findAttachmentWithPermission(authz.AttachmentRead, userID, attachmentID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if attachment.Status != models.AttachmentAvailable {
+		return nil, 0, errors.NewAppError(409, "Attachment upload not completed")
+	}
+	if index < 0 || index >= attachment.ChunkCount {
+		return nil, 0, errors.NewAppError(400, "Invalid chunk index")
+	}
+
+	file, err := os.Open(chunkPath(attachment.ID, index))
+	if err != nil {
+		return nil, 0, errors.NewAppError(404, "Chunk not found")
+	}
+
+	return file, attachment.ChunkSizes[index], nil
+}
+
+func GetAllAttachmentsFromPassword(userID, passwordID string) ([]models.AttachmentResponse, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid userID")
+	}
+
+	passwordObjID, err := primitive.ObjectIDFromHex(passwordID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid passwordID")
+	}
+
+	password, err := repository.FindPasswordByID(passwordObjID)
+	if err != nil {
+		return nil, errors.NewAppError(404, "Password not found")
+	}
+
+	if _, err := authorizeVault(authz.AttachmentList, userObjID, password.VaultID); err != nil {
+		return nil, err
+	}
+
+	attachments, err := repository.FindAllAttachmentsByPasswordID(passwordObjID)
+	if err != nil {
+		return nil, errors.NewAppError(404, "Attachments not found")
+	}
+
+	responses := make([]models.AttachmentResponse, 0, len(attachments))
+	for _, attachment := range attachments {
+		responses = append(responses, NewAttachmentResponse(attachment))
+	}
+
+	return responses, nil
+}
+
+func DeleteAttachment(userID, attachmentID string) error {
+	attachment, err := findAttachmentWithPermission(authz.AttachmentDelete, userID, attachmentID)
+	if err != nil {
+		return err
+	}
+
+	return removeAttachment(attachment)
+}
+
+func GetStorageUsage(orgID string) (*models.StorageUsageResponse, error) {
+	orgObjID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid orgID")
+	}
+
+	return getStorageUsage(orgObjID)
+}
+
+func getStorageUsage(orgID primitive.ObjectID) (*models.StorageUsageResponse, error) {
+	organization, err := repository.FindOrganizationByID(orgID)
+	if err != nil {
+		return nil, errors.NewAppError(404, "Organization not found")
+	}
+
+	quota := organization.StorageQuota
+	if quota == 0 {
+		quota = planStorageQuota[organization.SubscriptionPlan]
+	}
+
+	if organization.StorageUsed == nil {
+		// Organização anterior ao contador: ele parte da soma dos anexos existentes.
+		used, err := repository.SumAttachmentSizeByOrgID(orgID)
+		if err != nil {
+			return nil, errors.NewAppError(500, "Unknown Error")
+		}
+		if err := repository.InitOrgStorageUsed(orgID, used); err != nil {
+			return nil, errors.NewAppError(500, "Unknown Error")
+		}
+		organization.StorageUsed = &used
+	}
+
+	return &models.StorageUsageResponse{Used: *organization.StorageUsed, Quota: quota}, nil
+}
+
+// reserveStorage reserva na criação o tamanho declarado do anexo. A conferência
+// e a soma acontecem na mesma operação, então criações simultâneas não passam
+// juntas da cota.
+func reserveStorage(orgID primitive.ObjectID, size int64) error {
+	usage, err := getStorageUsage(orgID)
+	if err != nil {
+		return err
+	}
+
+	reserved, err := repository.ReserveOrgStorage(orgID, size, usage.Quota)
+	if err != nil {
+		return errors.NewAppError(500, "Unknown Error")
+	}
+	if !reserved {
+		return errors.NewAppError(413, "Organization storage quota exceeded")
+	}
+	return nil
+}
+
+func findAttachmentWithPermission(action authz.Action, userID, attachmentID string) (*models.Attachment, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid userID")
+	}
+
+	attachmentObjID, err := primitive.ObjectIDFromHex(attachmentID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid attachmentID")
+	}
+
+	attachment, err := repository.FindAttachmentByID(attachmentObjID)
+	if err != nil {
+		return nil, errors.NewAppError(404, "Attachment not found")
+	}
+
+	if _, err := authorizeVault(action, userObjID, attachment.VaultID); err != nil {
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+func removeAttachment(attachment *models.Attachment) error {
+	deleted, err := repository.DeleteAttachment(attachment.ID)
+	if err != nil {
+		return err
+	}
+	if deleted {
+		releaseAttachment(attachment)
+	}
+	return nil
+}
+
+// releaseAttachment apaga os chunks, devolve a cota e registra a remoção de um
+// anexo que acabou de sair do banco. Quem não apagou o documento não chama, para
+// a cota não ser devolvida duas vezes.
+func releaseAttachment(attachment *models.Attachment) {
+	dir := filepath.Join(attachmentDir, attachment.ID.Hex())
+	if err := os.RemoveAll(dir); err != nil {
+		fmt.Printf("Failed to delete attachment dir '%s' from filesystem: %v\n", dir, err)
+	}
+	if err := repository.ReleaseOrgStorage(attachment.OrgID, attachment.Size); err != nil {
+		fmt.Printf("Failed to release storage of attachment %s: %v\n", attachment.ID.Hex(), err)
+	}
+
+	recordTombstone(models.TombstoneAttachment, attachment.OrgID, attachment.VaultID, attachment.ID, nil)
+}
+
+func removeAttachmentsByPasswordID(passwordID primitive.ObjectID) error {
+	attachments, err := repository.FindAllAttachmentsByPasswordID(passwordID)
+	if err != nil {
+		return fmt.Errorf("failed to find attachments: %v", err)
+	}
+
+	for _, attachment := range attachments {
+		if err := removeAttachment(&attachment); err != nil {
+			return fmt.Errorf("failed to remove attachment: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func removeAttachmentsByVaultID(vaultID primitive.ObjectID) error {
+	attachments, err := repository.FindAllAttachmentsByVaultID(vaultID)
+	if err != nil {
+		return fmt.Errorf("failed to find attachments: %v", err)
+	}
+
+	for _, attachment := range attachments {
+		if err := removeAttachment(&attachment); err != nil {
+			return fmt.Errorf("failed to remove attachment: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func chunkPath(attachmentID primitive.ObjectID, index int) string {
+	return filepath.Join(attachmentDir, attachmentID.Hex(), strconv.Itoa(index)+".chunk")
+}
+
+func readChunkSizes(dir string, chunkCount int) []int64 {
+	sizes := make([]int64, chunkCount)
+	for i := range sizes {
+		info, err := os.Stat(filepath.Join(dir, strconv.Itoa(i)+".chunk"))
+		if err == nil {
+			sizes[i] = info.Size()
+		}
+	}
+	return sizes
+}
+
+func NewAttachmentResponse(attachment models.Attachment) models.AttachmentResponse {
+	chunkSizes := attachment.ChunkSizes
+	if chunkSizes == nil {
+		chunkSizes = []int64{}
+	}
+
+	return models.AttachmentResponse{
+		ID:                attachment.ID.Hex(),
+		VaultID:           attachment.VaultID.Hex(),
+		PasswordID:        attachment.PasswordID.Hex(),
+		EncryptedMetadata: utils.FacEncryptedKeyDto(attachment.EncryptedMetadata.Ciphertext, attachment.EncryptedMetadata.Nonce),
+		Size:              attachment.Size,
+		ChunkCount:        attachment.ChunkCount,
+		ChunkSizes:        chunkSizes,
+		Status:            string(attachment.Status),
+		CreatedBy:         attachment.CreatedBy.Hex(),
+		CreatedAt:         attachment.CreatedAt.Time().Format(time.RFC3339),
+		UpdatedAt:         attachment.UpdatedAt.Time().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"lembrago.com/lembrago/errors"
+	"lembrago.com/lembrago/models"
+	"lembrago.com/lembrago/repository"
+)
+
+const (
+	auditAppendAttempts = 10
+	auditDefaultLimit   = 50
+)
+
+// recordAudit acrescenta o registro ao fim da cadeia da organização. Roda fora
+// da requisição: se duas instâncias disputam o mesmo seq, o índice único recusa
+// uma delas, que relê o último registro e tenta de novo.
+func recordAudit(meta models.RequestMeta, entry models.AuditEntry) {
+	entry.IP = meta.IP
+	entry.UserAgent = meta.UserAgent
+	entry.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
+	forwardAuditEntry(&entry)
+
+	for attempt := 0; attempt < auditAppendAttempts; attempt++ {
+		last, err := repository.FindLastAuditEntry(entry.OrgID)
+		if err != nil {
+			fmt.Printf("Failed to read audit chain of org %s: %v\n", entry.OrgID.Hex(), err)
+			return
+		}
+
+		entry.ID = primitive.NewObjectID()
+		entry.Seq = 1
+		entry.PrevHash = nil
+		if last != nil {
+			entry.Seq = last.Seq + 1
+			entry.PrevHash = last.Hash
+		}
+		entry.Hash = auditEntryHash(&entry)
+
+		inserted, err := repository.InsertAuditEntry(&entry)
+		if err != nil {
+			fmt.Printf("Failed to record audit entry %s: %v\n", entry.Action, err)
+			return
+		}
+		if inserted {
+			return
+		}
+	}
+	fmt.Printf("Failed to record audit entry %s: too much contention\n", entry.Action)
+}
+
+// forwardSecurityEvent manda o registro só para o SIEM, sem gravá-lo na cadeia
+// da organização. Serve para eventos cuja organização vem do cliente e não foi
+// confirmada, que de outro modo encheriam a cadeia de qualquer orgId.
+func forwardSecurityEvent(meta models.RequestMeta, entry models.AuditEntry) {
+	entry.IP = meta.IP
+	entry.UserAgent = meta.UserAgent
+	entry.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
+	forwardAuditEntry(&entry)
+}
+
+// recordAuditEntries grava em sequência, para que registros da mesma ação não
+// disputem entre si o próximo seq da cadeia.
+func recordAuditEntries(meta models.RequestMeta, entries []models.AuditEntry) {
+	for _, entry := range entries {
+		recordAudit(meta, entry)
+	}
+}
+
+// vaultAuditEntry monta o registro das ações feitas dentro de um cofre.
+func vaultAuditEntry(action models.AuditAction, orgID, vaultID, actorID primitive.ObjectID, targetType, targetID string) models.AuditEntry {
+	return models.AuditEntry{
+		OrgID:      orgID,
+		Action:     action,
+		ActorID:    &actorID,
+		TargetType: targetType,
+		TargetID:   targetID,
+		VaultID:    &vaultID,
+	}
+}
+
+// memberAuditEntry é o registro da entrada ou saída de um membro do cofre.
+// source diz por onde: "direct", "access_request", "pending_share", "group",
+// "import", "expired" ou "service_account_deleted". actorID nil marca ações do sistema.
+func memberAuditEntry(action models.AuditAction, member *models.VaultMember, actorID *primitive.ObjectID, source string) models.AuditEntry {
+	details := map[string]string{"userId": member.UserID.Hex(), "source": source}
+	if action == models.AuditMemberAdded {
+		details["permission"] = string(member.Permission)
+	}
+	vaultID := member.VaultID
+	return models.AuditEntry{
+		OrgID:      member.OrgID,
+		Action:     action,
+		ActorID:    actorID,
+		TargetType: models.AuditTargetMember,
+		TargetID:   member.ID.Hex(),
+		VaultID:    &vaultID,
+		Details:    details,
+	}
+}
+
+// auditEntryHash cobre todos os campos do registro e o hash do anterior. O JSON
+// de uma struct tem ordem fixa e o de um map sai com as chaves ordenadas, então
+// o mesmo registro sempre gera os mesmos bytes.
+func auditEntryHash(entry *models.AuditEntry) []byte {
+	hashed := struct {
+		OrgID      string             `json:"orgId"`
+		Seq        int64              `json:"seq"`
+		Action     models.AuditAction `json:"action"`
+		ActorID    string             `json:"actorId"`
+		TargetType string             `json:"targetType"`
+		TargetID   string             `json:"targetId"`
+		VaultID    string             `json:"vaultId"`
+		IP         string             `json:"ip"`
+		UserAgent  string             `json:"userAgent"`
+		Details    map[string]string  `json:"details"`
+		CreatedAt  int64              `json:"createdAt"`
+		PrevHash   string             `json:"prevHash"`
+	}{
+		OrgID:      entry.OrgID.Hex(),
+		Seq:        entry.Seq,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		IP:         entry.IP,
+		UserAgent:  entry.UserAgent,
+		Details:    entry.Details,
+		CreatedAt:  int64(entry.CreatedAt),
+		PrevHash:   hex.EncodeToString(entry.PrevHash),
+	}
+	if entry.ActorID != nil {
+		hashed.ActorID = entry.ActorID.Hex()
+	}
+	if entry.VaultID != nil {
+		hashed.VaultID = entry.VaultID.Hex()
+	}
+
+	canonical, _ := json.Marshal(hashed)
+	sum := sha256.Sum256(canonical)
+	return sum[:]
+}
+
+func GetAuditLog(orgID string, query *models.AuditQuery) (*models.AuditLogResponse, error) {
+	orgObjID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid orgID")
+	}
+
+	filter := models.AuditFilter{
+		OrgID:    orgObjID,
+		Action:   models.AuditAction(query.Action),
+		TargetID: query.TargetID,
+	}
+	if query.ActorID != "" {
+		actorObjID, err := primitive.ObjectIDFromHex(query.ActorID)
+		if err != nil {
+			return nil, errors.NewAppError(400, "Invalid actorId")
+		}
+		filter.ActorID = &actorObjID
+	}
+	if query.VaultID != "" {
+		vaultObjID, err := primitive.ObjectIDFromHex(query.VaultID)
+		if err != nil {
+			return nil, errors.NewAppError(400, "Invalid vaultId")
+		}
+		filter.VaultID = &vaultObjID
+	}
+	if query.From != "" {
+		from, err := time.Parse(time.RFC3339, query.From)
+		if err != nil {
+			return nil, errors.NewAppError(400, "Invalid from")
+		}
+		filter.From = &from
+	}
+	if query.To != "" {
+		to, err := time.Parse(time.RFC3339, query.To)
+		if err != nil {
+			return nil, errors.NewAppError(400, "Invalid to")
+		}
+		filter.To = &to
+	}
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = auditDefaultLimit
+	}
+
+	entries, total, err := repository.FindAuditEntries(filter, query.Page, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	res := models.AuditLogResponse{
+		Entries: make([]models.AuditEntryResponse, 0, len(entries)),
+		Page:    query.Page,
+		Limit:   limit,
+		Total:   total,
+	}
+	for _, entry := range entries {
+		res.Entries = append(res.Entries, newAuditEntryResponse(&entry))
+	}
+	return &res, nil
+}
+
+// VerifyAuditLog refaz a cadeia da organização do primeiro ao último registro e
+// para no primeiro que não confere: seq fora de ordem, elo com o anterior
+// quebrado ou hash diferente do conteúdo.
+func VerifyAuditLog(orgID string) (*models.AuditVerifyResponse, error) {
+	orgObjID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid orgID")
+	}
+
+	res := models.AuditVerifyResponse{Valid: true}
+	var prevHash []byte
+	broken := fmt.Errorf("audit chain broken")
+
+	err = repository.ForEachAuditEntry(orgObjID, func(entry *models.AuditEntry) error {
+		reason := ""
+		switch {
+		case entry.Seq != res.Checked+1:
+			reason = "missing or duplicated entry"
+		case !bytes.Equal(entry.PrevHash, prevHash):
+			reason = "previous hash does not match"
+		case !bytes.Equal(entry.Hash, auditEntryHash(entry)):
+			reason = "entry was modified"
+		}
+		if reason != "" {
+			seq := entry.Seq
+			res.Valid = false
+			res.FirstInvalidSeq = &seq
+			res.Reason = reason
+			return broken
+		}
+
+		prevHash = entry.Hash
+		res.Checked++
+		return nil
+	})
+	if err != nil && err != broken {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func newAuditEntryResponse(entry *models.AuditEntry) models.AuditEntryResponse {
+	res := models.AuditEntryResponse{
+		ID:         entry.ID.Hex(),
+		Seq:        entry.Seq,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		IP:         entry.IP,
+		UserAgent:  entry.UserAgent,
+		Details:    entry.Details,
+		CreatedAt:  entry.CreatedAt.Time().Format(time.RFC3339),
+		Hash:       hex.EncodeToString(entry.Hash),
+	}
+	if entry.ActorID != nil {
+		res.ActorID = entry.ActorID.Hex()
+	}
+	if entry.VaultID != nil {
+		res.VaultID = entry.VaultID.Hex()
+	}
+	return res
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"lembrago.com/lembrago/authz"
+	"lembrago.com/lembrago/errors"
+	"lembrago.com/lembrago/models"
+	"lembrago.com/lembrago/repository"
+)
+
+// authorizeVault confere a ação no cofre e devolve o acesso de quem a faz. O
+// cofre só é carregado quando a regra exige o dono.
+func authorizeVault(action authz.Action, userID, vaultID primitive.ObjectID) (*models.VaultMember, error) {
+	subject := authz.Subject{}
+	if member, err := repository.FindMemberByUserVaultID(vaultID, userID); err == nil {
+		subject.Member = member
+	}
+	if authz.Matrix[action].Owner {
+		vault, err := repository.FindVaultByID(vaultID)
+		if err != nil {
+			return nil, errors.NewAppError(404, "Vault not found")
+		}
+		subject.Owner = vault.Owner() == userID
+	}
+
+	if err := authz.Check(action, subject); err != nil {
+		return nil, err
+	}
+	return subject.Member, nil
+}
+
+func authorizeOrg(action authz.Action, user *models.User) error {
+	permissions, err := RolePermissions(user.OrgID.Hex(), user.Role)
+	if err != nil {
+		return err
+	}
+	return authz.Check(action, authz.Subject{Permissions: permissions})
+}
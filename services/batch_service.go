@@ -0,0 +1,250 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"lembrago.com/lembrago/authz"
+	"lembrago.com/lembrago/database"
+	"lembrago.com/lembrago/errors"
+	"lembrago.com/lembrago/models"
+	"lembrago.com/lembrago/repository"
+	"lembrago.com/lembrago/utils"
+)
+
+// preparedOperation é uma operação do lote já validada e pronta para ser aplicada.
+type preparedOperation struct {
+	op       string
+	password models.Password
+	revision *int64
+	orgID    primitive.ObjectID
+}
+
+// ApplyItemsBatch valida todas as operações com as mesmas regras das rotas
+// individuais e aplica o lote inteiro numa única transação, ou nada.
+func ApplyItemsBatch(userID string, req *models.BatchItemsRequest, meta models.RequestMeta) (*models.BatchItemsResponse, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid userID")
+	}
+
+	response := models.BatchItemsResponse{
+		Applied: false,
+		Results: make([]models.BatchOperationResult, len(req.Operations)),
+	}
+	prepared := make([]preparedOperation, len(req.Operations))
+	memberships := make(map[primitive.ObjectID]*models.VaultMember)
+	failedStatus := 0
+
+	for i, operation := range req.Operations {
+		response.Results[i] = models.BatchOperationResult{Index: i, Op: operation.Op, PasswordID: operation.PasswordID}
+
+		prep, err := prepareBatchOperation(userObjID, operation, memberships)
+		if err != nil {
+			appErr, ok := err.(*errors.AppError)
+			if !ok {
+				appErr = errors.NewAppError(500, "Unknown Error")
+			}
+			response.Results[i].Status = appErr.Code
+			response.Results[i].Error = appErr.Message
+			if failedStatus == 0 {
+				failedStatus = appErr.Code
+			}
+			continue
+		}
+
+		prepared[i] = *prep
+		response.Results[i].PasswordID = prep.password.ID.Hex()
+	}
+
+	if failedStatus != 0 {
+		markNotApplied(&response)
+		return nil, errors.NewAppErrorWithDetails(failedStatus, "Batch rejected", response)
+	}
+
+	failedIndex := -1
+	err = database.WithTransaction(func(ctx mongo.SessionContext) error {
+		for i, prep := range prepared {
+			var err error
+			switch prep.op {
+			case models.BatchOpCreate:
+				err = repository.AddPasswordToVaultTx(ctx, prep.password)
+			case models.BatchOpUpdate:
+				err = repository.UpdatePasswordInVaultTx(ctx, prep.password.ID, prep.password.EncryptedItemData, userObjID, *prep.revision)
+			case models.BatchOpDelete:
+				err = repository.RemovePasswordFromVaultTx(ctx, prep.password.ID, prep.revision)
+			}
+			if err != nil {
+				failedIndex = i
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok && failedIndex >= 0 {
+			response.Results[failedIndex].Status = appErr.Code
+			response.Results[failedIndex].Error = appErr.Message
+			markNotApplied(&response)
+			return nil, errors.NewAppErrorWithDetails(appErr.Code, "Batch rejected", response)
+		}
+		return nil, fmt.Errorf("failed to apply batch: %v", err)
+	}
+
+	response.Applied = true
+	for i, prep := range prepared {
+		result := &response.Results[i]
+		switch prep.op {
+		case models.BatchOpCreate:
+			result.Status = 201
+			item := NewPasswordResponse(prep.password)
+			result.Item = &item
+		case models.BatchOpUpdate:
+			result.Status = 200
+			prep.password.Revision = *prep.revision + 1
+			item := NewPasswordResponse(prep.password)
+			result.Item = &item
+		case models.BatchOpDelete:
+			result.Status = 200
+		}
+	}
+
+	go afterItemsBatch(userObjID, prepared, meta)
+	return &response, nil
+}
+
+func prepareBatchOperation(userID primitive.ObjectID, operation models.BatchItemOperation, memberships map[primitive.ObjectID]*models.VaultMember) (*preparedOperation, error) {
+	var password models.Password
+
+	switch operation.Op {
+	case models.BatchOpCreate:
+		vaultObjID, err := primitive.ObjectIDFromHex(operation.VaultID)
+		if err != nil {
+			return nil, errors.NewAppError(400, "Invalid vaultID")
+		}
+		password = models.Password{
+			ID:             primitive.NewObjectID(),
+			VaultID:        vaultObjID,
+			CreatedBy:      userID,
+			LastModifiedBy: userID,
+			Revision:       1,
+			CreatedAt:      primitive.NewDateTimeFromTime(time.Now()),
+			UpdatedAt:      primitive.NewDateTimeFromTime(time.Now()),
+		}
+	case models.BatchOpUpdate, models.BatchOpDelete:
+		passwordObjID, err := primitive.ObjectIDFromHex(operation.PasswordID)
+		if err != nil {
+			return nil, errors.NewAppError(400, "Invalid passwordID")
+		}
+		current, err := repository.FindPasswordByID(passwordObjID)
+		if err != nil {
+			return nil, errors.NewAppError(404, "Password not found")
+		}
+		password = *current
+	default:
+		return nil, errors.NewAppError(400, "Invalid op")
+	}
+
+	membership, ok := memberships[password.VaultID]
+	if !ok {
+		member, err := repository.FindMemberByUserVaultID(password.VaultID, userID)
+		if err != nil {
+			member = nil
+		}
+		memberships[password.VaultID] = member
+		membership = member
+	}
+	// Mesmas regras de AddPasswordToVault, UpdatePasswordInVault e DeletePasswordFromVault.
+	if err := authz.Check(batchActions[operation.Op], authz.Subject{Member: membership}); err != nil {
+		return nil, err
+	}
+
+	if operation.Op == models.BatchOpUpdate {
+		if operation.Revision == nil {
+			return nil, errors.NewAppError(428, "Password revision is required")
+		}
+		if password.Revision != *operation.Revision {
+			return nil, errors.NewAppError(409, "Password was modified by someone else")
+		}
+	}
+	if operation.Op == models.BatchOpDelete && operation.Revision != nil && password.Revision != *operation.Revision {
+		return nil, errors.NewAppError(409, "Password was modified by someone else")
+	}
+
+	if operation.Op != models.BatchOpDelete {
+		if operation.EncryptedItemData == nil {
+			return nil, errors.NewAppError(400, "encryptedItemData is required")
+		}
+		cipherBytes, err := utils.Base64ToBytes(operation.EncryptedItemData.Ciphertext)
+		if err != nil || len(cipherBytes) == 0 {
+			return nil, errors.NewAppError(400, "Invalid base64 Ciphertext format")
+		}
+		nonceBytes, err := utils.Base64ToBytes(operation.EncryptedItemData.Nonce)
+		if err != nil || len(nonceBytes) == 0 {
+			return nil, errors.NewAppError(400, "Invalid base64 Nonce format")
+		}
+		password.EncryptedItemData = models.EncryptedKey{
+			Ciphertext: cipherBytes,
+			Nonce:      nonceBytes,
+		}
+		password.LastModifiedBy = userID
+		password.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
+	}
+
+	return &preparedOperation{
+		op:       operation.Op,
+		password: password,
+		revision: operation.Revision,
+		orgID:    membership.OrgID,
+	}, nil
+}
+
+var batchActions = map[string]authz.Action{
+	models.BatchOpCreate: authz.ItemCreate,
+	models.BatchOpUpdate: authz.ItemUpdate,
+	models.BatchOpDelete: authz.ItemDelete,
+}
+
+func markNotApplied(response *models.BatchItemsResponse) {
+	for i := range response.Results {
+		if response.Results[i].Status == 0 {
+			response.Results[i].Status = 424 // Failed Dependency
+			response.Results[i].Error = "Not applied because another operation failed"
+		}
+	}
+}
+
+func afterItemsBatch(userID primitive.ObjectID, prepared []preparedOperation, meta models.RequestMeta) {
+	recipients := make(map[primitive.ObjectID][]primitive.ObjectID)
+
+	for _, prep := range prepared {
+		vaultID := prep.password.VaultID
+		if _, ok := recipients[vaultID]; !ok {
+			members, err := repository.FindAllVaultMembersByVaultID(vaultID)
+			if err != nil {
+				fmt.Printf("Failed to find members to notify for vault %s: %v\n", vaultID.Hex(), err)
+			}
+			for _, member := range members {
+				recipients[vaultID] = append(recipients[vaultID], member.UserID)
+			}
+		}
+
+		switch prep.op {
+		case models.BatchOpCreate:
+			publishEvent(recipients[vaultID], models.EventItemCreated, prep.orgID, vaultID, prep.password.ID, userID)
+			recordAudit(meta, vaultAuditEntry(models.AuditItemCreated, prep.orgID, vaultID, userID, models.AuditTargetItem, prep.password.ID.Hex()))
+		case models.BatchOpUpdate:
+			publishEvent(recipients[vaultID], models.EventItemUpdated, prep.orgID, vaultID, prep.password.ID, userID)
+			recordAudit(meta, vaultAuditEntry(models.AuditItemUpdated, prep.orgID, vaultID, userID, models.AuditTargetItem, prep.password.ID.Hex()))
+		case models.BatchOpDelete:
+			recordTombstone(models.TombstoneItem, prep.orgID, vaultID, prep.password.ID, nil)
+			publishEvent(recipients[vaultID], models.EventItemDeleted, prep.orgID, vaultID, prep.password.ID, userID)
+			recordAudit(meta, vaultAuditEntry(models.AuditItemDeleted, prep.orgID, vaultID, userID, models.AuditTargetItem, prep.password.ID.Hex()))
+			if err := removeAttachmentsByPasswordID(prep.password.ID); err != nil {
+				fmt.Printf("Failed to remove attachments of %s: %v\n", prep.password.ID.Hex(), err)
+			}
+		}
+	}
+}
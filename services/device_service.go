@@ -0,0 +1,218 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"lembrago.com/lembrago/cache"
+	"lembrago.com/lembrago/errors"
+	"lembrago.com/lembrago/internal/config"
+	"lembrago.com/lembrago/models"
+	"lembrago.com/lembrago/repository"
+	"lembrago.com/lembrago/utils"
+)
+
+const (
+	deviceReportTTL = 7 * 24 * time.Hour
+	unlockTTL       = 5 * time.Minute
+	// sessionTTL acompanha a validade dos tokens de GenerateJWT.
+	sessionTTL = 2160 * time.Hour
+)
+
+// checkLoginDevice registra o aparelho do login e, se ele for novo para um
+// usuário que já tinha outros, manda o alerta com o link "não fui eu". O
+// primeiro aparelho de cada usuário não gera alerta.
+func checkLoginDevice(user *models.User, deviceID string, meta models.RequestMeta) {
+	hasDevices, err := repository.HasUserDevices(user.ID)
+	if err != nil {
+		fmt.Printf("Failed to look up devices of %s: %v\n", user.ID.Hex(), err)
+		return
+	}
+	device, isNew, err := saveLoginDevice(user, deviceID, meta)
+	if err != nil {
+		fmt.Printf("Failed to save device of %s: %v\n", user.ID.Hex(), err)
+		return
+	}
+	if !isNew || !hasDevices {
+		return
+	}
+
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return
+	}
+	reportToken := base64.RawURLEncoding.EncodeToString(token)
+	key := deviceReportKey(reportToken)
+	if err := cache.SetStruct(key, &models.DeviceReport{UserID: user.ID.Hex(), DeviceID: device.ID.Hex()}); err != nil {
+		fmt.Printf("Failed to save device report token: %v\n", err)
+		return
+	}
+	cache.SetTTL(key, deviceReportTTL)
+
+	reportURL := config.GetServerConfig().SELF_URL + "/environment/devices/report/" + reportToken
+	go queueEmail(utils.NewDeviceEmail(userMailBrand(user), user.Email, meta.UserAgent, meta.IP, device.FirstSeenAt.Time(), reportURL))
+	recordAudit(meta, userAuditEntry(user, models.AuditNewDeviceLogin, map[string]string{"device": device.ID.Hex(), "deviceId": deviceID}))
+}
+
+func saveLoginDevice(user *models.User, deviceID string, meta models.RequestMeta) (*models.UserDevice, bool, error) {
+	now := primitive.NewDateTimeFromTime(time.Now())
+	device := models.UserDevice{
+		ID:          primitive.NewObjectID(),
+		UserID:      user.ID,
+		OrgID:       user.OrgID,
+		Fingerprint: deviceFingerprint(deviceID, meta.UserAgent),
+		DeviceID:    deviceID,
+		UserAgent:   meta.UserAgent,
+		IP:          meta.IP,
+		FirstSeenAt: now,
+		LastSeenAt:  now,
+	}
+	isNew, err := repository.SaveUserDevice(&device)
+	if err != nil {
+		return nil, false, err
+	}
+	return &device, isNew, nil
+}
+
+// deviceFingerprint usa o deviceId do cliente quando houver; sem ele, o user
+// agent é o melhor que dá para identificar o aparelho.
+func deviceFingerprint(deviceID, userAgent string) string {
+	source := "ua:" + userAgent
+	if deviceID != "" {
+		source = "id:" + deviceID
+	}
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:])
+}
+
+func findDeviceReport(reportToken string) (*models.DeviceReport, *models.User, error) {
+	var report models.DeviceReport
+	if err := cache.GetStruct(deviceReportKey(reportToken), &report); err != nil {
+		return nil, nil, errors.NewAppError(404, "Report link is invalid or has expired")
+	}
+
+	userObjID, err := primitive.ObjectIDFromHex(report.UserID)
+	if err != nil {
+		return nil, nil, errors.NewAppError(404, "Report link is invalid or has expired")
+	}
+	user, err := repository.FindUserByID(userObjID)
+	if err != nil {
+		return nil, nil, errors.NewAppError(404, "User not found")
+	}
+	return &report, user, nil
+}
+
+// DeviceReportPage é o que o link "não fui eu" abre: só a confirmação, sem
+// mexer na conta. Leitores de e-mail e antivírus abrem links sozinhos, então
+// quem trava a conta é o POST do formulário (ReportDevice).
+func DeviceReportPage(reportToken string) (string, error) {
+	report, user, err := findDeviceReport(reportToken)
+	if err != nil {
+		return "", err
+	}
+
+	var device models.UserDevice
+	if deviceObjID, err := primitive.ObjectIDFromHex(report.DeviceID); err == nil {
+		if found, err := repository.FindUserDeviceByID(deviceObjID); err == nil {
+			device = *found
+		}
+	}
+	return utils.DeviceReportPage(userMailBrand(user), device.UserAgent, device.IP, device.FirstSeenAt.Time(), false)
+}
+
+// ReportDevice confirma o "não fui eu": revoga todas as sessões do usuário,
+// esquece o aparelho denunciado e trava a conta até o e-mail ser verificado de
+// novo. O link só funciona uma vez. Devolve a página de conta travada.
+func ReportDevice(reportToken string, meta models.RequestMeta) (string, error) {
+	report, user, err := findDeviceReport(reportToken)
+	if err != nil {
+		return "", err
+	}
+	page, err := utils.DeviceReportPage(userMailBrand(user), "", "", time.Time{}, true)
+	if err != nil {
+		return "", err
+	}
+	cache.Delete(deviceReportKey(reportToken))
+
+	if err := revokeSessions(user.ID); err != nil {
+		return "", err
+	}
+	// A marca acima some junto com as sessões, mas tokens pessoais podem durar
+	// mais; por isso eles são apagados.
+	if err := repository.DeletePersonalAccessTokensByUserID(user.ID); err != nil {
+		return "", err
+	}
+
+	// Um usuário suspenso continua suspenso; só o admin pode reativá-lo.
+	if user.Status != models.StatusSuspended {
+		if err := repository.UpdateUserStatus(user.ID, models.StatusLocked); err != nil {
+			return "", err
+		}
+		if err := cache.Set(utils.LockedUserKey(user.ID.Hex()), "1"); err != nil {
+			return "", err
+		}
+	}
+
+	if deviceObjID, err := primitive.ObjectIDFromHex(report.DeviceID); err == nil {
+		repository.DeleteUserDevice(deviceObjID)
+	}
+
+	go recordAudit(meta, userAuditEntry(user, models.AuditAccountLocked, map[string]string{"reason": "not_me", "device": report.DeviceID}))
+	return page, nil
+}
+
+// revokeSessions derruba todos os JWTs já emitidos para o usuário. A marca dura
+// o mesmo que uma sessão, depois disso não há mais token antigo válido.
+func revokeSessions(userID primitive.ObjectID) error {
+	key := utils.RevokedSessionsKey(userID.Hex())
+	if err := cache.Set(key, strconv.FormatInt(time.Now().Unix(), 10)); err != nil {
+		return err
+	}
+	cache.SetTTL(key, sessionTTL)
+	return nil
+}
+
+// allowUnlock marca que o dono da conta travada acabou de confirmar o e-mail
+// com o código; o próximo login com a Senha Mestra destrava a conta.
+func allowUnlock(users []models.User) {
+	for _, user := range users {
+		if user.Status != models.StatusLocked {
+			continue
+		}
+		key := unlockKey(user.ID.Hex())
+		cache.Set(key, "1")
+		cache.SetTTL(key, unlockTTL)
+	}
+}
+
+// unlockAccount destrava a conta se o e-mail foi verificado há pouco.
+func unlockAccount(user *models.User, meta models.RequestMeta) error {
+	key := unlockKey(user.ID.Hex())
+	if verified, _ := cache.Exists(key); !verified {
+		return errors.NewAppError(403, "Account is locked, verify your email to unlock it")
+	}
+
+	if err := repository.UpdateUserStatus(user.ID, models.StatusActive); err != nil {
+		return err
+	}
+	cache.Delete(utils.LockedUserKey(user.ID.Hex()))
+	cache.Delete(key)
+	user.Status = models.StatusActive
+
+	go recordAudit(meta, userAuditEntry(user, models.AuditAccountUnlocked, nil))
+	return nil
+}
+
+func deviceReportKey(token string) string {
+	return "device-report-" + token
+}
+
+func unlockKey(userID string) string {
+	return "unlock-" + userID
+}
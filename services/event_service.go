@@ -0,0 +1,54 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"lembrago.com/lembrago/models"
+	"lembrago.com/lembrago/realtime"
+	"lembrago.com/lembrago/repository"
+)
+
+// notifyVaultMembers avisa todos os membros atuais do cofre, mais os destinatários
+// extras (ex.: quem acabou de ser removido).
+func notifyVaultMembers(eventType models.EventType, orgID, vaultID, objectID, actorID primitive.ObjectID, extra ...primitive.ObjectID) {
+	members, err := repository.FindAllVaultMembersByVaultID(vaultID)
+	if err != nil {
+		fmt.Printf("Failed to find members to notify for vault %s: %v\n", vaultID.Hex(), err)
+		return
+	}
+
+	recipients := make([]primitive.ObjectID, 0, len(members)+len(extra))
+	for _, member := range members {
+		recipients = append(recipients, member.UserID)
+	}
+	recipients = append(recipients, extra...)
+
+	publishEvent(recipients, eventType, orgID, vaultID, objectID, actorID)
+}
+
+func publishEvent(recipients []primitive.ObjectID, eventType models.EventType, orgID, vaultID, objectID, actorID primitive.ObjectID) {
+	seen := make(map[primitive.ObjectID]bool, len(recipients))
+	ids := make([]string, 0, len(recipients))
+	for _, recipient := range recipients {
+		if seen[recipient] {
+			continue
+		}
+		seen[recipient] = true
+		ids = append(ids, recipient.Hex())
+	}
+
+	event := models.ChangeEvent{
+		Type:     eventType,
+		OrgID:    orgID.Hex(),
+		VaultID:  vaultID.Hex(),
+		ObjectID: objectID.Hex(),
+		ActorID:  actorID.Hex(),
+		At:       time.Now().Format(time.RFC3339),
+	}
+
+	if err := realtime.Publish(ids, event); err != nil {
+		fmt.Printf("Failed to publish %s event: %v\n", eventType, err)
+	}
+}
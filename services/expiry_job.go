@@ -0,0 +1,135 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"lembrago.com/lembrago/cache"
+	"lembrago.com/lembrago/models"
+	"lembrago.com/lembrago/repository"
+	"lembrago.com/lembrago/utils"
+)
+
+const (
+	expiryJobInterval = time.Minute
+	expiryJobLockKey  = "lembrago:jobs:expiry"
+)
+
+// RunExpiryJob remove periodicamente os acessos expirados e os uploads de anexo
+// abandonados, e fecha os pedidos de acesso vencidos. Com várias instâncias, só
+// quem pega a trava no Redis executa a rodada.
+func RunExpiryJob() {
+	ticker := time.NewTicker(expiryJobInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		locked, err := cache.SetNX(expiryJobLockKey, "1", expiryJobInterval/2)
+		if err != nil {
+			fmt.Printf("Failed to acquire expiry job lock: %v\n", err)
+			continue
+		}
+		if !locked {
+			continue
+		}
+		now := time.Now()
+		expireMemberships(now)
+		expireAccessRequests(now)
+		expireStaleUploads(now)
+	}
+}
+
+// expireMemberships apaga os acessos vencidos, avisa os admins do cofre e o
+// usuário removido, e marca o cofre para rotação de chave.
+func expireMemberships(now time.Time) {
+	members, err := repository.FindExpiredVaultMembers(now)
+	if err != nil {
+		fmt.Printf("Failed to find expired vault members: %v\n", err)
+		return
+	}
+
+	for _, member := range members {
+		if err := expireMembership(member, now); err != nil {
+			fmt.Printf("Failed to remove expired member %s: %v\n", member.ID.Hex(), err)
+		}
+	}
+}
+
+// expireMembership apaga um acesso vencido e faz o que vem junto. Não faz nada
+// se a expiração foi prorrogada ou outra instância já apagou o acesso.
+func expireMembership(member models.VaultMember, now time.Time) error {
+	deleted, err := repository.DeleteExpiredVaultMember(member.ID, now)
+	if err != nil || !deleted {
+		return err
+	}
+
+	if err := repository.MarkVaultNeedsKeyRotation(member.VaultID); err != nil {
+		fmt.Printf("Failed to flag vault %s for key rotation: %v\n", member.VaultID.Hex(), err)
+	}
+	recordTombstone(models.TombstoneMember, member.OrgID, member.VaultID, member.ID, &member.UserID)
+	recordAudit(models.RequestMeta{}, memberAuditEntry(models.AuditMemberRemoved, &member, nil, "expired"))
+
+	var adminIDs []primitive.ObjectID
+	admins, err := repository.FindAllVaultMembersByVaultID(member.VaultID)
+	if err == nil {
+		for _, admin := range admins {
+			if admin.Permission == models.ADMIN {
+				adminIDs = append(adminIDs, admin.UserID)
+			}
+		}
+	}
+	recipients := append([]primitive.ObjectID{member.UserID}, adminIDs...)
+	publishEvent(recipients, models.EventMemberExpired, member.OrgID, member.VaultID, member.ID, member.UserID)
+	go emailMemberExpired(&member, adminIDs)
+	return nil
+}
+
+// emailMemberExpired avisa por e-mail o usuário removido e os admins do cofre.
+// O nome só vai no e-mail se o cofre estiver no diretório da organização.
+func emailMemberExpired(member *models.VaultMember, adminIDs []primitive.ObjectID) {
+	vaultName := ""
+	if vault, err := repository.FindVaultByID(member.VaultID); err == nil {
+		vaultName = vault.DirectoryName
+	}
+	org, err := repository.FindOrganizationByID(member.OrgID)
+	if err != nil {
+		org = nil
+	}
+
+	user, err := repository.FindUserByID(member.UserID)
+	if err != nil {
+		fmt.Printf("Failed to find expired member user %s: %v\n", member.UserID.Hex(), err)
+		return
+	}
+	queueEmail(utils.MemberExpiredEmail(orgMailBrand(org, user), user.Email, vaultName, ""))
+
+	for _, adminID := range adminIDs {
+		admin, err := repository.FindUserByID(adminID)
+		if err != nil {
+			continue
+		}
+		queueEmail(utils.MemberExpiredEmail(orgMailBrand(org, admin), admin.Email, vaultName, user.Email))
+	}
+}
+
+// expireStaleUploads apaga os anexos que ficaram em upload por mais de
+// attachmentUploadTTL, com os chunks já enviados e a cota que reservavam.
+func expireStaleUploads(now time.Time) {
+	before := now.Add(-attachmentUploadTTL)
+	attachments, err := repository.FindStaleUploadingAttachments(before)
+	if err != nil {
+		fmt.Printf("Failed to find stale attachment uploads: %v\n", err)
+		return
+	}
+
+	for _, attachment := range attachments {
+		deleted, err := repository.DeleteStaleUploadingAttachment(attachment.ID, before)
+		if err != nil {
+			fmt.Printf("Failed to remove stale attachment %s: %v\n", attachment.ID.Hex(), err)
+			continue
+		}
+		if deleted {
+			releaseAttachment(&attachment)
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package services
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"lembrago.com/lembrago/authz"
+	"lembrago.com/lembrago/database"
+	"lembrago.com/lembrago/errors"
+	"lembrago.com/lembrago/internal/config"
+	"lembrago.com/lembrago/models"
+	"lembrago.com/lembrago/repository"
+	"lembrago.com/lembrago/utils"
+)
+
+// ExportVaults gera um bundle assinado com todos os cofres que o usuário acessa na organização.
+// O servidor só manipula ciphertext: o bundle continua legível apenas pelo dono das chaves.
+func ExportVaults(userID, orgID string, meta models.RequestMeta) (*models.ExportBundle, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid userID")
+	}
+
+	orgObjID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid orgID")
+	}
+
+	user, err := repository.FindUserByID(userObjID)
+	if err != nil {
+		return nil, errors.NewAppError(404, "User not found")
+	}
+
+	members, err := repository.FindAllVaultMembersByUserOrgID(orgObjID, userObjID)
+	if err != nil {
+		return nil, errors.NewAppError(500, "Unknown Error")
+	}
+
+	payload := models.ExportPayload{
+		Format:     models.ExportFormat,
+		Version:    models.ExportFormatVersion,
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Server:     config.GetServerConfig().SELF_URL,
+		Exporter: models.ExportExporter{
+			UserID:    user.ID.Hex(),
+			OrgID:     orgObjID.Hex(),
+			Email:     user.Email,
+			PublicKey: utils.BytesToBase64(user.Keys.PublicKey),
+		},
+		Vaults: []models.ExportVault{},
+	}
+	var readEntries []models.AuditEntry
+
+	if len(members) > 0 {
+		vaultIDs := make([]primitive.ObjectID, 0, len(members))
+		membersByVault := make(map[primitive.ObjectID]*models.VaultMember, len(members))
+		for i := range members {
+			vaultIDs = append(vaultIDs, members[i].VaultID)
+			membersByVault[members[i].VaultID] = &members[i]
+		}
+
+		vaults, err := repository.FindVaultsByIDs(vaultIDs)
+		if err != nil {
+			return nil, errors.NewAppError(500, "Unknown Error")
+		}
+
+		items, err := repository.FindPasswordsByVaultIDsSince(vaultIDs, time.Time{})
+		if err != nil {
+			return nil, errors.NewAppError(500, "Unknown Error")
+		}
+
+		itemsByVault := make(map[primitive.ObjectID][]models.ExportItem)
+		for _, item := range items {
+			itemsByVault[item.VaultID] = append(itemsByVault[item.VaultID], models.ExportItem{
+				ID:                item.ID.Hex(),
+				EncryptedItemData: utils.FacEncryptedKeyDto(item.EncryptedItemData.Ciphertext, item.EncryptedItemData.Nonce),
+				Revision:          item.Revision,
+				CreatedAt:         item.CreatedAt.Time().Format(time.RFC3339),
+				UpdatedAt:         item.UpdatedAt.Time().Format(time.RFC3339),
+			})
+		}
+
+		for _, vault := range vaults {
+			member := membersByVault[vault.ID]
+			exportItems := itemsByVault[vault.ID]
+			if exportItems == nil {
+				exportItems = []models.ExportItem{}
+			}
+
+			payload.Vaults = append(payload.Vaults, models.ExportVault{
+				ID:                     vault.ID.Hex(),
+				PersonalVault:          vault.PersonalVault,
+				Permission:             string(member.Permission),
+				EncryptedVaultMetadata: utils.FacEncryptedKeyDto(vault.EncryptedVaultMetadata.Ciphertext, vault.EncryptedVaultMetadata.Nonce),
+				ESVK_PubK_User:         utils.BytesToBase64(member.ESVK_PubK_User),
+				Revision:               vault.Revision,
+				CreatedAt:              vault.CreatedAt.Time().Format(time.RFC3339),
+				UpdatedAt:              vault.UpdatedAt.Time().Format(time.RFC3339),
+				Items:                  exportItems,
+			})
+
+			readEntry := vaultAuditEntry(models.AuditItemsRead, orgObjID, vault.ID, userObjID, models.AuditTargetVault, vault.ID.Hex())
+			readEntry.Details = map[string]string{"count": strconv.Itoa(len(exportItems)), "source": "export"}
+			readEntries = append(readEntries, readEntry)
+		}
+	}
+
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode export payload: %v", err)
+	}
+
+	signingKey, err := utils.GetExportSigningKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load export signing key: %v", err)
+	}
+	publicKey := signingKey.Public().(ed25519.PublicKey)
+
+	go recordAuditEntries(meta, readEntries)
+
+	return &models.ExportBundle{
+		Payload: payloadBytes,
+		Signature: models.ExportSignature{
+			Algorithm: models.ExportSignatureAlg,
+			KeyID:     utils.ExportKeyID(publicKey),
+			PublicKey: utils.BytesToBase64(publicKey),
+			Value:     utils.BytesToBase64(ed25519.Sign(signingKey, payloadBytes)),
+		},
+	}, nil
+}
+
+func GetExportPublicKey() (*models.ExportPublicKeyResponse, error) {
+	signingKey, err := utils.GetExportSigningKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load export signing key: %v", err)
+	}
+	publicKey := signingKey.Public().(ed25519.PublicKey)
+
+	return &models.ExportPublicKeyResponse{
+		Algorithm: models.ExportSignatureAlg,
+		KeyID:     utils.ExportKeyID(publicKey),
+		PublicKey: utils.BytesToBase64(publicKey),
+	}, nil
+}
+
+// ImportVaults recria os cofres do bundle sob a conta do usuário, com novos IDs.
+// Os itens continuam cifrados com a mesma chave do cofre; só o ESVK precisa ser
+// reembrulhado pelo cliente quando o par de chaves do usuário mudou.
+func ImportVaults(userID string, req *models.ImportRequest, meta models.RequestMeta) (*models.ImportResponse, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid userID")
+	}
+
+	user, err := repository.FindUserByID(userObjID)
+	if err != nil {
+		return nil, errors.NewAppError(404, "User not found")
+	}
+
+	payload, err := verifyExportBundle(&req.Bundle, req.TrustedPublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	exporterPublicKey, _ := utils.Base64ToBytes(payload.Exporter.PublicKey)
+	sameKeys := len(exporterPublicKey) > 0 && bytes.Equal(exporterPublicKey, user.Keys.PublicKey)
+
+	keysByVault := make(map[string]models.ImportVaultKeys, len(req.Vaults))
+	for _, keys := range req.Vaults {
+		keysByVault[keys.ExportedVaultID] = keys
+	}
+
+	_, err = repository.FindVaultByID(user.ID)
+	hasPersonalVault := err == nil
+
+	response := models.ImportResponse{Vaults: []models.ImportedVault{}, Skipped: []string{}}
+	var missingKeys []string
+	var vaults []models.Vault
+	var vaultMembers []models.VaultMember
+	var passwords [][]models.Password
+	now := primitive.NewDateTimeFromTime(time.Now())
+
+	for _, exported := range payload.Vaults {
+		keys := keysByVault[exported.ID]
+		if keys.Skip {
+			response.Skipped = append(response.Skipped, exported.ID)
+			continue
+		}
+
+		esvk := keys.ESVK_PubK_User
+		if esvk == "" && sameKeys {
+			esvk = exported.ESVK_PubK_User
+		}
+		if esvk == "" {
+			missingKeys = append(missingKeys, exported.ID)
+			continue
+		}
+
+		vaultID := primitive.NewObjectID()
+		if exported.PersonalVault {
+			if hasPersonalVault {
+				return nil, errors.NewAppErrorWithDetails(409, "User already has a personal vault", map[string]interface{}{"exportedVaultId": exported.ID})
+			}
+			vaultID = user.ID
+			hasPersonalVault = true
+		} else if authorizeOrg(authz.VaultCreate, user) != nil {
+			return nil, errors.NewAppErrorWithDetails(403, "Only admin can create vault", map[string]interface{}{"exportedVaultId": exported.ID})
+		}
+
+		esvkBytes, err := utils.Base64ToBytes(esvk)
+		if err != nil || len(esvkBytes) == 0 {
+			return nil, errors.NewAppErrorWithDetails(400, "Invalid eskv", map[string]interface{}{"exportedVaultId": exported.ID})
+		}
+
+		metadata, err := decodeEncryptedKeyDto(exported.EncryptedVaultMetadata)
+		if err != nil {
+			return nil, errors.NewAppErrorWithDetails(400, "Invalid EncryptedVaultMetadata", map[string]interface{}{"exportedVaultId": exported.ID})
+		}
+
+		vaults = append(vaults, models.Vault{
+			ID:                     vaultID,
+			OrgID:                  user.OrgID,
+			EncryptedVaultMetadata: metadata,
+			PersonalVault:          exported.PersonalVault,
+			CreatedBy:              user.ID,
+			OwnerID:                user.ID,
+			Revision:               1,
+			UpdatedAt:              now,
+			CreatedAt:              now,
+		})
+		vaultMembers = append(vaultMembers, models.VaultMember{
+			ID:             primitive.NewObjectID(),
+			VaultID:        vaultID,
+			OrgID:          user.OrgID,
+			UserID:         user.ID,
+			ESVK_PubK_User: esvkBytes,
+			Permission:     models.ADMIN,
+			AddedBy:        user.ID,
+			AddAt:          now,
+			UpdatedAt:      now,
+		})
+
+		vaultPasswords := make([]models.Password, 0, len(exported.Items))
+		for _, item := range exported.Items {
+			data, err := decodeEncryptedKeyDto(item.EncryptedItemData)
+			if err != nil {
+				return nil, errors.NewAppErrorWithDetails(400, "Invalid encryptedItemData", map[string]interface{}{"exportedItemId": item.ID})
+			}
+			vaultPasswords = append(vaultPasswords, models.Password{
+				ID:                primitive.NewObjectID(),
+				VaultID:           vaultID,
+				EncryptedItemData: data,
+				CreatedBy:         user.ID,
+				LastModifiedBy:    user.ID,
+				Revision:          1,
+				CreatedAt:         now,
+				UpdatedAt:         now,
+			})
+		}
+		passwords = append(passwords, vaultPasswords)
+
+		response.Vaults = append(response.Vaults, models.ImportedVault{
+			ExportedVaultID: exported.ID,
+			VaultID:         vaultID.Hex(),
+			PersonalVault:   exported.PersonalVault,
+			Items:           len(vaultPasswords),
+		})
+	}
+
+	if len(missingKeys) > 0 {
+		return nil, errors.NewAppErrorWithDetails(422, "Re-wrapped vault keys are required", map[string]interface{}{"missingVaultKeys": missingKeys})
+	}
+
+	err = database.WithTransaction(func(ctx mongo.SessionContext) error {
+		for i := range vaults {
+			if err := repository.CreateVaultTx(ctx, &vaults[i]); err != nil {
+				return err
+			}
+			if err := repository.AddVaultMemberTx(ctx, &vaultMembers[i]); err != nil {
+				return err
+			}
+			if err := repository.AddPasswordsToVaultTx(ctx, passwords[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to import vaults: %v", err)
+	}
+
+	var entries []models.AuditEntry
+	for i, vault := range vaults {
+		go publishEvent([]primitive.ObjectID{user.ID}, models.EventVaultCreated, vault.OrgID, vault.ID, vault.ID, user.ID)
+		go dispatchWebhook(vault.OrgID, models.WebhookVaultCreated, map[string]string{"vaultId": vault.ID.Hex(), "createdBy": user.ID.Hex()})
+
+		vaultEntry := vaultAuditEntry(models.AuditVaultCreated, vault.OrgID, vault.ID, user.ID, models.AuditTargetVault, vault.ID.Hex())
+		vaultEntry.Details = map[string]string{"source": "import", "items": strconv.Itoa(len(passwords[i]))}
+		entries = append(entries, vaultEntry, memberAuditEntry(models.AuditMemberAdded, &vaultMembers[i], &user.ID, "import"))
+		for _, password := range passwords[i] {
+			itemEntry := vaultAuditEntry(models.AuditItemCreated, vault.OrgID, vault.ID, user.ID, models.AuditTargetItem, password.ID.Hex())
+			itemEntry.Details = map[string]string{"source": "import"}
+			entries = append(entries, itemEntry)
+		}
+	}
+	go recordAuditEntries(meta, entries)
+
+	return &response, nil
+}
+
+func verifyExportBundle(bundle *models.ExportBundle, trustedPublicKey string) (*models.ExportPayload, error) {
+	if bundle.Signature.Algorithm != models.ExportSignatureAlg {
+		return nil, errors.NewAppError(422, "Unsupported signature algorithm")
+	}
+
+	publicKey, err := utils.Base64ToBytes(bundle.Signature.PublicKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return nil, errors.NewAppError(422, "Invalid bundle public key")
+	}
+
+	signature, err := utils.Base64ToBytes(bundle.Signature.Value)
+	if err != nil || !ed25519.Verify(publicKey, bundle.Payload, signature) {
+		return nil, errors.NewAppError(422, "Invalid bundle signature")
+	}
+
+	// A assinatura só prova algo se a chave for conhecida: a deste servidor
+	// ou a que o usuário obteve do servidor de origem.
+	signingKey, err := utils.GetExportSigningKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load export signing key: %v", err)
+	}
+	trusted := bytes.Equal(publicKey, signingKey.Public().(ed25519.PublicKey))
+	if !trusted && trustedPublicKey != "" {
+		trustedBytes, err := utils.Base64ToBytes(trustedPublicKey)
+		trusted = err == nil && bytes.Equal(publicKey, trustedBytes)
+	}
+	if !trusted {
+		return nil, errors.NewAppErrorWithDetails(422, "Bundle signed by an untrusted key", map[string]interface{}{"keyId": utils.ExportKeyID(publicKey)})
+	}
+
+	var payload models.ExportPayload
+	if err := json.Unmarshal(bundle.Payload, &payload); err != nil {
+		return nil, errors.NewAppError(422, "Invalid bundle payload")
+	}
+
+	if payload.Format != models.ExportFormat || payload.Version < 1 || payload.Version > models.ExportFormatVersion {
+		return nil, errors.NewAppError(422, "Unsupported bundle version")
+	}
+
+	return &payload, nil
+}
+
+func decodeEncryptedKeyDto(dto models.EncryptedKeyDto) (models.EncryptedKey, error) {
+	ciphertext, err := utils.Base64ToBytes(dto.Ciphertext)
+	if err != nil || len(ciphertext) == 0 {
+		return models.EncryptedKey{}, fmt.Errorf("invalid ciphertext")
+	}
+	nonce, err := utils.Base64ToBytes(dto.Nonce)
+	if err != nil || len(nonce) == 0 {
+		return models.EncryptedKey{}, fmt.Errorf("invalid nonce")
+	}
+	return models.EncryptedKey{Ciphertext: ciphertext, Nonce: nonce}, nil
+}
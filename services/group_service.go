@@ -0,0 +1,534 @@
+package services
+
+import (
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"lembrago.com/lembrago/authz"
+	"lembrago.com/lembrago/errors"
+	"lembrago.com/lembrago/models"
+	"lembrago.com/lembrago/repository"
+	"lembrago.com/lembrago/utils"
+)
+
+func CreateGroup(userID, orgID string, req *models.CreateGroupRequest) (*models.GroupResponse, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid userID format")
+	}
+	orgObjID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid orgID format")
+	}
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	group := models.Group{
+		ID:          primitive.NewObjectID(),
+		OrgID:       orgObjID,
+		Name:        req.Name,
+		Description: req.Description,
+		CreatedBy:   userObjID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := repository.CreateGroup(&group); err != nil {
+		return nil, err
+	}
+
+	res := utils.FacGroupRes(&group, nil)
+	return &res, nil
+}
+
+func GetGroups(orgID string) ([]models.GroupResponse, error) {
+	orgObjID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid orgID format")
+	}
+
+	groups, err := repository.FindGroupsByOrgID(orgObjID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := repository.FindGroupMembersByOrgID(orgObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	membersByGroup := make(map[primitive.ObjectID][]models.GroupMember)
+	for _, member := range members {
+		membersByGroup[member.GroupID] = append(membersByGroup[member.GroupID], member)
+	}
+
+	res := make([]models.GroupResponse, 0, len(groups))
+	for _, group := range groups {
+		res = append(res, utils.FacGroupRes(&group, membersByGroup[group.ID]))
+	}
+	return res, nil
+}
+
+func UpdateGroup(orgID, groupID string, req *models.UpdateGroupRequest) error {
+	group, err := findGroup(orgID, groupID)
+	if err != nil {
+		return err
+	}
+	return repository.UpdateGroup(group.ID, req.Name, req.Description)
+}
+
+// DeleteGroup revoga os acessos que vieram do grupo antes de apagá-lo.
+func DeleteGroup(userID, orgID, groupID string, meta models.RequestMeta) error {
+	group, err := findGroup(orgID, groupID)
+	if err != nil {
+		return err
+	}
+	actorID, _ := primitive.ObjectIDFromHex(userID)
+
+	members, err := repository.FindVaultMembersByGroupID(group.ID)
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		if err := revokeGroupAccess(&member, group.ID, actorID, meta); err != nil {
+			return err
+		}
+	}
+
+	if err := repository.DeleteKeyShareTasksByGroupID(group.ID); err != nil {
+		return err
+	}
+	if err := repository.DeleteGroupVaultGrantsByGroupID(group.ID); err != nil {
+		return err
+	}
+	if err := repository.DeleteGroupMembersByGroupID(group.ID); err != nil {
+		return err
+	}
+	return repository.DeleteGroup(group.ID)
+}
+
+// AddGroupMember põe o usuário no grupo e dá a ele os cofres do grupo: direto,
+// quando ele já tem o ESVK do cofre, ou por uma tarefa de compartilhamento.
+func AddGroupMember(userID, orgID, groupID string, req *models.AddGroupMemberRequest) error {
+	group, err := findGroup(orgID, groupID)
+	if err != nil {
+		return err
+	}
+	actorID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.NewAppError(400, "Invalid userID format")
+	}
+	targetObjID, err := primitive.ObjectIDFromHex(req.UserID)
+	if err != nil {
+		return errors.NewAppError(400, "Invalid userID format")
+	}
+
+	target, err := repository.FindUserByID(targetObjID)
+	if err != nil || target.OrgID != group.OrgID {
+		return errors.NewAppError(404, "User not found")
+	}
+
+	added, err := repository.AddGroupMember(&models.GroupMember{
+		ID:      primitive.NewObjectID(),
+		GroupID: group.ID,
+		OrgID:   group.OrgID,
+		UserID:  target.ID,
+		AddedBy: actorID,
+		AddAt:   primitive.NewDateTimeFromTime(time.Now()),
+	})
+	if err != nil {
+		return err
+	}
+	if !added {
+		return errors.NewAppError(409, "User is already in the group")
+	}
+
+	grants, err := repository.FindGroupVaultGrantsByGroupID(group.ID)
+	if err != nil {
+		return err
+	}
+	for _, grant := range grants {
+		if err := grantGroupAccess(&grant, target.ID, actorID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveGroupMember tira o usuário do grupo e revoga os acessos que vieram dele.
+func RemoveGroupMember(userID, orgID, groupID, targetUserID string, meta models.RequestMeta) error {
+	group, err := findGroup(orgID, groupID)
+	if err != nil {
+		return err
+	}
+	actorID, _ := primitive.ObjectIDFromHex(userID)
+	targetObjID, err := primitive.ObjectIDFromHex(targetUserID)
+	if err != nil {
+		return errors.NewAppError(400, "Invalid userID format")
+	}
+
+	deleted, err := repository.DeleteGroupMember(group.ID, targetObjID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.NewAppError(404, "User is not in the group")
+	}
+
+	if err := repository.DeleteKeyShareTasksByGroupUser(group.ID, targetObjID); err != nil {
+		return err
+	}
+
+	members, err := repository.FindAllVaultMembersByUserOrgID(group.OrgID, targetObjID)
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		if !slices.Contains(member.GroupIDs, group.ID) {
+			continue
+		}
+		if err := revokeGroupAccess(&member, group.ID, actorID, meta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func GetGroupVaultGrants(userID, vaultID string) ([]models.GroupVaultGrantResponse, error) {
+	admin, err := findVaultAdmin(authz.GroupGrantList, userID, vaultID)
+	if err != nil {
+		return nil, err
+	}
+
+	grants, err := repository.FindGroupVaultGrantsByVaultID(admin.VaultID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]models.GroupVaultGrantResponse, 0, len(grants))
+	for _, grant := range grants {
+		name := ""
+		if group, err := repository.FindGroupByID(grant.GroupID); err == nil {
+			name = group.Name
+		}
+		res = append(res, utils.FacGroupVaultGrantRes(&grant, name))
+	}
+	return res, nil
+}
+
+// GrantVaultToGroup dá o cofre a todos os membros do grupo. Só um admin do cofre
+// concede, e os membros sem ESVK ficam com tarefas pendentes.
+func GrantVaultToGroup(userID string, req *models.CreateGroupVaultGrantRequest) (*models.GroupVaultGrantResponse, error) {
+	admin, err := findVaultAdmin(authz.GroupGrantAdd, userID, req.VaultID)
+	if err != nil {
+		return nil, err
+	}
+	group, err := findGroup(admin.OrgID.Hex(), req.GroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	grant := models.GroupVaultGrant{
+		ID:         primitive.NewObjectID(),
+		GroupID:    group.ID,
+		VaultID:    admin.VaultID,
+		OrgID:      admin.OrgID,
+		Permission: req.Permission,
+		GrantedBy:  admin.UserID,
+		GrantedAt:  primitive.NewDateTimeFromTime(time.Now()),
+	}
+	created, err := repository.CreateGroupVaultGrant(&grant)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, errors.NewAppError(409, "Group already has access to this vault")
+	}
+
+	members, err := repository.FindGroupMembersByGroupID(group.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		if err := grantGroupAccess(&grant, member.UserID, admin.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	res := utils.FacGroupVaultGrantRes(&grant, group.Name)
+	return &res, nil
+}
+
+func RevokeGroupVaultGrant(userID, grantID string, meta models.RequestMeta) error {
+	grantObjID, err := primitive.ObjectIDFromHex(grantID)
+	if err != nil {
+		return errors.NewAppError(400, "Invalid grantID")
+	}
+	grant, err := repository.FindGroupVaultGrantByID(grantObjID)
+	if err != nil {
+		return errors.NewAppError(404, "Grant not found")
+	}
+	admin, err := findVaultAdmin(authz.GroupGrantRemove, userID, grant.VaultID.Hex())
+	if err != nil {
+		return err
+	}
+
+	if err := repository.DeleteGroupVaultGrant(grant.ID); err != nil {
+		return err
+	}
+	if err := repository.DeleteKeyShareTasksByGroupVault(grant.GroupID, grant.VaultID); err != nil {
+		return err
+	}
+
+	members, err := repository.FindAllVaultMembersByVaultID(grant.VaultID)
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		if !slices.Contains(member.GroupIDs, grant.GroupID) {
+			continue
+		}
+		if err := revokeGroupAccess(&member, grant.GroupID, admin.UserID, meta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetKeyShareTasks lista as tarefas pendentes dos cofres em que o usuário é admin.
+func GetKeyShareTasks(userID, orgID string) ([]models.KeyShareTaskResponse, error) {
+	vaultIDs, err := adminVaultIDs(userID, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]models.KeyShareTaskResponse, 0)
+	if len(vaultIDs) == 0 {
+		return res, nil
+	}
+
+	tasks, err := repository.FindKeyShareTasksByVaultIDs(vaultIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range tasks {
+		user, err := repository.FindUserByID(task.UserID)
+		if err != nil {
+			continue
+		}
+		res = append(res, utils.FacKeyShareTaskRes(&task, user))
+	}
+	return res, nil
+}
+
+// CompleteKeyShareTask recebe o ESVK embrulhado para o usuário da tarefa e cria o
+// acesso. As outras tarefas do mesmo cofre e usuário (de outros grupos) são
+// concluídas junto, já que o ESVK é o mesmo.
+func CompleteKeyShareTask(userID, taskID string, req *models.CompleteKeyShareTaskRequest, meta models.RequestMeta) (*models.VaultMemberResponse, error) {
+	taskObjID, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid taskID")
+	}
+	task, err := repository.FindKeyShareTaskByID(taskObjID)
+	if err != nil {
+		return nil, errors.NewAppError(404, "Task not found")
+	}
+	admin, err := findVaultAdmin(authz.MemberAdd, userID, task.VaultID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	esvkBytes, err := base64.StdEncoding.DecodeString(req.ESVK_PubK_User)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid eskv")
+	}
+
+	target, err := repository.FindUserByID(task.UserID)
+	if err != nil {
+		return nil, errors.NewAppError(404, "User not found")
+	}
+	if !models.SameFingerprint(req.Fingerprint, models.PublicKeyFingerprint(target.Keys.PublicKey)) {
+		return nil, errors.NewAppError(409, "Public key fingerprint does not match")
+	}
+
+	tasks, err := repository.FindKeyShareTasksByVaultUser(task.VaultID, task.UserID)
+	if err != nil {
+		return nil, err
+	}
+	permission := task.Permission
+	groupIDs := make([]primitive.ObjectID, 0, len(tasks))
+	for _, pending := range tasks {
+		groupIDs = append(groupIDs, pending.GroupID)
+		permission = authz.HigherPermission(permission, pending.Permission)
+	}
+
+	member, err := repository.FindMemberByUserVaultID(task.VaultID, task.UserID)
+	switch {
+	case err == mongo.ErrNoDocuments:
+		now := primitive.NewDateTimeFromTime(time.Now())
+		member = &models.VaultMember{
+			ID:             primitive.NewObjectID(),
+			VaultID:        task.VaultID,
+			OrgID:          task.OrgID,
+			UserID:         task.UserID,
+			ESVK_PubK_User: esvkBytes,
+			Permission:     permission,
+			AddedBy:        admin.UserID,
+			AddAt:          now,
+			UpdatedAt:      now,
+			GroupIDs:       groupIDs,
+		}
+		if err := addVaultMember(member); err != nil {
+			return nil, err
+		}
+		go notifyVaultMembers(models.EventMemberAdded, member.OrgID, member.VaultID, member.ID, admin.UserID)
+		go dispatchMemberAdded(member, "group")
+		go recordAudit(meta, memberAuditEntry(models.AuditMemberAdded, member, &admin.UserID, "group"))
+	case err != nil:
+		return nil, err
+	case len(member.GroupIDs) > 0:
+		// O usuário entrou no cofre por outro grupo enquanto a tarefa esperava.
+		for _, groupID := range groupIDs {
+			permission = authz.HigherPermission(permission, member.Permission)
+			if err := repository.AddVaultMemberGroup(member.ID, groupID, permission); err != nil {
+				return nil, err
+			}
+		}
+		member.Permission = permission
+		go notifyVaultMembers(models.EventMemberUpdated, member.OrgID, member.VaultID, member.ID, admin.UserID)
+	}
+
+	if err := repository.DeleteKeyShareTasksByVaultUser(task.VaultID, task.UserID); err != nil {
+		return nil, err
+	}
+
+	res := newVaultMemberResponse(member, target)
+	return &res, nil
+}
+
+// grantGroupAccess dá ao usuário o acesso de um grant. Quem já tem o ESVK do
+// cofre só ganha o grupo na lista de origens; um membro adicionado diretamente
+// fica como está.
+func grantGroupAccess(grant *models.GroupVaultGrant, userID, actorID primitive.ObjectID) error {
+	member, err := repository.FindMemberByUserVaultID(grant.VaultID, userID)
+	if err == mongo.ErrNoDocuments {
+		return repository.UpsertKeyShareTask(&models.KeyShareTask{
+			ID:         primitive.NewObjectID(),
+			OrgID:      grant.OrgID,
+			VaultID:    grant.VaultID,
+			UserID:     userID,
+			GroupID:    grant.GroupID,
+			Permission: grant.Permission,
+			CreatedAt:  primitive.NewDateTimeFromTime(time.Now()),
+		})
+	}
+	if err != nil {
+		return err
+	}
+	if len(member.GroupIDs) == 0 {
+		return nil
+	}
+
+	permission := authz.HigherPermission(member.Permission, grant.Permission)
+	if err := repository.AddVaultMemberGroup(member.ID, grant.GroupID, permission); err != nil {
+		return err
+	}
+	if permission != member.Permission {
+		go notifyVaultMembers(models.EventMemberUpdated, member.OrgID, member.VaultID, member.ID, actorID)
+	}
+	return nil
+}
+
+// revokeGroupAccess tira o grupo das origens do acesso. Sem outros grupos o
+// membro sai do cofre; com outros, fica com a maior permissão que eles dão.
+// Quem foi promovido a admin do cofre depois de entrar pelo grupo continua admin.
+func revokeGroupAccess(member *models.VaultMember, groupID, actorID primitive.ObjectID, meta models.RequestMeta) error {
+	remaining := slices.DeleteFunc(slices.Clone(member.GroupIDs), func(id primitive.ObjectID) bool {
+		return id == groupID
+	})
+
+	if len(remaining) == 0 && member.Permission != models.ADMIN {
+		if err := repository.DeleteVaultMember(member.ID); err != nil {
+			return fmt.Errorf("failed to remove vault member: %v", err)
+		}
+		go recordTombstone(models.TombstoneMember, member.OrgID, member.VaultID, member.ID, &member.UserID)
+		go notifyVaultMembers(models.EventMemberRemoved, member.OrgID, member.VaultID, member.ID, actorID, member.UserID)
+		go recordAudit(meta, memberAuditEntry(models.AuditMemberRemoved, member, &actorID, "group"))
+		return nil
+	}
+
+	permission := member.Permission
+	if permission != models.ADMIN {
+		grants, err := repository.FindGroupVaultGrantsByVaultID(member.VaultID)
+		if err != nil {
+			return err
+		}
+		permission = models.READ
+		for _, grant := range grants {
+			if slices.Contains(remaining, grant.GroupID) {
+				permission = authz.HigherPermission(permission, grant.Permission)
+			}
+		}
+	}
+
+	if err := repository.RemoveVaultMemberGroup(member.ID, groupID, permission); err != nil {
+		return err
+	}
+	if permission != member.Permission {
+		go notifyVaultMembers(models.EventMemberUpdated, member.OrgID, member.VaultID, member.ID, actorID)
+	}
+	return nil
+}
+
+func findGroup(orgID, groupID string) (*models.Group, error) {
+	groupObjID, err := primitive.ObjectIDFromHex(groupID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid groupID")
+	}
+
+	group, err := repository.FindGroupByID(groupObjID)
+	if err != nil || group.OrgID.Hex() != orgID {
+		return nil, errors.NewAppError(404, "Group not found")
+	}
+	return group, nil
+}
+
+// findVaultAdmin confere a ação no cofre e devolve o vínculo de quem a faz. Todas
+// as ações que passam por aqui exigem admin do cofre.
+func findVaultAdmin(action authz.Action, userID, vaultID string) (*models.VaultMember, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid userID")
+	}
+	vaultObjID, err := primitive.ObjectIDFromHex(vaultID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid vaultID")
+	}
+
+	return authorizeVault(action, userObjID, vaultObjID)
+}
+
+// adminVaultIDs lista os cofres da organização em que o usuário é admin.
+func adminVaultIDs(userID, orgID string) ([]primitive.ObjectID, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid userID format")
+	}
+	orgObjID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid orgID format")
+	}
+
+	memberships, err := repository.FindAllVaultMembersByUserOrgID(orgObjID, userObjID)
+	if err != nil {
+		return nil, err
+	}
+	var vaultIDs []primitive.ObjectID
+	for _, membership := range memberships {
+		if membership.Permission == models.ADMIN {
+			vaultIDs = append(vaultIDs, membership.VaultID)
+		}
+	}
+	return vaultIDs, nil
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"lembrago.com/lembrago/errors"
+	"lembrago.com/lembrago/mailer"
+	"lembrago.com/lembrago/models"
+	"lembrago.com/lembrago/repository"
+	"lembrago.com/lembrago/utils"
+)
+
+const (
+	mailMaxAttempts  = 8
+	mailBaseBackoff  = time.Minute
+	mailLease        = 2 * time.Minute
+	mailJobInterval  = 15 * time.Second
+	mailMaxErrorLen  = 500
+	deadLetterLimit  = 20
+	mailExpiredError = "expired before it could be sent"
+)
+
+// mailValidity diz por quanto tempo vale o conteúdo de cada tipo de e-mail;
+// passado esse prazo, o código ou link já não funciona e não adianta enviar.
+var mailValidity = map[string]time.Duration{
+	models.MailAuthCode:  5 * time.Minute,
+	models.MailInvite:    5 * time.Minute,
+	models.MailNewDevice: deviceReportTTL,
+}
+
+// orgMailBrand é a marca dos e-mails da organização, no idioma do usuário ou,
+// se ele não escolheu, no da organização. user pode ser nil (ex.: convite).
+func orgMailBrand(org *models.Organization, user *models.User) utils.MailBrand {
+	userLocale := ""
+	if user != nil {
+		userLocale = user.Locale
+	}
+	if org == nil {
+		return utils.MailBrand{Locale: utils.MailLocale(userLocale)}
+	}
+	return utils.MailBrand{
+		Locale:          utils.MailLocale(userLocale, org.Locale),
+		OrgName:         org.Name,
+		LogoURL:         org.ImageUrl,
+		SenderName:      org.Branding.SenderName,
+		PrimaryColor:    org.Branding.PrimaryColor,
+		BackgroundColor: org.Branding.BackgroundColor,
+	}
+}
+
+// userMailBrand busca a organização do usuário para montar a marca; se ela
+// não for encontrada, o e-mail sai sem marca, mas ainda no idioma do usuário.
+func userMailBrand(user *models.User) utils.MailBrand {
+	org, err := repository.FindOrganizationByID(user.OrgID)
+	if err != nil {
+		org = nil
+	}
+	return orgMailBrand(org, user)
+}
+
+// queueEmail grava o e-mail na fila de saída e faz a primeira tentativa.
+// Recebe direto o retorno dos construtores de utils, ex.:
+// queueEmail(utils.WelcomeEmail(brand, to, username)).
+func queueEmail(msg *mailer.Message, err error) {
+	if err != nil {
+		fmt.Printf("Failed to build e-mail: %v\n", err)
+		return
+	}
+
+	now := time.Now()
+	email := models.OutboxEmail{
+		ID:            primitive.NewObjectID(),
+		Kind:          msg.Kind,
+		To:            msg.To,
+		FromName:      msg.FromName,
+		Subject:       msg.Subject,
+		HTML:          msg.HTML,
+		Text:          msg.Text,
+		Status:        models.OutboxPending,
+		NextAttemptAt: primitive.NewDateTimeFromTime(now.Add(mailLease)),
+		CreatedAt:     primitive.NewDateTimeFromTime(now),
+		UpdatedAt:     primitive.NewDateTimeFromTime(now),
+	}
+	if validity, ok := mailValidity[msg.Kind]; ok {
+		expiresAt := primitive.NewDateTimeFromTime(now.Add(validity))
+		email.ExpiresAt = &expiresAt
+	}
+
+	if err := repository.EnqueueEmail(&email); err != nil {
+		fmt.Printf("Failed to queue %s e-mail to %s: %v\n", msg.Kind, msg.To, err)
+		return
+	}
+	attemptEmail(&email)
+}
+
+// attemptEmail envia e tira o e-mail da fila. Se falhar, agenda a próxima
+// tentativa com espera dobrando a cada vez (1min, 2min, 4min...) e, depois de
+// mailMaxAttempts, o deixa como "dead".
+func attemptEmail(email *models.OutboxEmail) {
+	now := time.Now()
+	var err error
+	if email.ExpiresAt != nil && email.ExpiresAt.Time().Before(now) {
+		err = fmt.Errorf(mailExpiredError)
+	} else {
+		email.Attempts++
+		err = mailer.Send(mailer.Message{
+			Kind:     email.Kind,
+			To:       email.To,
+			FromName: email.FromName,
+			Subject:  email.Subject,
+			HTML:     email.HTML,
+			Text:     email.Text,
+		})
+	}
+
+	if err == nil {
+		if err := repository.DeleteOutboxEmail(email.ID); err != nil {
+			fmt.Printf("Failed to remove sent e-mail %s: %v\n", email.ID.Hex(), err)
+		}
+		return
+	}
+
+	email.LastError = err.Error()
+	if len(email.LastError) > mailMaxErrorLen {
+		email.LastError = email.LastError[:mailMaxErrorLen]
+	}
+	email.UpdatedAt = primitive.NewDateTimeFromTime(now)
+	if email.LastError == mailExpiredError || email.Attempts >= mailMaxAttempts {
+		email.Status = models.OutboxDead
+		fmt.Printf("Giving up on %s e-mail %s to %s: %s\n", email.Kind, email.ID.Hex(), email.To, email.LastError)
+	} else {
+		backoff := mailBaseBackoff << (email.Attempts - 1)
+		email.NextAttemptAt = primitive.NewDateTimeFromTime(now.Add(backoff))
+	}
+
+	if err := repository.SaveEmailAttempt(email); err != nil {
+		fmt.Printf("Failed to save e-mail attempt %s: %v\n", email.ID.Hex(), err)
+	}
+}
+
+// RunMailJob envia os e-mails da fila cuja hora chegou. Como nos webhooks,
+// cada e-mail é reservado no banco antes do envio.
+func RunMailJob() {
+	ticker := time.NewTicker(mailJobInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		for {
+			email, err := repository.ClaimDueEmail(time.Now(), mailLease)
+			if err != nil {
+				fmt.Printf("Failed to claim queued e-mail: %v\n", err)
+				break
+			}
+			if email == nil {
+				break
+			}
+			attemptEmail(email)
+		}
+	}
+}
+
+func GetDeadLetters(query *models.DeadLetterQuery) (*models.DeadLettersResponse, error) {
+	limit := query.Limit
+	if limit == 0 {
+		limit = deadLetterLimit
+	}
+	emails, total, err := repository.FindDeadEmails(query.Page, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	res := models.DeadLettersResponse{
+		Messages: make([]models.OutboxEmailResponse, 0, len(emails)),
+		Page:     query.Page,
+		Limit:    limit,
+		Total:    total,
+	}
+	for _, email := range emails {
+		res.Messages = append(res.Messages, utils.FacOutboxEmailRes(&email))
+	}
+	return &res, nil
+}
+
+// RetryDeadLetter devolve um e-mail morto à fila; o job o envia na próxima
+// passada. E-mails vencidos não voltam, porque o código ou link já não vale.
+func RetryDeadLetter(emailID string) (*models.OutboxEmailResponse, error) {
+	emailObjID, err := primitive.ObjectIDFromHex(emailID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid emailID format")
+	}
+	email, err := repository.FindOutboxEmailByID(emailObjID)
+	if err != nil || email.Status != models.OutboxDead {
+		return nil, errors.NewAppError(404, "Dead letter not found")
+	}
+	if email.ExpiresAt != nil && email.ExpiresAt.Time().Before(time.Now()) {
+		return nil, errors.NewAppError(409, "E-mail has expired")
+	}
+
+	if err := repository.RequeueDeadEmail(email.ID, time.Now()); err != nil {
+		return nil, err
+	}
+	email, err = repository.FindOutboxEmailByID(emailObjID)
+	if err != nil {
+		return nil, errors.NewAppError(404, "Dead letter not found")
+	}
+
+	res := utils.FacOutboxEmailRes(email)
+	return &res, nil
+}
@@ -2,10 +2,13 @@ package services
 
 import (
 	"encoding/base64"
+	"strings"
 	"time"
+	"unicode"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"lembrago.com/lembrago/authz"
 	"lembrago.com/lembrago/cache"
 	"lembrago.com/lembrago/errors"
 	"lembrago.com/lembrago/models"
@@ -27,6 +30,7 @@ func CreateOrganization(request *models.CreateOrganizationRequest) (*models.Orga
 		Email:              request.Email,
 		SubscriptionPlan:   models.SubscriptionPlan(request.SubscriptionPlan),
 		SubscriptionStatus: "active",
+		Locale:             request.Locale,
 		UpdatedAt:          primitive.NewDateTimeFromTime(time.Now()),
 		CreatedAt:          primitive.NewDateTimeFromTime(time.Now()),
 	}
@@ -92,6 +96,7 @@ func CreateOrganization(request *models.CreateOrganizationRequest) (*models.Orga
 		Keys:             *keys,
 		Role:             models.RoleAdmin,
 		Status:           models.StatusActive,
+		Locale:           request.User.Locale,
 		UpdatedAt:        primitive.NewDateTimeFromTime(time.Now()),
 		CreatedAt:        primitive.NewDateTimeFromTime(time.Now()),
 	}
@@ -113,11 +118,11 @@ func CreateOrganization(request *models.CreateOrganizationRequest) (*models.Orga
 		}
 	}
 
-	go utils.SendWelcomeEmail(user.Email, user.Username)
+	go queueEmail(utils.WelcomeEmail(orgMailBrand(org, user), user.Email, user.Username))
 	return org, nil
 }
 
-func InviteUser(adminID, orgID, email string, role models.UserRole) (string, error) {
+func InviteUser(adminID, orgID, email string, role models.UserRole, meta models.RequestMeta) (string, error) {
 	adminObjID, err := primitive.ObjectIDFromHex(adminID)
 	if err != nil {
 		return "", err
@@ -128,8 +133,8 @@ func InviteUser(adminID, orgID, email string, role models.UserRole) (string, err
 		return "", errors.NewAppError(403, "Unauthorized")
 	}
 
-	if admin.Role != models.RoleAdmin {
-		return "", errors.NewAppError(403, "Only admins can invite users")
+	if err := authorizeOrg(authz.UserInvite, admin); err != nil {
+		return "", err
 	}
 
 	orgIbjID, err := primitive.ObjectIDFromHex(orgID)
@@ -137,6 +142,14 @@ func InviteUser(adminID, orgID, email string, role models.UserRole) (string, err
 		return "", errors.NewAppError(400, "Invalid OrgID")
 	}
 
+	invitedRole, err := findRole(orgIbjID, role)
+	if err != nil {
+		return "", err
+	}
+	if err := checkCanGrant(admin, invitedRole.Permissions); err != nil {
+		return "", err
+	}
+
 	user, err := repository.FindUserByEmailOrgID(email, orgIbjID)
 	if err == nil && user != nil {
 		return "", errors.NewAppError(403, "User already exists")
@@ -161,7 +174,16 @@ func InviteUser(adminID, orgID, email string, role models.UserRole) (string, err
 	}
 
 	go registerInvCode(code, inviteCode)
-	go utils.SendInviteEmail(email, organization.Name, string(role), code)
+	go queueEmail(utils.InviteEmail(orgMailBrand(organization, nil), email, organization.Name, invitedRole.Name, code))
+	go recordAudit(meta, models.AuditEntry{
+		OrgID:      orgIbjID,
+		Action:     models.AuditUserInvited,
+		ActorID:    &admin.ID,
+		TargetType: models.AuditTargetInvite,
+		TargetID:   email,
+		Details:    map[string]string{"role": string(role)},
+	})
+	go dispatchWebhook(orgIbjID, models.WebhookUserInvited, map[string]string{"email": email, "role": string(role), "invitedBy": admin.ID.Hex()})
 	return code, nil
 }
 
@@ -182,3 +204,38 @@ func GetInviteCodeToken(code string) (*models.MinOrgWithTokenResponse, error) {
 
 	return &minOrgWithTokenResponse, nil
 }
+
+func GetOrgBranding(orgID string) (*models.OrgBrandingResponse, error) {
+	orgObjID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid orgID format")
+	}
+
+	org, err := repository.FindOrganizationByID(orgObjID)
+	if err != nil {
+		return nil, errors.NewAppError(404, "Organization not found")
+	}
+
+	res := utils.FacOrgBrandingRes(org)
+	return &res, nil
+}
+
+// UpdateOrgBranding troca o idioma padrão e a marca dos e-mails da
+// organização. Campos vazios voltam ao padrão do LemBRAGO.
+func UpdateOrgBranding(orgID string, req *models.UpdateOrgBrandingRequest) (*models.OrgBrandingResponse, error) {
+	orgObjID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid orgID format")
+	}
+	// O nome vai no cabeçalho From; quebras de linha ali abririam espaço para
+	// injetar cabeçalhos.
+	req.SenderName = strings.TrimSpace(req.SenderName)
+	if strings.IndexFunc(req.SenderName, unicode.IsControl) >= 0 {
+		return nil, errors.NewAppError(400, "Invalid sender name")
+	}
+
+	if err := repository.UpdateOrganizationBranding(orgObjID, req); err != nil {
+		return nil, err
+	}
+	return GetOrgBranding(orgID)
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"encoding/base64"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"lembrago.com/lembrago/authz"
+	"lembrago.com/lembrago/errors"
+	"lembrago.com/lembrago/models"
+	"lembrago.com/lembrago/repository"
+	"lembrago.com/lembrago/utils"
+)
+
+const pendingShareTTL = 30 * 24 * time.Hour
+
+// CreatePendingShare concede o cofre a um e-mail que pode ainda não ter conta.
+// O acesso só vale depois de ConfirmPendingShare.
+func CreatePendingShare(userID string, req *models.CreatePendingShareRequest) (*models.PendingShareResponse, error) {
+	admin, err := findVaultAdmin(authz.MemberAdd, userID, req.VaultID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := repository.FindUserByEmailOrgID(req.Email, admin.OrgID)
+	if err != nil {
+		user = nil
+	}
+	if user != nil {
+		if user.IsServiceAccount() {
+			return nil, errors.NewAppError(400, "Service accounts are added with /vaults/members")
+		}
+		if _, err := repository.FindMemberByUserVaultID(admin.VaultID, user.ID); err == nil {
+			return nil, errors.NewAppError(409, "User is already a member of the vault")
+		}
+	}
+
+	now := time.Now()
+	share := models.PendingShare{
+		ID:         primitive.NewObjectID(),
+		OrgID:      admin.OrgID,
+		VaultID:    admin.VaultID,
+		Email:      req.Email,
+		Permission: req.Permission,
+		CreatedBy:  admin.UserID,
+		CreatedAt:  primitive.NewDateTimeFromTime(now),
+		ExpiresAt:  primitive.NewDateTimeFromTime(now.Add(pendingShareTTL)),
+	}
+	created, err := repository.CreatePendingShare(&share)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, errors.NewAppError(409, "There is already a pending share for this e-mail")
+	}
+
+	res := utils.FacPendingShareRes(&share, user)
+	return &res, nil
+}
+
+// GetPendingShares lista os acessos pendentes dos cofres em que o usuário é admin,
+// com a chave pública e a impressão digital de quem já se cadastrou.
+func GetPendingShares(userID, orgID string) ([]models.PendingShareResponse, error) {
+	vaultIDs, err := adminVaultIDs(userID, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]models.PendingShareResponse, 0)
+	if len(vaultIDs) == 0 {
+		return res, nil
+	}
+
+	shares, err := repository.FindPendingSharesByVaultIDs(vaultIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, share := range shares {
+		user, err := repository.FindUserByEmailOrgID(share.Email, share.OrgID)
+		if err != nil {
+			user = nil
+		}
+		res = append(res, utils.FacPendingShareRes(&share, user))
+	}
+	return res, nil
+}
+
+// ConfirmPendingShare cria o acesso com o ESVK embrulhado pelo admin. A impressão
+// digital enviada precisa ser a da chave pública atual do usuário, para que uma
+// chave trocada no servidor depois da conferência seja recusada.
+func ConfirmPendingShare(userID, shareID string, req *models.ConfirmPendingShareRequest, meta models.RequestMeta) (*models.VaultMemberResponse, error) {
+	share, err := findPendingShare(shareID)
+	if err != nil {
+		return nil, err
+	}
+	admin, err := findVaultAdmin(authz.MemberAdd, userID, share.VaultID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := repository.FindUserByEmailOrgID(share.Email, share.OrgID)
+	if err != nil {
+		return nil, errors.NewAppError(409, "User has not registered yet")
+	}
+	if !models.SameFingerprint(req.Fingerprint, models.PublicKeyFingerprint(user.Keys.PublicKey)) {
+		return nil, errors.NewAppError(409, "Public key fingerprint does not match")
+	}
+	if _, err := repository.FindMemberByUserVaultID(share.VaultID, user.ID); err == nil {
+		repository.DeletePendingShare(share.ID)
+		return nil, errors.NewAppError(409, "User is already a member of the vault")
+	}
+
+	esvkBytes, err := base64.StdEncoding.DecodeString(req.ESVK_PubK_User)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid eskv")
+	}
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	member := models.VaultMember{
+		ID:             primitive.NewObjectID(),
+		VaultID:        share.VaultID,
+		OrgID:          share.OrgID,
+		UserID:         user.ID,
+		ESVK_PubK_User: esvkBytes,
+		Permission:     share.Permission,
+		AddedBy:        admin.UserID,
+		AddAt:          now,
+		UpdatedAt:      now,
+	}
+	if err := addVaultMember(&member); err != nil {
+		return nil, err
+	}
+	if err := repository.DeletePendingShare(share.ID); err != nil {
+		return nil, err
+	}
+
+	go notifyVaultMembers(models.EventMemberAdded, member.OrgID, member.VaultID, member.ID, admin.UserID)
+	go dispatchMemberAdded(&member, "pending_share")
+	go recordAudit(meta, memberAuditEntry(models.AuditMemberAdded, &member, &admin.UserID, "pending_share"))
+
+	res := newVaultMemberResponse(&member, user)
+	return &res, nil
+}
+
+func CancelPendingShare(userID, shareID string) error {
+	share, err := findPendingShare(shareID)
+	if err != nil {
+		return err
+	}
+	if _, err := findVaultAdmin(authz.MemberAdd, userID, share.VaultID.Hex()); err != nil {
+		return err
+	}
+	return repository.DeletePendingShare(share.ID)
+}
+
+func findPendingShare(shareID string) (*models.PendingShare, error) {
+	shareObjID, err := primitive.ObjectIDFromHex(shareID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid shareID")
+	}
+
+	share, err := repository.FindPendingShareByID(shareObjID)
+	if err != nil || share.ExpiresAt.Time().Before(time.Now()) {
+		return nil, errors.NewAppError(404, "Pending share not found")
+	}
+	return share, nil
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"lembrago.com/lembrago/errors"
+	"lembrago.com/lembrago/models"
+	"lembrago.com/lembrago/repository"
+	"lembrago.com/lembrago/utils"
+)
+
+const (
+	maxTokensPerUser = 50
+	// lastUsedAt é gravado no máximo uma vez por intervalo para não escrever no
+	// banco a cada requisição.
+	tokenTouchInterval = time.Minute
+)
+
+func CreatePersonalAccessToken(userID string, req *models.CreatePersonalAccessTokenRequest) (*models.CreatedPersonalAccessTokenResponse, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid userID format")
+	}
+
+	user, err := repository.FindUserByID(userObjID)
+	if err != nil {
+		return nil, errors.NewAppError(404, "User not found")
+	}
+	if user.IsServiceAccount() {
+		return nil, errors.NewAppError(403, "Service accounts cannot create personal access tokens")
+	}
+
+	tokens, err := repository.FindPersonalAccessTokensByUserID(userObjID)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) >= maxTokensPerUser {
+		return nil, errors.NewAppError(400, "Too many personal access tokens")
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(secret)
+
+	token := &models.PersonalAccessToken{
+		ID:         primitive.NewObjectID(),
+		UserID:     user.ID,
+		OrgID:      user.OrgID,
+		Name:       req.Name,
+		SecretHash: hash[:],
+		Scopes:     req.Scopes,
+		CreatedAt:  primitive.NewDateTimeFromTime(time.Now()),
+		ExpiresAt:  primitive.NewDateTimeFromTime(time.Now().AddDate(0, 0, req.ExpiresInDays)),
+	}
+	if err := repository.CreatePersonalAccessToken(token); err != nil {
+		return nil, err
+	}
+
+	return &models.CreatedPersonalAccessTokenResponse{
+		Token:               models.PersonalAccessTokenPrefix + token.ID.Hex() + "." + base64.RawURLEncoding.EncodeToString(secret),
+		PersonalAccessToken: utils.FacPersonalAccessTokenRes(token),
+	}, nil
+}
+
+func GetPersonalAccessTokens(userID string) ([]models.PersonalAccessTokenResponse, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid userID format")
+	}
+
+	tokens, err := repository.FindPersonalAccessTokensByUserID(userObjID)
+	if err != nil {
+		return nil, errors.NewAppError(404, "Tokens not found")
+	}
+
+	res := make([]models.PersonalAccessTokenResponse, 0, len(tokens))
+	for _, token := range tokens {
+		res = append(res, utils.FacPersonalAccessTokenRes(&token))
+	}
+	return res, nil
+}
+
+func RevokePersonalAccessToken(userID, tokenID string) error {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.NewAppError(400, "Invalid userID format")
+	}
+	tokenObjID, err := primitive.ObjectIDFromHex(tokenID)
+	if err != nil {
+		return errors.NewAppError(400, "Invalid tokenID format")
+	}
+
+	deleted, err := repository.DeletePersonalAccessToken(tokenObjID, userObjID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.NewAppError(404, "Token not found")
+	}
+	return nil
+}
+
+// AuthenticatePersonalAccessToken confere um token "lbpat_<id>.<segredo>" e
+// devolve o token e o dono, com o papel atual dele na organização.
+func AuthenticatePersonalAccessToken(tokenString string) (*models.PersonalAccessToken, *models.User, error) {
+	id, encoded, ok := strings.Cut(strings.TrimPrefix(tokenString, models.PersonalAccessTokenPrefix), ".")
+	if !ok {
+		return nil, nil, errors.NewAppError(401, "Invalid token")
+	}
+	tokenObjID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, nil, errors.NewAppError(401, "Invalid token")
+	}
+	secret, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, nil, errors.NewAppError(401, "Invalid token")
+	}
+
+	token, err := repository.FindPersonalAccessTokenByID(tokenObjID)
+	if err != nil {
+		return nil, nil, errors.NewAppError(401, "Invalid token")
+	}
+	hash := sha256.Sum256(secret)
+	if subtle.ConstantTimeCompare(token.SecretHash, hash[:]) != 1 {
+		return nil, nil, errors.NewAppError(401, "Invalid token")
+	}
+	now := time.Now()
+	if token.ExpiresAt.Time().Before(now) {
+		return nil, nil, errors.NewAppError(401, "Token has expired")
+	}
+
+	user, err := repository.FindUserByID(token.UserID)
+	if err != nil || user.Status != models.StatusActive {
+		return nil, nil, errors.NewAppError(401, "Invalid token")
+	}
+
+	if token.LastUsedAt == nil || now.Sub(token.LastUsedAt.Time()) > tokenTouchInterval {
+		go repository.TouchPersonalAccessToken(token.ID, primitive.NewDateTimeFromTime(now))
+	}
+
+	return token, user, nil
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"lembrago.com/lembrago/cache"
+	"lembrago.com/lembrago/errors"
+	"lembrago.com/lembrago/models"
+	"lembrago.com/lembrago/repository"
+	"lembrago.com/lembrago/utils"
+)
+
+// RolePermissions resolve as permissões da função: as embutidas vêm do código e
+// as personalizadas do banco. Uma função desconhecida não tem permissão nenhuma.
+func RolePermissions(orgID string, role models.UserRole) ([]models.OrgPermission, error) {
+	for _, builtIn := range models.BuiltInRoles {
+		if builtIn.Key == role {
+			return builtIn.Permissions, nil
+		}
+	}
+
+	orgObjID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid orgID")
+	}
+	custom, err := repository.FindRoleByKeyOrgID(role, orgObjID)
+	if err != nil {
+		return nil, nil
+	}
+	return custom.Permissions, nil
+}
+
+func hasOrgPermission(user *models.User, permission models.OrgPermission) bool {
+	permissions, err := RolePermissions(user.OrgID.Hex(), user.Role)
+	if err != nil {
+		return false
+	}
+	return models.HasPermission(permissions, permission)
+}
+
+// findUserWithPermission carrega o usuário e exige a permissão; contas de
+// serviço nunca administram a organização.
+func findUserWithPermission(userID string, permission models.OrgPermission) (*models.User, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid userID format")
+	}
+
+	user, err := repository.FindUserByID(userObjID)
+	if err != nil {
+		return nil, errors.NewAppError(403, "Forbidden")
+	}
+	if user.IsServiceAccount() || !hasOrgPermission(user, permission) {
+		return nil, errors.NewAppError(403, "Invalid permission")
+	}
+	return user, nil
+}
+
+func findRole(orgID primitive.ObjectID, key models.UserRole) (*models.Role, error) {
+	for _, builtIn := range models.BuiltInRoles {
+		if builtIn.Key == key {
+			return &builtIn, nil
+		}
+	}
+
+	role, err := repository.FindRoleByKeyOrgID(key, orgID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Unknown role")
+	}
+	return role, nil
+}
+
+// checkCanGrant impede que alguém dê, por função, permissões que não tem.
+func checkCanGrant(user *models.User, permissions []models.OrgPermission) error {
+	own, err := RolePermissions(user.OrgID.Hex(), user.Role)
+	if err != nil {
+		return err
+	}
+	for _, permission := range permissions {
+		if !models.HasPermission(own, permission) {
+			return errors.NewAppError(403, "Cannot grant permission "+string(permission))
+		}
+	}
+	return nil
+}
+
+func GetPermissionCatalog() []models.PermissionInfo {
+	return models.PermissionCatalog
+}
+
+// GetRoles lista as funções embutidas seguidas das personalizadas da organização.
+func GetRoles(orgID string) ([]models.RoleResponse, error) {
+	orgObjID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid orgID format")
+	}
+
+	custom, err := repository.FindRolesByOrgID(orgObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]models.RoleResponse, 0, len(models.BuiltInRoles)+len(custom))
+	for _, role := range models.BuiltInRoles {
+		res = append(res, utils.FacRoleRes(&role))
+	}
+	for _, role := range custom {
+		res = append(res, utils.FacRoleRes(&role))
+	}
+	return res, nil
+}
+
+func CreateRole(userID string, req *models.CreateRoleRequest) (*models.RoleResponse, error) {
+	user, err := findUserWithPermission(userID, models.PermRolesManage)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateRolePermissions(user, req.Permissions); err != nil {
+		return nil, err
+	}
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	id := primitive.NewObjectID()
+	role := models.Role{
+		ID:          id,
+		OrgID:       user.OrgID,
+		Key:         models.UserRole(id.Hex()),
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+		CreatedBy:   user.ID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := repository.CreateRole(&role); err != nil {
+		return nil, err
+	}
+
+	res := utils.FacRoleRes(&role)
+	return &res, nil
+}
+
+// UpdateRole altera uma função personalizada; quem já a tem passa a ter as novas
+// permissões na próxima requisição.
+func UpdateRole(userID, key string, req *models.UpdateRoleRequest) (*models.RoleResponse, error) {
+	user, err := findUserWithPermission(userID, models.PermRolesManage)
+	if err != nil {
+		return nil, err
+	}
+	role, err := findCustomRole(user.OrgID, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateRolePermissions(user, role.Permissions); err != nil {
+		return nil, err
+	}
+	if err := validateRolePermissions(user, req.Permissions); err != nil {
+		return nil, err
+	}
+
+	if err := repository.UpdateRole(role.ID, req.Name, req.Description, req.Permissions); err != nil {
+		return nil, err
+	}
+
+	role.Name = req.Name
+	role.Description = req.Description
+	role.Permissions = req.Permissions
+	res := utils.FacRoleRes(role)
+	return &res, nil
+}
+
+// DeleteRole só apaga funções sem usuários.
+func DeleteRole(userID, key string) error {
+	user, err := findUserWithPermission(userID, models.PermRolesManage)
+	if err != nil {
+		return err
+	}
+	role, err := findCustomRole(user.OrgID, key)
+	if err != nil {
+		return err
+	}
+	if err := validateRolePermissions(user, role.Permissions); err != nil {
+		return err
+	}
+
+	count, err := repository.CountUsersByRole(user.OrgID, role.Key)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.NewAppError(409, "Role is still assigned to users")
+	}
+	return repository.DeleteRole(role.ID)
+}
+
+// UpdateUserStatus suspende ou reativa um usuário. A suspensão vale na hora,
+// inclusive para tokens já emitidos.
+func UpdateUserStatus(userID string, req *models.UpdateUserStatusRequest) error {
+	user, err := findUserWithPermission(userID, models.PermUsersSuspend)
+	if err != nil {
+		return err
+	}
+
+	targetObjID, err := primitive.ObjectIDFromHex(req.UserID)
+	if err != nil {
+		return errors.NewAppError(400, "Invalid targetUserID format")
+	}
+	if targetObjID == user.ID {
+		return errors.NewAppError(400, "Cannot change your own status")
+	}
+
+	target, err := repository.FindUserByID(targetObjID)
+	if err != nil || target.OrgID != user.OrgID {
+		return errors.NewAppError(404, "User not found")
+	}
+	if target.IsServiceAccount() {
+		return errors.NewAppError(400, "Use /org/users/service-accounts to manage service accounts")
+	}
+	targetPermissions, err := RolePermissions(target.OrgID.Hex(), target.Role)
+	if err != nil {
+		return err
+	}
+	if err := checkCanGrant(user, targetPermissions); err != nil {
+		return err
+	}
+
+	if err := repository.UpdateUserStatus(target.ID, req.Status); err != nil {
+		return err
+	}
+	if req.Status == models.StatusSuspended {
+		return cache.Set(utils.SuspendedUserKey(target.ID.Hex()), "1")
+	}
+	// Reativar também destrava uma conta bloqueada pelo link "não fui eu".
+	cache.Delete(utils.LockedUserKey(target.ID.Hex()))
+	return cache.Delete(utils.SuspendedUserKey(target.ID.Hex()))
+}
+
+func findCustomRole(orgID primitive.ObjectID, key string) (*models.Role, error) {
+	role, err := repository.FindRoleByKeyOrgID(models.UserRole(key), orgID)
+	if err != nil {
+		for _, builtIn := range models.BuiltInRoles {
+			if string(builtIn.Key) == key {
+				return nil, errors.NewAppError(400, "Built-in roles cannot be changed")
+			}
+		}
+		return nil, errors.NewAppError(404, "Role not found")
+	}
+	return role, nil
+}
+
+func validateRolePermissions(user *models.User, permissions []models.OrgPermission) error {
+	for _, permission := range permissions {
+		if !models.IsCatalogPermission(permission) {
+			return errors.NewAppError(400, "Unknown permission "+string(permission))
+		}
+	}
+	return checkCanGrant(user, permissions)
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"lembrago.com/lembrago/models"
+	"lembrago.com/lembrago/siem"
+)
+
+// auditSeverity dá a severidade (0 a 10) com que cada ação chega ao SIEM; as
+// ações fora do mapa saem com severidade 3.
+var auditSeverity = map[models.AuditAction]int{
+	models.AuditAccountLocked:   8,
+	models.AuditLoginFailed:     7,
+	models.AuditUserDeleted:     6,
+	models.AuditUserRoleChanged: 6,
+	models.AuditVaultDeleted:    6,
+	models.AuditNewDeviceLogin:  5,
+	models.AuditMemberAdded:     5,
+	models.AuditMemberUpdated:   5,
+	models.AuditMemberRemoved:   5,
+	models.AuditItemDeleted:     4,
+	models.AuditItemsRead:       4,
+}
+
+// forwardAuditEntry repassa ao SIEM o mesmo registro que vai para o log de
+// auditoria. siem.Emit não bloqueia e não faz nada se o envio estiver desligado.
+func forwardAuditEntry(entry *models.AuditEntry) {
+	severity, ok := auditSeverity[entry.Action]
+	if !ok {
+		severity = 3
+	}
+	outcome := siem.OutcomeSuccess
+	if entry.Action == models.AuditLoginFailed {
+		outcome = siem.OutcomeFailure
+	}
+
+	event := siem.Event{
+		Time:       entry.CreatedAt.Time(),
+		Name:       string(entry.Action),
+		Outcome:    outcome,
+		Severity:   severity,
+		OrgID:      entry.OrgID.Hex(),
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		IP:         entry.IP,
+		UserAgent:  entry.UserAgent,
+		Details:    entry.Details,
+	}
+	if entry.ActorID != nil {
+		event.ActorID = entry.ActorID.Hex()
+	}
+	if entry.VaultID != nil {
+		event.VaultID = entry.VaultID.Hex()
+	}
+	siem.Emit(event)
+}
+
+// ReportRateLimitHit avisa o SIEM de uma requisição recusada pelo limite de
+// taxa. Não entra no log de auditoria: não há organização nem autor conhecidos.
+func ReportRateLimitHit(meta models.RequestMeta, method, path string) {
+	siem.Emit(siem.Event{
+		Name:      "ratelimit.hit",
+		Outcome:   siem.OutcomeFailure,
+		Severity:  6,
+		IP:        meta.IP,
+		UserAgent: meta.UserAgent,
+		Details:   map[string]string{"method": method, "path": path},
+	})
+}
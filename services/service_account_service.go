@@ -0,0 +1,406 @@
+package services
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"lembrago.com/lembrago/cache"
+	"lembrago.com/lembrago/errors"
+	"lembrago.com/lembrago/models"
+	"lembrago.com/lembrago/repository"
+	"lembrago.com/lembrago/utils"
+)
+
+// clientSecretSize é o tamanho da chave de autenticação que o cliente deriva do
+// segredo da credencial. O segredo em si nunca chega ao servidor.
+const clientSecretSize = 32
+
+func CreateServiceAccount(adminID, orgID string, req *models.CreateServiceAccountRequest) (*models.ServiceAccountResponse, error) {
+	admin, err := findUserWithPermission(adminID, models.PermServiceAccountsManage)
+	if err != nil {
+		return nil, err
+	}
+
+	orgObjID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid orgID")
+	}
+
+	publicKey, err := utils.Base64ToBytes(req.Credential.Keys.PublicKey)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid base64 pubKey format")
+	}
+
+	id := primitive.NewObjectID()
+	account := &models.User{
+		ID:        id,
+		OrgID:     orgObjID,
+		Username:  req.Name,
+		Email:     fmt.Sprintf("%s@service-accounts.invalid", id.Hex()),
+		Keys:      models.Keys{PublicKey: publicKey},
+		Role:      models.RoleMember,
+		Status:    models.StatusActive,
+		Type:      models.UserTypeService,
+		UpdatedAt: primitive.NewDateTimeFromTime(time.Now()),
+		CreatedAt: primitive.NewDateTimeFromTime(time.Now()),
+	}
+
+	credential, err := newServiceAccountCredential(account, admin.ID, &req.Credential)
+	if err != nil {
+		return nil, err
+	}
+
+	err = repository.CreateUser(account)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errors.NewAppError(409, err.Error())
+		}
+		return nil, err
+	}
+
+	err = repository.CreateServiceAccountCredential(credential)
+	if err != nil {
+		repository.DeleteUser(account.ID)
+		return nil, err
+	}
+
+	res := utils.FacServiceAccountRes(account, []models.ServiceAccountCredential{*credential})
+	return &res, nil
+}
+
+func GetServiceAccounts(orgID string) ([]models.ServiceAccountResponse, error) {
+	orgObjID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid orgID")
+	}
+
+	accounts, err := repository.FindServiceAccountsByOrgID(orgObjID)
+	if err != nil {
+		return nil, errors.NewAppError(404, "Service accounts not found")
+	}
+	credentials, err := repository.FindServiceAccountCredentialsByOrgID(orgObjID)
+	if err != nil {
+		return nil, errors.NewAppError(404, "Service accounts not found")
+	}
+
+	byAccount := map[primitive.ObjectID][]models.ServiceAccountCredential{}
+	for _, credential := range credentials {
+		byAccount[credential.ServiceAccountID] = append(byAccount[credential.ServiceAccountID], credential)
+	}
+
+	res := make([]models.ServiceAccountResponse, 0, len(accounts))
+	for _, account := range accounts {
+		res = append(res, utils.FacServiceAccountRes(&account, byAccount[account.ID]))
+	}
+	return res, nil
+}
+
+// DeleteServiceAccount remove a conta, as credenciais e o acesso aos cofres. Os
+// tokens já emitidos deixam de valer na hora.
+func DeleteServiceAccount(userID, orgID, accountID string, meta models.RequestMeta) error {
+	account, err := findServiceAccount(orgID, accountID)
+	if err != nil {
+		return err
+	}
+	actorID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.NewAppError(400, "Invalid userID format")
+	}
+
+	credentials, err := repository.FindServiceAccountCredentialsByAccountID(account.ID)
+	if err != nil {
+		return err
+	}
+	for _, credential := range credentials {
+		revokeServiceAccountCredential(credential.ID)
+	}
+	if err := repository.DeleteServiceAccountCredentialsByAccountID(account.ID); err != nil {
+		return err
+	}
+
+	members, err := repository.FindAllVaultMembersByUserOrgID(account.OrgID, account.ID)
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		if err := repository.DeleteVaultMember(member.ID); err != nil {
+			return err
+		}
+		go recordTombstone(models.TombstoneMember, member.OrgID, member.VaultID, member.ID, &member.UserID)
+		go recordAudit(meta, memberAuditEntry(models.AuditMemberRemoved, &member, &actorID, "service_account_deleted"))
+	}
+
+	repository.DeleteGroupMembersByUserID(account.ID)
+	repository.DeleteKeyShareTasksByUserID(account.ID)
+
+	return repository.DeleteUser(account.ID)
+}
+
+func UpdateServiceAccountCredential(orgID, accountID, credentialID string, req *models.UpdateServiceAccountCredentialRequest) (*models.ServiceAccountCredentialResponse, error) {
+	account, err := findServiceAccount(orgID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	credential, err := findServiceAccountCredential(account, credentialID)
+	if err != nil {
+		return nil, err
+	}
+
+	scope, err := parseServiceAccountScope(account.OrgID, req.Scope)
+	if err != nil {
+		return nil, err
+	}
+	if err := repository.UpdateServiceAccountCredentialScope(credential.ID, scope); err != nil {
+		return nil, err
+	}
+
+	// Tokens antigos carregam o escopo anterior; obriga a pedir outro.
+	revokeServiceAccountCredential(credential.ID)
+
+	credential.Scope = scope
+	res := utils.FacServiceAccountCredentialRes(credential)
+	return &res, nil
+}
+
+func DeleteServiceAccountCredential(orgID, accountID, credentialID string) error {
+	account, err := findServiceAccount(orgID, accountID)
+	if err != nil {
+		return err
+	}
+	credential, err := findServiceAccountCredential(account, credentialID)
+	if err != nil {
+		return err
+	}
+
+	if err := repository.DeleteServiceAccountCredential(credential.ID); err != nil {
+		return err
+	}
+	revokeServiceAccountCredential(credential.ID)
+	return nil
+}
+
+// IssueServiceAccountToken troca ID + segredo da credencial por um token curto
+// e devolve as chaves da conta cifradas para essa credencial.
+func IssueServiceAccountToken(req *models.ServiceAccountTokenRequest) (*models.ServiceAccountTokenResponse, error) {
+	credentialObjID, err := primitive.ObjectIDFromHex(req.ClientID)
+	if err != nil {
+		return nil, errors.NewAppError(401, "Invalid credentials")
+	}
+	secret, err := utils.Base64ToBytes(req.ClientSecret)
+	if err != nil {
+		return nil, errors.NewAppError(401, "Invalid credentials")
+	}
+
+	credential, err := repository.FindServiceAccountCredentialByID(credentialObjID)
+	if err != nil {
+		return nil, errors.NewAppError(401, "Invalid credentials")
+	}
+	hash := sha256.Sum256(secret)
+	if subtle.ConstantTimeCompare(credential.SecretHash, hash[:]) != 1 {
+		return nil, errors.NewAppError(401, "Invalid credentials")
+	}
+	if credential.ExpiresAt != nil && credential.ExpiresAt.Time().Before(time.Now()) {
+		return nil, errors.NewAppError(401, "Credential has expired")
+	}
+
+	account, err := repository.FindUserByID(credential.ServiceAccountID)
+	if err != nil || !account.IsServiceAccount() {
+		return nil, errors.NewAppError(401, "Invalid credentials")
+	}
+	if account.Status != models.StatusActive {
+		return nil, errors.NewAppError(403, "Service account is not active")
+	}
+
+	token, expiresAt, err := utils.GenerateServiceAccountJWT(account.ID.Hex(), account.OrgID.Hex(), credential.ID.Hex(), utils.FacServiceAccountScopeDTO(credential.Scope))
+	if err != nil {
+		return nil, errors.NewAppError(500, "Unknonw Error")
+	}
+
+	go repository.TouchServiceAccountCredential(credential.ID, primitive.NewDateTimeFromTime(time.Now()))
+
+	account.Keys = credential.Keys
+	return &models.ServiceAccountTokenResponse{
+		Token:     token,
+		ExpiresAt: expiresAt.Format(time.RFC3339),
+		User:      utils.FacUserRes(account),
+	}, nil
+}
+
+// RotateServiceAccountCredential é chamada pela própria conta: só quem tem a
+// credencial atual consegue cifrar as chaves para o segredo novo. A credencial
+// nova herda escopo e validade da atual, que é removida.
+func RotateServiceAccountCredential(accountID, orgID, credentialID string, req *models.ServiceAccountCredentialRequest) (*models.ServiceAccountCredentialResponse, error) {
+	account, err := findServiceAccount(orgID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	current, err := findServiceAccountCredential(account, credentialID)
+	if err != nil {
+		return nil, errors.NewAppError(401, "Credential was revoked")
+	}
+
+	req.Scope = utils.FacServiceAccountScopeDTO(current.Scope)
+	req.ExpiresAt = ""
+	credential, err := newServiceAccountCredential(account, account.ID, req)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name == "" {
+		credential.Name = current.Name
+	}
+	credential.ExpiresAt = current.ExpiresAt
+
+	if err := repository.CreateServiceAccountCredential(credential); err != nil {
+		return nil, err
+	}
+	if err := repository.DeleteServiceAccountCredential(current.ID); err != nil {
+		return nil, err
+	}
+	revokeServiceAccountCredential(current.ID)
+
+	res := utils.FacServiceAccountCredentialRes(credential)
+	return &res, nil
+}
+
+// ResolveRequestVaultIDs descobre a quais cofres os objetos de uma requisição
+// pertencem, para checar o escopo de tokens de contas de serviço.
+func ResolveRequestVaultIDs(vaultIDs, passwordIDs, attachmentIDs []string) ([]string, error) {
+	resolved := append([]string{}, vaultIDs...)
+
+	for _, passwordID := range passwordIDs {
+		passwordObjID, err := primitive.ObjectIDFromHex(passwordID)
+		if err != nil {
+			return nil, errors.NewAppError(400, "Invalid passwordID")
+		}
+		password, err := repository.FindPasswordByID(passwordObjID)
+		if err != nil {
+			return nil, errors.NewAppError(404, "Password not found")
+		}
+		resolved = append(resolved, password.VaultID.Hex())
+	}
+
+	for _, attachmentID := range attachmentIDs {
+		attachmentObjID, err := primitive.ObjectIDFromHex(attachmentID)
+		if err != nil {
+			return nil, errors.NewAppError(400, "Invalid attachmentID")
+		}
+		attachment, err := repository.FindAttachmentByID(attachmentObjID)
+		if err != nil {
+			return nil, errors.NewAppError(404, "Attachment not found")
+		}
+		resolved = append(resolved, attachment.VaultID.Hex())
+	}
+
+	return resolved, nil
+}
+
+func newServiceAccountCredential(account *models.User, createdBy primitive.ObjectID, req *models.ServiceAccountCredentialRequest) (*models.ServiceAccountCredential, error) {
+	secret, err := utils.Base64ToBytes(req.ClientSecret)
+	if err != nil || len(secret) != clientSecretSize {
+		return nil, errors.NewAppError(400, "Invalid clientSecret")
+	}
+
+	publicKey, err := utils.Base64ToBytes(req.Keys.PublicKey)
+	if err != nil || subtle.ConstantTimeCompare(publicKey, account.Keys.PublicKey) != 1 {
+		return nil, errors.NewAppError(400, "Public key does not match the service account")
+	}
+	encryptedPrivateKey, err := decodeEncryptedKeyDto(req.Keys.EncryptedPrivateKey)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid base64 encryptedPrivateKey format")
+	}
+	encryptedSecretKey, err := decodeEncryptedKeyDto(req.Keys.EncryptedSecretKey)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid base64 encryptedSecretKey format")
+	}
+
+	scope, err := parseServiceAccountScope(account.OrgID, req.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	name := req.Name
+	if name == "" {
+		name = "default"
+	}
+
+	hash := sha256.Sum256(secret)
+	credential := &models.ServiceAccountCredential{
+		ID:               primitive.NewObjectID(),
+		ServiceAccountID: account.ID,
+		OrgID:            account.OrgID,
+		Name:             name,
+		SecretHash:       hash[:],
+		Keys: models.Keys{
+			PublicKey:           account.Keys.PublicKey,
+			EncryptedPrivateKey: encryptedPrivateKey,
+			EncryptedSecretKey:  encryptedSecretKey,
+		},
+		Scope:     scope,
+		CreatedBy: createdBy,
+		CreatedAt: primitive.NewDateTimeFromTime(time.Now()),
+	}
+
+	if req.ExpiresAt != "" {
+		expiresAt, err := time.Parse(time.RFC3339, req.ExpiresAt)
+		if err != nil || expiresAt.Before(time.Now()) {
+			return nil, errors.NewAppError(400, "Invalid expiresAt")
+		}
+		at := primitive.NewDateTimeFromTime(expiresAt)
+		credential.ExpiresAt = &at
+	}
+
+	return credential, nil
+}
+
+func parseServiceAccountScope(orgID primitive.ObjectID, dto models.ServiceAccountScopeDTO) (models.ServiceAccountScope, error) {
+	scope := models.ServiceAccountScope{ReadOnly: dto.ReadOnly, VaultIDs: []primitive.ObjectID{}}
+	for _, vaultID := range dto.VaultIDs {
+		vaultObjID, err := primitive.ObjectIDFromHex(vaultID)
+		if err != nil {
+			return scope, errors.NewAppError(400, "Invalid vaultID in scope")
+		}
+		vault, err := repository.FindVaultByID(vaultObjID)
+		if err != nil || vault.OrgID != orgID {
+			return scope, errors.NewAppError(404, "Vault not found")
+		}
+		scope.VaultIDs = append(scope.VaultIDs, vaultObjID)
+	}
+	return scope, nil
+}
+
+func findServiceAccount(orgID, accountID string) (*models.User, error) {
+	accountObjID, err := primitive.ObjectIDFromHex(accountID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid serviceAccountID")
+	}
+
+	account, err := repository.FindUserByID(accountObjID)
+	if err != nil || !account.IsServiceAccount() || account.OrgID.Hex() != orgID {
+		return nil, errors.NewAppError(404, "Service account not found")
+	}
+	return account, nil
+}
+
+func findServiceAccountCredential(account *models.User, credentialID string) (*models.ServiceAccountCredential, error) {
+	credentialObjID, err := primitive.ObjectIDFromHex(credentialID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid credentialID")
+	}
+
+	credential, err := repository.FindServiceAccountCredentialByID(credentialObjID)
+	if err != nil || credential.ServiceAccountID != account.ID {
+		return nil, errors.NewAppError(404, "Credential not found")
+	}
+	return credential, nil
+}
+
+func revokeServiceAccountCredential(credentialID primitive.ObjectID) {
+	key := utils.RevokedCredentialKey(credentialID.Hex())
+	cache.Set(key, strconv.FormatInt(time.Now().Unix(), 10))
+	cache.SetTTL(key, utils.ServiceAccountTokenTTL)
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"lembrago.com/lembrago/errors"
+	"lembrago.com/lembrago/models"
+	"lembrago.com/lembrago/repository"
+	"lembrago.com/lembrago/utils"
+)
+
+const (
+	syncTokenVersion = "v1"
+	// Janela de sobreposição para não perder escritas concorrentes à consulta.
+	// Clientes devem aplicar os objetos por ID, então repetições são inofensivas.
+	syncOverlap = 5 * time.Second
+	// Tombstones mais antigos que isso são removidos pelo índice TTL.
+	TombstoneRetention = 90 * 24 * time.Hour
+)
+
+// Sync aceita um token devolvido por um sync anterior ou um timestamp RFC3339.
+// Sem nenhum dos dois, devolve o estado completo do usuário. Como a listagem e
+// a exportação, registra uma leitura em massa por cofre de onde saíram itens.
+func Sync(userID, orgID, token, since string, meta models.RequestMeta) (*models.SyncResponse, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid userID")
+	}
+
+	orgObjID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid orgID")
+	}
+
+	var sinceTime time.Time
+	switch {
+	case token != "":
+		sinceTime, err = parseSyncToken(token)
+		if err != nil {
+			return nil, errors.NewAppError(400, "Invalid sync token")
+		}
+	case since != "":
+		sinceTime, err = time.Parse(time.RFC3339, since)
+		if err != nil {
+			return nil, errors.NewAppError(400, "Invalid since timestamp")
+		}
+	}
+
+	now := time.Now()
+	full := sinceTime.IsZero() || now.Sub(sinceTime) > TombstoneRetention
+	if full {
+		sinceTime = time.Time{}
+	} else {
+		sinceTime = sinceTime.Add(-syncOverlap)
+	}
+
+	user, err := repository.FindUserByID(userObjID)
+	if err != nil {
+		return nil, errors.NewAppError(404, "User not found")
+	}
+
+	members, err := repository.FindAllVaultMembersByUserOrgID(orgObjID, userObjID)
+	if err != nil {
+		return nil, errors.NewAppError(500, "Unknown Error")
+	}
+
+	response := models.SyncResponse{
+		Token:       newSyncToken(now),
+		Full:        full,
+		Vaults:      []models.VaultResponse{},
+		Memberships: []models.VaultMemberResponse{},
+		Items:       []models.PasswordResponse{},
+		Tombstones:  []models.TombstoneResponse{},
+	}
+
+	if len(members) == 0 {
+		return &response, nil
+	}
+
+	vaultIDs := make([]primitive.ObjectID, 0, len(members))
+	membersByVault := make(map[primitive.ObjectID]*models.VaultMember, len(members))
+	var newVaultIDs, knownVaultIDs []primitive.ObjectID
+	for i := range members {
+		member := &members[i]
+		vaultIDs = append(vaultIDs, member.VaultID)
+		membersByVault[member.VaultID] = member
+
+		if changedSince(member.AddAt, sinceTime) || changedSince(member.UpdatedAt, sinceTime) {
+			response.Memberships = append(response.Memberships, *utils.FacVaultMemberResponse(user.Email, member))
+		}
+
+		// Cofres em que o usuário acabou de entrar precisam ser enviados por completo.
+		if changedSince(member.AddAt, sinceTime) {
+			newVaultIDs = append(newVaultIDs, member.VaultID)
+		} else {
+			knownVaultIDs = append(knownVaultIDs, member.VaultID)
+		}
+	}
+
+	vaults, err := repository.FindVaultsByIDs(vaultIDs)
+	if err != nil {
+		return nil, errors.NewAppError(500, "Unknown Error")
+	}
+
+	for _, vault := range vaults {
+		member := membersByVault[vault.ID]
+		if changedSince(vault.UpdatedAt, sinceTime) || changedSince(member.AddAt, sinceTime) || changedSince(member.UpdatedAt, sinceTime) {
+			response.Vaults = append(response.Vaults, *utils.FacVaultResponse(&vault, user.Email, member))
+		}
+	}
+
+	readCounts := make(map[primitive.ObjectID]int)
+	if len(newVaultIDs) > 0 {
+		items, err := repository.FindPasswordsByVaultIDsSince(newVaultIDs, time.Time{})
+		if err != nil {
+			return nil, errors.NewAppError(500, "Unknown Error")
+		}
+		for _, item := range items {
+			response.Items = append(response.Items, NewPasswordResponse(item))
+			readCounts[item.VaultID]++
+		}
+	}
+
+	if len(knownVaultIDs) > 0 {
+		items, err := repository.FindPasswordsByVaultIDsSince(knownVaultIDs, sinceTime)
+		if err != nil {
+			return nil, errors.NewAppError(500, "Unknown Error")
+		}
+		for _, item := range items {
+			response.Items = append(response.Items, NewPasswordResponse(item))
+			readCounts[item.VaultID]++
+		}
+	}
+
+	var readEntries []models.AuditEntry
+	for _, vaultID := range vaultIDs {
+		if readCounts[vaultID] == 0 {
+			continue
+		}
+		readEntry := vaultAuditEntry(models.AuditItemsRead, orgObjID, vaultID, userObjID, models.AuditTargetVault, vaultID.Hex())
+		readEntry.Details = map[string]string{"count": strconv.Itoa(readCounts[vaultID]), "source": "sync", "full": strconv.FormatBool(full)}
+		readEntries = append(readEntries, readEntry)
+	}
+	go recordAuditEntries(meta, readEntries)
+
+	if full {
+		return &response, nil
+	}
+
+	tombstones, err := repository.FindTombstonesSince(userObjID, vaultIDs, sinceTime)
+	if err != nil {
+		return nil, errors.NewAppError(500, "Unknown Error")
+	}
+
+	for _, tombstone := range tombstones {
+		response.Tombstones = append(response.Tombstones, models.TombstoneResponse{
+			Kind:      string(tombstone.Kind),
+			ID:        tombstone.ObjectID.Hex(),
+			VaultID:   tombstone.VaultID.Hex(),
+			DeletedAt: tombstone.DeletedAt.Time().Format(time.RFC3339),
+		})
+	}
+
+	return &response, nil
+}
+
+func recordTombstone(kind models.TombstoneKind, orgID, vaultID, objectID primitive.ObjectID, userID *primitive.ObjectID) {
+	tombstone := models.Tombstone{
+		ID:        primitive.NewObjectID(),
+		OrgID:     orgID,
+		VaultID:   vaultID,
+		UserID:    userID,
+		Kind:      kind,
+		ObjectID:  objectID,
+		DeletedAt: primitive.NewDateTimeFromTime(time.Now()),
+	}
+
+	if err := repository.CreateTombstone(&tombstone); err != nil {
+		fmt.Printf("Failed to record tombstone for %s %s: %v\n", kind, objectID.Hex(), err)
+	}
+}
+
+func changedSince(at primitive.DateTime, since time.Time) bool {
+	return since.IsZero() || at.Time().After(since)
+}
+
+func newSyncToken(at time.Time) string {
+	raw := fmt.Sprintf("%s:%d", syncTokenVersion, at.UnixMilli())
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func parseSyncToken(token string) (time.Time, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	version, millis, found := strings.Cut(string(raw), ":")
+	if !found || version != syncTokenVersion {
+		return time.Time{}, fmt.Errorf("unsupported sync token")
+	}
+
+	ms, err := strconv.ParseInt(millis, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.UnixMilli(ms), nil
+}
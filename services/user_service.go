@@ -12,6 +12,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"lembrago.com/lembrago/authz"
 	"lembrago.com/lembrago/cache"
 	"lembrago.com/lembrago/errors"
 	"lembrago.com/lembrago/internal/config"
@@ -24,15 +25,19 @@ const (
 	uploadDir = "./uploads"
 )
 
-func SendAuthCode(email string) error {
+func SendAuthCode(email string, meta models.RequestMeta) error {
 	users, err := repository.FindAllUsersByEmail(email)
 	if err != nil || len(users) == 0 {
 		return errors.NewAppError(401, "Invalid Credentials")
 	}
 
+	// O código vale para todas as organizações do e-mail, por isso sai sem a
+	// marca de nenhuma delas, só no idioma do primeiro cadastro.
 	code := utils.Gen6DigCod()
-	go utils.SendAuthCodeEmail(email, code)
+	brand := utils.MailBrand{Locale: userMailBrand(&users[0]).Locale}
+	go queueEmail(utils.AuthCodeEmail(brand, email, code))
 	go regAuthCode(email, code)
+	go recordAuditEntries(meta, userAuditEntries(users, models.AuditAuthCodeRequested, nil))
 	return nil
 }
 
@@ -42,7 +47,7 @@ func regAuthCode(email, code string) {
 	cache.SetTTL(key, 5*time.Minute)
 }
 
-func GetLoginInfoFromUser(email, code string) ([]models.UserWithOrganizationResponse, error) {
+func GetLoginInfoFromUser(email, code string, meta models.RequestMeta) ([]models.UserWithOrganizationResponse, error) {
 	users, err := repository.FindAllUsersByEmail(email)
 	if err != nil {
 		return nil, errors.NewAppError(401, "User not found")
@@ -55,6 +60,7 @@ func GetLoginInfoFromUser(email, code string) ([]models.UserWithOrganizationResp
 	key := fmt.Sprintf("auth-%s", email)
 	cacheCode, err := cache.Get(key)
 	if err != nil || code != cacheCode {
+		go recordAuditEntries(meta, userAuditEntries(users, models.AuditLoginFailed, map[string]string{"reason": "invalid_code"}))
 		return nil, errors.NewAppError(403, "Invalid Code")
 	}
 
@@ -63,6 +69,9 @@ func GetLoginInfoFromUser(email, code string) ([]models.UserWithOrganizationResp
 	attKey := fmt.Sprintf("att-%s", email)
 	cache.Delete(attKey)
 
+	go recordAuditEntries(meta, userAuditEntries(users, models.AuditAuthCodeVerified, nil))
+	allowUnlock(users)
+
 	var userWithOrganizationResponseList []models.UserWithOrganizationResponse
 	for _, user := range users {
 		organization, err := repository.FindOrganizationByID(user.OrgID)
@@ -84,14 +93,20 @@ func GetLoginInfoFromUser(email, code string) ([]models.UserWithOrganizationResp
 	return userWithOrganizationResponseList, nil
 }
 
-func UserLogin(comparison *models.UserLoginComparison) (*models.UserLoginResponse, error) {
+func UserLogin(comparison *models.UserLoginComparison, meta models.RequestMeta) (*models.UserLoginResponse, error) {
 	orgObjID, err := primitive.ObjectIDFromHex(comparison.OrgID)
 	if err != nil {
 		return nil, errors.NewAppError(400, "Invalid orgID")
 	}
 
 	user, err := repository.FindUserByEmailOrgID(comparison.Email, orgObjID)
-	if err != nil {
+	if err != nil || user.IsServiceAccount() {
+		go forwardSecurityEvent(meta, models.AuditEntry{
+			OrgID:      orgObjID,
+			Action:     models.AuditLoginFailed,
+			TargetType: models.AuditTargetUser,
+			Details:    map[string]string{"email": comparison.Email, "reason": "unknown_user"},
+		})
 		return nil, errors.NewAppError(401, "User not found")
 	}
 
@@ -101,36 +116,52 @@ func UserLogin(comparison *models.UserLoginComparison) (*models.UserLoginRespons
 	}
 
 	if subtle.ConstantTimeCompare(user.PasswordVerifier, verifierBytes) != 1 {
+		go recordAudit(meta, userAuditEntry(user, models.AuditLoginFailed, map[string]string{"reason": "invalid_credentials"}))
 		return nil, errors.NewAppError(401, "Invalid credentials")
 	}
+	if user.Status == models.StatusSuspended {
+		go recordAudit(meta, userAuditEntry(user, models.AuditLoginFailed, map[string]string{"reason": "suspended"}))
+		return nil, errors.NewAppError(403, "User is suspended")
+	}
+	if user.Status == models.StatusLocked {
+		if err := unlockAccount(user, meta); err != nil {
+			go recordAudit(meta, userAuditEntry(user, models.AuditLoginFailed, map[string]string{"reason": "locked"}))
+			return nil, err
+		}
+	}
 
 	tokenStr, err := utils.GenerateJWT(user.ID.Hex(), user.OrgID.Hex(), user.Role)
 	if err != nil {
 		return nil, errors.NewAppError(500, "Unknonw Error")
 	}
 
-	userRespose := models.UserResponse{
-		ID:       user.ID.Hex(),
-		Email:    user.Email,
-		OrgId:    user.OrgID.Hex(),
-		Username: user.Username,
-		Role:     user.Role,
+	go recordAudit(meta, userAuditEntry(user, models.AuditLogin, nil))
+	go checkLoginDevice(user, comparison.DeviceID, meta)
 
-		PasswordVerifier: models.PasswordVerifierResponse{
-			Salt:       base64.StdEncoding.EncodeToString(user.SaltPV),
-			Parameters: user.Parameters,
-		},
+	userRespose := utils.FacUserRes(user)
 
-		Salt_ek: base64.StdEncoding.EncodeToString(user.SaltEk),
+	return &models.UserLoginResponse{User: userRespose, Token: tokenStr}, nil
+}
 
-		Keys: models.KeysDTO{
-			PublicKey:           utils.BytesToBase64(user.Keys.PublicKey),
-			EncryptedPrivateKey: utils.FacEncryptedKeyDto(user.Keys.EncryptedPrivateKey.Ciphertext, user.Keys.EncryptedPrivateKey.Nonce),
-			EncryptedSecretKey:  utils.FacEncryptedKeyDto(user.Keys.EncryptedSecretKey.Ciphertext, user.Keys.EncryptedSecretKey.Nonce),
-		},
+func userAuditEntry(user *models.User, action models.AuditAction, details map[string]string) models.AuditEntry {
+	return models.AuditEntry{
+		OrgID:      user.OrgID,
+		Action:     action,
+		ActorID:    &user.ID,
+		TargetType: models.AuditTargetUser,
+		TargetID:   user.ID.Hex(),
+		Details:    details,
 	}
+}
 
-	return &models.UserLoginResponse{User: userRespose, Token: tokenStr}, nil
+// userAuditEntries gera um registro por conta: o mesmo e-mail pode estar em
+// várias organizações e cada uma tem a sua cadeia.
+func userAuditEntries(users []models.User, action models.AuditAction, details map[string]string) []models.AuditEntry {
+	entries := make([]models.AuditEntry, 0, len(users))
+	for i := range users {
+		entries = append(entries, userAuditEntry(&users[i], action, details))
+	}
+	return entries
 }
 
 func UserRegister(request *models.CreateUserRequest, orgID, email string, role models.UserRole) error {
@@ -200,6 +231,7 @@ func UserRegister(request *models.CreateUserRequest, orgID, email string, role m
 		Keys:             *keys,
 		Role:             role,
 		Status:           models.StatusActive,
+		Locale:           request.Locale,
 		UpdatedAt:        primitive.NewDateTimeFromTime(time.Now()),
 		CreatedAt:        primitive.NewDateTimeFromTime(time.Now()),
 	}
@@ -220,12 +252,13 @@ func UserRegister(request *models.CreateUserRequest, orgID, email string, role m
 		}
 	}
 
-	go utils.SendWelcomeEmail(user.Email, user.Username)
+	go queueEmail(utils.WelcomeEmail(userMailBrand(user), user.Email, user.Username))
+	go dispatchWebhook(OrgObjectID, models.WebhookUserRegistered, map[string]string{"userId": userID.Hex(), "email": user.Email, "role": string(role)})
 
 	return nil
 }
 
-func DeleteUser(userID, targetUserID string) error {
+func DeleteUser(userID, targetUserID string, meta models.RequestMeta) error {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return errors.NewAppError(400, "Invalid userID format")
@@ -236,8 +269,8 @@ func DeleteUser(userID, targetUserID string) error {
 		return errors.NewAppError(404, "User not found")
 	}
 
-	if user.Role != models.RoleAdmin {
-		return errors.NewAppError(403, "Only admin can delete user")
+	if err := authorizeOrg(authz.UserDelete, user); err != nil {
+		return err
 	}
 
 	targetUserObjID, err := primitive.ObjectIDFromHex(targetUserID)
@@ -245,20 +278,43 @@ func DeleteUser(userID, targetUserID string) error {
 		return errors.NewAppError(400, "Invalid userID format")
 	}
 
-	_, err = repository.FindUserByID(targetUserObjID)
+	targetUser, err := repository.FindUserByID(targetUserObjID)
 	if err != nil {
 		return errors.NewAppError(404, "User not found")
 	}
+	if targetUser.IsServiceAccount() {
+		return errors.NewAppError(400, "Use /org/users/service-accounts to remove service accounts")
+	}
+	targetPermissions, err := RolePermissions(targetUser.OrgID.Hex(), targetUser.Role)
+	if err != nil {
+		return err
+	}
+	if err := checkCanGrant(user, targetPermissions); err != nil {
+		return err
+	}
 
 	err = repository.DeleteUser(targetUserObjID)
 	if err != nil {
 		return err
 	}
-
+	repository.DeletePersonalAccessTokensByUserID(targetUserObjID)
+	repository.DeleteGroupMembersByUserID(targetUserObjID)
+	repository.DeleteKeyShareTasksByUserID(targetUserObjID)
+	repository.DeleteAccessRequestsByUserID(targetUserObjID)
+	repository.DeleteUserDevicesByUserID(targetUserObjID)
+
+	go recordAudit(meta, models.AuditEntry{
+		OrgID:      user.OrgID,
+		Action:     models.AuditUserDeleted,
+		ActorID:    &user.ID,
+		TargetType: models.AuditTargetUser,
+		TargetID:   targetUserID,
+		Details:    map[string]string{"email": targetUser.Email},
+	})
 	return nil
 }
 
-func UpdateUserRole(userID, targetUserID, userRole string) error {
+func UpdateUserRole(userID, targetUserID, userRole string, meta models.RequestMeta) error {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return errors.NewAppError(400, "Invalid userID format")
@@ -269,22 +325,100 @@ func UpdateUserRole(userID, targetUserID, userRole string) error {
 		return errors.NewAppError(403, "Forbidden")
 	}
 
-	if user.Role != models.RoleAdmin {
-		return errors.NewAppError(403, "Invalid permission")
+	if err := authorizeOrg(authz.UserRoleUpdate, user); err != nil {
+		return err
 	}
 
 	tUserObjID, err := primitive.ObjectIDFromHex(targetUserID)
 	if err != nil {
 		return errors.NewAppError(400, "Invalid targetUserID format")
 	}
+	if tUserObjID == user.ID {
+		return errors.NewAppError(400, "Cannot change your own role")
+	}
 
-	_, err = repository.FindUserByID(tUserObjID)
+	targetUser, err := repository.FindUserByID(tUserObjID)
 	if err != nil {
 		return errors.NewAppError(404, "User not found")
 	}
+	if targetUser.IsServiceAccount() {
+		return errors.NewAppError(400, "Service accounts cannot change role")
+	}
+	if targetUser.OrgID != user.OrgID {
+		return errors.NewAppError(404, "User not found")
+	}
+
+	// Quem troca a função precisa ter tudo o que a função antiga e a nova dão.
+	newRole, err := findRole(user.OrgID, models.UserRole(userRole))
+	if err != nil {
+		return err
+	}
+	currentPermissions, err := RolePermissions(targetUser.OrgID.Hex(), targetUser.Role)
+	if err != nil {
+		return err
+	}
+	if err := checkCanGrant(user, currentPermissions); err != nil {
+		return err
+	}
+	if err := checkCanGrant(user, newRole.Permissions); err != nil {
+		return err
+	}
 
 	err = repository.UpdateUserRole(tUserObjID, userRole)
-	return err
+	if err != nil {
+		return err
+	}
+	// A função vai no JWT; as sessões abertas carregariam a antiga até expirar.
+	if err := revokeSessions(tUserObjID); err != nil {
+		return err
+	}
+
+	go recordAudit(meta, models.AuditEntry{
+		OrgID:      user.OrgID,
+		Action:     models.AuditUserRoleChanged,
+		ActorID:    &user.ID,
+		TargetType: models.AuditTargetUser,
+		TargetID:   targetUserID,
+		Details:    map[string]string{"from": string(targetUser.Role), "to": userRole},
+	})
+	go dispatchWebhook(user.OrgID, models.WebhookUserRoleChanged, map[string]string{
+		"userId":    targetUserID,
+		"from":      string(targetUser.Role),
+		"to":        userRole,
+		"changedBy": user.ID.Hex(),
+	})
+	return nil
+}
+
+// GetMe devolve o próprio usuário com as chaves cifradas, para clientes que já
+// têm um token e só precisam abrir as chaves localmente.
+func GetMe(userID string) (*models.UserResponse, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid userID format")
+	}
+
+	user, err := repository.FindUserByID(userObjID)
+	if err != nil {
+		return nil, errors.NewAppError(404, "User not found")
+	}
+
+	me := utils.FacUserRes(user)
+	return &me, nil
+}
+
+// UpdateUserPreferences grava o idioma dos e-mails do usuário; vazio volta ao
+// idioma da organização.
+func UpdateUserPreferences(userID string, req *models.UpdateUserPreferencesRequest) (*models.UserResponse, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid userID format")
+	}
+
+	if err := repository.UpdateUserLocale(userObjID, req.Locale); err != nil {
+		return nil, err
+	}
+	return GetMe(userID)
 }
 
 func GetUserByID(userID string) (*models.MinimalUserInfoResponse, error) {
@@ -303,13 +437,21 @@ func GetUserByID(userID string) (*models.MinimalUserInfoResponse, error) {
 	return &minimalUser, nil
 }
 
-func GetUsersByOrgID(orgID string) ([]models.MinimalUserInfoResponse, error) {
-	OrgObjectID, err := primitive.ObjectIDFromHex(orgID)
+// GetUsersByOrgID lista os usuários da organização de quem pede.
+func GetUsersByOrgID(userID string) ([]models.MinimalUserInfoResponse, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return nil, errors.NewAppError(400, "Invalid orgID format")
+		return nil, errors.NewAppError(400, "Invalid userID format")
+	}
+	user, err := repository.FindUserByID(userObjID)
+	if err != nil {
+		return nil, errors.NewAppError(404, "User not found")
+	}
+	if err := authorizeOrg(authz.UserList, user); err != nil {
+		return nil, err
 	}
 
-	users, err := repository.FindUsersByOrgID(OrgObjectID)
+	users, err := repository.FindUsersByOrgID(user.OrgID)
 	if err != nil {
 		return nil, errors.NewAppError(404, "Users not found")
 	}
@@ -352,7 +494,19 @@ func SignOut(token string) error {
 	return nil
 }
 
-func SaveMedia(orgID string, filename string, header *multipart.FileHeader, size int64, c *gin.Context) (*models.SavedMedia, error) {
+func SaveMedia(userID string, filename string, header *multipart.FileHeader, size int64, c *gin.Context) (*models.SavedMedia, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid userID format")
+	}
+	user, err := repository.FindUserByID(userObjID)
+	if err != nil {
+		return nil, errors.NewAppError(404, "User not found")
+	}
+	if err := authorizeOrg(authz.MediaUpload, user); err != nil {
+		return nil, err
+	}
+
 	dst := filepath.Join(uploadDir, filename)
 
 	if err := c.SaveUploadedFile(header, dst); err != nil {
@@ -362,13 +516,8 @@ func SaveMedia(orgID string, filename string, header *multipart.FileHeader, size
 	selfUrl := config.GetServerConfig().SELF_URL
 	fileURL := fmt.Sprintf("%s/media/%s", selfUrl, filename)
 
-	orgObjID, err := primitive.ObjectIDFromHex(orgID)
-	if err != nil {
-		return nil, fmt.Errorf("invalid (orgID): %v", err)
-	}
-
 	saveMedia := models.SavedMedia{
-		OrgID:    orgObjID,
+		OrgID:    user.OrgID,
 		Filename: filename,
 		URL:      fileURL,
 		Size:     header.Size,
@@ -383,12 +532,21 @@ func SaveMedia(orgID string, filename string, header *multipart.FileHeader, size
 	return &saveMedia, nil
 }
 
-func GetAllMediaFromTheOrg(orgID string) ([]models.SavedMedia, error) {
-	orgObjID, err := primitive.ObjectIDFromHex(orgID)
+// GetAllMediaFromTheOrg lista as imagens enviadas pela organização de quem pede.
+func GetAllMediaFromTheOrg(userID string) ([]models.SavedMedia, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid userID format")
+	}
+	user, err := repository.FindUserByID(userObjID)
 	if err != nil {
+		return nil, errors.NewAppError(404, "User not found")
+	}
+	if err := authorizeOrg(authz.MediaList, user); err != nil {
 		return nil, err
 	}
-	medias, err := repository.GetAllMediaByOrgID(orgObjID)
+
+	medias, err := repository.GetAllMediaByOrgID(user.OrgID)
 	if err != nil {
 		return nil, err
 	}
@@ -403,29 +561,27 @@ func GetAllMediaFromTheOrg(orgID string) ([]models.SavedMedia, error) {
 func DeleteMediaByID(userID, mediaID string) error {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return err
+		return errors.NewAppError(400, "Invalid userID format")
 	}
 
 	user, err := repository.FindUserByID(userObjID)
 	if err != nil {
-		return nil
+		return errors.NewAppError(404, "User not found")
 	}
 
-	if user.Role != models.RoleAdmin {
-		return errors.NewAppError(403, "Invalid Permission")
+	if err := authorizeOrg(authz.MediaDelete, user); err != nil {
+		return err
 	}
 
 	mObjID, err := primitive.ObjectIDFromHex(mediaID)
 	if err != nil {
-		return err
+		return errors.NewAppError(400, "Invalid media ID")
 	}
 
+	// Imagem de outra organização responde como inexistente.
 	mediaToDelete, err := repository.GetMediaByID(mObjID)
-	if err != nil {
-		return fmt.Errorf("Failed to get media details: %v", err)
-	}
-	if mediaToDelete == nil {
-		return fmt.Errorf("Media with ID %s not found", mediaID)
+	if err != nil || mediaToDelete.OrgID != user.OrgID {
+		return errors.NewAppError(404, "Media not found")
 	}
 
 	filePath := filepath.Join(uploadDir, mediaToDelete.Filename)
@@ -435,7 +591,5 @@ func DeleteMediaByID(userID, mediaID string) error {
 		}
 	}
 
-	repository.DeleteMediaInRepo(mObjID)
-
-	return nil
+	return repository.DeleteMediaInRepo(mObjID)
 }
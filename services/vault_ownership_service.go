@@ -0,0 +1,187 @@
+package services
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"lembrago.com/lembrago/authz"
+	"lembrago.com/lembrago/errors"
+	"lembrago.com/lembrago/models"
+	"lembrago.com/lembrago/repository"
+	"lembrago.com/lembrago/utils"
+)
+
+const vaultOwnershipTransferTTL = 7 * 24 * time.Hour
+
+// RequestVaultOwnershipTransfer propõe passar o cofre a outro admin do cofre. O
+// dono só muda quando o destinatário aceita.
+func RequestVaultOwnershipTransfer(userID string, req *models.TransferVaultOwnershipRequest) (*models.VaultOwnershipTransferResponse, error) {
+	owner, err := findVaultAdmin(authz.VaultTransfer, userID, req.VaultID)
+	if err != nil {
+		return nil, err
+	}
+
+	vault, err := repository.FindVaultByID(owner.VaultID)
+	if err != nil {
+		return nil, errors.NewAppError(404, "Vault not found")
+	}
+	if vault.PersonalVault {
+		return nil, errors.NewAppError(400, "Personal vaults cannot be transferred")
+	}
+
+	newOwner, err := findNewVaultOwner(vault, req.NewOwnerID)
+	if err != nil {
+		return nil, err
+	}
+	if newOwner.UserID == owner.UserID {
+		return nil, errors.NewAppError(400, "User already owns the vault")
+	}
+
+	now := time.Now()
+	transfer := models.VaultOwnershipTransfer{
+		ToUserID:    newOwner.UserID,
+		RequestedBy: owner.UserID,
+		RequestedAt: primitive.NewDateTimeFromTime(now),
+		ExpiresAt:   primitive.NewDateTimeFromTime(now.Add(vaultOwnershipTransferTTL)),
+	}
+	if err := repository.SetVaultOwnershipTransfer(vault.ID, &transfer); err != nil {
+		return nil, err
+	}
+
+	go publishEvent([]primitive.ObjectID{newOwner.UserID}, models.EventVaultUpdated, vault.OrgID, vault.ID, vault.ID, owner.UserID)
+
+	return utils.FacVaultOwnershipTransferRes(vault.ID, &transfer), nil
+}
+
+// AcceptVaultOwnershipTransfer conclui a transferência; quem aceita precisa
+// continuar sendo admin do cofre.
+func AcceptVaultOwnershipTransfer(userID string, req *models.VaultOwnershipRequest) (*models.VaultResponse, error) {
+	member, err := findVaultAdmin(authz.VaultAcceptTransfer, userID, req.VaultID)
+	if err != nil {
+		return nil, err
+	}
+
+	vault, err := repository.FindVaultByID(member.VaultID)
+	if err != nil {
+		return nil, errors.NewAppError(404, "Vault not found")
+	}
+	transfer := vault.OwnershipTransfer
+	if transfer == nil || transfer.ToUserID != member.UserID || transfer.ExpiresAt.Time().Before(time.Now()) {
+		return nil, errors.NewAppError(404, "Ownership transfer not found")
+	}
+
+	accepted, err := repository.AcceptVaultOwnershipTransfer(vault.ID, member.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !accepted {
+		return nil, errors.NewAppError(409, "Ownership transfer is no longer pending")
+	}
+
+	user, err := repository.FindUserByID(member.UserID)
+	if err != nil {
+		return nil, errors.NewAppError(404, "User not found")
+	}
+	vault, err = repository.FindVaultByID(vault.ID)
+	if err != nil {
+		return nil, errors.NewAppError(404, "Vault not found")
+	}
+
+	go notifyVaultMembers(models.EventVaultUpdated, vault.OrgID, vault.ID, vault.ID, member.UserID)
+
+	return utils.FacVaultResponse(vault, user.Email, member), nil
+}
+
+// CancelVaultOwnershipTransfer serve ao dono, para desistir, e ao destinatário, para recusar.
+func CancelVaultOwnershipTransfer(userID string, req *models.VaultOwnershipRequest) error {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.NewAppError(400, "Invalid userID")
+	}
+	vaultObjID, err := primitive.ObjectIDFromHex(req.VaultID)
+	if err != nil {
+		return errors.NewAppError(400, "Invalid vaultID")
+	}
+
+	vault, err := repository.FindVaultByID(vaultObjID)
+	if err != nil {
+		return errors.NewAppError(404, "Vault not found")
+	}
+	transfer := vault.OwnershipTransfer
+	if transfer == nil {
+		return errors.NewAppError(404, "Ownership transfer not found")
+	}
+	if vault.Owner() != userObjID && transfer.ToUserID != userObjID {
+		return errors.NewAppError(403, "Invalid Permission")
+	}
+
+	if err := repository.SetVaultOwnershipTransfer(vault.ID, nil); err != nil {
+		return err
+	}
+
+	go publishEvent([]primitive.ObjectID{vault.Owner(), transfer.ToUserID}, models.EventVaultUpdated, vault.OrgID, vault.ID, vault.ID, userObjID)
+	return nil
+}
+
+// ForceVaultOwnershipTransfer é a saída do admin da organização quando o dono foi
+// desligado: só vale se o dono não existe mais, está suspenso ou travado, ou
+// deixou de ser admin do cofre.
+func ForceVaultOwnershipTransfer(userID, orgID string, req *models.TransferVaultOwnershipRequest) error {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.NewAppError(400, "Invalid userID")
+	}
+	vaultObjID, err := primitive.ObjectIDFromHex(req.VaultID)
+	if err != nil {
+		return errors.NewAppError(400, "Invalid vaultID")
+	}
+
+	user, err := repository.FindUserByID(userObjID)
+	if err != nil {
+		return errors.NewAppError(404, "User not found")
+	}
+	if err := authorizeOrg(authz.VaultForceOwnership, user); err != nil {
+		return err
+	}
+
+	vault, err := repository.FindVaultByID(vaultObjID)
+	if err != nil || vault.OrgID.Hex() != orgID {
+		return errors.NewAppError(404, "Vault not found")
+	}
+	if vault.PersonalVault {
+		return errors.NewAppError(400, "Personal vaults cannot be transferred")
+	}
+
+	if owner, err := repository.FindUserByID(vault.Owner()); err == nil && owner.Status != models.StatusSuspended && owner.Status != models.StatusLocked {
+		current, err := repository.FindMemberByUserVaultID(vault.ID, vault.Owner())
+		if err == nil && current.Permission == models.ADMIN {
+			return errors.NewAppError(409, "The vault owner is still active; ask them to transfer ownership")
+		}
+	}
+
+	newOwner, err := findNewVaultOwner(vault, req.NewOwnerID)
+	if err != nil {
+		return err
+	}
+	if err := repository.SetVaultOwner(vault.ID, newOwner.UserID); err != nil {
+		return err
+	}
+
+	go notifyVaultMembers(models.EventVaultUpdated, vault.OrgID, vault.ID, vault.ID, userObjID)
+	return nil
+}
+
+// findNewVaultOwner exige que o novo dono já seja admin do cofre: ele precisa
+// ter a chave para atualizar os metadados.
+func findNewVaultOwner(vault *models.Vault, newOwnerID string) (*models.VaultMember, error) {
+	newOwnerObjID, err := primitive.ObjectIDFromHex(newOwnerID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid newOwnerId")
+	}
+
+	member, err := repository.FindMemberByUserVaultID(vault.ID, newOwnerObjID)
+	if err != nil || member.Permission != models.ADMIN {
+		return nil, errors.NewAppError(400, "New owner must be a vault admin")
+	}
+	return member, nil
+}
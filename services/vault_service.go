@@ -3,16 +3,19 @@ package services
 import (
 	"encoding/base64"
 	"fmt"
+	"strconv"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"lembrago.com/lembrago/authz"
 	"lembrago.com/lembrago/errors"
 	"lembrago.com/lembrago/models"
 	"lembrago.com/lembrago/repository"
 	"lembrago.com/lembrago/utils"
 )
 
-func CreateVault(userID string, req *models.CreateVaultRequest) (*models.VaultResponse, error) {
+func CreateVault(userID string, req *models.CreateVaultRequest, meta models.RequestMeta) (*models.VaultResponse, error) {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return nil, errors.NewAppError(400, "Invalid userID")
@@ -24,11 +27,16 @@ func CreateVault(userID string, req *models.CreateVaultRequest) (*models.VaultRe
 	}
 
 	if req.PersonalVault != nil && *req.PersonalVault {
-		return CreatePersonalVault(userID, user.OrgID.Hex(), req)
+		vaultResponse, err := CreatePersonalVault(userID, user.OrgID.Hex(), req)
+		if err != nil {
+			return nil, err
+		}
+		go recordAudit(meta, vaultAuditEntry(models.AuditVaultCreated, user.OrgID, user.ID, user.ID, models.AuditTargetVault, user.ID.Hex()))
+		return vaultResponse, nil
 	}
 
-	if user.Role != models.RoleAdmin {
-		return nil, errors.NewAppError(403, "Only admin can create vault")
+	if err := authorizeOrg(authz.VaultCreate, user); err != nil {
+		return nil, err
 	}
 
 	vaultID := primitive.NewObjectID()
@@ -58,6 +66,8 @@ func CreateVault(userID string, req *models.CreateVaultRequest) (*models.VaultRe
 		EncryptedVaultMetadata: encryptedVaultMetadata,
 		PersonalVault:          false,
 		CreatedBy:              user.ID,
+		OwnerID:                user.ID,
+		Revision:               1,
 		UpdatedAt:              primitive.NewDateTimeFromTime(time.Now()),
 		CreatedAt:              primitive.NewDateTimeFromTime(time.Now()),
 	}
@@ -70,17 +80,22 @@ func CreateVault(userID string, req *models.CreateVaultRequest) (*models.VaultRe
 		Permission:     models.ADMIN,
 		AddedBy:        user.ID,
 		AddAt:          primitive.NewDateTimeFromTime(time.Now()),
+		UpdatedAt:      primitive.NewDateTimeFromTime(time.Now()),
 	}
 
 	repository.CreateVault(&vault)
 	repository.AddVaultMember(&vaultMember)
 
+	go publishEvent([]primitive.ObjectID{user.ID}, models.EventVaultCreated, vault.OrgID, vault.ID, vault.ID, user.ID)
+	go recordAudit(meta, vaultAuditEntry(models.AuditVaultCreated, vault.OrgID, vault.ID, user.ID, models.AuditTargetVault, vault.ID.Hex()))
+	go dispatchWebhook(vault.OrgID, models.WebhookVaultCreated, map[string]string{"vaultId": vault.ID.Hex(), "createdBy": user.ID.Hex()})
+
 	vaultResponse := utils.FacVaultResponse(&vault, user.Email, &vaultMember)
 
 	return vaultResponse, nil
 }
 
-func UpdateVault(userID string, req *models.UpdateVaultRequest) (*models.VaultResponse, error) {
+func UpdateVault(userID string, req *models.UpdateVaultRequest, meta models.RequestMeta) (*models.VaultResponse, error) {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return nil, errors.NewAppError(400, "Invalid userID")
@@ -100,8 +115,9 @@ func UpdateVault(userID string, req *models.UpdateVaultRequest) (*models.VaultRe
 	if err != nil {
 		return nil, errors.NewAppError(404, "User not found")
 	}
-	if user.Role != models.RoleAdmin || vault.CreatedBy != userObjID {
-		return nil, errors.NewAppError(403, "Only admin or owner can update vault")
+	vaultMember, err := authorizeVault(authz.VaultUpdate, user.ID, vault.ID)
+	if err != nil {
+		return nil, err
 	}
 
 	cipherBytes, err := utils.Base64ToBytes(req.EncryptedVaultMetadata.Ciphertext)
@@ -113,16 +129,31 @@ func UpdateVault(userID string, req *models.UpdateVaultRequest) (*models.VaultRe
 		return nil, errors.NewAppError(400, "Invalid nonce")
 	}
 
+	if req.Revision == nil {
+		return nil, errors.NewAppError(428, "Vault revision is required")
+	}
+	if vault.Revision != *req.Revision {
+		return nil, vaultConflictError(vault, user.Email, vaultMember)
+	}
+
 	vault.EncryptedVaultMetadata = models.EncryptedKey{
 		Ciphertext: cipherBytes,
 		Nonce:      nonceBytes,
 	}
+	if req.KeyRotated {
+		vault.NeedsKeyRotation = false
+	}
 	vault.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
-	repository.UpdateVaultById(vault.ID, *vault)
-
-	vaultMember, err := repository.FindMemberByUserVaultID(vault.ID, user.ID)
-	if err != nil {
-		return nil, errors.NewAppError(404, "Vault member not found")
+	vault, err = repository.UpdateVaultById(vault.ID, *vault, *req.Revision)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Code == 409 {
+			current, findErr := repository.FindVaultByID(vaultObjID)
+			if findErr != nil {
+				return nil, errors.NewAppError(404, "Vault not found")
+			}
+			return nil, vaultConflictError(current, user.Email, vaultMember)
+		}
+		return nil, errors.NewAppError(500, "Failed to update vault")
 	}
 
 	esvkBytes, err := utils.Base64ToBytes(req.ESVK_PubK_User)
@@ -140,6 +171,9 @@ func UpdateVault(userID string, req *models.UpdateVaultRequest) (*models.VaultRe
 		return nil, errors.NewAppError(404, "Vault member not found")
 	}
 
+	go notifyVaultMembers(models.EventVaultUpdated, vault.OrgID, vault.ID, vault.ID, user.ID)
+	go recordAudit(meta, vaultAuditEntry(models.AuditVaultUpdated, vault.OrgID, vault.ID, user.ID, models.AuditTargetVault, vault.ID.Hex()))
+
 	vaultResponse := utils.FacVaultResponse(vault, user.Email, updatedMember)
 
 	return vaultResponse, nil
@@ -191,6 +225,8 @@ func CreatePersonalVault(userID, orgID string, req *models.CreateVaultRequest) (
 		EncryptedVaultMetadata: encryptedVaultMetadata,
 		PersonalVault:          true,
 		CreatedBy:              userObjID,
+		OwnerID:                userObjID,
+		Revision:               1,
 		UpdatedAt:              primitive.NewDateTimeFromTime(time.Now()),
 		CreatedAt:              primitive.NewDateTimeFromTime(time.Now()),
 	}
@@ -203,6 +239,7 @@ func CreatePersonalVault(userID, orgID string, req *models.CreateVaultRequest) (
 		Permission:     models.ADMIN,
 		AddedBy:        userObjID,
 		AddAt:          primitive.NewDateTimeFromTime(time.Now()),
+		UpdatedAt:      primitive.NewDateTimeFromTime(time.Now()),
 	}
 
 	repository.CreateVault(&vault)
@@ -225,6 +262,8 @@ func CreatePersonalVault(userID, orgID string, req *models.CreateVaultRequest) (
 		},
 		PersonalVault: true,
 		CreatedBy:     vault.CreatedBy.Hex(),
+		OwnerID:       vault.OwnerID.Hex(),
+		Revision:      vault.Revision,
 		UpdatedAt:     vault.UpdatedAt.Time().Format(time.RFC3339),
 		CreatedAt:     vault.CreatedAt.Time().Format(time.RFC3339),
 	}
@@ -232,7 +271,7 @@ func CreatePersonalVault(userID, orgID string, req *models.CreateVaultRequest) (
 	return &vaultResponse, nil
 }
 
-func RemoveVault(userID, vaultID string) error {
+func RemoveVault(userID, vaultID string, meta models.RequestMeta) error {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return errors.NewAppError(400, "Invalid userID")
@@ -248,13 +287,13 @@ func RemoveVault(userID, vaultID string) error {
 		return errors.NewAppError(404, "Vault not found")
 	}
 
-	vaultMem, err := repository.FindMemberByUserVaultID(vaultObjID, userObjID)
-	if err != nil {
-		return errors.NewAppError(403, "Vault member not found")
+	if _, err := authorizeVault(authz.VaultDelete, userObjID, vaultObjID); err != nil {
+		return err
 	}
 
-	if vault.CreatedBy != userObjID || vaultMem.Permission != models.ADMIN {
-		return errors.NewAppError(403, "You are not allowed to remove this vault")
+	members, err := repository.FindAllVaultMembersByVaultID(vaultObjID)
+	if err != nil {
+		return errors.NewAppError(500, "Failed to find vault members")
 	}
 
 	err = repository.RemoveVaultByID(vaultObjID)
@@ -262,7 +301,14 @@ func RemoveVault(userID, vaultID string) error {
 		return errors.NewAppError(500, "Failed to remove vault")
 	}
 
+	recipients := make([]primitive.ObjectID, 0, len(members))
+	for _, member := range members {
+		recipients = append(recipients, member.UserID)
+	}
+
+	go publishEvent(recipients, models.EventVaultRemoved, vault.OrgID, vault.ID, vault.ID, userObjID)
 	go removeVaultData(vaultObjID)
+	go recordAudit(meta, vaultAuditEntry(models.AuditVaultDeleted, vault.OrgID, vault.ID, userObjID, models.AuditTargetVault, vault.ID.Hex()))
 	return nil
 }
 
@@ -277,6 +323,7 @@ func removeVaultData(vaultID primitive.ObjectID) error {
 		if err != nil {
 			return fmt.Errorf("failed to remove vault member: %v", err)
 		}
+		recordTombstone(models.TombstoneVault, member.OrgID, vaultID, vaultID, &member.UserID)
 	}
 
 	passwords, err := repository.FindAllPasswordsByVaultID(vaultID)
@@ -291,7 +338,20 @@ func removeVaultData(vaultID primitive.ObjectID) error {
 		}
 	}
 
-	return nil
+	if err := repository.DeleteGroupVaultGrantsByVaultID(vaultID); err != nil {
+		return fmt.Errorf("failed to remove group grants: %v", err)
+	}
+	if err := repository.DeleteKeyShareTasksByVaultID(vaultID); err != nil {
+		return fmt.Errorf("failed to remove key share tasks: %v", err)
+	}
+	if err := repository.DeletePendingSharesByVaultID(vaultID); err != nil {
+		return fmt.Errorf("failed to remove pending shares: %v", err)
+	}
+	if err := repository.DeleteAccessRequestsByVaultID(vaultID); err != nil {
+		return fmt.Errorf("failed to remove access requests: %v", err)
+	}
+
+	return removeAttachmentsByVaultID(vaultID)
 }
 
 func FindMyAllVaultsByOrgID(userID, orgID string) ([]models.VaultWithMemberInfo, error) {
@@ -313,7 +373,7 @@ func FindMyAllVaultsByOrgID(userID, orgID string) ([]models.VaultWithMemberInfo,
 	return vaultsWithMember, nil
 }
 
-func AddMemberToVault(userID string, req *models.CreateVaultMemberRequest) (*models.VaultMemberResponse, error) {
+func AddMemberToVault(userID string, req *models.CreateVaultMemberRequest, meta models.RequestMeta) (*models.VaultMemberResponse, error) {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return nil, errors.NewAppError(400, "Invalid userID")
@@ -324,12 +384,9 @@ func AddMemberToVault(userID string, req *models.CreateVaultMemberRequest) (*mod
 		return nil, errors.NewAppError(400, "Invalid vaultID")
 	}
 
-	permission, err := repository.FindMemberByUserVaultID(vaultObjID, userObjID)
+	permission, err := authorizeVault(authz.MemberAdd, userObjID, vaultObjID)
 	if err != nil {
-		return nil, errors.NewAppError(403, "Invalid Permission")
-	}
-	if permission.Permission != models.ADMIN {
-		return nil, errors.NewAppError(403, "Invalid Permission")
+		return nil, err
 	}
 
 	eskvBytes, err := base64.StdEncoding.DecodeString(req.ESVK_PubK_User)
@@ -341,6 +398,18 @@ func AddMemberToVault(userID string, req *models.CreateVaultMemberRequest) (*mod
 	if err != nil {
 		return nil, errors.NewAppError(400, "Invalid userID")
 	}
+
+	targetUser, err := repository.FindUserByID(memberObJID)
+	if err != nil {
+		return nil, errors.NewAppError(404, "User not found")
+	}
+	if targetUser.IsServiceAccount() && req.Permission == models.ADMIN {
+		return nil, errors.NewAppError(400, "Service accounts cannot be vault admins")
+	}
+	expiresAt, err := parseMemberExpiry(req.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
 	vaultMember := models.VaultMember{
 		ID:             primitive.NewObjectID(),
 		VaultID:        vaultObjID,
@@ -350,31 +419,24 @@ func AddMemberToVault(userID string, req *models.CreateVaultMemberRequest) (*mod
 		Permission:     req.Permission,
 		AddedBy:        userObjID,
 		AddAt:          primitive.NewDateTimeFromTime(time.Now()),
+		UpdatedAt:      primitive.NewDateTimeFromTime(time.Now()),
+		ExpiresAt:      expiresAt,
 	}
 
-	repository.AddVaultMember(&vaultMember)
-
-	targetUser, err := repository.FindUserByID(memberObJID)
-	if err != nil {
-		return nil, errors.NewAppError(404, "User not found")
+	if err := addVaultMember(&vaultMember); err != nil {
+		return nil, err
 	}
 
-	vaultMemberResponse := models.VaultMemberResponse{
-		ID:             vaultMember.ID.Hex(),
-		VaultID:        vaultMember.VaultID.Hex(),
-		UserID:         vaultMember.UserID.Hex(),
-		Username:       &targetUser.Username,
-		Email:          targetUser.Email,
-		ESVK_PubK_User: base64.StdEncoding.EncodeToString(vaultMember.ESVK_PubK_User),
-		Permission:     string(vaultMember.Permission),
-		AddedBy:        vaultMember.AddedBy.Hex(),
-		AddAt:          vaultMember.AddAt.Time().Format(time.RFC3339),
-	}
+	go notifyVaultMembers(models.EventMemberAdded, vaultMember.OrgID, vaultMember.VaultID, vaultMember.ID, userObjID)
+	go dispatchMemberAdded(&vaultMember, "direct")
+	go recordAudit(meta, memberAuditEntry(models.AuditMemberAdded, &vaultMember, &userObjID, "direct"))
+
+	vaultMemberResponse := newVaultMemberResponse(&vaultMember, targetUser)
 
 	return &vaultMemberResponse, nil
 }
 
-func RemoveMemberFromVault(userID, memberId string) error {
+func RemoveMemberFromVault(userID, memberId string, meta models.RequestMeta) error {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return errors.NewAppError(400, "Invalid userID")
@@ -390,12 +452,8 @@ func RemoveMemberFromVault(userID, memberId string) error {
 		return errors.NewAppError(404, "Member not found")
 	}
 
-	currentVaultMember, err := repository.FindMemberByUserVaultID(vaultMember.VaultID, userObjID)
-	if err != nil {
-		return errors.NewAppError(403, "Invalid Permission")
-	}
-	if currentVaultMember.Permission != models.ADMIN {
-		return errors.NewAppError(403, "Invalid Permission")
+	if _, err := authorizeVault(authz.MemberRemove, userObjID, vaultMember.VaultID); err != nil {
+		return err
 	}
 
 	err = repository.DeleteVaultMember(memberObjId)
@@ -403,36 +461,45 @@ func RemoveMemberFromVault(userID, memberId string) error {
 		return errors.NewAppError(404, "Member not found")
 	}
 
+	go recordTombstone(models.TombstoneMember, vaultMember.OrgID, vaultMember.VaultID, vaultMember.ID, &vaultMember.UserID)
+	go notifyVaultMembers(models.EventMemberRemoved, vaultMember.OrgID, vaultMember.VaultID, vaultMember.ID, userObjID, vaultMember.UserID)
+	go recordAudit(meta, memberAuditEntry(models.AuditMemberRemoved, vaultMember, &userObjID, "direct"))
 	return nil
 }
 
-func UpdateMemberPermission(userID, OrgID string, req *models.UpdateVaultMemberRequest) error {
+func UpdateMemberPermission(userID string, req *models.UpdateVaultMemberRequest, meta models.RequestMeta) error {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return errors.NewAppError(400, "Invalid userID")
 	}
 
-	orgObjID, err := primitive.ObjectIDFromHex(OrgID)
+	memberObjId, err := primitive.ObjectIDFromHex(req.MemberID)
 	if err != nil {
-		return errors.NewAppError(400, "Invalid orgID")
+		return errors.NewAppError(400, "Invalid vaultID")
 	}
 
-	permission, err := repository.FindMemberByUserVaultID(orgObjID, userObjID)
+	targetMember, err := repository.FindMemberById(memberObjId)
 	if err != nil {
-		return errors.NewAppError(403, "Invalid Permission")
+		return errors.NewAppError(404, "Member not found")
 	}
-	if permission.Permission != models.ADMIN {
-		return errors.NewAppError(403, "Invalid Permission")
+	if _, err := authorizeVault(authz.MemberUpdate, userObjID, targetMember.VaultID); err != nil {
+		return err
 	}
-
-	memberObjId, err := primitive.ObjectIDFromHex(req.MemberID)
-	if err != nil {
-		return errors.NewAppError(400, "Invalid vaultID")
+	if req.Permission == models.ADMIN {
+		targetUser, err := repository.FindUserByID(targetMember.UserID)
+		if err == nil && targetUser.IsServiceAccount() {
+			return errors.NewAppError(400, "Service accounts cannot be vault admins")
+		}
 	}
 
-	_, err = repository.FindMemberById(memberObjId)
-	if err != nil {
-		return errors.NewAppError(404, "Member not found")
+	// Trocar a permissão ou reembrulhar o ESVK não mexe na expiração; só um
+	// expiresAt presente a altera, e vazio a remove.
+	var expiresAt *primitive.DateTime
+	if req.ExpiresAt != nil {
+		expiresAt, err = parseMemberExpiry(*req.ExpiresAt)
+		if err != nil {
+			return err
+		}
 	}
 
 	esvkBytes, err := base64.StdEncoding.DecodeString(req.ESVK_PubK_User)
@@ -444,7 +511,16 @@ func UpdateMemberPermission(userID, OrgID string, req *models.UpdateVaultMemberR
 	if err != nil {
 		return errors.NewAppError(404, "Member not found")
 	}
+	if req.ExpiresAt != nil {
+		if err := repository.SetVaultMemberExpiry(memberObjId, expiresAt); err != nil {
+			return errors.NewAppError(500, "Failed to update vault member")
+		}
+	}
 
+	go notifyVaultMembers(models.EventMemberUpdated, targetMember.OrgID, targetMember.VaultID, targetMember.ID, userObjID)
+	memberEntry := vaultAuditEntry(models.AuditMemberUpdated, targetMember.OrgID, targetMember.VaultID, userObjID, models.AuditTargetMember, targetMember.ID.Hex())
+	memberEntry.Details = map[string]string{"userId": targetMember.UserID.Hex(), "from": string(targetMember.Permission), "to": string(req.Permission)}
+	go recordAudit(meta, memberEntry)
 	return nil
 }
 
@@ -459,12 +535,8 @@ func GetAllMembersFromTheVault(vaultID, userID string) ([]models.VaultMemberResp
 		return nil, errors.NewAppError(400, "Invalid userID")
 	}
 
-	user, err := repository.FindUserByID(userObjID)
-	if err != nil {
-		return nil, errors.NewAppError(404, "User not found")
-	}
-	if user.Role != models.RoleAdmin {
-		return nil, errors.NewAppError(403, "Only admin can create vault")
+	if _, err := authorizeVault(authz.MemberList, userObjID, vaultObjID); err != nil {
+		return nil, err
 	}
 
 	vaultMembers, err := repository.FindAllVaultMembersByVaultID(vaultObjID)
@@ -493,13 +565,16 @@ func GetAllMembersFromTheVault(vaultID, userID string) ([]models.VaultMemberResp
 			AddedBy:        vaultMember.AddedBy.Hex(),
 			AddAt:          vaultMember.AddAt.Time().Format(time.RFC3339),
 		}
+		for _, groupID := range vaultMember.GroupIDs {
+			vaultMemberResponse.GroupIDs = append(vaultMemberResponse.GroupIDs, groupID.Hex())
+		}
 		vaultMemberResponses = append(vaultMemberResponses, vaultMemberResponse)
 	}
 
 	return vaultMemberResponses, nil
 }
 
-func AddPasswordToVault(userID string, req *models.CreatePasswordRequest) (*models.PasswordResponse, error) {
+func AddPasswordToVault(userID string, req *models.CreatePasswordRequest, meta models.RequestMeta) (*models.PasswordResponse, error) {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return nil, errors.NewAppError(400, "Invalid userID")
@@ -509,12 +584,9 @@ func AddPasswordToVault(userID string, req *models.CreatePasswordRequest) (*mode
 	if err != nil {
 		return nil, errors.NewAppError(400, "Invalid vaultID")
 	}
-	permission, err := repository.FindMemberByUserVaultID(vaultObjID, userObjID)
+	permission, err := authorizeVault(authz.ItemCreate, userObjID, vaultObjID)
 	if err != nil {
-		return nil, errors.NewAppError(403, "Invalid Permission")
-	}
-	if permission.Permission != models.ADMIN {
-		return nil, errors.NewAppError(403, "Invalid Permission")
+		return nil, err
 	}
 
 	cipherBytes, err := utils.Base64ToBytes(req.EncryptedItemData.Ciphertext)
@@ -536,6 +608,7 @@ func AddPasswordToVault(userID string, req *models.CreatePasswordRequest) (*mode
 		EncryptedItemData: eid,
 		CreatedBy:         userObjID,
 		LastModifiedBy:    userObjID,
+		Revision:          1,
 		CreatedAt:         primitive.NewDateTimeFromTime(time.Now()),
 		UpdatedAt:         primitive.NewDateTimeFromTime(time.Now()),
 	}
@@ -545,6 +618,9 @@ func AddPasswordToVault(userID string, req *models.CreatePasswordRequest) (*mode
 		return nil, errors.NewAppError(500, "Unknown Error")
 	}
 
+	go notifyVaultMembers(models.EventItemCreated, permission.OrgID, password.VaultID, password.ID, userObjID)
+	go recordAudit(meta, vaultAuditEntry(models.AuditItemCreated, permission.OrgID, password.VaultID, userObjID, models.AuditTargetItem, password.ID.Hex()))
+
 	passwords := models.PasswordResponse{
 		ID:      password.ID.Hex(),
 		VaultID: password.VaultID.Hex(),
@@ -552,6 +628,7 @@ func AddPasswordToVault(userID string, req *models.CreatePasswordRequest) (*mode
 			Ciphertext: utils.BytesToBase64(password.EncryptedItemData.Ciphertext),
 			Nonce:      utils.BytesToBase64(password.EncryptedItemData.Nonce),
 		},
+		Revision:  password.Revision,
 		CreatedAt: password.CreatedAt.Time().Format(time.RFC3339),
 		UpdatedAt: password.UpdatedAt.Time().Format(time.RFC3339),
 	}
@@ -559,7 +636,7 @@ func AddPasswordToVault(userID string, req *models.CreatePasswordRequest) (*mode
 	return &passwords, nil
 }
 
-func DeletePasswordFromVault(userID, passwordID string) error {
+func DeletePasswordFromVault(userID, passwordID string, meta models.RequestMeta) error {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return errors.NewAppError(400, "Invalid userID")
@@ -575,12 +652,9 @@ func DeletePasswordFromVault(userID, passwordID string) error {
 		return errors.NewAppError(404, "Password not found")
 	}
 
-	permission, err := repository.FindMemberByUserVaultID(password.VaultID, userObjID)
+	permission, err := authorizeVault(authz.ItemDelete, userObjID, password.VaultID)
 	if err != nil {
-		return errors.NewAppError(403, "Invalid Permission")
-	}
-	if permission.Permission != models.WRITE && permission.Permission != models.ADMIN {
-		return errors.NewAppError(403, "Invalid Permission")
+		return err
 	}
 
 	err = repository.RemovePasswordFromVault(passwordObjID)
@@ -588,10 +662,14 @@ func DeletePasswordFromVault(userID, passwordID string) error {
 		return errors.NewAppError(500, "Unknown Error")
 	}
 
+	go recordTombstone(models.TombstoneItem, permission.OrgID, password.VaultID, password.ID, nil)
+	go notifyVaultMembers(models.EventItemDeleted, permission.OrgID, password.VaultID, password.ID, userObjID)
+	go removeAttachmentsByPasswordID(passwordObjID)
+	go recordAudit(meta, vaultAuditEntry(models.AuditItemDeleted, permission.OrgID, password.VaultID, userObjID, models.AuditTargetItem, password.ID.Hex()))
 	return nil
 }
 
-func UpdatePasswordInVault(userID string, req *models.UpdatePasswordRequest) (*models.PasswordResponse, error) {
+func UpdatePasswordInVault(userID string, req *models.UpdatePasswordRequest, meta models.RequestMeta) (*models.PasswordResponse, error) {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return nil, errors.NewAppError(400, "Invalid userID")
@@ -607,12 +685,16 @@ func UpdatePasswordInVault(userID string, req *models.UpdatePasswordRequest) (*m
 		return nil, errors.NewAppError(404, "Password not found")
 	}
 
-	permission, err := repository.FindMemberByUserVaultID(password.VaultID, userObjID)
+	permission, err := authorizeVault(authz.ItemUpdate, userObjID, password.VaultID)
 	if err != nil {
-		return nil, errors.NewAppError(403, "Invalid Permission")
+		return nil, err
 	}
-	if permission.Permission != models.WRITE && permission.Permission != models.ADMIN {
-		return nil, errors.NewAppError(403, "Invalid Permission")
+
+	if req.Revision == nil {
+		return nil, errors.NewAppError(428, "Password revision is required")
+	}
+	if password.Revision != *req.Revision {
+		return nil, passwordConflictError(password)
 	}
 
 	cipherBytes, err := utils.Base64ToBytes(req.EncryptedItemData.Ciphertext)
@@ -630,17 +712,30 @@ func UpdatePasswordInVault(userID string, req *models.UpdatePasswordRequest) (*m
 	}
 	password.EncryptedItemData = eid
 
-	err = repository.UpdatePasswordInVault(passwordObjID, password.EncryptedItemData, userObjID)
+	err = repository.UpdatePasswordInVault(passwordObjID, password.EncryptedItemData, userObjID, *req.Revision)
 	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Code == 409 {
+			current, findErr := repository.FindPasswordByID(passwordObjID)
+			if findErr != nil {
+				return nil, errors.NewAppError(404, "Password not found")
+			}
+			return nil, passwordConflictError(current)
+		}
 		return nil, errors.NewAppError(500, "Unknown Error")
 	}
+	password.Revision = *req.Revision + 1
+	password.LastModifiedBy = userObjID
+	password.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
+
+	go notifyVaultMembers(models.EventItemUpdated, permission.OrgID, password.VaultID, password.ID, userObjID)
+	go recordAudit(meta, vaultAuditEntry(models.AuditItemUpdated, permission.OrgID, password.VaultID, userObjID, models.AuditTargetItem, password.ID.Hex()))
 
 	pRes := NewPasswordResponse(*password)
 
 	return &pRes, nil
 }
 
-func GetAllPasswordsFromVault(userID, vaultID string) ([]models.PasswordResponse, error) {
+func GetAllPasswordsFromVault(userID, vaultID string, meta models.RequestMeta) ([]models.PasswordResponse, error) {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return nil, errors.NewAppError(400, "Invalid userID")
@@ -651,12 +746,9 @@ func GetAllPasswordsFromVault(userID, vaultID string) ([]models.PasswordResponse
 		return nil, errors.NewAppError(400, "Invalid vaultID")
 	}
 
-	permission, err := repository.FindMemberByUserVaultID(vaultObjID, userObjID)
+	member, err := authorizeVault(authz.ItemList, userObjID, vaultObjID)
 	if err != nil {
-		return nil, errors.NewAppError(403, "Invalid Permission")
-	}
-	if permission.Permission != models.READ && permission.Permission != models.WRITE && permission.Permission != models.ADMIN {
-		return nil, errors.NewAppError(403, "Invalid Permission")
+		return nil, err
 	}
 
 	allEid, err := repository.FindAllPasswordsByVaultID(vaultObjID)
@@ -664,6 +756,10 @@ func GetAllPasswordsFromVault(userID, vaultID string) ([]models.PasswordResponse
 		return nil, errors.NewAppError(404, "Passwords not found")
 	}
 
+	readEntry := vaultAuditEntry(models.AuditItemsRead, member.OrgID, vaultObjID, userObjID, models.AuditTargetVault, vaultID)
+	readEntry.Details = map[string]string{"count": strconv.Itoa(len(allEid))}
+	go recordAudit(meta, readEntry)
+
 	if len(allEid) == 0 {
 		return make([]models.PasswordResponse, 0), nil
 	}
@@ -677,6 +773,7 @@ func GetAllPasswordsFromVault(userID, vaultID string) ([]models.PasswordResponse
 				Ciphertext: utils.BytesToBase64(password.EncryptedItemData.Ciphertext),
 				Nonce:      utils.BytesToBase64(password.EncryptedItemData.Nonce),
 			},
+			Revision:  password.Revision,
 			CreatedAt: password.CreatedAt.Time().Format(time.RFC3339),
 			UpdatedAt: password.UpdatedAt.Time().Format(time.RFC3339),
 		})
@@ -685,6 +782,46 @@ func GetAllPasswordsFromVault(userID, vaultID string) ([]models.PasswordResponse
 	return passwords, nil
 }
 
+// addVaultMember grava o novo acesso. Um acesso vencido do mesmo usuário que a
+// rodada de expiração ainda não apagou seguraria o índice único, então ele é
+// expirado aqui antes, com os mesmos efeitos da rodada.
+func addVaultMember(member *models.VaultMember) error {
+	expired, err := repository.FindExpiredVaultMember(member.VaultID, member.UserID, time.Now())
+	switch {
+	case err == nil:
+		if err := expireMembership(*expired, time.Now()); err != nil {
+			return err
+		}
+	case err != mongo.ErrNoDocuments:
+		return err
+	}
+
+	err = repository.AddVaultMember(member)
+	if mongo.IsDuplicateKeyError(err) {
+		return errors.NewAppError(409, "User is already a member of the vault")
+	}
+	return err
+}
+
+func newVaultMemberResponse(member *models.VaultMember, user *models.User) models.VaultMemberResponse {
+	res := *utils.FacVaultMemberResponse(user.Email, member)
+	res.Username = &user.Username
+	return res
+}
+
+// parseMemberExpiry aceita RFC3339 no futuro; vazio significa acesso sem expiração.
+func parseMemberExpiry(value string) (*primitive.DateTime, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil || !t.After(time.Now()) {
+		return nil, errors.NewAppError(400, "Invalid expiresAt")
+	}
+	expiresAt := primitive.NewDateTimeFromTime(t)
+	return &expiresAt, nil
+}
+
 func NewPasswordResponse(password models.Password) models.PasswordResponse {
 	return models.PasswordResponse{
 		ID:      password.ID.Hex(),
@@ -693,7 +830,16 @@ func NewPasswordResponse(password models.Password) models.PasswordResponse {
 			Ciphertext: utils.BytesToBase64(password.EncryptedItemData.Ciphertext),
 			Nonce:      utils.BytesToBase64(password.EncryptedItemData.Nonce),
 		},
+		Revision:  password.Revision,
 		CreatedAt: password.CreatedAt.Time().Format(time.RFC3339),
 		UpdatedAt: password.UpdatedAt.Time().Format(time.RFC3339),
 	}
 }
+
+func passwordConflictError(current *models.Password) error {
+	return errors.NewAppErrorWithDetails(409, "Password was modified by someone else", NewPasswordResponse(*current))
+}
+
+func vaultConflictError(current *models.Vault, email string, member *models.VaultMember) error {
+	return errors.NewAppErrorWithDetails(409, "Vault was modified by someone else", utils.FacVaultResponse(current, email, member))
+}
@@ -0,0 +1,461 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"lembrago.com/lembrago/errors"
+	"lembrago.com/lembrago/internal/config"
+	"lembrago.com/lembrago/models"
+	"lembrago.com/lembrago/repository"
+	"lembrago.com/lembrago/utils"
+)
+
+const (
+	webhookSecretPrefix  = "whsec_"
+	webhookMaxAttempts   = 8
+	webhookBaseBackoff   = 30 * time.Second
+	webhookLease         = time.Minute
+	webhookJobInterval   = 15 * time.Second
+	webhookTimeout       = 10 * time.Second
+	webhookMaxErrorLen   = 500
+	webhookDeliveryLimit = 20
+)
+
+// webhookClient não segue redirecionamentos e confere, na hora de conectar, o
+// IP já resolvido: um nome que passou na validação e depois passou a apontar
+// para a rede interna continua bloqueado.
+var webhookClient = &http.Client{
+	Timeout: webhookTimeout,
+	Transport: &http.Transport{
+		DialContext:         (&net.Dialer{Timeout: webhookTimeout, Control: webhookDialControl}).DialContext,
+		TLSHandshakeTimeout: webhookTimeout,
+	},
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+var errWebhookAddressBlocked = stderrors.New("destination address is not allowed")
+
+// webhookBlockedNets completa o que net.IP já sabe classificar: "esta rede"
+// (0.0.0.0/8) e o CGNAT (100.64.0.0/10).
+var webhookBlockedNets = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),
+	mustParseCIDR("100.64.0.0/10"),
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
+
+func CreateWebhook(userID, orgID string, req *models.CreateWebhookRequest) (*models.CreatedWebhookResponse, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid userID format")
+	}
+	orgObjID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid orgID format")
+	}
+	if err := validateWebhookURL(req.URL); err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	webhook := models.Webhook{
+		ID:          primitive.NewObjectID(),
+		OrgID:       orgObjID,
+		URL:         req.URL,
+		Description: req.Description,
+		Events:      req.Events,
+		Secret:      webhookSecretPrefix + base64.RawURLEncoding.EncodeToString(secret),
+		Active:      true,
+		CreatedBy:   userObjID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := repository.CreateWebhook(&webhook); err != nil {
+		return nil, err
+	}
+
+	return &models.CreatedWebhookResponse{
+		Secret:  webhook.Secret,
+		Webhook: utils.FacWebhookRes(&webhook),
+	}, nil
+}
+
+func GetWebhooks(orgID string) ([]models.WebhookResponse, error) {
+	orgObjID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid orgID format")
+	}
+
+	webhooks, err := repository.FindWebhooksByOrgID(orgObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]models.WebhookResponse, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		res = append(res, utils.FacWebhookRes(&webhook))
+	}
+	return res, nil
+}
+
+func UpdateWebhook(orgID, webhookID string, req *models.UpdateWebhookRequest) (*models.WebhookResponse, error) {
+	webhook, err := findWebhook(orgID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateWebhookURL(req.URL); err != nil {
+		return nil, err
+	}
+
+	if err := repository.UpdateWebhook(webhook.ID, req); err != nil {
+		return nil, err
+	}
+	webhook, err = repository.FindWebhookByID(webhook.ID)
+	if err != nil {
+		return nil, errors.NewAppError(404, "Webhook not found")
+	}
+
+	res := utils.FacWebhookRes(webhook)
+	return &res, nil
+}
+
+// DeleteWebhook apaga o webhook e o seu histórico de entregas.
+func DeleteWebhook(orgID, webhookID string) error {
+	webhook, err := findWebhook(orgID, webhookID)
+	if err != nil {
+		return err
+	}
+	if err := repository.DeleteWebhookDeliveriesByWebhookID(webhook.ID); err != nil {
+		return err
+	}
+	return repository.DeleteWebhook(webhook.ID)
+}
+
+func GetWebhookDeliveries(orgID, webhookID string, query *models.WebhookDeliveryQuery) (*models.WebhookDeliveriesResponse, error) {
+	webhook, err := findWebhook(orgID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = webhookDeliveryLimit
+	}
+	deliveries, total, err := repository.FindWebhookDeliveries(webhook.ID, query.Page, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	res := models.WebhookDeliveriesResponse{
+		Deliveries: make([]models.WebhookDeliveryResponse, 0, len(deliveries)),
+		Page:       query.Page,
+		Limit:      limit,
+		Total:      total,
+	}
+	for _, delivery := range deliveries {
+		res.Deliveries = append(res.Deliveries, utils.FacWebhookDeliveryRes(&delivery))
+	}
+	return &res, nil
+}
+
+// RedeliverWebhook envia de novo o mesmo corpo de uma entrega anterior, como
+// uma nova entrega. A primeira tentativa acontece na hora; se falhar, segue o
+// mesmo calendário de novas tentativas.
+func RedeliverWebhook(orgID, webhookID, deliveryID string) (*models.WebhookDeliveryResponse, error) {
+	webhook, err := findWebhook(orgID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	deliveryObjID, err := primitive.ObjectIDFromHex(deliveryID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid deliveryID format")
+	}
+	original, err := repository.FindWebhookDeliveryByID(deliveryObjID)
+	if err != nil || original.WebhookID != webhook.ID {
+		return nil, errors.NewAppError(404, "Delivery not found")
+	}
+
+	delivery := newWebhookDelivery(webhook, original.Event, original.Payload)
+	delivery.RedeliveryOf = &original.ID
+	if err := repository.CreateWebhookDelivery(&delivery); err != nil {
+		return nil, err
+	}
+	attemptWebhookDelivery(&delivery)
+
+	res := utils.FacWebhookDeliveryRes(&delivery)
+	return &res, nil
+}
+
+// RunWebhookJob tenta de novo as entregas que falharam, quando chega a hora de
+// cada uma. Cada entrega é reservada no banco antes do envio, então várias
+// instâncias podem rodar o job ao mesmo tempo.
+func RunWebhookJob() {
+	ticker := time.NewTicker(webhookJobInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		for {
+			delivery, err := repository.ClaimDueWebhookDelivery(time.Now(), webhookLease)
+			if err != nil {
+				fmt.Printf("Failed to claim webhook delivery: %v\n", err)
+				break
+			}
+			if delivery == nil {
+				break
+			}
+			attemptWebhookDelivery(delivery)
+		}
+	}
+}
+
+// dispatchWebhook cria uma entrega para cada webhook da organização que assina
+// o evento e faz a primeira tentativa. Roda fora da requisição.
+func dispatchWebhook(orgID primitive.ObjectID, event models.WebhookEvent, data map[string]string) {
+	webhooks, err := repository.FindActiveWebhooksByEvent(orgID, event)
+	if err != nil {
+		fmt.Printf("Failed to find webhooks for %s: %v\n", event, err)
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(models.WebhookPayload{
+		ID:        primitive.NewObjectID().Hex(),
+		Event:     event,
+		OrgID:     orgID.Hex(),
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		Data:      data,
+	})
+	if err != nil {
+		fmt.Printf("Failed to encode webhook payload for %s: %v\n", event, err)
+		return
+	}
+
+	for _, webhook := range webhooks {
+		delivery := newWebhookDelivery(&webhook, event, payload)
+		if err := repository.CreateWebhookDelivery(&delivery); err != nil {
+			fmt.Printf("Failed to create webhook delivery: %v\n", err)
+			continue
+		}
+		attemptWebhookDelivery(&delivery)
+	}
+}
+
+// newWebhookDelivery já nasce reservada por quem a criou, que faz a primeira tentativa.
+func newWebhookDelivery(webhook *models.Webhook, event models.WebhookEvent, payload []byte) models.WebhookDelivery {
+	now := time.Now()
+	return models.WebhookDelivery{
+		ID:            primitive.NewObjectID(),
+		OrgID:         webhook.OrgID,
+		WebhookID:     webhook.ID,
+		Event:         event,
+		Payload:       payload,
+		Status:        models.WebhookDeliveryPending,
+		NextAttemptAt: primitive.NewDateTimeFromTime(now.Add(webhookLease)),
+		CreatedAt:     primitive.NewDateTimeFromTime(now),
+		UpdatedAt:     primitive.NewDateTimeFromTime(now),
+	}
+}
+
+// attemptWebhookDelivery envia a entrega e agenda a próxima tentativa com espera
+// dobrando a cada falha (30s, 1min, 2min...), até webhookMaxAttempts.
+func attemptWebhookDelivery(delivery *models.WebhookDelivery) {
+	delivery.Attempts++
+
+	webhook, err := repository.FindWebhookByID(delivery.WebhookID)
+	switch {
+	case err != nil:
+		err = fmt.Errorf("webhook was removed")
+	case !webhook.Active:
+		err = fmt.Errorf("webhook is disabled")
+	default:
+		delivery.LastStatusCode, err = sendWebhook(webhook, delivery)
+	}
+
+	now := time.Now()
+	delivery.UpdatedAt = primitive.NewDateTimeFromTime(now)
+	if err == nil {
+		delivered := primitive.NewDateTimeFromTime(now)
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.DeliveredAt = &delivered
+		delivery.LastError = ""
+	} else {
+		delivery.LastError = err.Error()
+		if len(delivery.LastError) > webhookMaxErrorLen {
+			delivery.LastError = delivery.LastError[:webhookMaxErrorLen]
+		}
+		if webhook == nil || !webhook.Active || delivery.Attempts >= webhookMaxAttempts {
+			delivery.Status = models.WebhookDeliveryFailed
+		} else {
+			backoff := webhookBaseBackoff << (delivery.Attempts - 1)
+			delivery.NextAttemptAt = primitive.NewDateTimeFromTime(now.Add(backoff))
+		}
+	}
+
+	if err := repository.SaveWebhookDeliveryAttempt(delivery); err != nil {
+		fmt.Printf("Failed to save webhook delivery %s: %v\n", delivery.ID.Hex(), err)
+	}
+}
+
+// sendWebhook assina o corpo como "t=<unix>,v1=<hex>", onde v1 é o
+// HMAC-SHA256 de "<unix>.<corpo>" com o segredo do webhook. Só 2xx conta como entregue.
+func sendWebhook(webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "LemBRAGO-Webhook/"+config.GetServerVersion())
+	req.Header.Set(models.WebhookEventHeader, string(delivery.Event))
+	req.Header.Set(models.WebhookDeliveryHeader, delivery.ID.Hex())
+	req.Header.Set(models.WebhookSignatureHeader, "t="+timestamp+",v1="+signWebhookPayload(webhook.Secret, timestamp, delivery.Payload))
+
+	res, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, webhookRequestError(err)
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("endpoint answered %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
+
+func signWebhookPayload(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookRequestError troca o erro de rede por uma mensagem genérica: o erro
+// vai para o log de entregas, e o detalhe da conexão diria aos admins da
+// organização quais portas estão abertas do lado do servidor.
+func webhookRequestError(err error) error {
+	var netErr net.Error
+	switch {
+	case stderrors.Is(err, errWebhookAddressBlocked):
+		return errWebhookAddressBlocked
+	case stderrors.As(err, &netErr) && netErr.Timeout():
+		return fmt.Errorf("request timed out")
+	default:
+		return fmt.Errorf("could not reach the endpoint")
+	}
+}
+
+func webhookIPAllowed(ip net.IP) bool {
+	if config.GetWebhookConfig().AllowPrivateIPs {
+		return true
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, network := range webhookBlockedNets {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return errWebhookAddressBlocked
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !webhookIPAllowed(ip) {
+		return errWebhookAddressBlocked
+	}
+	return nil
+}
+
+// validateWebhookURL exige https (http só com WEBHOOK_ALLOW_HTTP) e um host
+// cujos endereços sejam todos públicos. A conexão confere de novo o IP.
+func validateWebhookURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Hostname() == "" {
+		return errors.NewAppError(400, "Invalid webhook URL")
+	}
+	if parsed.Scheme != "https" && (parsed.Scheme != "http" || !config.GetWebhookConfig().AllowHTTP) {
+		return errors.NewAppError(400, "Webhook URL must use https")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, parsed.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return errors.NewAppError(400, "Webhook host could not be resolved")
+	}
+	for _, addr := range addrs {
+		if !webhookIPAllowed(addr.IP) {
+			return errors.NewAppError(400, "Webhook URL must point to a public address")
+		}
+	}
+	return nil
+}
+
+func findWebhook(orgID, webhookID string) (*models.Webhook, error) {
+	orgObjID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid orgID format")
+	}
+	webhookObjID, err := primitive.ObjectIDFromHex(webhookID)
+	if err != nil {
+		return nil, errors.NewAppError(400, "Invalid webhookID format")
+	}
+
+	webhook, err := repository.FindWebhookByID(webhookObjID)
+	if err != nil || webhook.OrgID != orgObjID {
+		return nil, errors.NewAppError(404, "Webhook not found")
+	}
+	return webhook, nil
+}
+
+// dispatchMemberAdded avisa member.added; source diz por qual caminho o membro entrou.
+func dispatchMemberAdded(member *models.VaultMember, source string) {
+	dispatchWebhook(member.OrgID, models.WebhookMemberAdded, map[string]string{
+		"vaultId":    member.VaultID.Hex(),
+		"memberId":   member.ID.Hex(),
+		"userId":     member.UserID.Hex(),
+		"permission": string(member.Permission),
+		"addedBy":    member.AddedBy.Hex(),
+		"source":     source,
+	})
+}
@@ -0,0 +1,129 @@
+package siem
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// authpriv: mensagens de segurança e autorização.
+const syslogFacility = 10
+
+var (
+	cefHeaderEscaper    = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\n", " ", "\r", " ")
+	cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\n", `\n`, "\r", `\r`)
+)
+
+// formatCEF monta a linha no formato CEF:0. Os campos sem chave própria no
+// dicionário do CEF vão nos pares csN/csNLabel.
+func formatCEF(e Event, version string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "CEF:0|LemBRAGO|lembrago|%s|%s|%s|%d|",
+		cefHeaderEscaper.Replace(version),
+		cefHeaderEscaper.Replace(e.Name),
+		cefHeaderEscaper.Replace(e.Name),
+		e.Severity,
+	)
+
+	first := true
+	add := func(key, value string) {
+		if value == "" {
+			return
+		}
+		if !first {
+			b.WriteByte(' ')
+		}
+		first = false
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(cefExtensionEscaper.Replace(value))
+	}
+	addCustom := func(n int, label, value string) {
+		if value == "" {
+			return
+		}
+		add(fmt.Sprintf("cs%dLabel", n), label)
+		add(fmt.Sprintf("cs%d", n), value)
+	}
+
+	add("rt", strconv.FormatInt(e.Time.UnixMilli(), 10))
+	add("outcome", string(e.Outcome))
+	add("src", e.IP)
+	add("suid", e.ActorID)
+	add("requestClientApplication", e.UserAgent)
+	addCustom(1, "orgId", e.OrgID)
+	addCustom(2, "vaultId", e.VaultID)
+	addCustom(3, "targetType", e.TargetType)
+	addCustom(4, "targetId", e.TargetID)
+	addCustom(5, "details", joinDetails(e.Details))
+	return []byte(b.String())
+}
+
+func formatJSON(e Event) []byte {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return []byte(fmt.Sprintf(`{"event":%q,"error":"unencodable event"}`, e.Name))
+	}
+	return line
+}
+
+// syslogMessage envolve a mensagem no cabeçalho da RFC 5424, sem structured data.
+func syslogMessage(e Event, hostname, appName string, msg []byte) []byte {
+	header := fmt.Sprintf("<%d>1 %s %s %s %d %s - ",
+		syslogFacility*8+syslogSeverity(e.Severity),
+		e.Time.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogField(hostname, 255),
+		syslogField(appName, 48),
+		os.Getpid(),
+		syslogField(e.Name, 32),
+	)
+	return append([]byte(header), msg...)
+}
+
+// syslogSeverity traduz a escala 0-10 do CEF para a severidade do syslog.
+func syslogSeverity(severity int) int {
+	switch {
+	case severity >= 8:
+		return 3 // err
+	case severity >= 6:
+		return 4 // warning
+	case severity >= 4:
+		return 5 // notice
+	default:
+		return 6 // info
+	}
+}
+
+// syslogField deixa só ASCII visível e corta no tamanho máximo do campo.
+func syslogField(value string, max int) string {
+	field := strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return -1
+		}
+		return r
+	}, value)
+	if field == "" {
+		return "-"
+	}
+	if len(field) > max {
+		field = field[:max]
+	}
+	return field
+}
+
+func joinDetails(details map[string]string) string {
+	keys := make([]string, 0, len(details))
+	for key := range details {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+details[key])
+	}
+	return strings.Join(pairs, "; ")
+}
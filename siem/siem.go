@@ -0,0 +1,187 @@
+// Package siem encaminha eventos de segurança para o SIEM da organização, em
+// CEF ou JSON Lines, por syslog (UDP, TCP ou TLS) ou para um arquivo local com
+// rotação. O envio roda numa goroutine própria com um buffer limitado: quando
+// o destino está lento ou fora do ar, os eventos excedentes são descartados e
+// contados, e a requisição nunca espera.
+package siem
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	FormatCEF  = "cef"
+	FormatJSON = "json"
+
+	defaultBufferSize   = 1024
+	defaultFileMaxBytes = 100 << 20
+	defaultFileBackups  = 5
+	defaultAppName      = "lembrago"
+)
+
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+)
+
+// Event é um evento de segurança. Severity vai de 0 a 10, como no CEF.
+type Event struct {
+	Time       time.Time         `json:"time"`
+	Name       string            `json:"event"`
+	Outcome    Outcome           `json:"outcome"`
+	Severity   int               `json:"severity"`
+	OrgID      string            `json:"orgId,omitempty"`
+	ActorID    string            `json:"actorId,omitempty"`
+	TargetType string            `json:"targetType,omitempty"`
+	TargetID   string            `json:"targetId,omitempty"`
+	VaultID    string            `json:"vaultId,omitempty"`
+	IP         string            `json:"ip,omitempty"`
+	UserAgent  string            `json:"userAgent,omitempty"`
+	Details    map[string]string `json:"details,omitempty"`
+}
+
+// Config vem do ambiente (veja config.GetSIEMConfig). Target vazio desliga o envio.
+type Config struct {
+	Target       string // udp://host:514, tcp://host:601, tls://host:6514 ou file:///caminho/siem.log
+	Format       string // cef (padrão) ou json
+	BufferSize   int
+	FileMaxBytes int64
+	FileBackups  int
+	TLSCAFile    string
+	AppName      string
+	Version      string
+}
+
+type forwarder struct {
+	events   chan Event
+	done     chan struct{}
+	sink     sink
+	format   func(Event) []byte
+	syslog   bool
+	hostname string
+	appName  string
+	dropped  atomic.Int64
+}
+
+var current atomic.Pointer[forwarder]
+
+// Start valida a configuração e sobe o envio em segundo plano. Sem Target,
+// Emit continua valendo e não faz nada.
+func Start(cfg Config) error {
+	if cfg.Target == "" {
+		return nil
+	}
+
+	f := &forwarder{appName: cfg.AppName}
+	if f.appName == "" {
+		f.appName = defaultAppName
+	}
+	f.hostname, _ = os.Hostname()
+	if f.hostname == "" {
+		f.hostname = "-"
+	}
+
+	switch strings.ToLower(cfg.Format) {
+	case "", FormatCEF:
+		version := cfg.Version
+		f.format = func(e Event) []byte { return formatCEF(e, version) }
+	case FormatJSON:
+		f.format = formatJSON
+	default:
+		return fmt.Errorf("siem: unknown format %q", cfg.Format)
+	}
+
+	target, err := url.Parse(cfg.Target)
+	if err != nil {
+		return fmt.Errorf("siem: invalid target: %v", err)
+	}
+	switch target.Scheme {
+	case "udp", "tcp", "tls":
+		if target.Host == "" {
+			return fmt.Errorf("siem: target %q has no host", cfg.Target)
+		}
+		f.sink, err = newNetworkSink(target.Scheme, target.Host, cfg.TLSCAFile)
+		f.syslog = true
+	case "file":
+		maxBytes, backups := cfg.FileMaxBytes, cfg.FileBackups
+		if maxBytes <= 0 {
+			maxBytes = defaultFileMaxBytes
+		}
+		if backups <= 0 {
+			backups = defaultFileBackups
+		}
+		f.sink, err = newFileSink(target.Path, maxBytes, backups)
+	default:
+		return fmt.Errorf("siem: unsupported target scheme %q", target.Scheme)
+	}
+	if err != nil {
+		return err
+	}
+
+	size := cfg.BufferSize
+	if size <= 0 {
+		size = defaultBufferSize
+	}
+	f.events = make(chan Event, size)
+	f.done = make(chan struct{})
+
+	if previous := current.Swap(f); previous != nil {
+		close(previous.done)
+	}
+	go f.run()
+	return nil
+}
+
+// Emit enfileira o evento sem bloquear. Com o buffer cheio o evento é
+// descartado; a quantidade aparece no log na próxima entrega bem-sucedida.
+func Emit(event Event) {
+	f := current.Load()
+	if f == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	select {
+	case f.events <- event:
+	default:
+		f.dropped.Add(1)
+	}
+}
+
+func (f *forwarder) run() {
+	defer f.sink.close()
+
+	for {
+		var event Event
+		select {
+		case <-f.done:
+			return
+		case event = <-f.events:
+		}
+
+		msg := f.format(event)
+		if f.syslog {
+			msg = syslogMessage(event, f.hostname, f.appName, msg)
+		}
+
+		if err := f.sink.write(msg); err != nil {
+			f.dropped.Add(1)
+			if err != errSinkDown {
+				log.Printf("[siem] failed to deliver event: %v\n", err)
+			}
+			continue
+		}
+		if dropped := f.dropped.Swap(0); dropped > 0 {
+			log.Printf("[siem] %d events were dropped\n", dropped)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package siem
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testEvent = Event{
+	Time:      time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC),
+	Name:      "auth.login_failed",
+	Outcome:   OutcomeFailure,
+	Severity:  7,
+	OrgID:     "org1",
+	IP:        "203.0.113.7",
+	UserAgent: "curl/8.0 | a=b",
+	Details:   map[string]string{"reason": "invalid_credentials", "email": "x@example.com"},
+}
+
+func TestFormatCEF(t *testing.T) {
+	got := string(formatCEF(testEvent, "1.0|beta"))
+	want := `CEF:0|LemBRAGO|lembrago|1.0\|beta|auth.login_failed|auth.login_failed|7|` +
+		`rt=1772368200000 outcome=failure src=203.0.113.7 requestClientApplication=curl/8.0 | a\=b ` +
+		`cs1Label=orgId cs1=org1 cs5Label=details cs5=email\=x@example.com; reason\=invalid_credentials`
+	if got != want {
+		t.Fatalf("formatCEF:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestFormatJSON(t *testing.T) {
+	var decoded Event
+	if err := json.Unmarshal(formatJSON(testEvent), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Name != testEvent.Name || decoded.Details["reason"] != "invalid_credentials" || !decoded.Time.Equal(testEvent.Time) {
+		t.Fatalf("formatJSON round trip: %+v", decoded)
+	}
+}
+
+func TestSyslogMessage(t *testing.T) {
+	got := string(syslogMessage(testEvent, "host name", "lembrago", []byte("msg")))
+	// authpriv (10) * 8 + warning (4) = 84
+	pattern := `^<84>1 2026-03-01T12:30:00\.000000Z hostname lembrago \d+ auth\.login_failed - msg$`
+	if !regexp.MustCompile(pattern).MatchString(got) {
+		t.Fatalf("syslogMessage: %s", got)
+	}
+}
+
+func TestStartUDP(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	if err := Start(Config{Target: "udp://" + listener.LocalAddr().String(), Format: FormatJSON}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(stopForTest)
+	Emit(testEvent)
+
+	buf := make([]byte, 4096)
+	listener.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := listener.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("no datagram received: %v", err)
+	}
+	msg := string(buf[:n])
+	if !strings.HasPrefix(msg, "<84>1 ") || !strings.Contains(msg, `"event":"auth.login_failed"`) {
+		t.Fatalf("unexpected datagram: %s", msg)
+	}
+}
+
+func TestStartTCPFraming(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	if err := Start(Config{Target: "tcp://" + listener.Addr().String()}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(stopForTest)
+	Emit(testEvent)
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	reader := bufio.NewReader(conn)
+	length, err := reader.ReadString(' ')
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(length, " "))
+	if err != nil {
+		t.Fatalf("frame does not start with its length: %q", length)
+	}
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(reader, msg); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(msg), "<84>1 ") || !strings.HasSuffix(string(msg), "reason\\=invalid_credentials") {
+		t.Fatalf("unexpected frame: %q", msg)
+	}
+}
+
+func TestFileSinkRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "siem.log")
+	sink, err := newFileSink(path, 100, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.close()
+
+	line := []byte(strings.Repeat("x", 59))
+	for i := 0; i < 5; i++ {
+		if err := sink.write(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		data, err := os.ReadFile(name)
+		if err != nil || len(data) != 60 {
+			t.Fatalf("%s: %d bytes, %v", name, len(data), err)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatal("rotation kept more backups than configured")
+	}
+}
+
+func TestEmitNeverBlocks(t *testing.T) {
+	// Ninguém escuta nessa porta: as entregas falham e o buffer de 1 enche.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	if err := Start(Config{Target: "tcp://" + addr, BufferSize: 1}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(stopForTest)
+
+	start := time.Now()
+	for i := 0; i < 10000; i++ {
+		Emit(testEvent)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Emit blocked for %s", elapsed)
+	}
+	if current.Load().dropped.Load() == 0 {
+		t.Fatal("no event was counted as dropped")
+	}
+}
+
+func TestStartRejectsBadConfig(t *testing.T) {
+	for _, cfg := range []Config{
+		{Target: "http://example.com"},
+		{Target: "udp://"},
+		{Target: "file:///tmp/siem.log", Format: "xml"},
+	} {
+		if err := Start(cfg); err == nil {
+			t.Errorf("Start(%+v) accepted an invalid config", cfg)
+		}
+	}
+}
+
+func stopForTest() {
+	if f := current.Swap(nil); f != nil {
+		close(f.done)
+	}
+}
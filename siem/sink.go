@@ -0,0 +1,192 @@
+package siem
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const (
+	dialTimeout  = 5 * time.Second
+	writeTimeout = 5 * time.Second
+	maxBackoff   = time.Minute
+)
+
+// errSinkDown é devolvido enquanto o destino está em espera para reconectar.
+var errSinkDown = errors.New("siem: target is down")
+
+type sink interface {
+	write(msg []byte) error
+	close()
+}
+
+// networkSink mantém uma conexão com o coletor de syslog. Quando ela cai, os
+// eventos são descartados até a próxima tentativa, com espera crescente entre
+// tentativas para não travar o envio discando a cada evento.
+type networkSink struct {
+	network   string
+	addr      string
+	tlsConfig *tls.Config
+	conn      net.Conn
+	backoff   time.Duration
+	retryAt   time.Time
+}
+
+func newNetworkSink(scheme, addr, caFile string) (*networkSink, error) {
+	s := &networkSink{network: scheme, addr: addr}
+	if scheme != "tls" {
+		return s, nil
+	}
+
+	s.network = "tcp"
+	s.tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("siem: failed to read CA file: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("siem: no certificates in %s", caFile)
+		}
+		s.tlsConfig.RootCAs = pool
+	}
+	return s, nil
+}
+
+func (s *networkSink) write(msg []byte) error {
+	if s.conn == nil {
+		if time.Now().Before(s.retryAt) {
+			return errSinkDown
+		}
+		if err := s.dial(); err != nil {
+			s.fail()
+			return err
+		}
+	}
+
+	// Em TCP as mensagens vão com octet counting (RFC 6587); em UDP, uma por datagrama.
+	if s.network == "tcp" {
+		msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if _, err := s.conn.Write(msg); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		s.fail()
+		return err
+	}
+	s.backoff = 0
+	return nil
+}
+
+func (s *networkSink) dial() error {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	var err error
+	if s.tlsConfig != nil {
+		s.conn, err = tls.DialWithDialer(dialer, s.network, s.addr, s.tlsConfig)
+	} else {
+		s.conn, err = dialer.Dial(s.network, s.addr)
+	}
+	return err
+}
+
+func (s *networkSink) fail() {
+	if s.backoff == 0 {
+		s.backoff = time.Second
+	} else if s.backoff < maxBackoff {
+		s.backoff *= 2
+	}
+	s.retryAt = time.Now().Add(s.backoff)
+}
+
+func (s *networkSink) close() {
+	if s.conn != nil {
+		s.conn.Close()
+	}
+}
+
+// fileSink grava uma linha por evento e gira o arquivo quando ele passa de
+// maxBytes: siem.log vira siem.log.1, siem.log.1 vira siem.log.2 e assim por
+// diante, até backups arquivos antigos.
+type fileSink struct {
+	path     string
+	maxBytes int64
+	backups  int
+	file     *os.File
+	size     int64
+}
+
+func newFileSink(path string, maxBytes int64, backups int) (*fileSink, error) {
+	if path == "" {
+		return nil, errors.New("siem: file target has no path")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("siem: %v", err)
+	}
+	s := &fileSink{path: path, maxBytes: maxBytes, backups: backups}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *fileSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("siem: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("siem: %v", err)
+	}
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+func (s *fileSink) write(msg []byte) error {
+	if s.file == nil {
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+
+	line := append(msg, '\n')
+	if s.size > 0 && s.size+int64(len(line)) > s.maxBytes {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	return err
+}
+
+func (s *fileSink) rotate() error {
+	s.file.Close()
+	s.file = nil
+
+	os.Remove(fmt.Sprintf("%s.%d", s.path, s.backups))
+	for i := s.backups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", s.path, i), fmt.Sprintf("%s.%d", s.path, i+1))
+	}
+	if err := os.Rename(s.path, s.path+".1"); err != nil {
+		return fmt.Errorf("siem: failed to rotate %s: %v", s.path, err)
+	}
+	return s.open()
+}
+
+func (s *fileSink) close() {
+	if s.file != nil {
+		s.file.Close()
+	}
+}
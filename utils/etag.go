@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func FormatETag(revision int64) string {
+	return fmt.Sprintf("\"%d\"", revision)
+}
+
+// ParseIfMatch aceita "3", "\"3\"" ou "W/\"3\"".
+func ParseIfMatch(header string) (int64, error) {
+	value := strings.TrimSpace(header)
+	value = strings.TrimPrefix(value, "W/")
+	value = strings.Trim(value, "\"")
+
+	revision, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || revision < 0 {
+		return 0, fmt.Errorf("invalid If-Match header")
+	}
+
+	return revision, nil
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"lembrago.com/lembrago/internal/config"
+)
+
+// GetExportSigningKey usa EXPORT_SIGNING_SEED (32 bytes em base64) quando definido.
+// Sem ele, a seed é derivada do JWT_SECRET para que a chave seja estável entre reinícios.
+func GetExportSigningKey() (ed25519.PrivateKey, error) {
+	appConfig := config.GetServerConfig()
+
+	if appConfig.ExportSigningSeed != "" {
+		seed, err := Base64ToBytes(appConfig.ExportSigningSeed)
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("EXPORT_SIGNING_SEED must be %d bytes in base64", ed25519.SeedSize)
+		}
+		return ed25519.NewKeyFromSeed(seed), nil
+	}
+
+	if len(appConfig.JWTSecret) == 0 {
+		return nil, fmt.Errorf("no export signing key configured")
+	}
+
+	seed := sha256.Sum256(append([]byte("lembrago-export-signing:"), appConfig.JWTSecret...))
+	return ed25519.NewKeyFromSeed(seed[:]), nil
+}
+
+func ExportKeyID(publicKey ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKey)
+	return hex.EncodeToString(sum[:8])
+}
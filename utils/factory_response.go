@@ -3,6 +3,7 @@ package utils
 import (
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"lembrago.com/lembrago/models"
 )
 
@@ -20,14 +21,17 @@ func FacVaultResponse(vault *models.Vault, email string, vaultMember *models.Vau
 		EncryptedVaultMetadata: FacEncryptedKeyDto(vault.EncryptedVaultMetadata.Ciphertext, vault.EncryptedVaultMetadata.Nonce),
 		MyMembership:           *FacVaultMemberResponse(email, vaultMember),
 		PersonalVault:          vault.PersonalVault,
+		NeedsKeyRotation:       vault.NeedsKeyRotation,
 		CreatedBy:              vault.CreatedBy.Hex(),
+		OwnerID:                vault.Owner().Hex(),
+		Revision:               vault.Revision,
 		UpdatedAt:              vault.UpdatedAt.Time().Format(time.RFC3339),
 		CreatedAt:              vault.CreatedAt.Time().Format(time.RFC3339),
 	}
 }
 
 func FacVaultMemberResponse(email string, vaultMember *models.VaultMember) *models.VaultMemberResponse {
-	return &models.VaultMemberResponse{
+	res := &models.VaultMemberResponse{
 		ID:             vaultMember.ID.Hex(),
 		VaultID:        vaultMember.VaultID.Hex(),
 		UserID:         vaultMember.UserID.Hex(),
@@ -38,6 +42,14 @@ func FacVaultMemberResponse(email string, vaultMember *models.VaultMember) *mode
 		AddedBy:        vaultMember.AddedBy.Hex(),
 		AddAt:          vaultMember.AddAt.Time().Format(time.RFC3339),
 	}
+	if vaultMember.ExpiresAt != nil {
+		expiresAt := vaultMember.ExpiresAt.Time().Format(time.RFC3339)
+		res.ExpiresAt = &expiresAt
+	}
+	for _, groupID := range vaultMember.GroupIDs {
+		res.GroupIDs = append(res.GroupIDs, groupID.Hex())
+	}
+	return res
 }
 
 func FacMinimalUserRes(user *models.User) models.MinimalUserInfoResponse {
@@ -49,7 +61,283 @@ func FacMinimalUserRes(user *models.User) models.MinimalUserInfoResponse {
 		PublicKey: BytesToBase64(user.Keys.PublicKey),
 		Role:      user.Role,
 		Status:    user.Status,
+		Type:      user.Type,
 		CreatedAt: user.CreatedAt.Time().Format(time.RFC3339),
 		UpdatedAt: user.UpdatedAt.Time().Format(time.RFC3339),
 	}
 }
+
+func FacUserRes(user *models.User) models.UserResponse {
+	return models.UserResponse{
+		ID:       user.ID.Hex(),
+		Email:    user.Email,
+		OrgId:    user.OrgID.Hex(),
+		Username: user.Username,
+		Role:     user.Role,
+		Locale:   user.Locale,
+
+		PasswordVerifier: models.PasswordVerifierResponse{
+			Salt:       BytesToBase64(user.SaltPV),
+			Parameters: user.Parameters,
+		},
+
+		Salt_ek: BytesToBase64(user.SaltEk),
+
+		Keys: models.KeysDTO{
+			PublicKey:           BytesToBase64(user.Keys.PublicKey),
+			EncryptedPrivateKey: FacEncryptedKeyDto(user.Keys.EncryptedPrivateKey.Ciphertext, user.Keys.EncryptedPrivateKey.Nonce),
+			EncryptedSecretKey:  FacEncryptedKeyDto(user.Keys.EncryptedSecretKey.Ciphertext, user.Keys.EncryptedSecretKey.Nonce),
+		},
+	}
+}
+
+func FacServiceAccountRes(account *models.User, credentials []models.ServiceAccountCredential) models.ServiceAccountResponse {
+	res := models.ServiceAccountResponse{
+		ID:          account.ID.Hex(),
+		OrgID:       account.OrgID.Hex(),
+		Name:        account.Username,
+		PublicKey:   BytesToBase64(account.Keys.PublicKey),
+		Status:      account.Status,
+		Credentials: make([]models.ServiceAccountCredentialResponse, 0, len(credentials)),
+		CreatedAt:   account.CreatedAt.Time().Format(time.RFC3339),
+		UpdatedAt:   account.UpdatedAt.Time().Format(time.RFC3339),
+	}
+	for _, credential := range credentials {
+		res.Credentials = append(res.Credentials, FacServiceAccountCredentialRes(&credential))
+	}
+	return res
+}
+
+func FacServiceAccountCredentialRes(credential *models.ServiceAccountCredential) models.ServiceAccountCredentialResponse {
+	res := models.ServiceAccountCredentialResponse{
+		ID:        credential.ID.Hex(),
+		Name:      credential.Name,
+		Scope:     FacServiceAccountScopeDTO(credential.Scope),
+		CreatedBy: credential.CreatedBy.Hex(),
+		CreatedAt: credential.CreatedAt.Time().Format(time.RFC3339),
+	}
+	if credential.ExpiresAt != nil {
+		expiresAt := credential.ExpiresAt.Time().Format(time.RFC3339)
+		res.ExpiresAt = &expiresAt
+	}
+	if credential.LastUsedAt != nil {
+		lastUsedAt := credential.LastUsedAt.Time().Format(time.RFC3339)
+		res.LastUsedAt = &lastUsedAt
+	}
+	return res
+}
+
+func FacServiceAccountScopeDTO(scope models.ServiceAccountScope) models.ServiceAccountScopeDTO {
+	dto := models.ServiceAccountScopeDTO{ReadOnly: scope.ReadOnly}
+	for _, vaultID := range scope.VaultIDs {
+		dto.VaultIDs = append(dto.VaultIDs, vaultID.Hex())
+	}
+	return dto
+}
+
+func FacPersonalAccessTokenRes(token *models.PersonalAccessToken) models.PersonalAccessTokenResponse {
+	res := models.PersonalAccessTokenResponse{
+		ID:        token.ID.Hex(),
+		Name:      token.Name,
+		Scopes:    token.Scopes,
+		CreatedAt: token.CreatedAt.Time().Format(time.RFC3339),
+		ExpiresAt: token.ExpiresAt.Time().Format(time.RFC3339),
+	}
+	if token.LastUsedAt != nil {
+		lastUsedAt := token.LastUsedAt.Time().Format(time.RFC3339)
+		res.LastUsedAt = &lastUsedAt
+	}
+	return res
+}
+
+func FacGroupRes(group *models.Group, members []models.GroupMember) models.GroupResponse {
+	res := models.GroupResponse{
+		ID:          group.ID.Hex(),
+		Name:        group.Name,
+		Description: group.Description,
+		MemberIDs:   make([]string, 0, len(members)),
+		CreatedBy:   group.CreatedBy.Hex(),
+		CreatedAt:   group.CreatedAt.Time().Format(time.RFC3339),
+		UpdatedAt:   group.UpdatedAt.Time().Format(time.RFC3339),
+	}
+	for _, member := range members {
+		res.MemberIDs = append(res.MemberIDs, member.UserID.Hex())
+	}
+	return res
+}
+
+func FacGroupVaultGrantRes(grant *models.GroupVaultGrant, groupName string) models.GroupVaultGrantResponse {
+	return models.GroupVaultGrantResponse{
+		ID:         grant.ID.Hex(),
+		GroupID:    grant.GroupID.Hex(),
+		GroupName:  groupName,
+		VaultID:    grant.VaultID.Hex(),
+		Permission: string(grant.Permission),
+		GrantedBy:  grant.GrantedBy.Hex(),
+		GrantedAt:  grant.GrantedAt.Time().Format(time.RFC3339),
+	}
+}
+
+func FacKeyShareTaskRes(task *models.KeyShareTask, user *models.User) models.KeyShareTaskResponse {
+	return models.KeyShareTaskResponse{
+		ID:          task.ID.Hex(),
+		VaultID:     task.VaultID.Hex(),
+		UserID:      task.UserID.Hex(),
+		Email:       user.Email,
+		PublicKey:   BytesToBase64(user.Keys.PublicKey),
+		Fingerprint: models.PublicKeyFingerprint(user.Keys.PublicKey),
+		GroupID:     task.GroupID.Hex(),
+		Permission:  string(task.Permission),
+		CreatedAt:   task.CreatedAt.Time().Format(time.RFC3339),
+	}
+}
+
+// FacPendingShareRes monta a resposta; user é nil enquanto o e-mail não tem conta.
+func FacPendingShareRes(share *models.PendingShare, user *models.User) models.PendingShareResponse {
+	res := models.PendingShareResponse{
+		ID:         share.ID.Hex(),
+		VaultID:    share.VaultID.Hex(),
+		Email:      share.Email,
+		Permission: string(share.Permission),
+		Status:     models.PendingShareWaiting,
+		CreatedBy:  share.CreatedBy.Hex(),
+		CreatedAt:  share.CreatedAt.Time().Format(time.RFC3339),
+		ExpiresAt:  share.ExpiresAt.Time().Format(time.RFC3339),
+	}
+	if user != nil {
+		res.Status = models.PendingShareReady
+		res.UserID = user.ID.Hex()
+		res.PublicKey = BytesToBase64(user.Keys.PublicKey)
+		res.Fingerprint = models.PublicKeyFingerprint(user.Keys.PublicKey)
+	}
+	return res
+}
+
+// FacAccessRequestRes inclui e-mail e chave pública do solicitante quando user
+// não é nil, para que o admin do cofre confira a impressão digital.
+func FacAccessRequestRes(request *models.AccessRequest, user *models.User) models.AccessRequestResponse {
+	res := models.AccessRequestResponse{
+		ID:            request.ID.Hex(),
+		VaultID:       request.VaultID.Hex(),
+		VaultName:     request.VaultName,
+		UserID:        request.UserID.Hex(),
+		Permission:    string(request.Permission),
+		Justification: request.Justification,
+		Status:        request.Status,
+		DecisionNote:  request.DecisionNote,
+		CreatedAt:     request.CreatedAt.Time().Format(time.RFC3339),
+		ExpiresAt:     request.ExpiresAt.Time().Format(time.RFC3339),
+		History:       make([]models.AccessRequestEventResponse, 0, len(request.History)),
+	}
+	if request.DecidedBy != nil {
+		res.DecidedBy = request.DecidedBy.Hex()
+	}
+	if request.DecidedAt != nil {
+		res.DecidedAt = request.DecidedAt.Time().Format(time.RFC3339)
+	}
+	for _, event := range request.History {
+		eventRes := models.AccessRequestEventResponse{
+			Status: event.Status,
+			Note:   event.Note,
+			At:     event.At.Time().Format(time.RFC3339),
+		}
+		if event.ActorID != nil {
+			eventRes.ActorID = event.ActorID.Hex()
+		}
+		res.History = append(res.History, eventRes)
+	}
+	if user != nil {
+		res.Email = user.Email
+		res.PublicKey = BytesToBase64(user.Keys.PublicKey)
+		res.Fingerprint = models.PublicKeyFingerprint(user.Keys.PublicKey)
+	}
+	return res
+}
+
+func FacVaultOwnershipTransferRes(vaultID primitive.ObjectID, transfer *models.VaultOwnershipTransfer) *models.VaultOwnershipTransferResponse {
+	return &models.VaultOwnershipTransferResponse{
+		VaultID:     vaultID.Hex(),
+		ToUserID:    transfer.ToUserID.Hex(),
+		RequestedBy: transfer.RequestedBy.Hex(),
+		RequestedAt: transfer.RequestedAt.Time().Format(time.RFC3339),
+		ExpiresAt:   transfer.ExpiresAt.Time().Format(time.RFC3339),
+	}
+}
+
+func FacRoleRes(role *models.Role) models.RoleResponse {
+	return models.RoleResponse{
+		Key:         role.Key,
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: role.Permissions,
+		BuiltIn:     role.BuiltIn,
+	}
+}
+
+func FacWebhookRes(webhook *models.Webhook) models.WebhookResponse {
+	return models.WebhookResponse{
+		ID:          webhook.ID.Hex(),
+		URL:         webhook.URL,
+		Description: webhook.Description,
+		Events:      webhook.Events,
+		Active:      webhook.Active,
+		CreatedBy:   webhook.CreatedBy.Hex(),
+		CreatedAt:   webhook.CreatedAt.Time().Format(time.RFC3339),
+		UpdatedAt:   webhook.UpdatedAt.Time().Format(time.RFC3339),
+	}
+}
+
+func FacWebhookDeliveryRes(delivery *models.WebhookDelivery) models.WebhookDeliveryResponse {
+	res := models.WebhookDeliveryResponse{
+		ID:             delivery.ID.Hex(),
+		WebhookID:      delivery.WebhookID.Hex(),
+		Event:          delivery.Event,
+		Payload:        string(delivery.Payload),
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt.Time().Format(time.RFC3339),
+	}
+	if delivery.Status == models.WebhookDeliveryPending {
+		nextAttemptAt := delivery.NextAttemptAt.Time().Format(time.RFC3339)
+		res.NextAttemptAt = &nextAttemptAt
+	}
+	if delivery.RedeliveryOf != nil {
+		res.RedeliveryOf = delivery.RedeliveryOf.Hex()
+	}
+	if delivery.DeliveredAt != nil {
+		deliveredAt := delivery.DeliveredAt.Time().Format(time.RFC3339)
+		res.DeliveredAt = &deliveredAt
+	}
+	return res
+}
+
+func FacOutboxEmailRes(email *models.OutboxEmail) models.OutboxEmailResponse {
+	res := models.OutboxEmailResponse{
+		ID:        email.ID.Hex(),
+		Kind:      email.Kind,
+		To:        email.To,
+		Subject:   email.Subject,
+		Status:    email.Status,
+		Attempts:  email.Attempts,
+		LastError: email.LastError,
+		CreatedAt: email.CreatedAt.Time().Format(time.RFC3339),
+		UpdatedAt: email.UpdatedAt.Time().Format(time.RFC3339),
+	}
+	if email.ExpiresAt != nil {
+		expiresAt := email.ExpiresAt.Time().Format(time.RFC3339)
+		res.ExpiresAt = &expiresAt
+	}
+	return res
+}
+
+func FacOrgBrandingRes(org *models.Organization) models.OrgBrandingResponse {
+	return models.OrgBrandingResponse{
+		Locale:          MailLocale(org.Locale),
+		ImageUrl:        org.ImageUrl,
+		SenderName:      org.Branding.SenderName,
+		PrimaryColor:    org.Branding.PrimaryColor,
+		BackgroundColor: org.Branding.BackgroundColor,
+	}
+}
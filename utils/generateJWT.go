@@ -14,9 +14,18 @@ type CustomClaims struct {
 	OrgID  string           `json:"orgId"`
 	Role   *models.UserRole `json:"role"`
 	Email  *string          `json:"email"`
+
+	// Preenchidos só nos tokens de contas de serviço.
+	Kind         models.UserType                `json:"kind,omitempty"`
+	CredentialID string                         `json:"cid,omitempty"`
+	Scope        *models.ServiceAccountScopeDTO `json:"scope,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// ServiceAccountTokenTTL é curto porque a credencial pode ser trocada pelo
+// token de novo a qualquer momento.
+const ServiceAccountTokenTTL = time.Hour
+
 func GenerateJWT(userID, orgID string, role models.UserRole) (string, error) {
 	appConfig := config.GetServerConfig()
 	expirationTime := time.Now().Add(2160 * time.Hour)
@@ -63,3 +72,55 @@ func GenerateJWTUserCreation(userID, orgID string, role models.UserRole, email s
 
 	return tokenStr, nil
 }
+
+func GenerateServiceAccountJWT(accountID, orgID, credentialID string, scope models.ServiceAccountScopeDTO) (string, time.Time, error) {
+	appConfig := config.GetServerConfig()
+	expirationTime := time.Now().Add(ServiceAccountTokenTTL)
+	role := models.RoleMember
+	claims := &CustomClaims{
+		UserID:       accountID,
+		OrgID:        orgID,
+		Role:         &role,
+		Kind:         models.UserTypeService,
+		CredentialID: credentialID,
+		Scope:        &scope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    appConfig.JWTIssuer,
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenStr, err := token.SignedString(appConfig.JWTSecret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("erro ao assinar o Token")
+	}
+
+	return tokenStr, expirationTime, nil
+}
+
+// RevokedCredentialKey é a chave no Redis com o instante (Unix) da última
+// revogação de uma credencial de conta de serviço; tokens emitidos até ali
+// deixam de valer.
+func RevokedCredentialKey(credentialID string) string {
+	return "sa-revoked-" + credentialID
+}
+
+// SuspendedUserKey marca no Redis um usuário suspenso; enquanto existir, nenhum
+// token dele é aceito.
+func SuspendedUserKey(userID string) string {
+	return "user-suspended-" + userID
+}
+
+// LockedUserKey marca no Redis uma conta travada pelo próprio usuário no link
+// "não fui eu"; some quando ele verifica o e-mail de novo.
+func LockedUserKey(userID string) string {
+	return "user-locked-" + userID
+}
+
+// RevokedSessionsKey guarda o instante (Unix) em que todas as sessões do
+// usuário foram revogadas; tokens emitidos até ali deixam de valer.
+func RevokedSessionsKey(userID string) string {
+	return "user-sessions-revoked-" + userID
+}
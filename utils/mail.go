@@ -1,152 +1,146 @@
 package utils
 
 import (
-	"crypto/tls"
 	"fmt"
-	"os"
-	"strconv"
-	"strings"
 	"time"
 
-	"gopkg.in/gomail.v2"
 	"lembrago.com/lembrago/internal/config"
+	"lembrago.com/lembrago/mailer"
+	"lembrago.com/lembrago/models"
 )
 
-type InitialMailConfig struct {
-	Email    string
-	Password string
-	Host     string
-	Port     int
-}
+const (
+	mailTemplateDir    = "templates"
+	mailProjectName    = "LEMBRAGO"
+	defaultMailPrimary = "#007bff"
+	defaultMailBgColor = "#f4f4f4"
+)
 
-var mailConfig InitialMailConfig
+// mailTimeLayouts formata datas no costume de cada idioma.
+var mailTimeLayouts = map[string]string{
+	models.LocalePtBR: "02/01/2006 15:04 MST",
+	models.LocaleEnUS: "Jan 2, 2006 3:04 PM MST",
+}
 
-func init() {
-	portStr := os.Getenv("EMAIL_PORT")
-	port, _ := strconv.Atoi(portStr)
+// MailBrand diz em que idioma e com que marca o e-mail sai. O valor zero é o
+// e-mail do LemBRAGO em português.
+type MailBrand struct {
+	Locale          string
+	OrgName         string
+	LogoURL         string
+	SenderName      string
+	PrimaryColor    string
+	BackgroundColor string
+}
 
-	mailConfig = InitialMailConfig{
-		Email:    os.Getenv("EMAIL_AUTH_USER"),
-		Password: os.Getenv("EMAIL_AUTH_PASS"),
-		Host:     os.Getenv("EMAIL_HOST"),
-		Port:     port,
+// MailLocale devolve o primeiro idioma com modelos de e-mail entre os
+// candidatos (ex.: o do usuário, depois o da organização), ou o padrão.
+func MailLocale(candidates ...string) string {
+	for _, locale := range candidates {
+		if _, ok := mailTimeLayouts[locale]; ok {
+			return locale
+		}
 	}
+	return models.DefaultLocale
 }
 
-func sendEmail(to, subject, body string) error {
-	m := gomail.NewMessage()
-	m.SetHeader("From", mailConfig.Email)
-	m.SetHeader("To", to)
-	m.SetAddressHeader("Cc", "sanisamoj33@gmail.com", "Sanisamoj")
-	m.SetHeader("Subject", subject)
-	m.SetBody("text/html", body)
+// mailVars são os dados de um modelo; renderEmail acrescenta os comuns a todos.
+type mailVars map[string]interface{}
 
-	d := gomail.NewDialer(mailConfig.Host, mailConfig.Port, mailConfig.Email, mailConfig.Password)
-	d.TLSConfig = &tls.Config{ServerName: mailConfig.Host}
+// mailTemplates guarda os modelos de templates/<idioma>/ já lidos; veja mailer.Templates.
+var mailTemplates = &mailer.Templates{Dir: mailTemplateDir, Funcs: templateFuncs}
 
-	if err := d.DialAndSend(m); err != nil {
-		return err
-	}
-
-	return nil
+// renderEmail acrescenta a vars a marca e os dados comuns e monta o e-mail kind
+// no idioma da marca.
+func renderEmail(kind, to string, brand MailBrand, vars mailVars) (*mailer.Message, error) {
+	brand = addCommonVars(brand, vars)
+	return mailTemplates.Render(brand.Locale, kind, to, brand.SenderName, vars)
 }
 
-func SendWelcomeEmail(to, username string) error {
-	sub := "Boas vindas"
-	body, err := convWelcomeEmail(username)
-	if err != nil {
-		return err
+// addCommonVars completa a marca com os padrões e acrescenta a vars os dados
+// que o layout usa.
+func addCommonVars(brand MailBrand, vars mailVars) MailBrand {
+	brand.Locale = MailLocale(brand.Locale)
+	if brand.PrimaryColor == "" {
+		brand.PrimaryColor = defaultMailPrimary
 	}
-
-	err = sendEmail(to, sub, body)
-	return err
-}
-
-func convWelcomeEmail(username string) (string, error) {
-	templateBytes, err := os.ReadFile("templates/welcome.html")
-	if err != nil {
-		return "", err
+	if brand.BackgroundColor == "" {
+		brand.BackgroundColor = defaultMailBgColor
 	}
 
 	lUrl := config.GetServerConfig().SELF_PAGE_URL
-	lFaqUrl := fmt.Sprintf("%s/faq", lUrl)
-
-	htmlTemplate := string(templateBytes)
-	body := htmlTemplate
-	body = strings.ReplaceAll(body, "{PROJECT_NAME}", "LEMBRAGO")
-	body = strings.ReplaceAll(body, "{USER_NAME}", username)
-	body = strings.ReplaceAll(body, "{LEMBRAGO_URL}", lUrl)
-	body = strings.ReplaceAll(body, "{FAQ_URL}", lFaqUrl)
-
-	actYear := time.Now().Year()
-	body = strings.ReplaceAll(body, "{ACTUAL_YEAR}", strconv.Itoa(actYear))
-
-	return body, nil
+	vars["Brand"] = brand
+	vars["ProjectName"] = mailProjectName
+	vars["SelfURL"] = lUrl
+	vars["FAQURL"] = fmt.Sprintf("%s/faq", lUrl)
+	vars["Year"] = time.Now().Year()
+	return brand
 }
 
-func SendInviteEmail(to, env, role, code string) error {
-	sub := "Você foi convidado(a)"
-	body, err := convInviteEmail(env, role, code)
-	if err != nil {
-		return err
+func templateFuncs(locale string) map[string]interface{} {
+	timeLayout := mailTimeLayouts[locale]
+	return map[string]interface{}{
+		"datetime": func(t time.Time) string { return t.Format(timeLayout) },
 	}
-
-	err = sendEmail(to, sub, body)
-	return err
 }
 
-func convInviteEmail(env, role, code string) (string, error) {
-	templateBytes, err := os.ReadFile("templates/invite.html")
-	if err != nil {
-		return "", err
-	}
-
-	lUrl := config.GetServerConfig().SELF_PAGE_URL
-	lFaqUrl := fmt.Sprintf("%s/faq", lUrl)
-
-	htmlTemplate := string(templateBytes)
-	body := htmlTemplate
-	body = strings.ReplaceAll(body, "{PROJECT_NAME}", "LEMBRAGO")
-	body = strings.ReplaceAll(body, "{ENVIRONMENT_NAME}", env)
-	body = strings.ReplaceAll(body, "{ROLE_NAME}", role)
-	body = strings.ReplaceAll(body, "{INVITATION_CODE}", code)
-	body = strings.ReplaceAll(body, "{SELF_URL}", lUrl)
-	body = strings.ReplaceAll(body, "{FAQ_URL}", lFaqUrl)
-
-	actYear := time.Now().Year()
-	body = strings.ReplaceAll(body, "{ACTUAL_YEAR}", strconv.Itoa(actYear))
-
-	return body, nil
+func WelcomeEmail(brand MailBrand, to, username string) (*mailer.Message, error) {
+	return renderEmail(models.MailWelcome, to, brand, mailVars{
+		"Username": username,
+	})
 }
 
-func SendAuthCodeEmail(to, code string) error {
-	sub := "Código de autenticação"
-	body, err := convAuthCodeEmail(code)
-	if err != nil {
-		return err
-	}
-
-	err = sendEmail(to, sub, body)
-	return err
+func InviteEmail(brand MailBrand, to, env, role, code string) (*mailer.Message, error) {
+	return renderEmail(models.MailInvite, to, brand, mailVars{
+		"Environment": env,
+		"Role":        role,
+		"Code":        code,
+	})
 }
 
-func convAuthCodeEmail(code string) (string, error) {
-	templateBytes, err := os.ReadFile("templates/auth_code.html")
-	if err != nil {
-		return "", err
-	}
+func AuthCodeEmail(brand MailBrand, to, code string) (*mailer.Message, error) {
+	return renderEmail(models.MailAuthCode, to, brand, mailVars{
+		"Code": code,
+	})
+}
 
-	lUrl := config.GetServerConfig().SELF_PAGE_URL
-	lFaqUrl := fmt.Sprintf("%s/faq", lUrl)
+func AccessRequestEmail(brand MailBrand, to, requesterEmail, vaultName string, permission models.VaultPermission, justification string, expiresAt time.Time) (*mailer.Message, error) {
+	return renderEmail(models.MailAccessRequest, to, brand, mailVars{
+		"RequesterEmail": requesterEmail,
+		"VaultName":      vaultName,
+		"Permission":     permission,
+		"Justification":  justification,
+		"ExpiresAt":      expiresAt,
+	})
+}
 
-	htmlTemplate := string(templateBytes)
-	body := htmlTemplate
-	body = strings.ReplaceAll(body, "{PROJECT_NAME}", "LEMBRAGO")
-	body = strings.ReplaceAll(body, "{AUTH_CODE}", code)
-	body = strings.ReplaceAll(body, "{FAQ_URL}", lFaqUrl)
+// AccessRequestDecisionEmail é o aviso ao solicitante de que o pedido foi
+// aprovado, recusado ou expirou.
+func AccessRequestDecisionEmail(brand MailBrand, to, vaultName string, decision models.AccessRequestStatus, note string) (*mailer.Message, error) {
+	return renderEmail(models.MailAccessRequestDecision, to, brand, mailVars{
+		"VaultName": vaultName,
+		"Decision":  decision,
+		"Note":      note,
+	})
+}
 
-	actYear := time.Now().Year()
-	body = strings.ReplaceAll(body, "{ACTUAL_YEAR}", strconv.Itoa(actYear))
+// MemberExpiredEmail avisa que um acesso a cofre expirou. Com memberEmail
+// vazio o aviso é para o próprio usuário removido; senão, para um admin do
+// cofre. vaultName pode vir vazio, já que o nome do cofre é cifrado.
+func MemberExpiredEmail(brand MailBrand, to, vaultName, memberEmail string) (*mailer.Message, error) {
+	return renderEmail(models.MailMemberExpired, to, brand, mailVars{
+		"VaultName":   vaultName,
+		"MemberEmail": memberEmail,
+	})
+}
 
-	return body, nil
+// NewDeviceEmail avisa de um login vindo de um aparelho que o usuário nunca
+// usou. reportURL é o link "não fui eu".
+func NewDeviceEmail(brand MailBrand, to, userAgent, ip string, loginAt time.Time, reportURL string) (*mailer.Message, error) {
+	return renderEmail(models.MailNewDevice, to, brand, mailVars{
+		"UserAgent": userAgent,
+		"IP":        ip,
+		"LoginAt":   loginAt,
+		"ReportURL": reportURL,
+	})
 }
@@ -0,0 +1,17 @@
+package utils
+
+import "time"
+
+// DeviceReportPage monta a página do link "não fui eu" com o layout e a marca
+// dos e-mails. Sem done, ela pede a confirmação num formulário POST; com done,
+// avisa que a conta foi travada. seenAt zero omite o aparelho.
+func DeviceReportPage(brand MailBrand, userAgent, ip string, seenAt time.Time, done bool) (string, error) {
+	vars := mailVars{
+		"UserAgent": userAgent,
+		"IP":        ip,
+		"SeenAt":    seenAt,
+		"Done":      done,
+	}
+	brand = addCommonVars(brand, vars)
+	return mailTemplates.Page(brand.Locale, "device_report", vars)
+}